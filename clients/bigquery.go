@@ -16,9 +16,10 @@ package clients
 
 import (
 	"context"
-	"fmt"
 
 	"cloud.google.com/go/bigquery"
+	"github.com/pkg/errors"
+	"google.golang.org/api/option"
 )
 
 // BigQuery client.
@@ -27,10 +28,10 @@ type BigQuery struct {
 }
 
 // NewBigQuery returns the BigQuery client.
-func NewBigQuery(ctx context.Context, projectID string) (*BigQuery, error) {
-	client, err := bigquery.NewClient(ctx, projectID)
+func NewBigQuery(ctx context.Context, projectID string, opts ...option.ClientOption) (*BigQuery, error) {
+	client, err := bigquery.NewClient(ctx, projectID, opts...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to init bigquery: %q", err)
+		return nil, errors.Wrap(err, "failed to init bigquery")
 	}
 	return &BigQuery{client: client}, nil
 }
@@ -47,3 +48,8 @@ func (bq *BigQuery) OverwriteDatasetMetadata(ctx context.Context, projectID, dat
 	blindWrite := ""
 	return bq.client.DatasetInProject(projectID, datasetID).Update(ctx, dm, blindWrite)
 }
+
+// Insert streams rows into the table.
+func (bq *BigQuery) Insert(ctx context.Context, projectID, datasetID, tableID string, rows interface{}) error {
+	return bq.client.DatasetInProject(projectID, datasetID).Table(tableID).Inserter().Put(ctx, rows)
+}
@@ -20,6 +20,8 @@ import (
 	"log"
 	"time"
 
+	"github.com/pkg/errors"
+	"google.golang.org/api/option"
 	sqladmin "google.golang.org/api/sqladmin/v1beta4"
 )
 
@@ -30,10 +32,10 @@ type CloudSQL struct {
 }
 
 // NewCloudSQL returns and initializes a Cloud SQL client.
-func NewCloudSQL(ctx context.Context) (*CloudSQL, error) {
-	sql, err := sqladmin.NewService(ctx)
+func NewCloudSQL(ctx context.Context, opts ...option.ClientOption) (*CloudSQL, error) {
+	sql, err := sqladmin.NewService(ctx, opts...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to init scc: %q", err)
+		return nil, errors.Wrap(err, "failed to init scc")
 	}
 	return &CloudSQL{
 		service:    sql,
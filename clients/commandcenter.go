@@ -18,8 +18,12 @@ package clients
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	commandcenter "cloud.google.com/go/securitycenter/apiv1beta1"
+	"github.com/pkg/errors"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
 	sccpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1beta1"
 )
 
@@ -29,10 +33,10 @@ type SecurityCommandCenter struct {
 }
 
 // NewSecurityCommandCenter returns and initializes a SecurityCommandCenter client.
-func NewSecurityCommandCenter(ctx context.Context) (*SecurityCommandCenter, error) {
-	scc, err := commandcenter.NewClient(ctx)
+func NewSecurityCommandCenter(ctx context.Context, opts ...option.ClientOption) (*SecurityCommandCenter, error) {
+	scc, err := commandcenter.NewClient(ctx, opts...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to init scc: %q", err)
+		return nil, errors.Wrap(err, "failed to init scc")
 	}
 	return &SecurityCommandCenter{service: scc}, nil
 }
@@ -51,3 +55,38 @@ func (s *SecurityCommandCenter) AddSecurityMarks(ctx context.Context, request *s
 func (s *SecurityCommandCenter) SetFindingState(ctx context.Context, request *sccpb.SetFindingStateRequest) (*sccpb.Finding, error) {
 	return s.service.SetFindingState(ctx, request)
 }
+
+// GetFinding returns the finding with the given name, such as
+// organizations/123/sources/456/findings/789.
+func (s *SecurityCommandCenter) GetFinding(ctx context.Context, name string) (*sccpb.Finding, error) {
+	i := strings.Index(name, "/findings/")
+	if i < 0 {
+		return nil, fmt.Errorf("%q is not a finding name", name)
+	}
+	it := s.service.ListFindings(ctx, &sccpb.ListFindingsRequest{
+		Parent: name[:i],
+		Filter: fmt.Sprintf("name=%q", name),
+	})
+	f, err := it.Next()
+	if err == iterator.Done {
+		return nil, fmt.Errorf("finding %q not found", name)
+	}
+	return f, err
+}
+
+// ListFindings returns the findings under the parent, such as organizations/123/sources/-,
+// matching the filter.
+func (s *SecurityCommandCenter) ListFindings(ctx context.Context, parent, filter string) ([]*sccpb.Finding, error) {
+	var findings []*sccpb.Finding
+	it := s.service.ListFindings(ctx, &sccpb.ListFindingsRequest{Parent: parent, Filter: filter})
+	for {
+		f, err := it.Next()
+		if err == iterator.Done {
+			return findings, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		findings = append(findings, f)
+	}
+}
@@ -20,7 +20,9 @@ import (
 	"log"
 	"time"
 
+	"github.com/pkg/errors"
 	compute "google.golang.org/api/compute/v1"
+	"google.golang.org/api/option"
 )
 
 const (
@@ -40,10 +42,10 @@ type Compute struct {
 }
 
 // NewCompute returns and initializes a Compute client.
-func NewCompute(ctx context.Context) (*Compute, error) {
-	cc, err := compute.NewService(ctx)
+func NewCompute(ctx context.Context, opts ...option.ClientOption) (*Compute, error) {
+	cc, err := compute.NewService(ctx, opts...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to init cs: %q", err)
+		return nil, errors.Wrap(err, "failed to init cs")
 	}
 	return &Compute{
 		compute:   cc,
@@ -84,6 +86,21 @@ func (c *Compute) GetInstance(ctx context.Context, project, zone, instance strin
 	return c.compute.Instances.Get(project, zone, instance).Context(ctx).Do()
 }
 
+// SetTags sets the network tags of an instance.
+func (c *Compute) SetTags(ctx context.Context, project, zone, instance string, tags *compute.Tags) (*compute.Operation, error) {
+	return c.compute.Instances.SetTags(project, zone, instance, tags).Context(ctx).Do()
+}
+
+// SetMetadata sets the metadata of an instance.
+func (c *Compute) SetMetadata(ctx context.Context, project, zone, instance string, metadata *compute.Metadata) (*compute.Operation, error) {
+	return c.compute.Instances.SetMetadata(project, zone, instance, metadata).Context(ctx).Do()
+}
+
+// AddAccessConfig adds an access config to an instance's network interface.
+func (c *Compute) AddAccessConfig(ctx context.Context, project, zone, instance, networkInterface string, accessConfig *compute.AccessConfig) (*compute.Operation, error) {
+	return c.compute.Instances.AddAccessConfig(project, zone, instance, networkInterface, accessConfig).Context(ctx).Do()
+}
+
 // DeleteAccessConfig deletes an access config from an instance's network interface.
 func (c *Compute) DeleteAccessConfig(ctx context.Context, project, zone, instance, accessConfig, networkInterface string) (*compute.Operation, error) {
 	return c.compute.Instances.DeleteAccessConfig(project, zone, instance, accessConfig, networkInterface).Context(ctx).Do()
@@ -16,9 +16,10 @@ package clients
 
 import (
 	"context"
-	"fmt"
 
+	"github.com/pkg/errors"
 	container "google.golang.org/api/container/v1"
+	"google.golang.org/api/option"
 )
 
 // Container client.
@@ -27,14 +28,19 @@ type Container struct {
 }
 
 // NewContainer returns and initializes a Container client.
-func NewContainer(ctx context.Context) (*Container, error) {
-	cc, err := container.NewService(ctx)
+func NewContainer(ctx context.Context, opts ...option.ClientOption) (*Container, error) {
+	cc, err := container.NewService(ctx, opts...)
 	if err != nil {
-		return nil, fmt.Errorf("Failed to init container service: %q", err)
+		return nil, errors.Wrap(err, "Failed to init container service")
 	}
 	return &Container{container: cc}, nil
 }
 
+// GetCluster returns the cluster.
+func (c *Container) GetCluster(ctx context.Context, projectID, zone, clusterID string) (*container.Cluster, error) {
+	return c.container.Projects.Zones.Clusters.Get(projectID, zone, clusterID).Context(ctx).Do()
+}
+
 // UpdateAddonsConfig updates the addons configuration of a given cluster.
 func (c *Container) UpdateAddonsConfig(ctx context.Context, projectID, zone, clusterID string, conf *container.SetAddonsConfigRequest) (*container.Operation, error) {
 	return c.container.Projects.Zones.Clusters.Addons(projectID, zone, clusterID, conf).Context(ctx).Do()
@@ -0,0 +1,51 @@
+package clients
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	dns "google.golang.org/api/dns/v1"
+)
+
+// DNS client.
+type DNS struct {
+	service *dns.Service
+}
+
+// NewDNS returns and initializes a Cloud DNS client.
+func NewDNS(ctx context.Context) (*DNS, error) {
+	d, err := dns.NewService(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to init dns")
+	}
+	return &DNS{service: d}, nil
+}
+
+// ManagedZone returns the managed zone for the given project.
+func (d *DNS) ManagedZone(ctx context.Context, projectID, zone string) (*dns.ManagedZone, error) {
+	return d.service.ManagedZones.Get(projectID, zone).Context(ctx).Do()
+}
+
+// CreateManagedZone creates a new managed zone in the given project.
+func (d *DNS) CreateManagedZone(ctx context.Context, projectID string, zone *dns.ManagedZone) (*dns.ManagedZone, error) {
+	return d.service.ManagedZones.Create(projectID, zone).Context(ctx).Do()
+}
+
+// PatchManagedZone updates the managed zone in the given project.
+func (d *DNS) PatchManagedZone(ctx context.Context, projectID, name string, zone *dns.ManagedZone) (*dns.Operation, error) {
+	return d.service.ManagedZones.Patch(projectID, name, zone).Context(ctx).Do()
+}
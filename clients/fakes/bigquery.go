@@ -0,0 +1,126 @@
+package fakes
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"reflect"
+	"sync"
+
+	"cloud.google.com/go/bigquery"
+)
+
+// BigQuery is a fake BigQuery client serving datasets and recording the rows streamed into
+// tables.
+type BigQuery struct {
+	mu sync.Mutex
+	// datasets are keyed by project and dataset ID. rows are keyed by project, dataset and table
+	// ID.
+	datasets map[string]*bigquery.DatasetMetadata
+	rows     map[string][]interface{}
+}
+
+// NewBigQuery returns a fake without datasets.
+func NewBigQuery() *BigQuery {
+	return &BigQuery{
+		datasets: make(map[string]*bigquery.DatasetMetadata),
+		rows:     make(map[string][]interface{}),
+	}
+}
+
+// AddDataset seeds a dataset.
+func (bq *BigQuery) AddDataset(projectID, datasetID string, md *bigquery.DatasetMetadata) {
+	bq.mu.Lock()
+	defer bq.mu.Unlock()
+	bq.datasets[key(projectID, datasetID)] = copyDataset(md)
+}
+
+// Rows returns the rows streamed into a table in the order they were inserted.
+func (bq *BigQuery) Rows(projectID, datasetID, tableID string) []interface{} {
+	bq.mu.Lock()
+	defer bq.mu.Unlock()
+	return append([]interface{}(nil), bq.rows[key(projectID, datasetID, tableID)]...)
+}
+
+// copyDataset copies the metadata so callers do not share its labels or access entries.
+func copyDataset(md *bigquery.DatasetMetadata) *bigquery.DatasetMetadata {
+	out := *md
+	out.Labels = copyLabels(md.Labels)
+	out.Access = copyAccess(md.Access)
+	return &out
+}
+
+func copyAccess(access []*bigquery.AccessEntry) []*bigquery.AccessEntry {
+	if access == nil {
+		return nil
+	}
+	out := make([]*bigquery.AccessEntry, 0, len(access))
+	for _, a := range access {
+		e := *a
+		out = append(out, &e)
+	}
+	return out
+}
+
+// DatasetMetadata returns the metadata of a dataset.
+func (bq *BigQuery) DatasetMetadata(ctx context.Context, projectID, datasetID string) (*bigquery.DatasetMetadata, error) {
+	bq.mu.Lock()
+	defer bq.mu.Unlock()
+	md, ok := bq.datasets[key(projectID, datasetID)]
+	if !ok {
+		return nil, notFound("Not found: Dataset %s:%s", projectID, datasetID)
+	}
+	return copyDataset(md), nil
+}
+
+// OverwriteDatasetMetadata replaces the description, name and access list of a dataset when set
+// in the update.
+func (bq *BigQuery) OverwriteDatasetMetadata(ctx context.Context, projectID, datasetID string, dm bigquery.DatasetMetadataToUpdate) (*bigquery.DatasetMetadata, error) {
+	bq.mu.Lock()
+	defer bq.mu.Unlock()
+	md, ok := bq.datasets[key(projectID, datasetID)]
+	if !ok {
+		return nil, notFound("Not found: Dataset %s:%s", projectID, datasetID)
+	}
+	if d, ok := dm.Description.(string); ok {
+		md.Description = d
+	}
+	if n, ok := dm.Name.(string); ok {
+		md.Name = n
+	}
+	if dm.Access != nil {
+		md.Access = copyAccess(dm.Access)
+	}
+	return copyDataset(md), nil
+}
+
+// Insert records the rows, a single row or a slice of rows, as streamed into the table.
+func (bq *BigQuery) Insert(ctx context.Context, projectID, datasetID, tableID string, rows interface{}) error {
+	bq.mu.Lock()
+	defer bq.mu.Unlock()
+	if _, ok := bq.datasets[key(projectID, datasetID)]; !ok {
+		return notFound("Not found: Dataset %s:%s", projectID, datasetID)
+	}
+	k := key(projectID, datasetID, tableID)
+	v := reflect.ValueOf(rows)
+	if v.Kind() != reflect.Slice {
+		bq.rows[k] = append(bq.rows[k], rows)
+		return nil
+	}
+	for i := 0; i < v.Len(); i++ {
+		bq.rows[k] = append(bq.rows[k], v.Index(i).Interface())
+	}
+	return nil
+}
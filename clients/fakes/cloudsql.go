@@ -0,0 +1,141 @@
+package fakes
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	sqladmin "google.golang.org/api/sqladmin/v1beta4"
+)
+
+// CloudSQL is a fake Cloud SQL Admin client serving instances and their users. Operations
+// complete immediately.
+type CloudSQL struct {
+	mu sync.Mutex
+	// instances are keyed by project and name. users are keyed by project, instance, host and
+	// name.
+	instances map[string]*sqladmin.DatabaseInstance
+	users     map[string]*sqladmin.User
+	ops       int
+}
+
+// NewCloudSQL returns a fake without instances.
+func NewCloudSQL() *CloudSQL {
+	return &CloudSQL{
+		instances: make(map[string]*sqladmin.DatabaseInstance),
+		users:     make(map[string]*sqladmin.User),
+	}
+}
+
+// AddInstance seeds an instance.
+func (c *CloudSQL) AddInstance(project string, instance *sqladmin.DatabaseInstance) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var i sqladmin.DatabaseInstance
+	clone(instance, &i)
+	i.Project = project
+	c.instances[key(project, i.Name)] = &i
+}
+
+// AddUser seeds a user of an instance.
+func (c *CloudSQL) AddUser(project, instance string, user *sqladmin.User) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var u sqladmin.User
+	clone(user, &u)
+	c.users[key(project, instance, u.Host, u.Name)] = &u
+}
+
+// Instance returns the current state of an instance, nil if it does not exist.
+func (c *CloudSQL) Instance(project, name string) *sqladmin.DatabaseInstance {
+	i, _ := c.InstanceDetails(context.Background(), project, name)
+	return i
+}
+
+// User returns the current state of a user, nil if it does not exist.
+func (c *CloudSQL) User(project, instance, host, name string) *sqladmin.User {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	u, ok := c.users[key(project, instance, host, name)]
+	if !ok {
+		return nil
+	}
+	var out sqladmin.User
+	clone(u, &out)
+	return &out
+}
+
+func (c *CloudSQL) op(kind, project, target string) *sqladmin.Operation {
+	c.ops++
+	return &sqladmin.Operation{Name: fmt.Sprintf("operation-%d", c.ops), OperationType: kind, TargetProject: project, TargetId: target, Status: "DONE"}
+}
+
+func (c *CloudSQL) instance(project, name string) (*sqladmin.DatabaseInstance, error) {
+	i, ok := c.instances[key(project, name)]
+	if !ok {
+		return nil, notFound("The Cloud SQL instance %q does not exist in project %q", name, project)
+	}
+	return i, nil
+}
+
+// PatchInstance updates the fields of an instance set in the patch, as the API does.
+func (c *CloudSQL) PatchInstance(ctx context.Context, projectID, instance string, databaseInstance *sqladmin.DatabaseInstance) (*sqladmin.Operation, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	i, err := c.instance(projectID, instance)
+	if err != nil {
+		return nil, err
+	}
+	// Fields left empty in the patch are omitted from its JSON, so they are kept.
+	clone(databaseInstance, i)
+	return c.op("UPDATE", projectID, instance), nil
+}
+
+// WaitSQL returns immediately as operations are already done.
+func (c *CloudSQL) WaitSQL(projectID string, op *sqladmin.Operation) []error {
+	return nil
+}
+
+// InstanceDetails returns an instance.
+func (c *CloudSQL) InstanceDetails(ctx context.Context, projectID, instance string) (*sqladmin.DatabaseInstance, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	i, err := c.instance(projectID, instance)
+	if err != nil {
+		return nil, err
+	}
+	var out sqladmin.DatabaseInstance
+	clone(i, &out)
+	return &out, nil
+}
+
+// UpdateUser updates a user of an instance, creating it if it does not exist.
+func (c *CloudSQL) UpdateUser(ctx context.Context, projectID, instance, host, name string, user *sqladmin.User) (*sqladmin.Operation, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, err := c.instance(projectID, instance); err != nil {
+		return nil, err
+	}
+	k := key(projectID, instance, host, name)
+	u, ok := c.users[k]
+	if !ok {
+		u = &sqladmin.User{Host: host, Name: name, Instance: instance, Project: projectID}
+		c.users[k] = u
+	}
+	clone(user, u)
+	return c.op("UPDATE_USER", projectID, instance), nil
+}
@@ -0,0 +1,136 @@
+package fakes
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	sccpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1beta1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// SecurityCommandCenter is a fake Security Command Center client serving findings.
+type SecurityCommandCenter struct {
+	mu sync.Mutex
+	// findings are keyed by name, such as organizations/123/sources/456/findings/789.
+	findings map[string]*sccpb.Finding
+}
+
+// NewSecurityCommandCenter returns a fake without findings.
+func NewSecurityCommandCenter() *SecurityCommandCenter {
+	return &SecurityCommandCenter{findings: make(map[string]*sccpb.Finding)}
+}
+
+// AddFinding seeds a finding.
+func (s *SecurityCommandCenter) AddFinding(f *sccpb.Finding) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.findings[f.GetName()] = proto.Clone(f).(*sccpb.Finding)
+}
+
+// Finding returns the current state of a finding, nil if it does not exist.
+func (s *SecurityCommandCenter) Finding(name string) *sccpb.Finding {
+	f, _ := s.GetFinding(context.Background(), name)
+	return f
+}
+
+func (s *SecurityCommandCenter) finding(name string) (*sccpb.Finding, error) {
+	f, ok := s.findings[name]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "Requested entity was not found: %s", name)
+	}
+	return f, nil
+}
+
+// AddSecurityMarks updates the marks of a finding in the request's mask, or replaces all of them
+// without a mask.
+func (s *SecurityCommandCenter) AddSecurityMarks(ctx context.Context, request *sccpb.UpdateSecurityMarksRequest) (*sccpb.SecurityMarks, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	name := strings.TrimSuffix(request.GetSecurityMarks().GetName(), "/securityMarks")
+	f, err := s.finding(name)
+	if err != nil {
+		return nil, err
+	}
+	if f.SecurityMarks == nil || f.SecurityMarks.Marks == nil {
+		f.SecurityMarks = &sccpb.SecurityMarks{Name: name + "/securityMarks", Marks: make(map[string]string)}
+	}
+	marks := request.GetSecurityMarks().GetMarks()
+	paths := request.GetUpdateMask().GetPaths()
+	if len(paths) == 0 {
+		f.SecurityMarks.Marks = make(map[string]string)
+		for k, v := range marks {
+			f.SecurityMarks.Marks[k] = v
+		}
+	}
+	for _, p := range paths {
+		k := strings.TrimPrefix(p, "marks.")
+		if v, ok := marks[k]; ok {
+			f.SecurityMarks.Marks[k] = v
+		} else {
+			delete(f.SecurityMarks.Marks, k)
+		}
+	}
+	return proto.Clone(f.SecurityMarks).(*sccpb.SecurityMarks), nil
+}
+
+// SetFindingState sets the state of a finding.
+func (s *SecurityCommandCenter) SetFindingState(ctx context.Context, request *sccpb.SetFindingStateRequest) (*sccpb.Finding, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := s.finding(request.GetName())
+	if err != nil {
+		return nil, err
+	}
+	f.State = request.GetState()
+	return proto.Clone(f).(*sccpb.Finding), nil
+}
+
+// GetFinding returns a finding by name. As the client lists findings to look one up, a missing
+// finding is not a NotFound error.
+func (s *SecurityCommandCenter) GetFinding(ctx context.Context, name string) (*sccpb.Finding, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, ok := s.findings[name]
+	if !ok {
+		return nil, fmt.Errorf("finding %q not found", name)
+	}
+	return proto.Clone(f).(*sccpb.Finding), nil
+}
+
+// ListFindings returns the findings under the parent sorted by name. A source of "-" matches
+// every source. The filter is not interpreted, so callers must check the findings returned.
+func (s *SecurityCommandCenter) ListFindings(ctx context.Context, parent, filter string) ([]*sccpb.Finding, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prefix := strings.TrimSuffix(parent, "/sources/-") + "/sources/"
+	if !strings.HasSuffix(parent, "/sources/-") {
+		prefix = parent + "/findings/"
+	}
+	var findings []*sccpb.Finding
+	for name, f := range s.findings {
+		if strings.HasPrefix(name, prefix) {
+			findings = append(findings, proto.Clone(f).(*sccpb.Finding))
+		}
+	}
+	sort.Slice(findings, func(i, j int) bool { return findings[i].GetName() < findings[j].GetName() })
+	return findings, nil
+}
@@ -0,0 +1,380 @@
+package fakes
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	compute "google.golang.org/api/compute/v1"
+)
+
+// Compute is a fake Compute Engine client serving instances, disks, snapshots and firewall
+// rules. Operations complete immediately.
+type Compute struct {
+	mu sync.Mutex
+	// instances and disks are keyed by project, zone and name. snapshots and firewalls are
+	// keyed by project and name.
+	instances map[string]*compute.Instance
+	disks     map[string]*compute.Disk
+	snapshots map[string]*compute.Snapshot
+	firewalls map[string]*compute.Firewall
+	ops       uint64
+}
+
+// NewCompute returns a fake without resources.
+func NewCompute() *Compute {
+	return &Compute{
+		instances: make(map[string]*compute.Instance),
+		disks:     make(map[string]*compute.Disk),
+		snapshots: make(map[string]*compute.Snapshot),
+		firewalls: make(map[string]*compute.Firewall),
+	}
+}
+
+// AddInstance seeds an instance.
+func (c *Compute) AddInstance(project, zone string, instance *compute.Instance) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var i compute.Instance
+	clone(instance, &i)
+	if i.Status == "" {
+		i.Status = "RUNNING"
+	}
+	c.instances[key(project, zone, i.Name)] = &i
+}
+
+// AddDisk seeds a disk.
+func (c *Compute) AddDisk(project, zone string, disk *compute.Disk) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var d compute.Disk
+	clone(disk, &d)
+	c.disks[key(project, zone, d.Name)] = &d
+}
+
+// AddFirewall seeds a firewall rule.
+func (c *Compute) AddFirewall(project string, rule *compute.Firewall) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var fw compute.Firewall
+	clone(rule, &fw)
+	c.firewalls[key(project, fw.Name)] = &fw
+}
+
+// Instance returns the current state of an instance, nil if it does not exist.
+func (c *Compute) Instance(project, zone, name string) *compute.Instance {
+	i, _ := c.GetInstance(context.Background(), project, zone, name)
+	return i
+}
+
+// Firewall returns the current state of a firewall rule, nil if it does not exist.
+func (c *Compute) Firewall(project, name string) *compute.Firewall {
+	fw, _ := c.FirewallRule(context.Background(), project, name)
+	return fw
+}
+
+// Snapshots returns the snapshots of a project sorted by name.
+func (c *Compute) Snapshots(project string) []*compute.Snapshot {
+	l, _ := c.ListProjectSnapshots(context.Background(), project)
+	return l.Items
+}
+
+// op returns a completed operation.
+func (c *Compute) op(kind, target string) *compute.Operation {
+	c.ops++
+	return &compute.Operation{Id: c.ops, Name: fmt.Sprintf("operation-%d", c.ops), OperationType: kind, TargetLink: target, Status: "DONE"}
+}
+
+func (c *Compute) instance(project, zone, name string) (*compute.Instance, error) {
+	i, ok := c.instances[key(project, zone, name)]
+	if !ok {
+		return nil, notFound("The resource 'projects/%s/zones/%s/instances/%s' was not found", project, zone, name)
+	}
+	return i, nil
+}
+
+// DiskInsert creates a disk.
+func (c *Compute) DiskInsert(ctx context.Context, project, zone string, disk *compute.Disk) (*compute.Operation, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	k := key(project, zone, disk.Name)
+	if _, ok := c.disks[k]; ok {
+		return nil, alreadyExists("The resource 'projects/%s/zones/%s/disks/%s' already exists", project, zone, disk.Name)
+	}
+	var d compute.Disk
+	clone(disk, &d)
+	c.disks[k] = &d
+	return c.op("insert", d.Name), nil
+}
+
+// CreateSnapshot creates a snapshot of a disk.
+func (c *Compute) CreateSnapshot(ctx context.Context, project, zone, disk string, snapshot *compute.Snapshot) (*compute.Operation, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	d, ok := c.disks[key(project, zone, disk)]
+	if !ok {
+		return nil, notFound("The resource 'projects/%s/zones/%s/disks/%s' was not found", project, zone, disk)
+	}
+	k := key(project, snapshot.Name)
+	if _, ok := c.snapshots[k]; ok {
+		return nil, alreadyExists("The resource 'projects/%s/global/snapshots/%s' already exists", project, snapshot.Name)
+	}
+	var s compute.Snapshot
+	clone(snapshot, &s)
+	s.SourceDisk = d.SelfLink
+	s.DiskSizeGb = d.SizeGb
+	s.Status = "READY"
+	c.snapshots[k] = &s
+	return c.op("createSnapshot", s.Name), nil
+}
+
+// AddAccessConfig adds an access config to an instance's network interface.
+func (c *Compute) AddAccessConfig(ctx context.Context, project, zone, instance, networkInterface string, accessConfig *compute.AccessConfig) (*compute.Operation, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	i, err := c.instance(project, zone, instance)
+	if err != nil {
+		return nil, err
+	}
+	for _, nic := range i.NetworkInterfaces {
+		if nic.Name == networkInterface {
+			var ac compute.AccessConfig
+			clone(accessConfig, &ac)
+			nic.AccessConfigs = append(nic.AccessConfigs, &ac)
+			return c.op("addAccessConfig", instance), nil
+		}
+	}
+	return nil, notFound("network interface %q of instance %q was not found", networkInterface, instance)
+}
+
+// DeleteAccessConfig deletes an access config from an instance's network interface.
+func (c *Compute) DeleteAccessConfig(ctx context.Context, project, zone, instance, accessConfig, networkInterface string) (*compute.Operation, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	i, err := c.instance(project, zone, instance)
+	if err != nil {
+		return nil, err
+	}
+	for _, nic := range i.NetworkInterfaces {
+		if nic.Name != networkInterface {
+			continue
+		}
+		for j, ac := range nic.AccessConfigs {
+			if ac.Name == accessConfig {
+				nic.AccessConfigs = append(nic.AccessConfigs[:j], nic.AccessConfigs[j+1:]...)
+				return c.op("deleteAccessConfig", instance), nil
+			}
+		}
+	}
+	return nil, notFound("access config %q of network interface %q of instance %q was not found", accessConfig, networkInterface, instance)
+}
+
+// DeleteDiskSnapshot deletes a snapshot.
+func (c *Compute) DeleteDiskSnapshot(ctx context.Context, project, snapshot string) (*compute.Operation, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	k := key(project, snapshot)
+	if _, ok := c.snapshots[k]; !ok {
+		return nil, notFound("The resource 'projects/%s/global/snapshots/%s' was not found", project, snapshot)
+	}
+	delete(c.snapshots, k)
+	return c.op("delete", snapshot), nil
+}
+
+// DeleteInstance deletes an instance.
+func (c *Compute) DeleteInstance(ctx context.Context, project, zone, instance string) (*compute.Operation, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, err := c.instance(project, zone, instance); err != nil {
+		return nil, err
+	}
+	delete(c.instances, key(project, zone, instance))
+	return c.op("delete", instance), nil
+}
+
+// GetInstance returns an instance.
+func (c *Compute) GetInstance(ctx context.Context, project, zone, instance string) (*compute.Instance, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	i, err := c.instance(project, zone, instance)
+	if err != nil {
+		return nil, err
+	}
+	var out compute.Instance
+	clone(i, &out)
+	return &out, nil
+}
+
+// ListDisks returns the disks of a zone sorted by name.
+func (c *Compute) ListDisks(ctx context.Context, project, zone string) (*compute.DiskList, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	l := &compute.DiskList{}
+	for k, d := range c.disks {
+		if k == key(project, zone, d.Name) {
+			var out compute.Disk
+			clone(d, &out)
+			l.Items = append(l.Items, &out)
+		}
+	}
+	sort.Slice(l.Items, func(i, j int) bool { return l.Items[i].Name < l.Items[j].Name })
+	return l, nil
+}
+
+// ListProjectSnapshots returns the snapshots of a project sorted by name.
+func (c *Compute) ListProjectSnapshots(ctx context.Context, project string) (*compute.SnapshotList, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	l := &compute.SnapshotList{}
+	for k, s := range c.snapshots {
+		if k == key(project, s.Name) {
+			var out compute.Snapshot
+			clone(s, &out)
+			l.Items = append(l.Items, &out)
+		}
+	}
+	sort.Slice(l.Items, func(i, j int) bool { return l.Items[i].Name < l.Items[j].Name })
+	return l, nil
+}
+
+// SetLabels sets the labels of a snapshot.
+func (c *Compute) SetLabels(ctx context.Context, project, resource string, req *compute.GlobalSetLabelsRequest) (*compute.Operation, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.snapshots[key(project, resource)]
+	if !ok {
+		return nil, notFound("The resource 'projects/%s/global/snapshots/%s' was not found", project, resource)
+	}
+	s.Labels = make(map[string]string, len(req.Labels))
+	for k, v := range req.Labels {
+		s.Labels[k] = v
+	}
+	s.LabelFingerprint = req.LabelFingerprint
+	return c.op("setLabels", resource), nil
+}
+
+// SetMetadata sets the metadata of an instance.
+func (c *Compute) SetMetadata(ctx context.Context, project, zone, instance string, metadata *compute.Metadata) (*compute.Operation, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	i, err := c.instance(project, zone, instance)
+	if err != nil {
+		return nil, err
+	}
+	var md compute.Metadata
+	clone(metadata, &md)
+	i.Metadata = &md
+	return c.op("setMetadata", instance), nil
+}
+
+// SetTags sets the network tags of an instance.
+func (c *Compute) SetTags(ctx context.Context, project, zone, instance string, tags *compute.Tags) (*compute.Operation, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	i, err := c.instance(project, zone, instance)
+	if err != nil {
+		return nil, err
+	}
+	var t compute.Tags
+	clone(tags, &t)
+	i.Tags = &t
+	return c.op("setTags", instance), nil
+}
+
+// StartInstance starts an instance.
+func (c *Compute) StartInstance(ctx context.Context, project, zone, instance string) (*compute.Operation, error) {
+	return c.setStatus(project, zone, instance, "start", "RUNNING")
+}
+
+// StopInstance stops an instance.
+func (c *Compute) StopInstance(ctx context.Context, project, zone, instance string) (*compute.Operation, error) {
+	return c.setStatus(project, zone, instance, "stop", "TERMINATED")
+}
+
+func (c *Compute) setStatus(project, zone, instance, kind, status string) (*compute.Operation, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	i, err := c.instance(project, zone, instance)
+	if err != nil {
+		return nil, err
+	}
+	i.Status = status
+	return c.op(kind, instance), nil
+}
+
+// WaitGlobal returns immediately as operations are already done.
+func (c *Compute) WaitGlobal(project string, op *compute.Operation) []error {
+	return nil
+}
+
+// WaitZone returns immediately as operations are already done.
+func (c *Compute) WaitZone(project, zone string, op *compute.Operation) []error {
+	return nil
+}
+
+// InsertFirewallRule creates a firewall rule.
+func (c *Compute) InsertFirewallRule(ctx context.Context, project string, rule *compute.Firewall) (*compute.Operation, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	k := key(project, rule.Name)
+	if _, ok := c.firewalls[k]; ok {
+		return nil, alreadyExists("The resource 'projects/%s/global/firewalls/%s' already exists", project, rule.Name)
+	}
+	var fw compute.Firewall
+	clone(rule, &fw)
+	c.firewalls[k] = &fw
+	return c.op("insert", fw.Name), nil
+}
+
+// PatchFirewallRule updates the fields of a firewall rule set in the patch, as the API does.
+func (c *Compute) PatchFirewallRule(ctx context.Context, project, rule string, patch *compute.Firewall) (*compute.Operation, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fw, ok := c.firewalls[key(project, rule)]
+	if !ok {
+		return nil, notFound("The resource 'projects/%s/global/firewalls/%s' was not found", project, rule)
+	}
+	// Fields left empty in the patch are omitted from its JSON, so they are kept.
+	clone(patch, fw)
+	return c.op("patch", rule), nil
+}
+
+// FirewallRule returns a firewall rule.
+func (c *Compute) FirewallRule(ctx context.Context, project, rule string) (*compute.Firewall, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fw, ok := c.firewalls[key(project, rule)]
+	if !ok {
+		return nil, notFound("The resource 'projects/%s/global/firewalls/%s' was not found", project, rule)
+	}
+	var out compute.Firewall
+	clone(fw, &out)
+	return &out, nil
+}
+
+// DeleteFirewallRule deletes a firewall rule.
+func (c *Compute) DeleteFirewallRule(ctx context.Context, project, rule string) (*compute.Operation, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	k := key(project, rule)
+	if _, ok := c.firewalls[k]; !ok {
+		return nil, notFound("The resource 'projects/%s/global/firewalls/%s' was not found", project, rule)
+	}
+	delete(c.firewalls, k)
+	return c.op("delete", rule), nil
+}
@@ -0,0 +1,630 @@
+package fakes
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The conformance tests run the same scenario against a client replaying a fixture and against
+// the fake, and check both observed the same. The fixtures under testdata follow the responses
+// documented in the API references. Re-record a fixture against real resources with -record,
+// for instance:
+//
+//	SRA_TEST_PROJECT=my-project go test ./clients/fakes -run TestComputeConformance -record
+//
+// Recording expects the resources each test documents to exist. Resource names other than the
+// project, bucket or source are fixed and the one from the environment is replaced by a
+// placeholder in the fixture.
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/storage"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/google/go-cmp/cmp"
+	"github.com/googlecloudplatform/security-response-automation/clients"
+	crm "google.golang.org/api/cloudresourcemanager/v1"
+	compute "google.golang.org/api/compute/v1"
+	container "google.golang.org/api/container/v1"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+	sqladmin "google.golang.org/api/sqladmin/v1beta4"
+	sccpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1beta1"
+	"google.golang.org/genproto/protobuf/field_mask"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// placeholder names the project, bucket or resource recorded against in fixtures.
+	placeholder = "sra-conformance"
+	// zone is the zone of the zonal resources recorded against.
+	zone = "us-central1-a"
+	// cloudPlatform is the scope the clients record with.
+	cloudPlatform = "https://www.googleapis.com/auth/cloud-platform"
+)
+
+// conformance returns the options for the client constructors replaying the fixture, along with
+// the name of the project, bucket or source the scenario runs against. With -record the
+// scenario runs against the one named by the environment variable instead and the fixture is
+// saved once done is called.
+func conformance(t *testing.T, fixture, env, name string, grpcAPI bool, scopes ...string) ([]option.ClientOption, string, func()) {
+	if !*record {
+		rep, err := LoadReplayer(fixture)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if grpcAPI {
+			return rep.GRPCClient(), name, func() {}
+		}
+		return []option.ClientOption{rep.Client()}, name, func() {}
+	}
+	recorded := os.Getenv(env)
+	if recorded == "" {
+		t.Fatalf("%s must name the resource to record against", env)
+	}
+	var opts []option.ClientOption
+	rec := NewRecorder(nil)
+	if grpcAPI {
+		opts = []option.ClientOption{rec.GRPCClient()}
+	} else {
+		o, r, err := NewRecordingClient(context.Background(), option.WithScopes(scopes...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		opts, rec = []option.ClientOption{o}, r
+	}
+	rec.Replace(recorded, name)
+	return opts, recorded, func() {
+		if err := rec.Save(fixture); err != nil {
+			t.Errorf("failed to save fixture: %v", err)
+		}
+	}
+}
+
+// observations is what a scenario observed, one line per call. Errors are reduced to their code
+// as the fakes do not reproduce the APIs' messages.
+type observations []string
+
+func (o *observations) note(op string, v interface{}, err error) {
+	var s string
+	switch e, ok := err.(*googleapi.Error); {
+	case err == storage.ErrObjectNotExist:
+		s = "object not found"
+	case ok:
+		s = fmt.Sprintf("error %d", e.Code)
+	case err != nil:
+		if st, ok := status.FromError(err); ok {
+			s = "error " + st.Code().String()
+		} else {
+			s = err.Error()
+		}
+	default:
+		s = strings.TrimSpace(strings.Replace(fmt.Sprint(v), "\n", " ", -1))
+	}
+	*o = append(*o, op+": "+s)
+}
+
+// first returns the first error an operation failed with, if any.
+func first(errs []error) error {
+	if len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// compare reports where the fake's observations differ from the recorded API's.
+func compare(t *testing.T, api string, recorded, fake observations) {
+	t.Helper()
+	if diff := cmp.Diff(recorded, fake); diff != "" {
+		t.Errorf("fake diverges from %s (-recorded +fake):\n%s", api, diff)
+	}
+}
+
+// computeClient is implemented by the Compute fake and client.
+type computeClient interface {
+	GetInstance(ctx context.Context, project, zone, instance string) (*compute.Instance, error)
+	SetTags(ctx context.Context, project, zone, instance string, tags *compute.Tags) (*compute.Operation, error)
+	AddAccessConfig(ctx context.Context, project, zone, instance, networkInterface string, accessConfig *compute.AccessConfig) (*compute.Operation, error)
+	DeleteAccessConfig(ctx context.Context, project, zone, instance, accessConfig, networkInterface string) (*compute.Operation, error)
+	StopInstance(ctx context.Context, project, zone, instance string) (*compute.Operation, error)
+	StartInstance(ctx context.Context, project, zone, instance string) (*compute.Operation, error)
+	CreateSnapshot(ctx context.Context, project, zone, disk string, snapshot *compute.Snapshot) (*compute.Operation, error)
+	ListProjectSnapshots(ctx context.Context, project string) (*compute.SnapshotList, error)
+	SetLabels(ctx context.Context, project, resource string, req *compute.GlobalSetLabelsRequest) (*compute.Operation, error)
+	DeleteDiskSnapshot(ctx context.Context, project, snapshot string) (*compute.Operation, error)
+	InsertFirewallRule(ctx context.Context, project string, rule *compute.Firewall) (*compute.Operation, error)
+	PatchFirewallRule(ctx context.Context, project, rule string, patch *compute.Firewall) (*compute.Operation, error)
+	FirewallRule(ctx context.Context, project, rule string) (*compute.Firewall, error)
+	DeleteFirewallRule(ctx context.Context, project, rule string) (*compute.Operation, error)
+	WaitZone(project, zone string, op *compute.Operation) []error
+	WaitGlobal(project string, op *compute.Operation) []error
+}
+
+// computeScenario exercises the instance, snapshot and firewall methods and returns what they
+// observed.
+func computeScenario(ctx context.Context, c computeClient, project string) observations {
+	var got observations
+	zonal := func(op string) func(*compute.Operation, error) {
+		return func(o *compute.Operation, err error) {
+			if err == nil {
+				err = first(c.WaitZone(project, zone, o))
+			}
+			got.note(op, "ok", err)
+		}
+	}
+	global := func(op string) func(*compute.Operation, error) {
+		return func(o *compute.Operation, err error) {
+			if err == nil {
+				err = first(c.WaitGlobal(project, o))
+			}
+			got.note(op, "ok", err)
+		}
+	}
+	instance := func() *compute.Instance {
+		i, err := c.GetInstance(ctx, project, zone, placeholder)
+		got.note("get instance", describeInstance(i), err)
+		return i
+	}
+	_, err := c.GetInstance(ctx, project, zone, placeholder+"-missing")
+	got.note("get instance", nil, err)
+	var fingerprint string
+	if i := instance(); i != nil && i.Tags != nil {
+		fingerprint = i.Tags.Fingerprint
+	}
+	zonal("set tags")(c.SetTags(ctx, project, zone, placeholder, &compute.Tags{Items: []string{"sra-quarantine"}, Fingerprint: fingerprint}))
+	zonal("delete access config")(c.DeleteAccessConfig(ctx, project, zone, placeholder, "external-nat", "nic0"))
+	instance()
+	zonal("add access config")(c.AddAccessConfig(ctx, project, zone, placeholder, "nic0", &compute.AccessConfig{Name: "external-nat", Type: "ONE_TO_ONE_NAT"}))
+	zonal("stop instance")(c.StopInstance(ctx, project, zone, placeholder))
+	instance()
+	zonal("start instance")(c.StartInstance(ctx, project, zone, placeholder))
+	instance()
+
+	snapshots := func() {
+		l, err := c.ListProjectSnapshots(ctx, project)
+		var names []string
+		if l != nil {
+			for _, s := range l.Items {
+				names = append(names, fmt.Sprintf("%s%v", s.Name, s.Labels))
+			}
+		}
+		got.note("list snapshots", names, err)
+	}
+	zonal("create snapshot")(c.CreateSnapshot(ctx, project, zone, placeholder, &compute.Snapshot{Name: placeholder}))
+	snapshots()
+	var labelFingerprint string
+	if l, err := c.ListProjectSnapshots(ctx, project); err == nil && len(l.Items) > 0 {
+		labelFingerprint = l.Items[0].LabelFingerprint
+	}
+	global("set labels")(c.SetLabels(ctx, project, placeholder, &compute.GlobalSetLabelsRequest{Labels: map[string]string{"sra": "conformance"}, LabelFingerprint: labelFingerprint}))
+	snapshots()
+	global("delete snapshot")(c.DeleteDiskSnapshot(ctx, project, placeholder))
+	snapshots()
+
+	rule := &compute.Firewall{
+		Name:         placeholder,
+		Network:      "global/networks/default",
+		SourceRanges: []string{"10.0.0.0/8"},
+		Allowed:      []*compute.FirewallAllowed{{IPProtocol: "tcp", Ports: []string{"22"}}},
+	}
+	global("insert firewall rule")(c.InsertFirewallRule(ctx, project, rule))
+	_, err = c.InsertFirewallRule(ctx, project, rule)
+	got.note("insert firewall rule", nil, err)
+	global("patch firewall rule")(c.PatchFirewallRule(ctx, project, placeholder, &compute.Firewall{Disabled: true}))
+	fw, err := c.FirewallRule(ctx, project, placeholder)
+	if err == nil {
+		got.note("get firewall rule", fmt.Sprintf("disabled=%t ranges=%v", fw.Disabled, fw.SourceRanges), nil)
+	} else {
+		got.note("get firewall rule", nil, err)
+	}
+	global("delete firewall rule")(c.DeleteFirewallRule(ctx, project, placeholder))
+	_, err = c.FirewallRule(ctx, project, placeholder)
+	got.note("get firewall rule", nil, err)
+	return got
+}
+
+// describeInstance returns the status, tags and access configs of an instance.
+func describeInstance(i *compute.Instance) string {
+	if i == nil {
+		return ""
+	}
+	var tags, configs []string
+	if i.Tags != nil {
+		tags = i.Tags.Items
+	}
+	for _, nic := range i.NetworkInterfaces {
+		for _, ac := range nic.AccessConfigs {
+			configs = append(configs, nic.Name+"/"+ac.Name)
+		}
+	}
+	return fmt.Sprintf("%s tags=%v access=%v", i.Status, tags, configs)
+}
+
+// TestComputeConformance checks the Compute fake behaves as Compute Engine. Recording expects a
+// running instance named sra-conformance in us-central1-a with an external-nat access config on
+// nic0 and a boot disk of the same name.
+func TestComputeConformance(t *testing.T) {
+	ctx := context.Background()
+	opts, project, done := conformance(t, "testdata/compute.json", "SRA_TEST_PROJECT", placeholder, false, cloudPlatform)
+	defer done()
+	client, err := clients.NewCompute(ctx, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := computeScenario(ctx, client, project)
+
+	fake := NewCompute()
+	fake.AddInstance(placeholder, zone, &compute.Instance{
+		Name: placeholder,
+		Tags: &compute.Tags{Fingerprint: "42WmSpB8rSM="},
+		NetworkInterfaces: []*compute.NetworkInterface{{
+			Name:          "nic0",
+			AccessConfigs: []*compute.AccessConfig{{Name: "external-nat", Type: "ONE_TO_ONE_NAT"}},
+		}},
+	})
+	fake.AddDisk(placeholder, zone, &compute.Disk{Name: placeholder, SizeGb: 10})
+	compare(t, "Compute Engine", want, computeScenario(ctx, fake, placeholder))
+}
+
+// crmClient is implemented by the ResourceManager fake and client.
+type crmClient interface {
+	GetAncestry(ctx context.Context, projectID string) (*crm.GetAncestryResponse, error)
+	GetPolicyProject(ctx context.Context, projectID string) (*crm.Policy, error)
+	SetPolicyProject(ctx context.Context, projectID string, p *crm.Policy) (*crm.Policy, error)
+}
+
+// crmScenario exercises the project ancestry and IAM policy methods and returns what they
+// observed.
+func crmScenario(ctx context.Context, c crmClient, project string) observations {
+	var got observations
+	member := fmt.Sprintf("serviceAccount:%s@%s.iam.gserviceaccount.com", placeholder, project)
+	bound := func(p *crm.Policy) string {
+		for _, b := range p.Bindings {
+			for _, m := range b.Members {
+				if b.Role == "roles/viewer" && m == member {
+					return "bound"
+				}
+			}
+		}
+		return "unbound"
+	}
+	a, err := c.GetAncestry(ctx, project)
+	var ancestry []string
+	if err == nil {
+		for _, r := range a.Ancestor {
+			ancestry = append(ancestry, r.ResourceId.Type)
+		}
+	}
+	got.note("get ancestry", ancestry, err)
+	_, err = c.GetPolicyProject(ctx, project+"-missing")
+	got.note("get policy", nil, err)
+	p, err := c.GetPolicyProject(ctx, project)
+	got.note("get policy", "ok", err)
+	if err != nil {
+		return got
+	}
+	updated := &crm.Policy{Etag: p.Etag, Version: p.Version, Bindings: append(p.Bindings, &crm.Binding{Role: "roles/viewer", Members: []string{member}})}
+	set, err := c.SetPolicyProject(ctx, project, updated)
+	if err == nil {
+		got.note("set policy", fmt.Sprintf("%s etag changed=%t", bound(set), set.Etag != p.Etag), nil)
+	} else {
+		got.note("set policy", nil, err)
+	}
+	_, err = c.SetPolicyProject(ctx, project, updated)
+	got.note("set stale policy", nil, err)
+	if p, err = c.GetPolicyProject(ctx, project); err != nil {
+		got.note("get policy", nil, err)
+		return got
+	}
+	got.note("get policy", bound(p), nil)
+	var bindings []*crm.Binding
+	for _, b := range p.Bindings {
+		if b.Role != "roles/viewer" {
+			bindings = append(bindings, b)
+			continue
+		}
+		var members []string
+		for _, m := range b.Members {
+			if m != member {
+				members = append(members, m)
+			}
+		}
+		if len(members) > 0 {
+			bindings = append(bindings, &crm.Binding{Role: b.Role, Members: members})
+		}
+	}
+	set, err = c.SetPolicyProject(ctx, project, &crm.Policy{Etag: p.Etag, Version: p.Version, Bindings: bindings})
+	if err == nil {
+		got.note("set policy", bound(set), nil)
+	} else {
+		got.note("set policy", nil, err)
+	}
+	return got
+}
+
+// TestResourceManagerConformance checks the ResourceManager fake behaves as Cloud Resource
+// Manager. Recording expects a project directly within an organization and changes the
+// project's IAM policy.
+func TestResourceManagerConformance(t *testing.T) {
+	ctx := context.Background()
+	opts, project, done := conformance(t, "testdata/resourcemanager.json", "SRA_TEST_PROJECT", placeholder, false, cloudPlatform)
+	defer done()
+	client, err := clients.NewCloudResourceManager(ctx, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := crmScenario(ctx, client, project)
+
+	fake := NewResourceManager()
+	fake.AddProject(placeholder, "organizations/1")
+	compare(t, "Cloud Resource Manager", want, crmScenario(ctx, fake, placeholder))
+}
+
+// cloudSQLClient is implemented by the CloudSQL fake and client.
+type cloudSQLClient interface {
+	InstanceDetails(ctx context.Context, projectID, instance string) (*sqladmin.DatabaseInstance, error)
+	PatchInstance(ctx context.Context, projectID, instance string, databaseInstance *sqladmin.DatabaseInstance) (*sqladmin.Operation, error)
+	UpdateUser(ctx context.Context, projectID, instance, host, name string, user *sqladmin.User) (*sqladmin.Operation, error)
+	WaitSQL(projectID string, op *sqladmin.Operation) []error
+}
+
+// cloudSQLScenario exercises the instance and user methods and returns what they observed.
+func cloudSQLScenario(ctx context.Context, c cloudSQLClient, project string) observations {
+	var got observations
+	wait := func(op string) func(*sqladmin.Operation, error) {
+		return func(o *sqladmin.Operation, err error) {
+			if err == nil {
+				err = first(c.WaitSQL(project, o))
+			}
+			got.note(op, "ok", err)
+		}
+	}
+	instance := func() {
+		i, err := c.InstanceDetails(ctx, project, placeholder)
+		var ssl bool
+		if err == nil && i.Settings != nil && i.Settings.IpConfiguration != nil {
+			ssl = i.Settings.IpConfiguration.RequireSsl
+		}
+		got.note("get instance", fmt.Sprintf("requireSsl=%t", ssl), err)
+	}
+	_, err := c.InstanceDetails(ctx, project, placeholder+"-missing")
+	got.note("get instance", nil, err)
+	instance()
+	wait("patch instance")(c.PatchInstance(ctx, project, placeholder, &sqladmin.DatabaseInstance{
+		Settings: &sqladmin.Settings{IpConfiguration: &sqladmin.IpConfiguration{RequireSsl: true}},
+	}))
+	instance()
+	wait("update user")(c.UpdateUser(ctx, project, placeholder, "%", "root", &sqladmin.User{Name: "root", Password: "conformance-password"}))
+	return got
+}
+
+// TestCloudSQLConformance checks the CloudSQL fake behaves as Cloud SQL. Recording expects a
+// MySQL instance named sra-conformance not requiring SSL, and changes its root password.
+func TestCloudSQLConformance(t *testing.T) {
+	ctx := context.Background()
+	const fixture = "testdata/cloudsql.json"
+	opts, project, done := conformance(t, fixture, "SRA_TEST_PROJECT", placeholder, false, cloudPlatform)
+	defer done()
+	client, err := clients.NewCloudSQL(ctx, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := cloudSQLScenario(ctx, client, project)
+
+	fake := NewCloudSQL()
+	fake.AddInstance(placeholder, &sqladmin.DatabaseInstance{
+		Name:     placeholder,
+		Settings: &sqladmin.Settings{IpConfiguration: &sqladmin.IpConfiguration{Ipv4Enabled: true}},
+	})
+	fake.AddUser(placeholder, placeholder, &sqladmin.User{Name: "root", Host: "%"})
+	compare(t, "Cloud SQL", want, cloudSQLScenario(ctx, fake, placeholder))
+	if b, err := ioutil.ReadFile(fixture); err == nil && strings.Contains(string(b), "conformance-password") {
+		t.Errorf("%s holds the password set", fixture)
+	}
+}
+
+// bigQueryClient is implemented by the BigQuery fake and client.
+type bigQueryClient interface {
+	DatasetMetadata(ctx context.Context, projectID, datasetID string) (*bigquery.DatasetMetadata, error)
+	OverwriteDatasetMetadata(ctx context.Context, projectID, datasetID string, dm bigquery.DatasetMetadataToUpdate) (*bigquery.DatasetMetadata, error)
+	Insert(ctx context.Context, projectID, datasetID, tableID string, rows interface{}) error
+}
+
+// conformanceRow is a row streamed by bigQueryScenario.
+type conformanceRow struct {
+	Name string
+}
+
+// bigQueryScenario exercises the dataset and streaming methods and returns what they observed.
+func bigQueryScenario(ctx context.Context, c bigQueryClient, project string) observations {
+	const dataset = "sra_conformance"
+	var got observations
+	describe := func(op string) func(*bigquery.DatasetMetadata, error) {
+		return func(md *bigquery.DatasetMetadata, err error) {
+			if err != nil {
+				got.note(op, nil, err)
+				return
+			}
+			var access []string
+			for _, a := range md.Access {
+				access = append(access, fmt.Sprintf("%s:%s", a.Role, a.Entity))
+			}
+			sort.Strings(access)
+			got.note(op, fmt.Sprintf("%q access=%v", md.Description, access), nil)
+		}
+	}
+	_, err := c.DatasetMetadata(ctx, project, dataset+"_missing")
+	got.note("get dataset", nil, err)
+	md, err := c.DatasetMetadata(ctx, project, dataset)
+	if err == nil {
+		got.note("get dataset", fmt.Sprintf("%q", md.Description), nil)
+	} else {
+		got.note("get dataset", nil, err)
+	}
+	describe("update dataset")(c.OverwriteDatasetMetadata(ctx, project, dataset, bigquery.DatasetMetadataToUpdate{
+		Description: "closed by sra",
+		Access: []*bigquery.AccessEntry{
+			{Role: bigquery.OwnerRole, EntityType: bigquery.SpecialGroupEntity, Entity: "projectOwners"},
+			{Role: bigquery.ReaderRole, EntityType: bigquery.SpecialGroupEntity, Entity: "projectReaders"},
+		},
+	}))
+	describe("get dataset")(c.DatasetMetadata(ctx, project, dataset))
+	got.note("insert", "ok", c.Insert(ctx, project, dataset, "rows", []*conformanceRow{{Name: "first"}, {Name: "second"}}))
+	got.note("insert", "ok", c.Insert(ctx, project, dataset+"_missing", "rows", &conformanceRow{Name: "first"}))
+	return got
+}
+
+// TestBigQueryConformance checks the BigQuery fake behaves as BigQuery. Recording expects a
+// dataset named sra_conformance described as "sra conformance" with a table named rows holding
+// a STRING column Name.
+func TestBigQueryConformance(t *testing.T) {
+	ctx := context.Background()
+	opts, project, done := conformance(t, "testdata/bigquery.json", "SRA_TEST_PROJECT", placeholder, false, cloudPlatform)
+	defer done()
+	client, err := clients.NewBigQuery(ctx, project, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := bigQueryScenario(ctx, client, project)
+
+	fake := NewBigQuery()
+	fake.AddDataset(placeholder, "sra_conformance", &bigquery.DatasetMetadata{Description: "sra conformance"})
+	compare(t, "BigQuery", want, bigQueryScenario(ctx, fake, placeholder))
+}
+
+// containerClient is implemented by the Container fake and client.
+type containerClient interface {
+	GetCluster(ctx context.Context, projectID, zone, clusterID string) (*container.Cluster, error)
+	UpdateAddonsConfig(ctx context.Context, projectID, zone, clusterID string, conf *container.SetAddonsConfigRequest) (*container.Operation, error)
+}
+
+// containerScenario exercises the cluster methods and returns what they observed.
+func containerScenario(ctx context.Context, c containerClient, project string) observations {
+	var got observations
+	cluster := func(name string) {
+		cl, err := c.GetCluster(ctx, project, zone, name)
+		var disabled bool
+		if err == nil && cl.AddonsConfig != nil && cl.AddonsConfig.KubernetesDashboard != nil {
+			disabled = cl.AddonsConfig.KubernetesDashboard.Disabled
+		}
+		got.note("get cluster", fmt.Sprintf("dashboard disabled=%t", disabled), err)
+	}
+	disable := &container.SetAddonsConfigRequest{
+		AddonsConfig: &container.AddonsConfig{KubernetesDashboard: &container.KubernetesDashboard{Disabled: true}},
+	}
+	cluster(placeholder + "-missing")
+	cluster(placeholder)
+	_, err := c.UpdateAddonsConfig(ctx, project, zone, placeholder, disable)
+	got.note("update addons", "ok", err)
+	cluster(placeholder)
+	_, err = c.UpdateAddonsConfig(ctx, project, zone, placeholder+"-missing", disable)
+	got.note("update addons", "ok", err)
+	return got
+}
+
+// TestContainerConformance checks the Container fake behaves as Kubernetes Engine. Recording
+// expects a zonal cluster named sra-conformance in us-central1-a with the Kubernetes dashboard
+// enabled.
+func TestContainerConformance(t *testing.T) {
+	ctx := context.Background()
+	opts, project, done := conformance(t, "testdata/container.json", "SRA_TEST_PROJECT", placeholder, false, cloudPlatform)
+	defer done()
+	client, err := clients.NewContainer(ctx, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := containerScenario(ctx, client, project)
+
+	fake := NewContainer()
+	fake.AddCluster(placeholder, zone, &container.Cluster{
+		Name:         placeholder,
+		AddonsConfig: &container.AddonsConfig{KubernetesDashboard: &container.KubernetesDashboard{}},
+	})
+	compare(t, "Kubernetes Engine", want, containerScenario(ctx, fake, placeholder))
+}
+
+// commandCenterClient is implemented by the SecurityCommandCenter fake and client.
+type commandCenterClient interface {
+	GetFinding(ctx context.Context, name string) (*sccpb.Finding, error)
+	AddSecurityMarks(ctx context.Context, request *sccpb.UpdateSecurityMarksRequest) (*sccpb.SecurityMarks, error)
+	SetFindingState(ctx context.Context, request *sccpb.SetFindingStateRequest) (*sccpb.Finding, error)
+	ListFindings(ctx context.Context, parent, filter string) ([]*sccpb.Finding, error)
+}
+
+// commandCenterScenario exercises the finding methods and returns what they observed.
+func commandCenterScenario(ctx context.Context, c commandCenterClient, source string) observations {
+	var got observations
+	name := source + "/findings/sraconformance"
+	// A fixed start time keeps the requests replayable.
+	startTime := &timestamp.Timestamp{Seconds: 1577836800}
+	finding := func(op string) func(*sccpb.Finding, error) {
+		return func(f *sccpb.Finding, err error) {
+			if err == nil {
+				got.note(op, fmt.Sprintf("%s marks=%v", f.GetState(), f.GetSecurityMarks().GetMarks()), nil)
+			} else {
+				got.note(op, nil, err)
+			}
+		}
+	}
+	marks := func(m map[string]string) {
+		sm, err := c.AddSecurityMarks(ctx, &sccpb.UpdateSecurityMarksRequest{
+			SecurityMarks: &sccpb.SecurityMarks{Name: name + "/securityMarks", Marks: m},
+			UpdateMask:    &field_mask.FieldMask{Paths: []string{"marks.sra-conformance"}},
+		})
+		got.note("mark", sm.GetMarks(), err)
+	}
+	state := func(s sccpb.Finding_State) {
+		finding("set state")(c.SetFindingState(ctx, &sccpb.SetFindingStateRequest{Name: name, State: s, StartTime: startTime}))
+	}
+	_, err := c.GetFinding(ctx, source+"/findings/missing")
+	got.note("get finding", nil, err)
+	finding("get finding")(c.GetFinding(ctx, name))
+	marks(map[string]string{"sra-conformance": "marked"})
+	state(sccpb.Finding_INACTIVE)
+	finding("get finding")(c.GetFinding(ctx, name))
+	findings, err := c.ListFindings(ctx, source, "")
+	var names []string
+	for _, f := range findings {
+		names = append(names, f.GetName())
+	}
+	got.note("list findings", names, err)
+	state(sccpb.Finding_ACTIVE)
+	marks(nil)
+	return got
+}
+
+// TestSecurityCommandCenterConformance checks the SecurityCommandCenter fake behaves as
+// Security Command Center. Recording expects an active finding with the ID sraconformance to be
+// the only finding of the source named by SRA_TEST_SOURCE, such as
+// organizations/123/sources/456.
+func TestSecurityCommandCenterConformance(t *testing.T) {
+	ctx := context.Background()
+	const source = "organizations/1/sources/2"
+	opts, recorded, done := conformance(t, "testdata/commandcenter.json", "SRA_TEST_SOURCE", source, true)
+	defer done()
+	client, err := clients.NewSecurityCommandCenter(ctx, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := commandCenterScenario(ctx, client, recorded)
+
+	fake := NewSecurityCommandCenter()
+	fake.AddFinding(&sccpb.Finding{Name: source + "/findings/sraconformance", Parent: source, State: sccpb.Finding_ACTIVE})
+	compare(t, "Security Command Center", want, commandCenterScenario(ctx, fake, source))
+}
@@ -0,0 +1,83 @@
+package fakes
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	container "google.golang.org/api/container/v1"
+)
+
+// Container is a fake Kubernetes Engine client serving clusters. Operations complete
+// immediately.
+type Container struct {
+	mu sync.Mutex
+	// clusters are keyed by project, zone and name.
+	clusters map[string]*container.Cluster
+	ops      int
+}
+
+// NewContainer returns a fake without clusters.
+func NewContainer() *Container {
+	return &Container{clusters: make(map[string]*container.Cluster)}
+}
+
+// AddCluster seeds a cluster.
+func (c *Container) AddCluster(project, zone string, cluster *container.Cluster) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var cl container.Cluster
+	clone(cluster, &cl)
+	c.clusters[key(project, zone, cl.Name)] = &cl
+}
+
+// Cluster returns the current state of a cluster, nil if it does not exist.
+func (c *Container) Cluster(project, zone, name string) *container.Cluster {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cl, ok := c.clusters[key(project, zone, name)]
+	if !ok {
+		return nil
+	}
+	var out container.Cluster
+	clone(cl, &out)
+	return &out
+}
+
+// GetCluster returns a copy of the cluster.
+func (c *Container) GetCluster(ctx context.Context, projectID, zone, clusterID string) (*container.Cluster, error) {
+	if cl := c.Cluster(projectID, zone, clusterID); cl != nil {
+		return cl, nil
+	}
+	return nil, notFound("Not found: projects/%s/zones/%s/clusters/%s.", projectID, zone, clusterID)
+}
+
+// UpdateAddonsConfig updates the addons set in the request, as the API does.
+func (c *Container) UpdateAddonsConfig(ctx context.Context, projectID, zone, clusterID string, conf *container.SetAddonsConfigRequest) (*container.Operation, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cl, ok := c.clusters[key(projectID, zone, clusterID)]
+	if !ok {
+		return nil, notFound("Not found: projects/%s/zones/%s/clusters/%s.", projectID, zone, clusterID)
+	}
+	if cl.AddonsConfig == nil {
+		cl.AddonsConfig = &container.AddonsConfig{}
+	}
+	clone(conf.AddonsConfig, cl.AddonsConfig)
+	c.ops++
+	return &container.Operation{Name: fmt.Sprintf("operation-%d", c.ops), OperationType: "UPDATE_CLUSTER", Zone: zone, TargetLink: clusterID, Status: "DONE"}, nil
+}
@@ -0,0 +1,117 @@
+package fakes_test
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/pubsub"
+	"cloud.google.com/go/storage"
+	"github.com/google/go-cmp/cmp"
+	"github.com/googlecloudplatform/security-response-automation/clients/fakes"
+	"github.com/googlecloudplatform/security-response-automation/clients/stubs"
+	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/gcs/closebucket"
+	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/router"
+	"github.com/googlecloudplatform/security-response-automation/services"
+	sccpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1beta1"
+)
+
+const findingName = "organizations/154584661726/sources/2673592633662526977/findings/782e52631d61da6117a3772137c270d8"
+
+// TestCloseBucket routes a public bucket finding through the router to close_bucket, as the
+// deployed functions would, and checks the bucket is no longer public.
+func TestCloseBucket(t *testing.T) {
+	ctx := context.Background()
+	crm := fakes.NewResourceManager()
+	crm.AddProject("test-project", "folders/123", "organizations/456")
+	gcs := fakes.NewStorage()
+	gcs.AddBucket(&storage.BucketAttrs{Name: "this-is-public-on-purpose"}, map[string][]string{
+		"roles/storage.objectViewer":       {"allUsers", "user:alice@example.com"},
+		"roles/storage.legacyBucketReader": {"allAuthenticatedUsers"},
+	})
+	gcs.AddBucket(&storage.BucketAttrs{Name: "sra-snapshots"}, nil)
+	bq := fakes.NewBigQuery()
+	bq.AddDataset("audit-project", "sra_audit", &bigquery.DatasetMetadata{})
+	scc := fakes.NewSecurityCommandCenter()
+	scc.AddFinding(&sccpb.Finding{Name: findingName, State: sccpb.Finding_ACTIVE})
+
+	resource := services.NewResource(crm, gcs)
+	logger := services.NewLogger(&stubs.LoggerStub{})
+	dispatcher := services.NewDispatcher()
+	dispatcher.Handle("threat-findings-close-bucket", func(ctx context.Context, m pubsub.Message) error {
+		var values closebucket.Values
+		if err := json.Unmarshal(m.Data, &values); err != nil {
+			return err
+		}
+		return closebucket.Execute(ctx, &values, &closebucket.Services{
+			Resource:  resource,
+			Logger:    logger,
+			Audit:     services.NewAudit(bq, "audit-project", "sra_audit", "remediations"),
+			Snapshots: services.NewSnapshots(gcs, "sra-snapshots"),
+		})
+	})
+	conf := &router.Configuration{}
+	conf.Spec.Parameters = map[string]map[string][]router.Automation{"sha": {
+		"public_bucket_acl": {{Action: "close_bucket", Target: []string{"organizations/456/folders/123/*"}}},
+	}}
+	finding, err := ioutil.ReadFile("../../cloudfunctions/router/testdata/public_bucket_acl.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := router.Execute(ctx, &router.Values{Finding: finding}, &router.Services{
+		PubSub:                services.NewPubSub(dispatcher),
+		Configuration:         conf,
+		Logger:                logger,
+		Resource:              resource,
+		SecurityCommandCenter: services.NewCommandCenter(scc),
+	}); err != nil {
+		t.Fatalf("router.Execute() = %v, want nil", err)
+	}
+
+	want := map[string][]string{"roles/storage.objectViewer": {"user:alice@example.com"}}
+	if diff := cmp.Diff(want, gcs.BucketBindings("this-is-public-on-purpose")); diff != "" {
+		t.Errorf("bucket bindings (-want +got):\n%s", diff)
+	}
+	rows := bq.Rows("audit-project", "sra_audit", "remediations")
+	if len(rows) != 1 {
+		t.Fatalf("audit rows = %d, want 1", len(rows))
+	}
+	row := rows[0].(*services.RemediationRow)
+	if before := `{"roles/storage.legacyBucketReader":["allAuthenticatedUsers"],"roles/storage.objectViewer":["allUsers","user:alice@example.com"]}`; row.Before != before {
+		t.Errorf("audit before = %s, want %s", row.Before, before)
+	}
+	if after := `{"roles/storage.objectViewer":["user:alice@example.com"]}`; row.After != after {
+		t.Errorf("audit after = %s, want %s", row.After, after)
+	}
+	snapshots, err := gcs.ListObjects(ctx, "sra-snapshots", "")
+	if err != nil || len(snapshots) != 1 {
+		t.Fatalf("snapshots = %q, %v, want one snapshot", snapshots, err)
+	}
+	if snapshots[0] != "snapshots/"+row.RemediationID {
+		t.Errorf("snapshot = %q, want it stored under remediation %q", snapshots[0], row.RemediationID)
+	}
+	wantMetadata := map[string]string{"action": "close_bucket", "resource": "//storage.googleapis.com/this-is-public-on-purpose"}
+	if diff := cmp.Diff(wantMetadata, gcs.ObjectMetadata("sra-snapshots", snapshots[0])); diff != "" {
+		t.Errorf("snapshot metadata (-want +got):\n%s", diff)
+	}
+	if marks := scc.Finding(findingName).GetSecurityMarks().GetMarks(); marks["sra-remediated-event-time"] == "" {
+		t.Errorf("finding marks = %v, want the remediated event time", marks)
+	}
+}
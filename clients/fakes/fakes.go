@@ -0,0 +1,60 @@
+// Package fakes provides stateful in-memory fakes of the Google Cloud clients.
+//
+// Unlike the stubs, which return canned responses, each fake keeps a model of the resources it
+// serves: tests seed resources, run automations against the fakes and assert on the resulting
+// state, such as a bucket's IAM policy after closing it. Fakes answer missing resources with the
+// same errors as the APIs they stand for.
+//
+// Record real API responses with a Recorder and replay them with a Replayer to check the fakes
+// behave as the APIs do.
+package fakes
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"google.golang.org/api/googleapi"
+)
+
+// notFound returns the error the REST APIs answer missing resources with.
+func notFound(format string, a ...interface{}) error {
+	return &googleapi.Error{Code: http.StatusNotFound, Message: fmt.Sprintf(format, a...)}
+}
+
+// alreadyExists returns the error the REST APIs answer conflicting inserts with.
+func alreadyExists(format string, a ...interface{}) error {
+	return &googleapi.Error{Code: http.StatusConflict, Message: fmt.Sprintf(format, a...)}
+}
+
+// clone deep copies in into out through its JSON representation, as resources travel over the
+// wire, so callers never share state with the fake.
+func clone(in, out interface{}) {
+	b, err := json.Marshal(in)
+	if err != nil {
+		panic(fmt.Sprintf("fakes: failed to marshal %T: %v", in, err))
+	}
+	if err := json.Unmarshal(b, out); err != nil {
+		panic(fmt.Sprintf("fakes: failed to unmarshal %T: %v", out, err))
+	}
+}
+
+// key joins the parts naming a resource.
+func key(parts ...string) string {
+	return strings.Join(parts, "/")
+}
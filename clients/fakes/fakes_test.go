@@ -0,0 +1,140 @@
+package fakes
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/storage"
+	"github.com/google/go-cmp/cmp"
+	"github.com/googlecloudplatform/security-response-automation/clients"
+)
+
+var record = flag.Bool("record", false, "record the conformance fixtures against the resources named by the environment")
+
+func TestRecordReplay(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(r.Method + " " + r.URL.Path + " " + string(b)))
+	}))
+	defer srv.Close()
+	dir, err := ioutil.TempDir("", "fakes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "fixture.json")
+
+	rec := NewRecorder(http.DefaultTransport)
+	c := &http.Client{Transport: rec}
+	want := []string{do(t, c, "POST", srv.URL+"/b?key=secret", "one"), do(t, c, "POST", srv.URL+"/b?key=secret", `{"name":"root","password":"hunter2"}`)}
+	if err := rec.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	fixture, _ := ioutil.ReadFile(path)
+	if strings.Contains(string(fixture), "secret") {
+		t.Errorf("fixture holds the API key:\n%s", fixture)
+	}
+	var recorded []Interaction
+	if err := json.Unmarshal(fixture, &recorded); err != nil {
+		t.Fatal(err)
+	}
+	if len(recorded) != 2 || strings.Contains(recorded[1].RequestBody, "hunter2") {
+		t.Errorf("recorded %+v, want the password redacted", recorded)
+	}
+
+	rep, err := LoadReplayer(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c = &http.Client{Transport: rep}
+	got := []string{do(t, c, "POST", srv.URL+"/b?key=other", "one"), do(t, c, "POST", srv.URL+"/b", "")}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("replayed responses (-want +got):\n%s", diff)
+	}
+	if _, err := c.Get(srv.URL + "/b"); err == nil {
+		t.Errorf("Get() of an unrecorded request = nil, want error")
+	}
+}
+
+func do(t *testing.T, c *http.Client, method, url, body string) string {
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.Status + " " + string(b)
+}
+
+// objectClient is implemented by the Storage fake and client.
+type objectClient interface {
+	WriteObject(ctx context.Context, bucketName, name string, b []byte) error
+	ReadObject(ctx context.Context, bucketName, name string) ([]byte, error)
+	ListObjects(ctx context.Context, bucketName, prefix string) ([]string, error)
+	DeleteObject(ctx context.Context, bucketName, name string) error
+}
+
+// objectScenario exercises the object methods and returns what they observed.
+func objectScenario(ctx context.Context, c objectClient, bucket string) observations {
+	var got observations
+	got.note("write", "ok", c.WriteObject(ctx, bucket, "sra-conformance/a", []byte("first")))
+	got.note("write", "ok", c.WriteObject(ctx, bucket, "sra-conformance/a", []byte("second")))
+	got.note("write", "ok", c.WriteObject(ctx, bucket, "sra-conformance/b", []byte("other")))
+	b, err := c.ReadObject(ctx, bucket, "sra-conformance/a")
+	got.note("read", string(b), err)
+	names, err := c.ListObjects(ctx, bucket, "sra-conformance/")
+	got.note("list", names, err)
+	got.note("delete", "ok", c.DeleteObject(ctx, bucket, "sra-conformance/a"))
+	got.note("delete", "ok", c.DeleteObject(ctx, bucket, "sra-conformance/b"))
+	b, err = c.ReadObject(ctx, bucket, "sra-conformance/a")
+	got.note("read", string(b), err)
+	got.note("delete", "ok", c.DeleteObject(ctx, bucket, "sra-conformance/a"))
+	return got
+}
+
+// TestStorageConformance checks the Storage fake behaves as Cloud Storage. Recording expects
+// SRA_TEST_BUCKET to name a bucket without objects under sra-conformance/.
+func TestStorageConformance(t *testing.T) {
+	ctx := context.Background()
+	opts, bucket, done := conformance(t, "testdata/storage.json", "SRA_TEST_BUCKET", placeholder, false, storage.ScopeFullControl)
+	defer done()
+	client, err := clients.NewStorage(ctx, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := objectScenario(ctx, client, bucket)
+
+	fake := NewStorage()
+	fake.AddBucket(&storage.BucketAttrs{Name: placeholder}, nil)
+	compare(t, "Cloud Storage", want, objectScenario(ctx, fake, placeholder))
+}
@@ -0,0 +1,345 @@
+package fakes
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"google.golang.org/api/option"
+	htransport "google.golang.org/api/transport/http"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// redacted replaces the values of request fields holding secrets.
+const redacted = "REDACTED"
+
+// secretFields are the JSON fields of request bodies never recorded, such as the password set
+// by updatepassword.
+var secretFields = map[string]bool{"password": true, "privateKeyData": true, "secret": true}
+
+// Interaction is an HTTP request made to a Google Cloud API and the response it got. Calls to
+// gRPC APIs are recorded with the full method name as Method, no URL and the gRPC code as
+// Status. Their bodies are the messages in JSON, or the error message for failed calls.
+type Interaction struct {
+	Method       string
+	URL          string
+	RequestBody  string `json:",omitempty"`
+	Status       int
+	ResponseBody string `json:",omitempty"`
+}
+
+// Recorder is an http.RoundTripper recording the interactions made through it. Headers are not
+// recorded and secrets are redacted from request bodies so fixtures never hold credentials.
+type Recorder struct {
+	mu           sync.Mutex
+	transport    http.RoundTripper
+	interactions []Interaction
+	// replacements are pairs of strings replaced when saving.
+	replacements []string
+}
+
+// NewRecorder returns a Recorder sending requests through the transport.
+func NewRecorder(transport http.RoundTripper) *Recorder {
+	return &Recorder{transport: transport}
+}
+
+// NewRecordingClient returns an option for the client constructors, such as
+// clients.NewStorage, that authenticates with the default credentials and records the
+// interactions with the API.
+func NewRecordingClient(ctx context.Context, opts ...option.ClientOption) (option.ClientOption, *Recorder, error) {
+	r := NewRecorder(http.DefaultTransport)
+	t, err := htransport.NewTransport(ctx, r, opts...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to init recording transport: %q", err)
+	}
+	return option.WithHTTPClient(&http.Client{Transport: t}), r, nil
+}
+
+// RoundTrip sends the request and records it along with its response.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readBody(&req.Body)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := readBody(&resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.interactions = append(r.interactions, Interaction{
+		Method:       req.Method,
+		URL:          redact(req.URL),
+		RequestBody:  redactBody(reqBody),
+		Status:       resp.StatusCode,
+		ResponseBody: respBody,
+	})
+	return resp, nil
+}
+
+// GRPCClient returns an option for the gRPC client constructors, such as
+// clients.NewSecurityCommandCenter, recording the calls made with the default credentials.
+func (r *Recorder) GRPCClient() option.ClientOption {
+	return option.WithGRPCDialOption(grpc.WithUnaryInterceptor(r.intercept))
+}
+
+// intercept makes the call and records it along with its reply.
+func (r *Recorder) intercept(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	in := Interaction{Method: method, RequestBody: marshalMessage(req), Status: int(status.Code(err))}
+	if err != nil {
+		in.ResponseBody = status.Convert(err).Message()
+	} else {
+		in.ResponseBody = marshalMessage(reply)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.interactions = append(r.interactions, in)
+	return err
+}
+
+// Replace replaces old with new in the URLs and bodies of the saved interactions, such as the
+// ID of the project recorded against, so replays do not depend on it.
+func (r *Recorder) Replace(old, new string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.replacements = append(r.replacements, old, new)
+}
+
+// Save writes the recorded interactions to a fixture.
+func (r *Recorder) Save(path string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rep := strings.NewReplacer(r.replacements...)
+	interactions := make([]Interaction, 0, len(r.interactions))
+	for _, in := range r.interactions {
+		in.URL = rep.Replace(in.URL)
+		in.RequestBody = rep.Replace(in.RequestBody)
+		in.ResponseBody = rep.Replace(in.ResponseBody)
+		interactions = append(interactions, in)
+	}
+	b, err := json.MarshalIndent(interactions, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(b, '\n'), 0644)
+}
+
+// Replayer is an http.RoundTripper answering requests with the responses of a fixture.
+type Replayer struct {
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// LoadReplayer returns a Replayer for the fixture saved by a Recorder.
+func LoadReplayer(path string) (*Replayer, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var interactions []Interaction
+	if err := json.Unmarshal(b, &interactions); err != nil {
+		return nil, fmt.Errorf("failed to parse fixture %q: %q", path, err)
+	}
+	return &Replayer{interactions: interactions, used: make([]bool, len(interactions))}, nil
+}
+
+// Client returns an option for the client constructors replaying the fixture.
+func (r *Replayer) Client() option.ClientOption {
+	return option.WithHTTPClient(&http.Client{Transport: r})
+}
+
+// GRPCClient returns options for the gRPC client constructors replaying the fixture. No
+// connection is made.
+func (r *Replayer) GRPCClient() []option.ClientOption {
+	return []option.ClientOption{
+		option.WithEndpoint("localhost:0"),
+		option.WithoutAuthentication(),
+		option.WithGRPCDialOption(grpc.WithInsecure()),
+		option.WithGRPCDialOption(grpc.WithUnaryInterceptor(r.intercept)),
+	}
+}
+
+// intercept answers the call with the first unused interaction of the same method and request.
+func (r *Replayer) intercept(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	body := marshalMessage(req)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, in := range r.interactions {
+		if r.used[i] || in.Method != method || in.URL != "" || in.RequestBody != body {
+			continue
+		}
+		r.used[i] = true
+		if c := codes.Code(in.Status); c != codes.OK {
+			return status.Error(c, in.ResponseBody)
+		}
+		m, ok := reply.(proto.Message)
+		if !ok {
+			return fmt.Errorf("reply of %s is a %T, not a message", method, reply)
+		}
+		return protojson.Unmarshal([]byte(in.ResponseBody), m)
+	}
+	return status.Errorf(codes.Unimplemented, "no recorded interaction for %s %s", method, body)
+}
+
+// RoundTrip answers the request with the first unused interaction of the same method and URL.
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	if _, err := readBody(&req.Body); err != nil {
+		return nil, err
+	}
+	u := redact(req.URL)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, in := range r.interactions {
+		if r.used[i] || in.Method != req.Method || in.URL != u {
+			continue
+		}
+		r.used[i] = true
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", in.Status, http.StatusText(in.Status)),
+			StatusCode:    in.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        http.Header{"Content-Type": []string{"application/json; charset=UTF-8"}},
+			Body:          ioutil.NopCloser(bytes.NewBufferString(in.ResponseBody)),
+			ContentLength: int64(len(in.ResponseBody)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("no recorded interaction for %s %s", req.Method, u)
+}
+
+// readBody reads a request or response body and replaces it so it can be read again.
+func readBody(body *io.ReadCloser) (string, error) {
+	if *body == nil {
+		return "", nil
+	}
+	b, err := ioutil.ReadAll(*body)
+	(*body).Close()
+	if err != nil {
+		return "", err
+	}
+	*body = ioutil.NopCloser(bytes.NewReader(b))
+	return string(b), nil
+}
+
+// marshalMessage returns the gRPC message in JSON with its secrets redacted. Field masks are
+// recorded as their comma separated paths since protojson rejects paths that are not valid
+// field names, such as those of security marks.
+func marshalMessage(v interface{}) string {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return ""
+	}
+	m = proto.Clone(m)
+	r := m.ProtoReflect()
+	masks := map[string]interface{}{}
+	var fields []protoreflect.FieldDescriptor
+	r.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if fd.Message() == nil || fd.Message().FullName() != "google.protobuf.FieldMask" || fd.IsList() || fd.IsMap() {
+			return true
+		}
+		paths := v.Message().Get(fd.Message().Fields().ByName("paths")).List()
+		joined := make([]string, paths.Len())
+		for i := range joined {
+			joined[i] = paths.Get(i).String()
+		}
+		masks[fd.JSONName()] = strings.Join(joined, ",")
+		fields = append(fields, fd)
+		return true
+	})
+	for _, fd := range fields {
+		r.Clear(fd)
+	}
+	b, err := protojson.Marshal(m)
+	if err != nil {
+		return err.Error()
+	}
+	if len(masks) == 0 {
+		return redactBody(string(b))
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal(b, &body); err != nil {
+		return err.Error()
+	}
+	for k, v := range masks {
+		body[k] = v
+	}
+	if b, err = json.Marshal(redactValue(body)); err != nil {
+		return err.Error()
+	}
+	return string(b)
+}
+
+// redactBody returns the JSON body with the values of secretFields replaced. Other bodies, such
+// as uploaded objects, are returned as is.
+func redactBody(body string) string {
+	var v interface{}
+	if err := json.Unmarshal([]byte(body), &v); err != nil {
+		return body
+	}
+	b, err := json.Marshal(redactValue(v))
+	if err != nil {
+		return body
+	}
+	return string(b)
+}
+
+func redactValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, f := range v {
+			if secretFields[k] {
+				v[k] = redacted
+				continue
+			}
+			v[k] = redactValue(f)
+		}
+	case []interface{}:
+		for i, f := range v {
+			v[i] = redactValue(f)
+		}
+	}
+	return v
+}
+
+// redact returns the URL without its API key, if any.
+func redact(u *url.URL) string {
+	c := *u
+	q := c.Query()
+	q.Del("key")
+	c.RawQuery = q.Encode()
+	return c.String()
+}
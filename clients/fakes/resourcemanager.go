@@ -0,0 +1,199 @@
+package fakes
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	crm "google.golang.org/api/cloudresourcemanager/v1"
+	"google.golang.org/api/googleapi"
+)
+
+// ResourceManager is a fake Cloud Resource Manager client serving projects, organizations and
+// their IAM policies.
+type ResourceManager struct {
+	mu sync.Mutex
+	// ancestry maps project IDs to their ancestors, starting with the project itself.
+	ancestry map[string][]*crm.Ancestor
+	// organizations are keyed by their resource name, such as organizations/456.
+	organizations map[string]*crm.Organization
+	// policies are keyed by the resource name of projects and organizations, such as
+	// projects/test-project.
+	policies map[string]*crm.Policy
+	etags    uint64
+}
+
+// NewResourceManager returns a fake without projects or organizations.
+func NewResourceManager() *ResourceManager {
+	return &ResourceManager{
+		ancestry:      make(map[string][]*crm.Ancestor),
+		organizations: make(map[string]*crm.Organization),
+		policies:      make(map[string]*crm.Policy),
+	}
+}
+
+// AddProject seeds a project with an empty IAM policy. Parents are resource names such as
+// folders/123 and organizations/456 ordered from the project's direct parent up.
+func (r *ResourceManager) AddProject(projectID string, parents ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ancestors := []*crm.Ancestor{{ResourceId: &crm.ResourceId{Type: "project", Id: projectID}}}
+	for _, p := range parents {
+		kind, id := splitName(p)
+		ancestors = append(ancestors, &crm.Ancestor{ResourceId: &crm.ResourceId{Type: kind, Id: id}})
+	}
+	r.ancestry[projectID] = ancestors
+	r.policies["projects/"+projectID] = &crm.Policy{Etag: r.etag()}
+}
+
+// AddOrganization seeds an organization with an empty IAM policy.
+func (r *ResourceManager) AddOrganization(org *crm.Organization) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var o crm.Organization
+	clone(org, &o)
+	r.organizations[o.Name] = &o
+	r.policies[o.Name] = &crm.Policy{Etag: r.etag()}
+}
+
+// ProjectPolicy returns the current IAM policy of a project, nil if it does not exist.
+func (r *ResourceManager) ProjectPolicy(projectID string) *crm.Policy {
+	p, _ := r.GetPolicyProject(context.Background(), projectID)
+	return p
+}
+
+// splitName splits a resource name such as folders/123 into the type "folder" and the ID.
+func splitName(name string) (string, string) {
+	parts := strings.SplitN(name, "/", 2)
+	if len(parts) != 2 {
+		panic(fmt.Sprintf("fakes: %q is not a resource name such as folders/123", name))
+	}
+	return strings.TrimSuffix(parts[0], "s"), parts[1]
+}
+
+// etag returns a new etag, as the API changes it on every update.
+func (r *ResourceManager) etag() string {
+	r.etags++
+	return base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("etag-%d", r.etags)))
+}
+
+func (r *ResourceManager) getPolicy(name string) (*crm.Policy, error) {
+	p, ok := r.policies[name]
+	if !ok {
+		return nil, &googleapi.Error{Code: http.StatusForbidden, Message: fmt.Sprintf("The caller does not have permission on %s", name)}
+	}
+	var out crm.Policy
+	clone(p, &out)
+	return &out, nil
+}
+
+// setPolicy replaces the fields of the policy in the mask. As with the API, the update fails if
+// the etag of the new policy is set and out of date.
+func (r *ResourceManager) setPolicy(name string, p *crm.Policy, mask ...string) (*crm.Policy, error) {
+	cur, ok := r.policies[name]
+	if !ok {
+		return nil, &googleapi.Error{Code: http.StatusForbidden, Message: fmt.Sprintf("The caller does not have permission on %s", name)}
+	}
+	if p.Etag != "" && p.Etag != cur.Etag {
+		return nil, &googleapi.Error{Code: http.StatusConflict, Message: "There were concurrent policy changes. Please retry the whole read-modify-write with exponential backoff."}
+	}
+	var next crm.Policy
+	clone(p, &next)
+	if len(mask) == 0 {
+		mask = []string{"bindings"}
+	}
+	for _, f := range mask {
+		switch f {
+		case "bindings":
+			cur.Bindings = next.Bindings
+		case "auditConfigs":
+			cur.AuditConfigs = next.AuditConfigs
+		case "etag":
+		default:
+			return nil, &googleapi.Error{Code: http.StatusBadRequest, Message: fmt.Sprintf("Invalid update mask field %q", f)}
+		}
+	}
+	cur.Version = next.Version
+	cur.Etag = r.etag()
+	var out crm.Policy
+	clone(cur, &out)
+	return &out, nil
+}
+
+// GetPolicyProject returns the IAM policy of a project.
+func (r *ResourceManager) GetPolicyProject(ctx context.Context, projectID string) (*crm.Policy, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.getPolicy("projects/" + projectID)
+}
+
+// SetPolicyProject replaces the bindings of a project's IAM policy.
+func (r *ResourceManager) SetPolicyProject(ctx context.Context, projectID string, p *crm.Policy) (*crm.Policy, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.setPolicy("projects/"+projectID, p)
+}
+
+// SetPolicyProjectWithMask replaces the given fields of a project's IAM policy.
+func (r *ResourceManager) SetPolicyProjectWithMask(ctx context.Context, projectID string, p *crm.Policy, updateField ...string) (*crm.Policy, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.setPolicy("projects/"+projectID, p, updateField...)
+}
+
+// GetAncestry returns the ancestry of a project.
+func (r *ResourceManager) GetAncestry(ctx context.Context, projectID string) (*crm.GetAncestryResponse, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	a, ok := r.ancestry[projectID]
+	if !ok {
+		return nil, &googleapi.Error{Code: http.StatusForbidden, Message: fmt.Sprintf("The caller does not have permission on projects/%s", projectID)}
+	}
+	var out crm.GetAncestryResponse
+	clone(&crm.GetAncestryResponse{Ancestor: a}, &out)
+	return &out, nil
+}
+
+// GetPolicyOrganization returns the IAM policy of an organization.
+func (r *ResourceManager) GetPolicyOrganization(ctx context.Context, name string) (*crm.Policy, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.getPolicy(name)
+}
+
+// SetPolicyOrganization replaces the bindings of an organization's IAM policy.
+func (r *ResourceManager) SetPolicyOrganization(ctx context.Context, name string, p *crm.Policy) (*crm.Policy, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.setPolicy(name, p)
+}
+
+// GetOrganization returns an organization by its resource name.
+func (r *ResourceManager) GetOrganization(ctx context.Context, name string) (*crm.Organization, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	o, ok := r.organizations[name]
+	if !ok {
+		return nil, &googleapi.Error{Code: http.StatusForbidden, Message: fmt.Sprintf("The caller does not have permission on %s", name)}
+	}
+	var out crm.Organization
+	clone(o, &out)
+	return &out, nil
+}
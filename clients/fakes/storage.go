@@ -0,0 +1,337 @@
+package fakes
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"cloud.google.com/go/iam"
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/googleapi"
+	iampb "google.golang.org/genproto/googleapis/iam/v1"
+)
+
+// Storage is a fake Cloud Storage client serving buckets, their IAM policies and objects.
+type Storage struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	// generation numbers object writes across all buckets, as the API does.
+	generation int64
+}
+
+type bucket struct {
+	attrs storage.BucketAttrs
+	// bindings maps roles to their members.
+	bindings map[string][]string
+	etag     int
+	// objects maps object names to their generations, oldest first.
+	objects map[string][]object
+}
+
+type object struct {
+	generation int64
+	contents   []byte
+	metadata   map[string]string
+	deleted    bool
+}
+
+// NewStorage returns a fake without buckets.
+func NewStorage() *Storage {
+	return &Storage{buckets: make(map[string]*bucket)}
+}
+
+// AddBucket seeds a bucket with the given attributes and IAM role bindings.
+func (s *Storage) AddBucket(attrs *storage.BucketAttrs, bindings map[string][]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b := &bucket{attrs: *attrs, bindings: make(map[string][]string), objects: make(map[string][]object)}
+	b.attrs.Labels = copyLabels(attrs.Labels)
+	for role, members := range bindings {
+		b.bindings[role] = append([]string(nil), members...)
+	}
+	s.buckets[attrs.Name] = b
+}
+
+// BucketBindings returns the current IAM role bindings of a bucket, nil if it does not exist.
+func (s *Storage) BucketBindings(name string) map[string][]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.buckets[name]
+	if !ok {
+		return nil
+	}
+	out := make(map[string][]string, len(b.bindings))
+	for role, members := range b.bindings {
+		out[role] = append([]string(nil), members...)
+	}
+	return out
+}
+
+func copyLabels(labels map[string]string) map[string]string {
+	if labels == nil {
+		return nil
+	}
+	out := make(map[string]string, len(labels))
+	for k, v := range labels {
+		out[k] = v
+	}
+	return out
+}
+
+func (s *Storage) bucket(name string) (*bucket, error) {
+	b, ok := s.buckets[name]
+	if !ok {
+		return nil, storage.ErrBucketNotExist
+	}
+	return b, nil
+}
+
+// SetBucketPolicy replaces the IAM policy of a bucket. As with the API, the update fails if the
+// policy was read before the bucket's last update.
+func (s *Storage) SetBucketPolicy(ctx context.Context, bucketName string, policy *iam.Policy) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, err := s.bucket(bucketName)
+	if err != nil {
+		return err
+	}
+	if etag := policy.InternalProto.GetEtag(); len(etag) > 0 && string(etag) != fmt.Sprint(b.etag) {
+		return &googleapi.Error{Code: http.StatusPreconditionFailed, Message: "Precondition Failed"}
+	}
+	b.bindings = make(map[string][]string)
+	for _, role := range policy.Roles() {
+		if members := policy.Members(role); len(members) > 0 {
+			b.bindings[string(role)] = append([]string(nil), members...)
+		}
+	}
+	b.etag++
+	return nil
+}
+
+// BucketPolicy returns the IAM policy of a bucket.
+func (s *Storage) BucketPolicy(ctx context.Context, bucketName string) (*iam.Policy, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, err := s.bucket(bucketName)
+	if err != nil {
+		return nil, &googleapi.Error{Code: http.StatusNotFound, Message: err.Error()}
+	}
+	p := &iampb.Policy{Etag: []byte(fmt.Sprint(b.etag))}
+	roles := make([]string, 0, len(b.bindings))
+	for role := range b.bindings {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	for _, role := range roles {
+		p.Bindings = append(p.Bindings, &iampb.Binding{Role: role, Members: append([]string(nil), b.bindings[role]...)})
+	}
+	return &iam.Policy{InternalProto: p}, nil
+}
+
+// EnableBucketOnlyPolicy enables uniform bucket-level access.
+func (s *Storage) EnableBucketOnlyPolicy(ctx context.Context, bucketName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, err := s.bucket(bucketName)
+	if err != nil {
+		return err
+	}
+	b.attrs.BucketPolicyOnly.Enabled = true
+	b.attrs.UniformBucketLevelAccess.Enabled = true
+	return nil
+}
+
+// BucketAttrs returns the attributes of a bucket.
+func (s *Storage) BucketAttrs(ctx context.Context, bucketName string) (*storage.BucketAttrs, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, err := s.bucket(bucketName)
+	if err != nil {
+		return nil, err
+	}
+	attrs := b.attrs
+	attrs.Labels = copyLabels(b.attrs.Labels)
+	return &attrs, nil
+}
+
+// live returns the current generation of an object.
+func (b *bucket) live(name string) (object, bool) {
+	gens := b.objects[name]
+	if len(gens) == 0 || gens[len(gens)-1].deleted {
+		return object{}, false
+	}
+	return gens[len(gens)-1], true
+}
+
+// WriteObject writes a new generation of an object.
+func (s *Storage) WriteObject(ctx context.Context, bucketName, name string, contents []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, err := s.bucket(bucketName)
+	if err != nil {
+		return err
+	}
+	s.generation++
+	b.objects[name] = append(b.objects[name], object{generation: s.generation, contents: append([]byte(nil), contents...)})
+	return nil
+}
+
+// WriteObjectMetadata writes a new generation of an object with custom metadata.
+func (s *Storage) WriteObjectMetadata(ctx context.Context, bucketName, name string, contents []byte, metadata map[string]string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, err := s.bucket(bucketName)
+	if err != nil {
+		return err
+	}
+	s.generation++
+	b.objects[name] = append(b.objects[name], object{generation: s.generation, contents: append([]byte(nil), contents...), metadata: copyLabels(metadata)})
+	return nil
+}
+
+// WriteObjectGeneration writes a new generation of an object if its current generation matches,
+// failing with a 412 error otherwise.
+func (s *Storage) WriteObjectGeneration(ctx context.Context, bucketName, name string, contents []byte, generation int64) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, err := s.bucket(bucketName)
+	if err != nil {
+		return 0, err
+	}
+	if o, ok := b.live(name); !ok || o.generation != generation {
+		return 0, &googleapi.Error{Code: http.StatusPreconditionFailed, Message: "Precondition Failed"}
+	}
+	s.generation++
+	b.objects[name] = append(b.objects[name], object{generation: s.generation, contents: append([]byte(nil), contents...)})
+	return s.generation, nil
+}
+
+// ObjectMetadata returns the custom metadata of an object's current generation.
+func (s *Storage) ObjectMetadata(bucketName, name string) map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.buckets[bucketName]
+	if !ok {
+		return nil
+	}
+	o, ok := b.live(name)
+	if !ok {
+		return nil
+	}
+	return copyLabels(o.metadata)
+}
+
+// ReadObject reads the current contents of an object.
+func (s *Storage) ReadObject(ctx context.Context, bucketName, name string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, err := s.bucket(bucketName)
+	if err != nil {
+		return nil, err
+	}
+	o, ok := b.live(name)
+	if !ok {
+		return nil, storage.ErrObjectNotExist
+	}
+	return append([]byte(nil), o.contents...), nil
+}
+
+// ObjectGeneration returns the generation of an object's current contents.
+func (s *Storage) ObjectGeneration(ctx context.Context, bucketName, name string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, err := s.bucket(bucketName)
+	if err != nil {
+		return 0, err
+	}
+	o, ok := b.live(name)
+	if !ok {
+		return 0, storage.ErrObjectNotExist
+	}
+	return o.generation, nil
+}
+
+// ReadObjectGeneration reads the contents of a generation of an object, including generations
+// since replaced or deleted.
+func (s *Storage) ReadObjectGeneration(ctx context.Context, bucketName, name string, generation int64) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, err := s.bucket(bucketName)
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range b.objects[name] {
+		if o.generation == generation && !o.deleted {
+			return append([]byte(nil), o.contents...), nil
+		}
+	}
+	return nil, storage.ErrObjectNotExist
+}
+
+// ListObjects returns the names of the live objects starting with the prefix in lexical order.
+func (s *Storage) ListObjects(ctx context.Context, bucketName, prefix string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, err := s.bucket(bucketName)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for name := range b.objects {
+		if _, ok := b.live(name); ok && strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// ObjectGenerations returns the current generation of the live objects starting with the prefix.
+func (s *Storage) ObjectGenerations(ctx context.Context, bucketName, prefix string) (map[string]int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, err := s.bucket(bucketName)
+	if err != nil {
+		return nil, err
+	}
+	generations := make(map[string]int64)
+	for name := range b.objects {
+		if o, ok := b.live(name); ok && strings.HasPrefix(name, prefix) {
+			generations[name] = o.generation
+		}
+	}
+	return generations, nil
+}
+
+// DeleteObject deletes an object, keeping its past generations readable.
+func (s *Storage) DeleteObject(ctx context.Context, bucketName, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, err := s.bucket(bucketName)
+	if err != nil {
+		return err
+	}
+	if _, ok := b.live(name); !ok {
+		return storage.ErrObjectNotExist
+	}
+	s.generation++
+	b.objects[name] = append(b.objects[name], object{generation: s.generation, deleted: true})
+	return nil
+}
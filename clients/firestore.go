@@ -0,0 +1,130 @@
+package clients
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	firestore "google.golang.org/api/firestore/v1"
+)
+
+// Firestore client.
+type Firestore struct {
+	service *firestore.Service
+	// documents is the root path of the project's default database documents.
+	documents string
+}
+
+// NewFirestore returns and initializes the Firestore client for the project's default database.
+func NewFirestore(ctx context.Context, projectID string) (*Firestore, error) {
+	fs, err := firestore.NewService(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to init firestore")
+	}
+	return &Firestore{
+		service:   fs,
+		documents: fmt.Sprintf("projects/%s/databases/(default)/documents", projectID),
+	}, nil
+}
+
+// CreateDocument creates a document with the given string fields. It fails with a 409 error if
+// the document already exists.
+func (f *Firestore) CreateDocument(ctx context.Context, collection, id string, fields map[string]string) error {
+	doc := &firestore.Document{Fields: map[string]firestore.Value{}}
+	for k, v := range fields {
+		doc.Fields[k] = firestore.Value{StringValue: v}
+	}
+	_, err := f.service.Projects.Databases.Documents.CreateDocument(f.documents, collection, doc).DocumentId(id).Context(ctx).Do()
+	return err
+}
+
+// DeleteDocument deletes a document.
+func (f *Firestore) DeleteDocument(ctx context.Context, collection, id string) error {
+	_, err := f.service.Projects.Databases.Documents.Delete(fmt.Sprintf("%s/%s/%s", f.documents, collection, id)).Context(ctx).Do()
+	return err
+}
+
+// DeleteDocumentVersion deletes a document only if it was not updated since the given update
+// time, as returned by GetDocumentVersion.
+func (f *Firestore) DeleteDocumentVersion(ctx context.Context, collection, id, version string) error {
+	_, err := f.service.Projects.Databases.Documents.Delete(fmt.Sprintf("%s/%s/%s", f.documents, collection, id)).CurrentDocumentUpdateTime(version).Context(ctx).Do()
+	return err
+}
+
+// GetDocumentVersion returns the string fields of a document and the time it was last updated.
+func (f *Firestore) GetDocumentVersion(ctx context.Context, collection, id string) (map[string]string, string, error) {
+	doc, err := f.service.Projects.Databases.Documents.Get(fmt.Sprintf("%s/%s/%s", f.documents, collection, id)).Context(ctx).Do()
+	if err != nil {
+		return nil, "", err
+	}
+	fields := map[string]string{}
+	for k, v := range doc.Fields {
+		fields[k] = v.StringValue
+	}
+	return fields, doc.UpdateTime, nil
+}
+
+// GetDocument returns the string fields of a document.
+func (f *Firestore) GetDocument(ctx context.Context, collection, id string) (map[string]string, error) {
+	doc, err := f.service.Projects.Databases.Documents.Get(fmt.Sprintf("%s/%s/%s", f.documents, collection, id)).Context(ctx).Do()
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]string{}
+	for k, v := range doc.Fields {
+		fields[k] = v.StringValue
+	}
+	return fields, nil
+}
+
+// SetDocument creates or replaces a document with the given string fields.
+func (f *Firestore) SetDocument(ctx context.Context, collection, id string, fields map[string]string) error {
+	doc := &firestore.Document{Fields: map[string]firestore.Value{}}
+	for k, v := range fields {
+		doc.Fields[k] = firestore.Value{StringValue: v}
+	}
+	_, err := f.service.Projects.Databases.Documents.Patch(fmt.Sprintf("%s/%s/%s", f.documents, collection, id), doc).Context(ctx).Do()
+	return err
+}
+
+// Increment atomically adds one to an integer field of a document, creating the document if it
+// does not exist, and returns the field's new value. The given string fields are set as well.
+func (f *Firestore) Increment(ctx context.Context, collection, id, field string, fields map[string]string) (int64, error) {
+	doc := &firestore.Document{Name: fmt.Sprintf("%s/%s/%s", f.documents, collection, id), Fields: map[string]firestore.Value{}}
+	mask := &firestore.DocumentMask{}
+	for k, v := range fields {
+		doc.Fields[k] = firestore.Value{StringValue: v}
+		mask.FieldPaths = append(mask.FieldPaths, k)
+	}
+	write := &firestore.Write{
+		Update:     doc,
+		UpdateMask: mask,
+		UpdateTransforms: []*firestore.FieldTransform{
+			{FieldPath: field, Increment: &firestore.Value{IntegerValue: 1}},
+		},
+	}
+	database := strings.TrimSuffix(f.documents, "/documents")
+	resp, err := f.service.Projects.Databases.Documents.Commit(database, &firestore.CommitRequest{Writes: []*firestore.Write{write}}).Context(ctx).Do()
+	if err != nil {
+		return 0, err
+	}
+	if len(resp.WriteResults) != 1 || len(resp.WriteResults[0].TransformResults) != 1 {
+		return 0, fmt.Errorf("unexpected commit response incrementing %s/%s", collection, id)
+	}
+	return resp.WriteResults[0].TransformResults[0].IntegerValue, nil
+}
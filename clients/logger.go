@@ -21,6 +21,7 @@ import (
 	"os"
 
 	"cloud.google.com/go/logging"
+	"github.com/pkg/errors"
 )
 
 const loggerName = "security-response-automation"
@@ -38,7 +39,7 @@ type Logger struct {
 func NewLogger(ctx context.Context) (*Logger, error) {
 	c, err := logging.NewClient(ctx, projectID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to init logger: %q", err)
+		return nil, errors.Wrap(err, "failed to init logger")
 	}
 	return &Logger{client: c, logger: c.Logger(loggerName)}, nil
 }
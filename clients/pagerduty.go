@@ -1,17 +1,28 @@
 package clients
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
 	pagerduty "github.com/PagerDuty/go-pagerduty"
+	"github.com/pkg/errors"
 )
 
+// pagerDutyEndpoint is the PagerDuty REST API.
+const pagerDutyEndpoint = "https://api.pagerduty.com"
+
 // PagerDuty client.
 type PagerDuty struct {
 	client *pagerduty.Client
+	apiKey string
 }
 
 // NewPagerDuty returns a PagerDuty client initialized.
 func NewPagerDuty(apiKey string) *PagerDuty {
-	return &PagerDuty{client: pagerduty.NewClient(apiKey)}
+	return &PagerDuty{client: pagerduty.NewClient(apiKey), apiKey: apiKey}
 }
 
 // CreateIncident will create a new incident.
@@ -30,3 +41,100 @@ func (p *PagerDuty) CreateIncident(from, serviceID, title, body string) (*pagerd
 		},
 	})
 }
+
+// CreateIncidentWithKey creates a new incident deduplicated on the given key. The incident's
+// urgency is updated after creation when set as it can not be given on creation.
+func (p *PagerDuty) CreateIncidentWithKey(from, serviceID, title, body, key, urgency string) (*pagerduty.Incident, error) {
+	incident, err := p.client.CreateIncident(from, &pagerduty.CreateIncidentOptions{
+		Type:  "incident",
+		Title: title,
+		Service: &pagerduty.APIReference{
+			ID:   serviceID,
+			Type: "service_reference",
+		},
+		IncidentKey: key,
+		Body: &pagerduty.APIDetails{
+			Type:    "incident_body",
+			Details: body,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	withID(incident)
+	if urgency == "" || incident.Urgency == urgency {
+		return incident, nil
+	}
+	if err := p.setUrgency(from, incident.ID, urgency); err != nil {
+		return nil, err
+	}
+	incident.Urgency = urgency
+	return incident, nil
+}
+
+// setUrgency updates the urgency of an incident. The request is built here rather than with
+// ManageIncidents as marshaling a pagerduty.Incident sends its struct fields as empty objects
+// and its Id field, which is never set, in place of the embedded APIObject's ID.
+func (p *PagerDuty) setUrgency(from, incidentID, urgency string) error {
+	type update struct {
+		ID      string `json:"id"`
+		Type    string `json:"type"`
+		Urgency string `json:"urgency"`
+	}
+	b, err := json.Marshal(map[string][]update{"incidents": {{ID: incidentID, Type: "incident_reference", Urgency: urgency}}})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPut, pagerDutyEndpoint+"/incidents", bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.pagerduty+json;version=2")
+	req.Header.Set("Authorization", "Token token="+p.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("From", from)
+	resp, err := p.client.HTTPClient.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "failed to update urgency of incident %q", incidentID)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("failed to update urgency of incident %q: %s: %s", incidentID, resp.Status, body)
+	}
+	return nil
+}
+
+// withID copies the incident's ID into the embedded APIObject. The API's "id" field is decoded
+// into Incident.Id, which shadows APIObject.ID.
+func withID(incident *pagerduty.Incident) {
+	if incident.ID == "" {
+		incident.ID = incident.Id
+	}
+}
+
+// OpenIncident returns the triggered or acknowledged incident of the service with the given key.
+// A nil incident is returned if there is none.
+func (p *PagerDuty) OpenIncident(serviceID, key string) (*pagerduty.Incident, error) {
+	r, err := p.client.ListIncidents(pagerduty.ListIncidentsOptions{
+		Statuses:    []string{"triggered", "acknowledged"},
+		IncidentKey: key,
+		ServiceIDs:  []string{serviceID},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(r.Incidents) == 0 {
+		return nil, nil
+	}
+	withID(&r.Incidents[0])
+	return &r.Incidents[0], nil
+}
+
+// AddNote adds a note to an incident.
+func (p *PagerDuty) AddNote(from, incidentID, content string) error {
+	return p.client.CreateIncidentNote(incidentID, pagerduty.IncidentNote{
+		User:    pagerduty.APIObject{Summary: from},
+		Content: content,
+	})
+}
@@ -0,0 +1,87 @@
+package clients
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// pagerDutyHTTP records requests made to the PagerDuty API and answers them in turn.
+type pagerDutyHTTP struct {
+	requests  []string
+	responses []string
+}
+
+func (h *pagerDutyHTTP) Do(req *http.Request) (*http.Response, error) {
+	body := []byte{}
+	if req.Body != nil {
+		body, _ = ioutil.ReadAll(req.Body)
+	}
+	h.requests = append(h.requests, req.Method+" "+req.URL.Path+" "+string(body))
+	resp := h.responses[0]
+	h.responses = h.responses[1:]
+	return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(bytes.NewBufferString(resp))}, nil
+}
+
+func TestClientPagerDutyCreateIncidentWithKey(t *testing.T) {
+	const created = `{"incident":{"id":"PT4KHLK","type":"incident","urgency":"high"}}`
+	tests := []struct {
+		name             string
+		urgency          string
+		expectedRequests int
+		expectedUpdate   string
+	}{
+		{
+			name:             "urgency updated",
+			urgency:          "low",
+			expectedRequests: 2,
+			expectedUpdate:   `PUT /incidents {"incidents":[{"id":"PT4KHLK","type":"incident_reference","urgency":"low"}]}`,
+		},
+		{
+			name:             "urgency already set",
+			urgency:          "high",
+			expectedRequests: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &pagerDutyHTTP{responses: []string{created, `{}`}}
+			p := NewPagerDuty("api-key")
+			p.client.HTTPClient = h
+
+			incident, err := p.CreateIncidentWithKey("sra@example.com", "PSERVICE", "title", "body", "key", tt.urgency)
+			if err != nil {
+				t.Fatalf("%s failed: %q", tt.name, err)
+			}
+			if incident.ID != "PT4KHLK" || incident.Urgency != tt.urgency {
+				t.Errorf("%s failed, got incident %q with urgency %q", tt.name, incident.ID, incident.Urgency)
+			}
+			if len(h.requests) != tt.expectedRequests {
+				t.Fatalf("%s failed, got requests %q", tt.name, h.requests)
+			}
+			if tt.expectedUpdate == "" {
+				return
+			}
+			if diff := cmp.Diff(tt.expectedUpdate, h.requests[1]); diff != "" {
+				t.Errorf("%s failed, update request (-want +got):\n%s", tt.name, diff)
+			}
+		})
+	}
+}
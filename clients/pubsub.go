@@ -16,9 +16,9 @@ package clients
 
 import (
 	"context"
-	"fmt"
 
 	"cloud.google.com/go/pubsub"
+	"github.com/pkg/errors"
 )
 
 // PubSub client.
@@ -30,18 +30,14 @@ type PubSub struct {
 func NewPubSub(ctx context.Context, projectID string) (*PubSub, error) {
 	client, err := pubsub.NewClient(ctx, projectID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to init pubsub: %q", err)
+		return nil, errors.Wrap(err, "failed to init pubsub")
 	}
 	return &PubSub{client: client}, nil
 }
 
-// Topic returns a reference to a topic.
-func (p *PubSub) Topic(id string) *pubsub.Topic {
-	return p.client.Topic(id)
-}
-
 // Publish will publish a message to a PubSub topic.
-func (p *PubSub) Publish(ctx context.Context, topic *pubsub.Topic, message *pubsub.Message) (string, error) {
+func (p *PubSub) Publish(ctx context.Context, topicID string, message *pubsub.Message) (string, error) {
+	topic := p.client.Topic(topicID)
 	defer topic.Stop()
 	return topic.Publish(ctx, message).Get(ctx)
 }
@@ -16,10 +16,11 @@ package clients
 
 import (
 	"context"
-	"fmt"
 	"strings"
 
+	"github.com/pkg/errors"
 	crm "google.golang.org/api/cloudresourcemanager/v1"
+	"google.golang.org/api/option"
 )
 
 // CloudResourceManager client.
@@ -28,11 +29,11 @@ type CloudResourceManager struct {
 }
 
 // NewCloudResourceManager returns and initalizes the Cloud Resource Manager client.
-func NewCloudResourceManager(ctx context.Context) (*CloudResourceManager, error) {
-	s, err := crm.NewService(ctx)
+func NewCloudResourceManager(ctx context.Context, opts ...option.ClientOption) (*CloudResourceManager, error) {
+	s, err := crm.NewService(ctx, opts...)
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to init crm: %q", err)
+		return nil, errors.Wrap(err, "failed to init crm")
 	}
 	return &CloudResourceManager{service: s}, nil
 }
@@ -16,10 +16,13 @@ package clients
 
 import (
 	"context"
-	"fmt"
+	"io/ioutil"
 
 	"cloud.google.com/go/iam"
 	"cloud.google.com/go/storage"
+	"github.com/pkg/errors"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
 )
 
 // Storage client.
@@ -28,10 +31,10 @@ type Storage struct {
 }
 
 // NewStorage returns and initializes the Storage client.
-func NewStorage(ctx context.Context) (*Storage, error) {
-	c, err := storage.NewClient(ctx)
+func NewStorage(ctx context.Context, opts ...option.ClientOption) (*Storage, error) {
+	c, err := storage.NewClient(ctx, opts...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to init storage: %q", err)
+		return nil, errors.Wrap(err, "failed to init storage")
 	}
 	return &Storage{service: c}, nil
 }
@@ -46,6 +49,11 @@ func (s *Storage) BucketPolicy(ctx context.Context, bucketName string) (*iam.Pol
 	return s.service.Bucket(bucketName).IAM().Policy(ctx)
 }
 
+// BucketAttrs gets the attributes of the given bucket.
+func (s *Storage) BucketAttrs(ctx context.Context, bucketName string) (*storage.BucketAttrs, error) {
+	return s.service.Bucket(bucketName).Attrs(ctx)
+}
+
 // EnableBucketOnlyPolicy enables the bucket only policy for the given bucket.
 func (s *Storage) EnableBucketOnlyPolicy(ctx context.Context, bucketName string) error {
 	enableBucketPolicyOnly := storage.BucketAttrsToUpdate{
@@ -58,3 +66,107 @@ func (s *Storage) EnableBucketOnlyPolicy(ctx context.Context, bucketName string)
 	}
 	return nil
 }
+
+// WriteObject writes the contents of an object, replacing any existing object.
+func (s *Storage) WriteObject(ctx context.Context, bucketName, name string, b []byte) error {
+	w := s.service.Bucket(bucketName).Object(name).NewWriter(ctx)
+	if _, err := w.Write(b); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// WriteObjectMetadata writes the contents of an object along with custom metadata, replacing any
+// existing object.
+func (s *Storage) WriteObjectMetadata(ctx context.Context, bucketName, name string, b []byte, metadata map[string]string) error {
+	w := s.service.Bucket(bucketName).Object(name).NewWriter(ctx)
+	w.Metadata = metadata
+	if _, err := w.Write(b); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// WriteObjectGeneration replaces the contents of an object only if its current generation
+// matches, returning the generation written. The write fails with a 412 error if the object has
+// changed since.
+func (s *Storage) WriteObjectGeneration(ctx context.Context, bucketName, name string, b []byte, generation int64) (int64, error) {
+	w := s.service.Bucket(bucketName).Object(name).If(storage.Conditions{GenerationMatch: generation}).NewWriter(ctx)
+	if _, err := w.Write(b); err != nil {
+		w.Close()
+		return 0, err
+	}
+	if err := w.Close(); err != nil {
+		return 0, err
+	}
+	return w.Attrs().Generation, nil
+}
+
+// ReadObject reads the contents of an object.
+func (s *Storage) ReadObject(ctx context.Context, bucketName, name string) ([]byte, error) {
+	r, err := s.service.Bucket(bucketName).Object(name).NewReader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+
+// ObjectGeneration returns the generation of an object's current contents.
+func (s *Storage) ObjectGeneration(ctx context.Context, bucketName, name string) (int64, error) {
+	attrs, err := s.service.Bucket(bucketName).Object(name).Attrs(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return attrs.Generation, nil
+}
+
+// ReadObjectGeneration reads the contents of the given generation of an object.
+func (s *Storage) ReadObjectGeneration(ctx context.Context, bucketName, name string, generation int64) ([]byte, error) {
+	r, err := s.service.Bucket(bucketName).Object(name).Generation(generation).NewReader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+
+// ListObjects returns the names of the objects whose names start with the prefix.
+func (s *Storage) ListObjects(ctx context.Context, bucketName, prefix string) ([]string, error) {
+	var names []string
+	it := s.service.Bucket(bucketName).Objects(ctx, &storage.Query{Prefix: prefix})
+	for {
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			return names, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, attrs.Name)
+	}
+}
+
+// ObjectGenerations returns the generation of each object whose name starts with the prefix,
+// keyed by object name.
+func (s *Storage) ObjectGenerations(ctx context.Context, bucketName, prefix string) (map[string]int64, error) {
+	generations := make(map[string]int64)
+	it := s.service.Bucket(bucketName).Objects(ctx, &storage.Query{Prefix: prefix})
+	for {
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			return generations, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		generations[attrs.Name] = attrs.Generation
+	}
+}
+
+// DeleteObject deletes an object.
+func (s *Storage) DeleteObject(ctx context.Context, bucketName, name string) error {
+	return s.service.Bucket(bucketName).Object(name).Delete(ctx)
+}
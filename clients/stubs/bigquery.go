@@ -24,6 +24,7 @@ import (
 type BigQueryStub struct {
 	StubbedMetadata      *bigquery.DatasetMetadata
 	SavedDatasetMetadata *bigquery.DatasetMetadataToUpdate
+	InsertedRows         []interface{}
 }
 
 // DatasetMetadata fetches the metadata for the dataset.
@@ -36,3 +37,9 @@ func (s *BigQueryStub) OverwriteDatasetMetadata(ctx context.Context, projectID,
 	s.SavedDatasetMetadata = &dm
 	return nil, nil
 }
+
+// Insert streams rows into the table.
+func (s *BigQueryStub) Insert(ctx context.Context, projectID, datasetID, tableID string, rows interface{}) error {
+	s.InsertedRows = append(s.InsertedRows, rows)
+	return nil
+}
@@ -27,6 +27,10 @@ var ErrEntityNonExistent = fmt.Errorf("rpc error: code = NotFound desc = Request
 // SecurityCommandCenterStub provides a stub for the Security Command center client.
 type SecurityCommandCenterStub struct {
 	GetUpdateSecurityMarksRequest *sccpb.UpdateSecurityMarksRequest
+	GetFindingResponse            *sccpb.Finding
+	ListFindingsResponse          []*sccpb.Finding
+	// SetFindingStateRequests holds every SetFindingState request in order.
+	SetFindingStateRequests []*sccpb.SetFindingStateRequest
 }
 
 // AddSecurityMarks adds Security Marks to a finding or asset.
@@ -40,5 +44,19 @@ func (s *SecurityCommandCenterStub) AddSecurityMarks(ctx context.Context, reques
 
 // SetFindingState sets finding state
 func (s *SecurityCommandCenterStub) SetFindingState(ctx context.Context, request *sccpb.SetFindingStateRequest) (*sccpb.Finding, error) {
+	s.SetFindingStateRequests = append(s.SetFindingStateRequests, request)
 	return &sccpb.Finding{}, nil
 }
+
+// GetFinding returns the stubbed finding.
+func (s *SecurityCommandCenterStub) GetFinding(ctx context.Context, name string) (*sccpb.Finding, error) {
+	if s.GetFindingResponse == nil {
+		return nil, ErrEntityNonExistent
+	}
+	return s.GetFindingResponse, nil
+}
+
+// ListFindings returns the stubbed findings.
+func (s *SecurityCommandCenterStub) ListFindings(ctx context.Context, parent, filter string) ([]*sccpb.Finding, error) {
+	return s.ListFindingsResponse, nil
+}
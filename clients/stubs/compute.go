@@ -20,6 +20,7 @@ import (
 
 	"github.com/pkg/errors"
 	compute "google.golang.org/api/compute/v1"
+	"google.golang.org/api/googleapi"
 )
 
 // ErrNonexistentVM is a stub error returned simulating an error in case of VM not found.
@@ -28,9 +29,16 @@ var ErrNonexistentVM = fmt.Errorf("googleapi: Error 404: The resource 'projects/
 // ComputeStub provides a stub for the compute client.
 type ComputeStub struct {
 	SavedFirewallRule            *compute.Firewall
+	InsertedFirewallRules        []*compute.Firewall
+	InsertedFirewallProjects     []string
+	SavedTags                    *compute.Tags
+	SavedMetadata                *compute.Metadata
+	StoppedInstance              string
 	SavedCreateSnapshots         map[string]compute.Snapshot
 	DeletedAccessConfigs         []NetworkAccessConfigStub
+	AddedAccessConfigs           []NetworkAccessConfigStub
 	DeleteAccessConfigShouldFail bool
+	DeleteAccessConfigErr        error
 	GetInstanceShouldFail        bool
 	StubbedListProjectSnapshots  []*compute.SnapshotList
 	StubbedListDisks             *compute.DiskList
@@ -58,6 +66,8 @@ type NetworkAccessConfigStub struct {
 // InsertFirewallRule inserts a new firewall rule.
 func (c *ComputeStub) InsertFirewallRule(ctx context.Context, projectID string, fw *compute.Firewall) (*compute.Operation, error) {
 	c.SavedFirewallRule = fw
+	c.InsertedFirewallRules = append(c.InsertedFirewallRules, fw)
+	c.InsertedFirewallProjects = append(c.InsertedFirewallProjects, projectID)
 	return nil, nil
 }
 
@@ -74,6 +84,9 @@ func (c *ComputeStub) DeleteFirewallRule(ctx context.Context, projectID string,
 
 // FirewallRule get the details of a firewall rule
 func (c *ComputeStub) FirewallRule(ctx context.Context, projectID string, ruleID string) (*compute.Firewall, error) {
+	if c.StubbedFirewall == nil {
+		return nil, &googleapi.Error{Code: 404}
+	}
 	return c.StubbedFirewall, nil
 }
 
@@ -85,11 +98,35 @@ func (c *ComputeStub) GetInstance(ctx context.Context, project, zone, instance s
 	return c.StubbedInstance, nil
 }
 
+// SetTags sets the network tags of an instance.
+func (c *ComputeStub) SetTags(ctx context.Context, project, zone, instance string, tags *compute.Tags) (*compute.Operation, error) {
+	c.SavedTags = tags
+	return nil, nil
+}
+
+// SetMetadata sets the metadata of an instance.
+func (c *ComputeStub) SetMetadata(ctx context.Context, project, zone, instance string, metadata *compute.Metadata) (*compute.Operation, error) {
+	c.SavedMetadata = metadata
+	return nil, nil
+}
+
+// AddAccessConfig adds an access config to an instance's network interface.
+func (c *ComputeStub) AddAccessConfig(ctx context.Context, project, zone, instance, networkInterface string, accessConfig *compute.AccessConfig) (*compute.Operation, error) {
+	c.AddedAccessConfigs = append(c.AddedAccessConfigs, NetworkAccessConfigStub{
+		NetworkInterfaceName: networkInterface,
+		AccessConfigName:     accessConfig.Name,
+	})
+	return nil, nil
+}
+
 // DeleteAccessConfig deletes an access config from an instance's network interface.
 func (c *ComputeStub) DeleteAccessConfig(ctx context.Context, project, zone, instance, accessConfig, networkInterface string) (*compute.Operation, error) {
 	if c.DeleteAccessConfigShouldFail {
 		return nil, errors.New("api call failed")
 	}
+	if c.DeleteAccessConfigErr != nil {
+		return nil, c.DeleteAccessConfigErr
+	}
 	c.DeletedAccessConfigs = append(c.DeletedAccessConfigs, NetworkAccessConfigStub{
 		NetworkInterfaceName: networkInterface,
 		AccessConfigName:     accessConfig,
@@ -143,6 +180,7 @@ func (c *ComputeStub) WaitZone(_, _ string, _ *compute.Operation) []error {
 
 // StopInstance stops an instance.
 func (c *ComputeStub) StopInstance(ctx context.Context, projectID, zone, instance string) (*compute.Operation, error) {
+	c.StoppedInstance = instance
 	return c.StubbedStopInstance, nil
 }
 
@@ -23,6 +23,15 @@ import (
 // ContainerStub provides a stub for the Container client.
 type ContainerStub struct {
 	UpdatedAddonsConfig *container.SetAddonsConfigRequest
+	GetClusterResponse  *container.Cluster
+}
+
+// GetCluster returns the stubbed cluster, or a cluster without add-ons configured if none is set.
+func (c *ContainerStub) GetCluster(ctx context.Context, projectID, zone, clusterID string) (*container.Cluster, error) {
+	if c.GetClusterResponse == nil {
+		return &container.Cluster{Name: clusterID}, nil
+	}
+	return c.GetClusterResponse, nil
 }
 
 // UpdateAddonsConfig updates the addons configuration of a given cluster.
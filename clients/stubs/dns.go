@@ -0,0 +1,49 @@
+package stubs
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+
+	dns "google.golang.org/api/dns/v1"
+	"google.golang.org/api/googleapi"
+)
+
+// DNSStub provides a stub for the DNS client.
+type DNSStub struct {
+	StubbedManagedZone *dns.ManagedZone
+	SavedManagedZone   *dns.ManagedZone
+	PatchedManagedZone *dns.ManagedZone
+}
+
+// ManagedZone returns the stubbed managed zone or a not found error if none is set.
+func (d *DNSStub) ManagedZone(ctx context.Context, projectID, zone string) (*dns.ManagedZone, error) {
+	if d.StubbedManagedZone == nil {
+		return nil, &googleapi.Error{Code: 404}
+	}
+	return d.StubbedManagedZone, nil
+}
+
+// CreateManagedZone saves the created managed zone.
+func (d *DNSStub) CreateManagedZone(ctx context.Context, projectID string, zone *dns.ManagedZone) (*dns.ManagedZone, error) {
+	d.SavedManagedZone = zone
+	return zone, nil
+}
+
+// PatchManagedZone saves the patched managed zone.
+func (d *DNSStub) PatchManagedZone(ctx context.Context, projectID, name string, zone *dns.ManagedZone) (*dns.Operation, error) {
+	d.PatchedManagedZone = zone
+	return &dns.Operation{}, nil
+}
@@ -0,0 +1,139 @@
+package stubs
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"google.golang.org/api/googleapi"
+)
+
+// FirestoreStub provides a stub for the Firestore client.
+type FirestoreStub struct {
+	mu sync.Mutex
+	// Documents holds document fields keyed by collection then document ID.
+	Documents map[string]map[string]map[string]string
+	// versions counts writes to each document keyed by collection and document ID.
+	versions map[string]int
+}
+
+// CreateDocument saves a document, failing with a 409 error if it already exists.
+func (s *FirestoreStub) CreateDocument(ctx context.Context, collection, id string, fields map[string]string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := s.collection(collection)
+	if _, ok := c[id]; ok {
+		return &googleapi.Error{Code: http.StatusConflict}
+	}
+	c[id] = fields
+	s.written(collection, id)
+	return nil
+}
+
+// GetDocument returns the fields of a saved document.
+func (s *FirestoreStub) GetDocument(ctx context.Context, collection, id string) (map[string]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fields, ok := s.Documents[collection][id]
+	if !ok {
+		return nil, &googleapi.Error{Code: http.StatusNotFound}
+	}
+	return fields, nil
+}
+
+// GetDocumentVersion returns the fields of a saved document and the number of times it was
+// written as its version.
+func (s *FirestoreStub) GetDocumentVersion(ctx context.Context, collection, id string) (map[string]string, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fields, ok := s.Documents[collection][id]
+	if !ok {
+		return nil, "", &googleapi.Error{Code: http.StatusNotFound}
+	}
+	return fields, strconv.Itoa(s.versions[collection+"/"+id]), nil
+}
+
+// SetDocument saves a document, replacing any existing one.
+func (s *FirestoreStub) SetDocument(ctx context.Context, collection, id string, fields map[string]string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.collection(collection)[id] = fields
+	s.written(collection, id)
+	return nil
+}
+
+// Increment adds one to an integer field of a document, saved as a decimal string, and returns
+// its new value.
+func (s *FirestoreStub) Increment(ctx context.Context, collection, id, field string, fields map[string]string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := s.collection(collection)
+	doc := c[id]
+	if doc == nil {
+		doc = map[string]string{}
+		c[id] = doc
+	}
+	for k, v := range fields {
+		doc[k] = v
+	}
+	n, _ := strconv.ParseInt(doc[field], 10, 64)
+	n++
+	doc[field] = strconv.FormatInt(n, 10)
+	s.written(collection, id)
+	return n, nil
+}
+
+func (s *FirestoreStub) written(collection, id string) {
+	if s.versions == nil {
+		s.versions = map[string]int{}
+	}
+	s.versions[collection+"/"+id]++
+}
+
+func (s *FirestoreStub) collection(name string) map[string]map[string]string {
+	if s.Documents == nil {
+		s.Documents = map[string]map[string]map[string]string{}
+	}
+	if s.Documents[name] == nil {
+		s.Documents[name] = map[string]map[string]string{}
+	}
+	return s.Documents[name]
+}
+
+// DeleteDocument removes a saved document.
+func (s *FirestoreStub) DeleteDocument(ctx context.Context, collection, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.Documents[collection][id]; !ok {
+		return &googleapi.Error{Code: http.StatusNotFound}
+	}
+	delete(s.Documents[collection], id)
+	return nil
+}
+
+// DeleteDocumentVersion removes a saved document if it was not written since the given version,
+// failing with a 400 error as Firestore does otherwise.
+func (s *FirestoreStub) DeleteDocumentVersion(ctx context.Context, collection, id, version string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.Documents[collection][id]; !ok || strconv.Itoa(s.versions[collection+"/"+id]) != version {
+		return &googleapi.Error{Code: http.StatusBadRequest}
+	}
+	delete(s.Documents[collection], id)
+	return nil
+}
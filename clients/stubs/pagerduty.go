@@ -0,0 +1,64 @@
+package stubs
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"fmt"
+
+	"github.com/PagerDuty/go-pagerduty"
+)
+
+// PagerDutyStub provides a stub for the PagerDuty client.
+type PagerDutyStub struct {
+	// Incidents holds created incidents keyed by their incident key.
+	Incidents map[string]*pagerduty.Incident
+	// Notes holds notes added to incidents keyed by incident ID.
+	Notes map[string][]string
+}
+
+// CreateIncident creates an incident without an incident key.
+func (p *PagerDutyStub) CreateIncident(from, serviceID, title, body string) (*pagerduty.Incident, error) {
+	return p.CreateIncidentWithKey(from, serviceID, title, body, "", "")
+}
+
+// CreateIncidentWithKey creates an incident deduplicated on key.
+func (p *PagerDutyStub) CreateIncidentWithKey(from, serviceID, title, body, key, urgency string) (*pagerduty.Incident, error) {
+	if p.Incidents == nil {
+		p.Incidents = map[string]*pagerduty.Incident{}
+	}
+	incident := &pagerduty.Incident{
+		APIObject: pagerduty.APIObject{ID: fmt.Sprintf("P%d", len(p.Incidents)+1)},
+		Title:     title,
+		Service:   pagerduty.APIObject{ID: serviceID},
+		Urgency:   urgency,
+		Status:    "triggered",
+	}
+	p.Incidents[key] = incident
+	return incident, nil
+}
+
+// OpenIncident returns the incident with the given key.
+func (p *PagerDutyStub) OpenIncident(serviceID, key string) (*pagerduty.Incident, error) {
+	return p.Incidents[key], nil
+}
+
+// AddNote adds a note to an incident.
+func (p *PagerDutyStub) AddNote(from, incidentID, content string) error {
+	if p.Notes == nil {
+		p.Notes = map[string][]string{}
+	}
+	p.Notes[incidentID] = append(p.Notes[incidentID], content)
+	return nil
+}
@@ -22,17 +22,21 @@ import (
 
 // PubSubStub provides a stub for the PubSub client.
 type PubSubStub struct {
-	StubbedTopic     *pubsub.Topic
+	PublishedTopic   string
 	PublishedMessage *pubsub.Message
-}
-
-// Topic returns a reference to a topic.
-func (p *PubSubStub) Topic(id string) *pubsub.Topic {
-	return p.StubbedTopic
+	// PublishedMessages holds every published message in order.
+	PublishedMessages []*pubsub.Message
+	// PublishError is returned by Publish if set.
+	PublishError error
 }
 
 // Publish will publish a message to a PubSub topic.
-func (p *PubSubStub) Publish(ctx context.Context, topic *pubsub.Topic, message *pubsub.Message) (string, error) {
+func (p *PubSubStub) Publish(ctx context.Context, topicID string, message *pubsub.Message) (string, error) {
+	if p.PublishError != nil {
+		return "", p.PublishError
+	}
+	p.PublishedTopic = topicID
 	p.PublishedMessage = message
+	p.PublishedMessages = append(p.PublishedMessages, message)
 	return "", nil
 }
@@ -23,9 +23,11 @@ import (
 type SendGridStub struct {
 	StubbedSend    *rest.Response
 	StubbedSendErr error
+	SentMail       *mail.SGMailV3
 }
 
 // Send to send email
 func (e *SendGridStub) Send(mail *mail.SGMailV3) (*rest.Response, error) {
+	e.SentMail = mail
 	return e.StubbedSend, e.StubbedSendErr
 }
@@ -16,8 +16,13 @@ package stubs
 
 import (
 	"context"
+	"net/http"
+	"sort"
+	"strings"
 
 	"cloud.google.com/go/iam"
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/googleapi"
 )
 
 // StorageStub provides a stub for the Storage client.
@@ -25,6 +30,14 @@ type StorageStub struct {
 	BucketPolicyResponse  *iam.Policy
 	RemoveBucketPolicy    *iam.Policy
 	EnabledPolicyOnBucket string
+	BucketAttrsResponse   *storage.BucketAttrs
+	// Objects holds object contents keyed by bucket name then object name.
+	Objects map[string]map[string][]byte
+	// Generations holds object generations keyed by bucket name then object name. It is
+	// incremented each time an object is written.
+	Generations map[string]map[string]int64
+	// Metadata holds custom object metadata keyed by bucket name then object name.
+	Metadata map[string]map[string]map[string]string
 }
 
 // SetBucketPolicy set a policy for the given bucket.
@@ -38,8 +51,104 @@ func (s *StorageStub) BucketPolicy(ctx context.Context, bucketName string) (*iam
 	return s.BucketPolicyResponse, nil
 }
 
+// BucketAttrs gets a bucket's attributes.
+func (s *StorageStub) BucketAttrs(ctx context.Context, bucketName string) (*storage.BucketAttrs, error) {
+	if s.BucketAttrsResponse == nil {
+		return &storage.BucketAttrs{Name: bucketName}, nil
+	}
+	return s.BucketAttrsResponse, nil
+}
+
 // EnableBucketOnlyPolicy saves the bucket that receives the request for enabling bucket only policy.
 func (s *StorageStub) EnableBucketOnlyPolicy(ctx context.Context, bucketName string) error {
 	s.EnabledPolicyOnBucket = bucketName
 	return nil
 }
+
+// WriteObject saves the contents of an object.
+func (s *StorageStub) WriteObject(ctx context.Context, bucketName, name string, b []byte) error {
+	if s.Objects == nil {
+		s.Objects = map[string]map[string][]byte{}
+	}
+	if s.Objects[bucketName] == nil {
+		s.Objects[bucketName] = map[string][]byte{}
+	}
+	s.Objects[bucketName][name] = b
+	if s.Generations == nil {
+		s.Generations = map[string]map[string]int64{}
+	}
+	if s.Generations[bucketName] == nil {
+		s.Generations[bucketName] = map[string]int64{}
+	}
+	s.Generations[bucketName][name]++
+	return nil
+}
+
+// WriteObjectMetadata saves the contents and custom metadata of an object.
+func (s *StorageStub) WriteObjectMetadata(ctx context.Context, bucketName, name string, b []byte, metadata map[string]string) error {
+	if s.Metadata == nil {
+		s.Metadata = map[string]map[string]map[string]string{}
+	}
+	if s.Metadata[bucketName] == nil {
+		s.Metadata[bucketName] = map[string]map[string]string{}
+	}
+	s.Metadata[bucketName][name] = metadata
+	return s.WriteObject(ctx, bucketName, name, b)
+}
+
+// WriteObjectGeneration saves the contents of an object if it is at the given generation.
+func (s *StorageStub) WriteObjectGeneration(ctx context.Context, bucketName, name string, b []byte, generation int64) (int64, error) {
+	if s.Generations[bucketName][name] != generation {
+		return 0, &googleapi.Error{Code: http.StatusPreconditionFailed}
+	}
+	if err := s.WriteObject(ctx, bucketName, name, b); err != nil {
+		return 0, err
+	}
+	return s.Generations[bucketName][name], nil
+}
+
+// ReadObject returns the contents of a saved object.
+func (s *StorageStub) ReadObject(ctx context.Context, bucketName, name string) ([]byte, error) {
+	b, ok := s.Objects[bucketName][name]
+	if !ok {
+		return nil, storage.ErrObjectNotExist
+	}
+	return b, nil
+}
+
+// ObjectGeneration returns the generation of a saved object.
+func (s *StorageStub) ObjectGeneration(ctx context.Context, bucketName, name string) (int64, error) {
+	if _, ok := s.Objects[bucketName][name]; !ok {
+		return 0, storage.ErrObjectNotExist
+	}
+	return s.Generations[bucketName][name], nil
+}
+
+// ReadObjectGeneration returns the contents of a saved object if it is at the given generation.
+func (s *StorageStub) ReadObjectGeneration(ctx context.Context, bucketName, name string, generation int64) ([]byte, error) {
+	if s.Generations[bucketName][name] != generation {
+		return nil, storage.ErrObjectNotExist
+	}
+	return s.ReadObject(ctx, bucketName, name)
+}
+
+// ListObjects returns the sorted names of saved objects whose names start with the prefix.
+func (s *StorageStub) ListObjects(ctx context.Context, bucketName, prefix string) ([]string, error) {
+	var names []string
+	for name := range s.Objects[bucketName] {
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// DeleteObject removes a saved object.
+func (s *StorageStub) DeleteObject(ctx context.Context, bucketName, name string) error {
+	if _, ok := s.Objects[bucketName][name]; !ok {
+		return storage.ErrObjectNotExist
+	}
+	delete(s.Objects[bucketName], name)
+	return nil
+}
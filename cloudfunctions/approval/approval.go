@@ -0,0 +1,318 @@
+package approval
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+	"github.com/googlecloudplatform/security-response-automation/services"
+	"github.com/pkg/errors"
+)
+
+const (
+	// Topic is the PubSub topic the router publishes automations requiring approval to.
+	Topic = "threat-findings-approval-request"
+	// EmailTemplate is the template of the email sent to approvers.
+	EmailTemplate = "approval_email.tmpl"
+	// WebhookTemplate is the template of the webhook payload sent to approvers.
+	WebhookTemplate = "approval_webhook.tmpl"
+	// Approve and Deny are the decisions an approver can make.
+	Approve = "approve"
+	Deny    = "deny"
+)
+
+var (
+	// ErrInvalidSignature is returned when a decision's signature does not match its request.
+	ErrInvalidSignature = errors.New("invalid signature")
+	// ErrDecided is returned when a decision is made on a request that is no longer pending, along
+	// with the request as decided.
+	ErrDecided = errors.New("request already decided")
+)
+
+// Values contains the required values needed for this function.
+type Values struct {
+	Action    string
+	ProjectID string
+	Rule      string
+	Finding   string
+	// Topic, Values and Attributes are the message published to run the automation once approved.
+	Topic      string
+	Values     json.RawMessage
+	Attributes map[string]string
+	// Limits are counted when the automation is approved, DryRunValues are published instead of
+	// Values if one is exceeded.
+	Limits       []services.Limit
+	DryRunValues json.RawMessage
+	// Email and Webhook are the approvers notified of the request.
+	Email       []string
+	From        string
+	Webhook     string
+	AutoApprove string
+}
+
+// Decision is an approver's decision on a request, as sent by a signed link.
+type Decision struct {
+	ID        string
+	Decision  string
+	Signature string
+}
+
+// ParseToken returns the decision carried by the token parameter of a signed link.
+func ParseToken(token string) (*Decision, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidSignature
+	}
+	return &Decision{ID: parts[0], Decision: parts[1], Signature: parts[2]}, nil
+}
+
+// Services contains the services needed for this function.
+type Services struct {
+	Approvals   *services.Approvals
+	PubSub      *services.PubSub
+	Limiter     *services.Limiter
+	Email       *services.Email
+	Webhook     *services.Webhook
+	Logger      *services.Logger
+	Audit       *services.Audit
+	Idempotency *services.Idempotency
+}
+
+// Notice is the data approval templates are executed with.
+type Notice struct {
+	*services.ApprovalRequest
+	ApproveURL string
+	DenyURL    string
+}
+
+// Summary returns a plain text summary of the request.
+func (n *Notice) Summary() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Security Response Automation wants to run %s in project %s for a %s finding.", n.Action, n.ProjectID, n.Rule)
+	if !n.AutoApprove.IsZero() {
+		fmt.Fprintf(&b, " It will run automatically at %s unless denied.", n.AutoApprove.Format(time.RFC1123))
+	}
+	fmt.Fprintf(&b, "\nApprove: %s\nDeny: %s", n.ApproveURL, n.DenyURL)
+	return b.String()
+}
+
+// Request parks the automation until it is approved and sends approvers links to approve or
+// deny it.
+func Request(ctx context.Context, values *Values, services *Services) (err error) {
+	if !services.Idempotency.Begin(ctx, "request_approval:"+values.Action) {
+		return nil
+	}
+	defer func() { services.Idempotency.End(ctx, "request_approval:"+values.Action, err) }()
+	remediation := services.Audit.Remediation("request_approval", fmt.Sprintf("//cloudresourcemanager.googleapis.com/projects/%s", values.ProjectID), false)
+	defer func() { services.Audit.Record(ctx, remediation, err) }()
+	if services.Approvals == nil {
+		return errors.New("approvals are not configured")
+	}
+	r := newRequest(values)
+	if values.AutoApprove != "" {
+		d, err := time.ParseDuration(values.AutoApprove)
+		if err != nil {
+			return errors.Wrapf(err, "invalid auto_approve %q", values.AutoApprove)
+		}
+		r.AutoApprove = r.Created.Add(d)
+	}
+	// A redelivered request finds the one saved by the previous delivery, so approvers are only
+	// ever asked about one request per automation.
+	stored, err := services.Approvals.Create(ctx, r)
+	if err != nil {
+		return err
+	}
+	if !stored.IsPending() {
+		services.Logger.Info("approval %q to run %q in project %q was already %s", r.ID, r.Action, r.ProjectID, strings.ToLower(stored.Status))
+		return nil
+	}
+	r = stored
+	remediation.After = r
+	notice := &Notice{
+		ApprovalRequest: r,
+		ApproveURL:      services.Approvals.Link(r.ID, Approve),
+		DenyURL:         services.Approvals.Link(r.ID, Deny),
+	}
+	if len(values.Email) > 0 {
+		if err := sendEmail(values, notice, services); err != nil {
+			return err
+		}
+	}
+	if values.Webhook != "" {
+		if err := sendWebhook(ctx, values, notice, services); err != nil {
+			return err
+		}
+	}
+	services.Logger.Info("requested approval %q to run %q in project %q", r.ID, r.Action, r.ProjectID)
+	return nil
+}
+
+func newRequest(values *Values) *services.ApprovalRequest {
+	r := services.NewApprovalRequest()
+	if values.Finding != "" {
+		r.ID = requestID(values)
+	}
+	r.Action = values.Action
+	r.ProjectID = values.ProjectID
+	r.Rule = values.Rule
+	r.Finding = values.Finding
+	r.Topic = values.Topic
+	r.Values = values.Values
+	r.Attributes = values.Attributes
+	r.Limits = values.Limits
+	r.DryRunValues = values.DryRunValues
+	return r
+}
+
+// requestID returns the ID of the request to run the automation, derived from the action and the
+// attributes identifying the finding's delivery and the automation's index within the rule.
+func requestID(values *Values) string {
+	keys := make([]string, 0, len(values.Attributes))
+	for k := range values.Attributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n", values.Action)
+	for _, k := range keys {
+		fmt.Fprintf(h, "%s=%s\n", k, values.Attributes[k])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func sendEmail(values *Values, notice *Notice, services *Services) error {
+	if services.Email == nil {
+		return errors.New("email is not configured")
+	}
+	body, err := services.Email.RenderTemplate(EmailTemplate, notice)
+	if err != nil {
+		return errors.Wrapf(err, "failed to render template %q", EmailTemplate)
+	}
+	subject := fmt.Sprintf("Approval required: %s in %s", values.Action, values.ProjectID)
+	if _, err := services.Email.Send(subject, values.From, body, values.Email); err != nil {
+		return errors.Wrapf(err, "failed to email %q", values.Email)
+	}
+	return nil
+}
+
+func sendWebhook(ctx context.Context, values *Values, notice *Notice, services *Services) error {
+	if services.Webhook == nil {
+		return errors.New("webhook is not configured")
+	}
+	body, err := services.Webhook.RenderTemplate(WebhookTemplate, notice)
+	if err != nil {
+		return errors.Wrapf(err, "failed to render template %q", WebhookTemplate)
+	}
+	if err := services.Webhook.Post(ctx, values.Webhook, body); err != nil {
+		return errors.Wrap(err, "failed to send webhook")
+	}
+	return nil
+}
+
+// Decide applies an approver's decision to a pending request. Approved automations are
+// published to their topic.
+func Decide(ctx context.Context, values *Decision, services *Services) (_ *services.ApprovalRequest, err error) {
+	if services.Approvals == nil {
+		return nil, errors.New("approvals are not configured")
+	}
+	if (values.Decision != Approve && values.Decision != Deny) || !services.Approvals.Verify(values.ID, values.Decision, values.Signature) {
+		return nil, ErrInvalidSignature
+	}
+	r, err := services.Approvals.Load(ctx, values.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !r.IsPending() {
+		return r, ErrDecided
+	}
+	if err := decide(ctx, r, values.Decision == Approve, "approver", services); err != ErrDecided {
+		return r, err
+	}
+	// A concurrent decision won, report it rather than the one attempted.
+	if r, err = services.Approvals.Load(ctx, values.ID); err != nil {
+		return nil, err
+	}
+	return r, ErrDecided
+}
+
+// Sweep approves pending requests whose auto approval time has passed. A request that fails is
+// logged and left pending for the next sweep.
+func Sweep(ctx context.Context, services *Services) error {
+	if services.Approvals == nil {
+		return errors.New("approvals are not configured")
+	}
+	pending, err := services.Approvals.Pending(ctx)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	failed := 0
+	for _, r := range pending {
+		if r.AutoApprove.IsZero() || now.Before(r.AutoApprove) {
+			continue
+		}
+		if err := decide(ctx, r, true, "timeout", services); err == ErrDecided {
+			services.Logger.Info("skipped %q, decided while sweeping", r.ID)
+		} else if err != nil {
+			services.Logger.Error("failed to approve %q: %q", r.ID, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return errors.Errorf("failed to approve %d requests", failed)
+	}
+	return nil
+}
+
+// decide records the decision on the request, then publishes the automation if it was approved,
+// with dry_run on if it exceeds the limits it was requested with.
+// The decision is only written if the request is unchanged since it was loaded so concurrent
+// decisions publish at most once. The request is reopened if publishing fails so it can be
+// decided again.
+func decide(ctx context.Context, r *services.ApprovalRequest, approved bool, by string, svcs *Services) (err error) {
+	remediation := svcs.Audit.Remediation("approval", fmt.Sprintf("//cloudresourcemanager.googleapis.com/projects/%s", r.ProjectID), false)
+	defer func() { svcs.Audit.Record(ctx, remediation, err) }()
+	remediation.Before = r.Status
+	r.Decide(approved, by)
+	if err := svcs.Approvals.Update(ctx, r); err == services.ErrApprovalChanged {
+		return ErrDecided
+	} else if err != nil {
+		return err
+	}
+	if approved {
+		b, ok := svcs.Limiter.Release(ctx, r.Limits, r.Values, r.DryRunValues)
+		if !ok {
+			svcs.Logger.Warning("limit exceeded, running %q in project %q with dry_run on", r.Action, r.ProjectID)
+		}
+		if _, err := svcs.PubSub.Publish(ctx, r.Topic, &pubsub.Message{Data: b, Attributes: r.Attributes}); err != nil {
+			r.Reopen()
+			if uerr := svcs.Approvals.Update(ctx, r); uerr != nil {
+				svcs.Logger.Error("failed to reopen %q: %q", r.ID, uerr)
+			}
+			return errors.Wrapf(err, "failed to publish %q to %q", r.Action, r.Topic)
+		}
+	}
+	remediation.After = r.Status
+	svcs.Logger.Info("%s %q to run %q in project %q, decided by %s", strings.ToLower(r.Status), r.ID, r.Action, r.ProjectID, by)
+	return nil
+}
@@ -0,0 +1,342 @@
+package approval
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/googlecloudplatform/security-response-automation/clients"
+	"github.com/googlecloudplatform/security-response-automation/clients/stubs"
+	"github.com/googlecloudplatform/security-response-automation/services"
+	"github.com/pkg/errors"
+	"github.com/sendgrid/rest"
+)
+
+// approvalSetup returns the services used to request and decide approvals along with the stubs
+// recording published messages and sent emails.
+func approvalSetup() (*Services, *stubs.PubSubStub, *stubs.SendGridStub) {
+	psStub := &stubs.PubSubStub{}
+	sgStub := &stubs.SendGridStub{StubbedSend: &rest.Response{StatusCode: 202}}
+	return &Services{
+		Approvals: services.NewApprovals(&stubs.StorageStub{}, "approvals-bucket", []byte("secret"), "https://region-project.cloudfunctions.net/Approve"),
+		PubSub:    services.NewPubSub(psStub),
+		Email:     services.NewEmailWithTemplates(&clients.SendGrid{Service: sgStub}, "../../templates"),
+		Logger:    services.NewLogger(&stubs.LoggerStub{}),
+	}, psStub, sgStub
+}
+
+// links returns the decisions carried by the links in the email sent to approvers.
+func links(t *testing.T, sgStub *stubs.SendGridStub) map[string]*Decision {
+	t.Helper()
+	decisions := map[string]*Decision{}
+	for _, line := range strings.Split(sgStub.SentMail.Content[0].Value, "\n") {
+		i := strings.Index(line, "https://")
+		if i < 0 {
+			continue
+		}
+		u, err := url.Parse(line[i:])
+		if err != nil {
+			t.Fatalf("failed to parse link %q: %q", line[i:], err)
+		}
+		d, err := ParseToken(u.Query().Get("token"))
+		if err != nil {
+			t.Fatalf("failed to parse token of %q: %q", line[i:], err)
+		}
+		decisions[d.Decision] = d
+	}
+	return decisions
+}
+
+func TestApproval(t *testing.T) {
+	ctx := context.Background()
+	values := &Values{
+		Action:     "remediate_firewall",
+		ProjectID:  "test-project",
+		Rule:       "open_firewall",
+		Topic:      "threat-findings-open-firewall",
+		Values:     json.RawMessage(`{"ProjectID":"test-project","FirewallID":"123","Action":"delete"}`),
+		Attributes: map[string]string{"rule": "open_firewall"},
+		Email:      []string{"secops@example.com"},
+		From:       "sra@example.com",
+	}
+	for _, tt := range []struct {
+		name        string
+		decision    string
+		wantPublish bool
+	}{
+		{name: "approve", decision: Approve, wantPublish: true},
+		{name: "deny", decision: Deny, wantPublish: false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			svcs, psStub, sgStub := approvalSetup()
+			if err := Request(ctx, values, svcs); err != nil {
+				t.Fatalf("Request() failed: %q", err)
+			}
+			if psStub.PublishedMessage != nil {
+				t.Fatalf("automation published before a decision")
+			}
+			decisions := links(t, sgStub)
+			if len(decisions) != 2 {
+				t.Fatalf("got links %v, want approve and deny", decisions)
+			}
+
+			forged := *decisions[tt.decision]
+			forged.Signature = strings.Repeat("0", len(forged.Signature))
+			if _, err := Decide(ctx, &forged, svcs); err != ErrInvalidSignature {
+				t.Errorf("Decide() with forged signature = %v, want %v", err, ErrInvalidSignature)
+			}
+
+			if _, err := Decide(ctx, decisions[tt.decision], svcs); err != nil {
+				t.Fatalf("Decide() failed: %q", err)
+			}
+			if got := psStub.PublishedMessage != nil; got != tt.wantPublish {
+				t.Fatalf("published %v, want %v", got, tt.wantPublish)
+			}
+			if tt.wantPublish && string(psStub.PublishedMessage.Data) != string(values.Values) {
+				t.Errorf("published %s, want %s", psStub.PublishedMessage.Data, values.Values)
+			}
+			if _, err := Decide(ctx, decisions[Approve], svcs); err != ErrDecided {
+				t.Errorf("second Decide() = %v, want %v", err, ErrDecided)
+			}
+		})
+	}
+}
+
+func TestSweep(t *testing.T) {
+	ctx := context.Background()
+	svcs, psStub, _ := approvalSetup()
+	now := time.Now()
+	for _, r := range []*services.ApprovalRequest{
+		{ID: "due", Action: "remediate_firewall", Topic: "threat-findings-open-firewall", Values: json.RawMessage(`{"FirewallID":"due"}`), Status: services.ApprovalPending, AutoApprove: now.Add(-time.Minute)},
+		{ID: "waiting", Action: "remediate_firewall", Topic: "threat-findings-open-firewall", Values: json.RawMessage(`{"FirewallID":"waiting"}`), Status: services.ApprovalPending, AutoApprove: now.Add(time.Hour)},
+		{ID: "manual", Action: "remediate_firewall", Topic: "threat-findings-open-firewall", Values: json.RawMessage(`{"FirewallID":"manual"}`), Status: services.ApprovalPending},
+	} {
+		if err := svcs.Approvals.Save(ctx, r); err != nil {
+			t.Fatalf("Save() failed: %q", err)
+		}
+	}
+	if err := Sweep(ctx, svcs); err != nil {
+		t.Fatalf("Sweep() failed: %q", err)
+	}
+	if psStub.PublishedMessage == nil || string(psStub.PublishedMessage.Data) != `{"FirewallID":"due"}` {
+		t.Errorf("Sweep() did not publish the due request")
+	}
+	pending, err := svcs.Approvals.Pending(ctx)
+	if err != nil {
+		t.Fatalf("Pending() failed: %q", err)
+	}
+	if len(pending) != 2 || pending[0].ID != "manual" || pending[1].ID != "waiting" {
+		t.Errorf("Pending() = %+v, want manual and waiting", pending)
+	}
+	r, err := svcs.Approvals.Load(ctx, "due")
+	if err != nil {
+		t.Fatalf("Load() failed: %q", err)
+	}
+	if r.Status != services.ApprovalApproved || r.DecidedBy != "timeout" {
+		t.Errorf("due request status %q decided by %q, want approved by timeout", r.Status, r.DecidedBy)
+	}
+}
+
+func TestDecideConcurrently(t *testing.T) {
+	ctx := context.Background()
+	svcs, psStub, _ := approvalSetup()
+	r := &services.ApprovalRequest{ID: "due", Action: "remediate_firewall", Topic: "threat-findings-open-firewall", Values: json.RawMessage(`{"FirewallID":"due"}`), Status: services.ApprovalPending}
+	if err := svcs.Approvals.Save(ctx, r); err != nil {
+		t.Fatalf("Save() failed: %q", err)
+	}
+	// Both deciders load the request before either writes its decision.
+	first, err := svcs.Approvals.Load(ctx, "due")
+	if err != nil {
+		t.Fatalf("Load() failed: %q", err)
+	}
+	second, err := svcs.Approvals.Load(ctx, "due")
+	if err != nil {
+		t.Fatalf("Load() failed: %q", err)
+	}
+	if err := decide(ctx, first, true, "approver", svcs); err != nil {
+		t.Fatalf("decide() failed: %q", err)
+	}
+	if err := decide(ctx, second, true, "timeout", svcs); err != ErrDecided {
+		t.Errorf("second decide() = %v, want %v", err, ErrDecided)
+	}
+	if len(psStub.PublishedMessages) != 1 {
+		t.Errorf("published %d messages, want 1", len(psStub.PublishedMessages))
+	}
+}
+
+func TestSweepContinues(t *testing.T) {
+	ctx := context.Background()
+	svcs, psStub, _ := approvalSetup()
+	now := time.Now()
+	for _, id := range []string{"first", "second"} {
+		r := &services.ApprovalRequest{ID: id, Action: "remediate_firewall", Topic: "threat-findings-open-firewall", Values: json.RawMessage(`{"FirewallID":"` + id + `"}`), Status: services.ApprovalPending, AutoApprove: now.Add(-time.Minute)}
+		if err := svcs.Approvals.Save(ctx, r); err != nil {
+			t.Fatalf("Save() failed: %q", err)
+		}
+	}
+	psStub.PublishError = errors.New("unavailable")
+	if err := Sweep(ctx, svcs); err == nil {
+		t.Fatalf("Sweep() succeeded, want an error")
+	}
+	pending, err := svcs.Approvals.Pending(ctx)
+	if err != nil {
+		t.Fatalf("Pending() failed: %q", err)
+	}
+	if len(pending) != 2 {
+		t.Fatalf("Pending() = %+v, want both requests reopened", pending)
+	}
+	psStub.PublishError = nil
+	if err := Sweep(ctx, svcs); err != nil {
+		t.Fatalf("Sweep() failed: %q", err)
+	}
+	if len(psStub.PublishedMessages) != 2 {
+		t.Errorf("published %d messages, want 2", len(psStub.PublishedMessages))
+	}
+}
+
+func TestRequestRedelivered(t *testing.T) {
+	ctx := context.Background()
+	svcs, psStub, sgStub := approvalSetup()
+	values := &Values{
+		Action:     "close_bucket",
+		ProjectID:  "test-project",
+		Rule:       "public_bucket_acl",
+		Finding:    "organizations/1/sources/2/findings/3",
+		Topic:      "threat-findings-close-bucket",
+		Values:     json.RawMessage(`{"BucketName":"public-bucket"}`),
+		Attributes: map[string]string{"rule": "public_bucket_acl", "finding": "organizations/1/sources/2/findings/3", "event_time": "2020-10-01T00:00:00Z", "index": "0"},
+		Email:      []string{"secops@example.com"},
+		From:       "sra@example.com",
+	}
+	sgStub.StubbedSendErr = errors.New("unavailable")
+	if err := Request(ctx, values, svcs); err == nil {
+		t.Fatalf("Request() succeeded, want an error")
+	}
+	sgStub.StubbedSendErr = nil
+	if err := Request(ctx, values, svcs); err != nil {
+		t.Fatalf("Request() failed: %q", err)
+	}
+	pending, err := svcs.Approvals.Pending(ctx)
+	if err != nil {
+		t.Fatalf("Pending() failed: %q", err)
+	}
+	if len(pending) != 1 {
+		t.Fatalf("Pending() = %+v, want one request", pending)
+	}
+	decisions := links(t, sgStub)
+	if decisions[Deny] == nil || decisions[Deny].ID != pending[0].ID {
+		t.Fatalf("got links %v, want links to %q", decisions, pending[0].ID)
+	}
+	if _, err := Decide(ctx, decisions[Deny], svcs); err != nil {
+		t.Fatalf("Decide() failed: %q", err)
+	}
+	if err := Request(ctx, values, svcs); err != nil {
+		t.Fatalf("Request() after deny failed: %q", err)
+	}
+	if pending, err := svcs.Approvals.Pending(ctx); err != nil || len(pending) != 0 {
+		t.Errorf("Pending() = %+v, %v, want no requests after deny", pending, err)
+	}
+	if psStub.PublishedMessage != nil {
+		t.Errorf("published %s, want the denied automation not to run", psStub.PublishedMessage.Data)
+	}
+}
+
+// racingStorage runs race once before the first conditional write, as a concurrent decision
+// would.
+type racingStorage struct {
+	*stubs.StorageStub
+	race func()
+}
+
+func (s *racingStorage) WriteObjectGeneration(ctx context.Context, bucketName, name string, b []byte, generation int64) (int64, error) {
+	if race := s.race; race != nil {
+		s.race = nil
+		race()
+	}
+	return s.StorageStub.WriteObjectGeneration(ctx, bucketName, name, b, generation)
+}
+
+func TestDecideReportsStoredDecision(t *testing.T) {
+	ctx := context.Background()
+	svcs, psStub, _ := approvalSetup()
+	storage := &racingStorage{StorageStub: &stubs.StorageStub{}}
+	svcs.Approvals = services.NewApprovals(storage, "approvals-bucket", []byte("secret"), "https://region-project.cloudfunctions.net/Approve")
+	r := &services.ApprovalRequest{ID: "due", Action: "remediate_firewall", Topic: "threat-findings-open-firewall", Values: json.RawMessage(`{"FirewallID":"due"}`), Status: services.ApprovalPending}
+	if err := svcs.Approvals.Save(ctx, r); err != nil {
+		t.Fatalf("Save() failed: %q", err)
+	}
+	storage.race = func() {
+		denied, err := svcs.Approvals.Load(ctx, "due")
+		if err != nil {
+			t.Fatalf("Load() failed: %q", err)
+		}
+		if err := decide(ctx, denied, false, "approver", svcs); err != nil {
+			t.Fatalf("decide() failed: %q", err)
+		}
+	}
+	u, err := url.Parse(svcs.Approvals.Link("due", Approve))
+	if err != nil {
+		t.Fatalf("failed to parse link: %q", err)
+	}
+	approve, err := ParseToken(u.Query().Get("token"))
+	if err != nil {
+		t.Fatalf("ParseToken() failed: %q", err)
+	}
+	got, err := Decide(ctx, approve, svcs)
+	if err != ErrDecided {
+		t.Fatalf("Decide() = %v, want %v", err, ErrDecided)
+	}
+	if got.Status != services.ApprovalDenied {
+		t.Errorf("Decide() returned status %q, want %q", got.Status, services.ApprovalDenied)
+	}
+	if psStub.PublishedMessage != nil {
+		t.Errorf("published %s, want the denied automation not to run", psStub.PublishedMessage.Data)
+	}
+	if _, ok := storage.Objects["approvals-bucket"]["pending-approvals/due"]; ok {
+		t.Errorf("denied request is still indexed as pending")
+	}
+}
+
+func TestSweepLimits(t *testing.T) {
+	ctx := context.Background()
+	svcs, psStub, _ := approvalSetup()
+	svcs.Limiter = services.NewLimiter(services.NewMemoryLimitStore())
+	for _, id := range []string{"first", "second"} {
+		r := &services.ApprovalRequest{
+			ID:           id,
+			Action:       "close_bucket",
+			Topic:        "threat-findings-close-bucket",
+			Values:       json.RawMessage(`{"DryRun":false}`),
+			DryRunValues: json.RawMessage(`{"DryRun":true}`),
+			Limits:       []services.Limit{{Key: "close_bucket/organization/1h", Max: 1, Window: time.Hour}},
+			Status:       services.ApprovalPending,
+			AutoApprove:  time.Now().Add(-time.Minute),
+		}
+		if err := svcs.Approvals.Save(ctx, r); err != nil {
+			t.Fatalf("Save() failed: %q", err)
+		}
+	}
+	if err := Sweep(ctx, svcs); err != nil {
+		t.Fatalf("Sweep() failed: %q", err)
+	}
+	if len(psStub.PublishedMessages) != 2 || string(psStub.PublishedMessages[0].Data) != `{"DryRun":false}` || string(psStub.PublishedMessages[1].Data) != `{"DryRun":true}` {
+		t.Errorf("published %d messages, want the second approved automation run with dry_run on", len(psStub.PublishedMessages))
+	}
+}
@@ -0,0 +1,148 @@
+# Copyright 2019 Google LLC
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+# 	https://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+resource "google_cloudfunctions_function" "request-approval" {
+  name                  = "RequestApproval"
+  description           = "Parks an automation until it is approved and notifies approvers."
+  runtime               = "go113"
+  available_memory_mb   = 128
+  source_archive_bucket = var.setup.gcf-bucket-name
+  source_archive_object = var.setup.gcf-object-name
+  timeout               = 60
+  project               = var.setup.automation-project
+  region                = var.setup.region
+  entry_point           = "RequestApproval"
+  service_account_email = var.setup.automation-service-account
+
+  event_trigger {
+    event_type = "google.pubsub.topic.publish"
+    resource   = "threat-findings-approval-request"
+    failure_policy {
+      retry = true
+    }
+  }
+  environment_variables = {
+    GCP_PROJECT            = var.setup.automation-project
+    AUDIT_DATASET          = var.setup.audit-dataset
+    AUDIT_TABLE            = var.setup.audit-table
+    APPROVAL_BUCKET        = google_storage_bucket.approvals.name
+    APPROVAL_SECRET        = random_id.approval-secret.hex
+    APPROVAL_URL           = google_cloudfunctions_function.approve.https_trigger_url
+    SENDGRID_API_KEY       = var.sendgrid-api-key
+    WEBHOOK_SECRET         = var.webhook-secret
+    WEBHOOK_URLS           = jsonencode(var.webhook-urls)
+    IDEMPOTENCY_COLLECTION = var.setup.idempotency-collection
+    DEAD_LETTER_TOPIC      = var.setup.dead-letter-topic
+  }
+}
+
+resource "google_cloudfunctions_function" "approve" {
+  name                  = "Approve"
+  description           = "Records decisions made with the links sent to approvers."
+  runtime               = "go113"
+  available_memory_mb   = 128
+  source_archive_bucket = var.setup.gcf-bucket-name
+  source_archive_object = var.setup.gcf-object-name
+  timeout               = 60
+  project               = var.setup.automation-project
+  region                = var.setup.region
+  entry_point           = "Approve"
+  service_account_email = var.setup.automation-service-account
+  trigger_http          = true
+
+  environment_variables = {
+    GCP_PROJECT       = var.setup.automation-project
+    AUDIT_DATASET     = var.setup.audit-dataset
+    AUDIT_TABLE       = var.setup.audit-table
+    APPROVAL_BUCKET   = google_storage_bucket.approvals.name
+    APPROVAL_SECRET   = random_id.approval-secret.hex
+    LIMITS_COLLECTION = var.setup.limits-collection
+  }
+}
+
+# Approvers are not Google identities, links are authenticated by their signature instead.
+resource "google_cloudfunctions_function_iam_member" "approve-invoker" {
+  project        = var.setup.automation-project
+  region         = var.setup.region
+  cloud_function = google_cloudfunctions_function.approve.name
+  role           = "roles/cloudfunctions.invoker"
+  member         = "allUsers"
+}
+
+resource "google_cloudfunctions_function" "approval-timeout" {
+  name                  = "ApprovalTimeout"
+  description           = "Approves pending automations whose auto approval time has passed."
+  runtime               = "go113"
+  available_memory_mb   = 128
+  source_archive_bucket = var.setup.gcf-bucket-name
+  source_archive_object = var.setup.gcf-object-name
+  timeout               = 120
+  project               = var.setup.automation-project
+  region                = var.setup.region
+  entry_point           = "ApprovalTimeout"
+  service_account_email = var.setup.automation-service-account
+
+  event_trigger {
+    event_type = "google.pubsub.topic.publish"
+    resource   = "threat-findings-approval-timeout"
+  }
+  environment_variables = {
+    GCP_PROJECT       = var.setup.automation-project
+    AUDIT_DATASET     = var.setup.audit-dataset
+    AUDIT_TABLE       = var.setup.audit-table
+    APPROVAL_BUCKET   = google_storage_bucket.approvals.name
+    APPROVAL_SECRET   = random_id.approval-secret.hex
+    LIMITS_COLLECTION = var.setup.limits-collection
+  }
+}
+
+# Secret used to sign approval links.
+resource "random_id" "approval-secret" {
+  byte_length = 32
+}
+
+# GCS bucket holding automations waiting for approval.
+resource "google_storage_bucket" "approvals" {
+  name    = "${var.setup.automation-project}-sra-approvals"
+  project = var.setup.automation-project
+}
+
+resource "google_storage_bucket_iam_member" "approvals-writer" {
+  bucket = google_storage_bucket.approvals.name
+  role   = "roles/storage.objectAdmin"
+  member = "serviceAccount:${var.setup.automation-service-account}"
+}
+
+# PubSub topic to trigger this automation.
+resource "google_pubsub_topic" "topic" {
+  name    = "threat-findings-approval-request"
+  project = var.setup.automation-project
+}
+
+# PubSub topic triggering the auto approval of pending automations every five minutes.
+resource "google_pubsub_topic" "timeout-topic" {
+  name    = "threat-findings-approval-timeout"
+  project = var.setup.automation-project
+}
+
+resource "google_cloud_scheduler_job" "approval-timeout" {
+  name     = "sra-approval-timeout"
+  project  = var.setup.automation-project
+  region   = var.setup.region
+  schedule = "*/5 * * * *"
+
+  pubsub_target {
+    topic_name = google_pubsub_topic.timeout-topic.id
+    data       = base64encode("{}")
+  }
+}
@@ -0,0 +1,16 @@
+variable "setup" {}
+
+variable "sendgrid-api-key" {
+  type        = string
+  description = "SendGrid API key used to email approvers."
+}
+
+variable "webhook-secret" {
+  type        = string
+  description = "Secret used to sign webhooks sent to approvers."
+}
+
+variable "webhook-urls" {
+  type        = map(string)
+  description = "Webhook URLs keyed by webhook name."
+}
@@ -16,6 +16,7 @@ package closepublicdataset
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/googlecloudplatform/security-response-automation/services"
 	"github.com/pkg/errors"
@@ -30,12 +31,33 @@ type Values struct {
 
 // Services contains the services needed for this function.
 type Services struct {
-	BigQuery *services.BigQuery
-	Logger   *services.Logger
+	BigQuery    *services.BigQuery
+	Logger      *services.Logger
+	Audit       *services.Audit
+	Idempotency *services.Idempotency
 }
 
 // Execute removes public access of a BigQuery dataset.
-func Execute(ctx context.Context, values *Values, services *Services) error {
+func Execute(ctx context.Context, values *Values, services *Services) (err error) {
+	if !services.Idempotency.Begin(ctx, "close_public_dataset") {
+		return nil
+	}
+	defer func() { services.Idempotency.End(ctx, "close_public_dataset", err) }()
+	remediation := services.Audit.Remediation("close_public_dataset", fmt.Sprintf("//bigquery.googleapis.com/projects/%s/datasets/%s", values.ProjectID, values.DatasetID), values.DryRun)
+	defer func() { services.Audit.Record(ctx, remediation, err) }()
+	labels, err := services.BigQuery.DatasetLabels(ctx, values.ProjectID, values.DatasetID)
+	if err != nil {
+		return err
+	}
+	if remediation.Exempt(labels) {
+		services.Logger.Info("skipped bigquery dataset %q in project %q, %s", values.DatasetID, values.ProjectID, remediation.Skipped)
+		return nil
+	}
+	access, err := services.BigQuery.DatasetAccess(ctx, values.ProjectID, values.DatasetID)
+	if err != nil {
+		return err
+	}
+	remediation.Before = access
 	if values.DryRun {
 		services.Logger.Info("dry_run on, would have removed public access on bigquery dataset %q in project %q", values.DatasetID, values.ProjectID)
 		return nil
@@ -43,6 +65,9 @@ func Execute(ctx context.Context, values *Values, services *Services) error {
 	if err := services.BigQuery.RemoveDatasetPublicAccess(ctx, values.ProjectID, values.DatasetID); err != nil {
 		return errors.Wrapf(err, "error removing bigquery dataset %q public access in project %q", values.DatasetID, values.ProjectID)
 	}
+	if remediation.After, err = services.BigQuery.DatasetAccess(ctx, values.ProjectID, values.DatasetID); err != nil {
+		return err
+	}
 	services.Logger.Info("removed public access on bigquery dataset %q in project %q", values.DatasetID, values.ProjectID)
 	return nil
 }
@@ -16,6 +16,7 @@ package removepublic
 
 import (
 	"context"
+	"fmt"
 	"log"
 
 	"github.com/googlecloudplatform/security-response-automation/services"
@@ -29,13 +30,29 @@ type Values struct {
 
 // Services contains the services needed for this function.
 type Services struct {
-	CloudSQL *services.CloudSQL
-	Resource *services.Resource
-	Logger   *services.Logger
+	CloudSQL    *services.CloudSQL
+	Resource    *services.Resource
+	Logger      *services.Logger
+	Audit       *services.Audit
+	Idempotency *services.Idempotency
 }
 
 // Execute will remove any public IPs in SQL instance found within the provided resources.
-func Execute(ctx context.Context, values *Values, services *Services) error {
+func Execute(ctx context.Context, values *Values, services *Services) (err error) {
+	if !services.Idempotency.Begin(ctx, "close_cloud_sql") {
+		return nil
+	}
+	defer func() { services.Idempotency.End(ctx, "close_cloud_sql", err) }()
+	remediation := services.Audit.Remediation("close_cloud_sql", fmt.Sprintf("//cloudsql.googleapis.com/projects/%s/instances/%s", values.ProjectID, values.InstanceName), values.DryRun)
+	defer func() { services.Audit.Record(ctx, remediation, err) }()
+	labels, err := services.CloudSQL.InstanceLabels(ctx, values.ProjectID, values.InstanceName)
+	if err != nil {
+		return err
+	}
+	if remediation.Exempt(labels) {
+		services.Logger.Info("skipped Cloud SQL instance %q in project %q, %s", values.InstanceName, values.ProjectID, remediation.Skipped)
+		return nil
+	}
 	log.Printf("getting details from Cloud SQL instance %q in project %q.", values.InstanceName, values.ProjectID)
 	instance, err := services.CloudSQL.InstanceDetails(ctx, values.ProjectID, values.InstanceName)
 	if err != nil {
@@ -43,6 +60,7 @@ func Execute(ctx context.Context, values *Values, services *Services) error {
 	}
 
 	acls := instance.Settings.IpConfiguration.AuthorizedNetworks
+	remediation.Before = map[string]interface{}{"authorized_networks": acls}
 	if !services.CloudSQL.IsPublic(acls) {
 		services.Logger.Info("instance %q does not have public access enabled", values.InstanceName)
 		return nil
@@ -54,6 +72,10 @@ func Execute(ctx context.Context, values *Values, services *Services) error {
 	if err := services.CloudSQL.ClosePublicAccess(ctx, values.ProjectID, values.InstanceName, acls); err != nil {
 		return err
 	}
+	if instance, err = services.CloudSQL.InstanceDetails(ctx, values.ProjectID, values.InstanceName); err != nil {
+		return err
+	}
+	remediation.After = map[string]interface{}{"authorized_networks": instance.Settings.IpConfiguration.AuthorizedNetworks}
 	services.Logger.Info("removed public access from Cloud SQL instance %q in project %q.", values.InstanceName, values.ProjectID)
 	return nil
 }
@@ -16,8 +16,10 @@ package requiressl
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/googlecloudplatform/security-response-automation/services"
+	sqladmin "google.golang.org/api/sqladmin/v1beta4"
 )
 
 // Values contains the required values needed for this function.
@@ -28,13 +30,34 @@ type Values struct {
 
 // Services contains the services needed for this function.
 type Services struct {
-	CloudSQL *services.CloudSQL
-	Resource *services.Resource
-	Logger   *services.Logger
+	CloudSQL    *services.CloudSQL
+	Resource    *services.Resource
+	Logger      *services.Logger
+	Audit       *services.Audit
+	Idempotency *services.Idempotency
 }
 
 // Execute will remove any public ips in sql instance found within the provided folders.
-func Execute(ctx context.Context, values *Values, services *Services) error {
+func Execute(ctx context.Context, values *Values, services *Services) (err error) {
+	if !services.Idempotency.Begin(ctx, "cloud_sql_require_ssl") {
+		return nil
+	}
+	defer func() { services.Idempotency.End(ctx, "cloud_sql_require_ssl", err) }()
+	remediation := services.Audit.Remediation("cloud_sql_require_ssl", fmt.Sprintf("//cloudsql.googleapis.com/projects/%s/instances/%s", values.ProjectID, values.InstanceName), values.DryRun)
+	defer func() { services.Audit.Record(ctx, remediation, err) }()
+	labels, err := services.CloudSQL.InstanceLabels(ctx, values.ProjectID, values.InstanceName)
+	if err != nil {
+		return err
+	}
+	if remediation.Exempt(labels) {
+		services.Logger.Info("skipped Cloud SQL instance %q in project %q, %s", values.InstanceName, values.ProjectID, remediation.Skipped)
+		return nil
+	}
+	instance, err := services.CloudSQL.InstanceDetails(ctx, values.ProjectID, values.InstanceName)
+	if err != nil {
+		return err
+	}
+	remediation.Before = sslState(instance)
 	if values.DryRun {
 		services.Logger.Info("dry_run on, enforced ssl on sql instance %q in project %q.", values.InstanceName, values.ProjectID)
 		return nil
@@ -42,6 +65,16 @@ func Execute(ctx context.Context, values *Values, services *Services) error {
 	if err := services.CloudSQL.RequireSSL(ctx, values.ProjectID, values.InstanceName); err != nil {
 		return err
 	}
+	if instance, err = services.CloudSQL.InstanceDetails(ctx, values.ProjectID, values.InstanceName); err != nil {
+		return err
+	}
+	remediation.After = sslState(instance)
 	services.Logger.Info("enforced ssl on sql instance %q in project %q.", values.InstanceName, values.ProjectID)
 	return nil
 }
+
+// sslState returns whether the instance requires SSL connections, as recorded in the audit table.
+func sslState(instance *sqladmin.DatabaseInstance) map[string]bool {
+	required := instance.Settings != nil && instance.Settings.IpConfiguration != nil && instance.Settings.IpConfiguration.RequireSsl
+	return map[string]bool{"require_ssl": required}
+}
@@ -69,7 +69,7 @@ func TestCloudSQLRequireSSL(t *testing.T) {
 func cloudSQLRequireSSL() (*services.Global, *stubs.CloudSQL) {
 	loggerStub := &stubs.LoggerStub{}
 	log := services.NewLogger(loggerStub)
-	sqlStub := &stubs.CloudSQL{}
+	sqlStub := &stubs.CloudSQL{InstanceDetailsResponse: &sqladmin.DatabaseInstance{}}
 	sql := services.NewCloudSQL(sqlStub)
 	storageStub := &stubs.StorageStub{}
 	crmStub := &stubs.ResourceManagerStub{}
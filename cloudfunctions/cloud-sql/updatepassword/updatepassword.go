@@ -16,6 +16,7 @@ package updatepassword
 
 import (
 	"context"
+	"fmt"
 	"log"
 
 	"github.com/googlecloudplatform/security-response-automation/services"
@@ -29,13 +30,31 @@ type Values struct {
 
 // Services contains the services needed for this function.
 type Services struct {
-	CloudSQL *services.CloudSQL
-	Resource *services.Resource
-	Logger   *services.Logger
+	CloudSQL    *services.CloudSQL
+	Resource    *services.Resource
+	Logger      *services.Logger
+	Audit       *services.Audit
+	Idempotency *services.Idempotency
 }
 
 // Execute will update the root password for the MySQL instance found within the provided resources.
-func Execute(ctx context.Context, values *Values, services *Services) error {
+func Execute(ctx context.Context, values *Values, services *Services) (err error) {
+	if !services.Idempotency.Begin(ctx, "cloud_sql_update_password") {
+		return nil
+	}
+	defer func() { services.Idempotency.End(ctx, "cloud_sql_update_password", err) }()
+	remediation := services.Audit.Remediation("cloud_sql_update_password", fmt.Sprintf("//cloudsql.googleapis.com/projects/%s/instances/%s", values.ProjectID, values.InstanceName), values.DryRun)
+	defer func() { services.Audit.Record(ctx, remediation, err) }()
+	labels, err := services.CloudSQL.InstanceLabels(ctx, values.ProjectID, values.InstanceName)
+	if err != nil {
+		return err
+	}
+	if remediation.Exempt(labels) {
+		services.Logger.Info("skipped Cloud SQL instance %q in project %q, %s", values.InstanceName, values.ProjectID, remediation.Skipped)
+		return nil
+	}
+	// The password itself is never recorded, only whether it was changed.
+	remediation.Before = map[string]interface{}{"user": values.UserName, "host": values.Host, "password_updated": false}
 	log.Printf("updating root password for MySQL instance %q in project %q.", values.InstanceName, values.ProjectID)
 	if values.DryRun {
 		services.Logger.Info("dry_run on, would have updated root password for MySQL instance %q in project %q.", values.InstanceName, values.ProjectID)
@@ -44,6 +63,7 @@ func Execute(ctx context.Context, values *Values, services *Services) error {
 	if err := services.CloudSQL.UpdateUserPassword(ctx, values.ProjectID, values.InstanceName, values.Host, values.UserName, values.Password); err != nil {
 		return err
 	}
+	remediation.After = map[string]interface{}{"user": values.UserName, "host": values.Host, "password_updated": true}
 	services.Logger.Info("updated root password for MySQL instance %q in project %q.", values.InstanceName, values.ProjectID)
 	return nil
 }
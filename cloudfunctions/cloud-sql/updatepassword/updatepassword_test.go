@@ -65,7 +65,7 @@ func TestUpdatePassword(t *testing.T) {
 func updatePasswordSetup() (*services.Global, *stubs.CloudSQL) {
 	loggerStub := &stubs.LoggerStub{}
 	log := services.NewLogger(loggerStub)
-	sqlStub := &stubs.CloudSQL{}
+	sqlStub := &stubs.CloudSQL{InstanceDetailsResponse: &sqladmin.DatabaseInstance{}}
 	sql := services.NewCloudSQL(sqlStub)
 	storageStub := &stubs.StorageStub{}
 	crmStub := &stubs.ResourceManagerStub{}
@@ -0,0 +1,97 @@
+package blockdomain
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/googlecloudplatform/security-response-automation/services"
+	"github.com/pkg/errors"
+)
+
+// Values contains the required values needed for this function.
+type Values struct {
+	ProjectID string
+	Instance  string
+	Zone      string
+	Domains   []string
+	DryRun    bool
+}
+
+// Services contains the services needed for this function.
+type Services struct {
+	DNS         *services.DNS
+	Host        *services.Host
+	Logger      *services.Logger
+	Audit       *services.Audit
+	Idempotency *services.Idempotency
+}
+
+// Execute blocks resolution of the domains from the networks the affected instance is attached to.
+func Execute(ctx context.Context, values *Values, services *Services) (err error) {
+	if !services.Idempotency.Begin(ctx, "block_domain") {
+		return nil
+	}
+	defer func() { services.Idempotency.End(ctx, "block_domain", err) }()
+	remediation := services.Audit.Remediation("block_domain", fmt.Sprintf("//compute.googleapis.com/projects/%s/zones/%s/instances/%s", values.ProjectID, values.Zone, values.Instance), values.DryRun)
+	defer func() { services.Audit.Record(ctx, remediation, err) }()
+	if len(values.Domains) == 0 {
+		return errors.New("no domains to block")
+	}
+	networks, err := services.Host.InstanceNetworks(ctx, values.ProjectID, values.Zone, values.Instance)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get networks for instance %q", values.Instance)
+	}
+	before := map[string][]string{}
+	for _, domain := range values.Domains {
+		if before[domain], err = services.DNS.BlockedNetworks(ctx, values.ProjectID, domain); err != nil {
+			return err
+		}
+	}
+	remediation.Before = before
+	if values.DryRun {
+		services.Logger.Info("dry_run on, would have blocked %q for networks %q in project %q", values.Domains, networks, values.ProjectID)
+		return nil
+	}
+	// Zone updates complete asynchronously so the networks each domain is blocked for once they
+	// do are recorded.
+	after := map[string][]string{}
+	for _, domain := range values.Domains {
+		if err := services.DNS.BlockDomain(ctx, values.ProjectID, domain, networks); err != nil {
+			return errors.Wrapf(err, "failed to block %q", domain)
+		}
+		after[domain] = union(before[domain], networks)
+		services.Logger.Info("blocked %q for networks %q in project %q", domain, networks, values.ProjectID)
+	}
+	remediation.After = after
+	return nil
+}
+
+// union returns the networks in a followed by those in b not already in a.
+func union(a, b []string) []string {
+	out := append([]string{}, a...)
+	seen := map[string]bool{}
+	for _, n := range a {
+		seen[n] = true
+	}
+	for _, n := range b {
+		if !seen[n] {
+			seen[n] = true
+			out = append(out, n)
+		}
+	}
+	return out
+}
@@ -0,0 +1,132 @@
+package blockdomain
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	compute "google.golang.org/api/compute/v1"
+	dns "google.golang.org/api/dns/v1"
+
+	"github.com/googlecloudplatform/security-response-automation/clients/stubs"
+	"github.com/googlecloudplatform/security-response-automation/services"
+)
+
+const (
+	network      = "https://www.googleapis.com/compute/v1/projects/project-id/global/networks/default"
+	otherNetwork = "https://www.googleapis.com/compute/v1/projects/project-id/global/networks/other"
+)
+
+func TestBlockDomain(t *testing.T) {
+	ctx := context.Background()
+
+	test := []struct {
+		name          string
+		existing      *dns.ManagedZone
+		dryRun        bool
+		expectedSaved *dns.ManagedZone
+		expectedPatch *dns.ManagedZone
+		expectedError bool
+	}{
+		{
+			name: "create zone",
+			expectedSaved: &dns.ManagedZone{
+				Name:        "sra-block-3322-org-cda9dc4c",
+				DnsName:     "3322.org.",
+				Description: "Block 3322.org by Security Response Automation",
+				Visibility:  "private",
+				PrivateVisibilityConfig: &dns.ManagedZonePrivateVisibilityConfig{
+					Networks: []*dns.ManagedZonePrivateVisibilityConfigNetwork{{NetworkUrl: network}},
+				},
+			},
+		},
+		{
+			name: "add network to existing zone",
+			existing: &dns.ManagedZone{
+				Name:    "sra-block-3322-org-cda9dc4c",
+				DnsName: "3322.org.",
+				PrivateVisibilityConfig: &dns.ManagedZonePrivateVisibilityConfig{
+					Networks: []*dns.ManagedZonePrivateVisibilityConfigNetwork{{NetworkUrl: otherNetwork}},
+				},
+			},
+			expectedPatch: &dns.ManagedZone{
+				PrivateVisibilityConfig: &dns.ManagedZonePrivateVisibilityConfig{
+					Networks: []*dns.ManagedZonePrivateVisibilityConfigNetwork{{NetworkUrl: otherNetwork}, {NetworkUrl: network}},
+				},
+			},
+		},
+		{
+			name: "network already blocked",
+			existing: &dns.ManagedZone{
+				Name:    "sra-block-3322-org-cda9dc4c",
+				DnsName: "3322.org.",
+				PrivateVisibilityConfig: &dns.ManagedZonePrivateVisibilityConfig{
+					Networks: []*dns.ManagedZonePrivateVisibilityConfigNetwork{{NetworkUrl: network}},
+				},
+			},
+		},
+		{
+			name: "zone for another domain",
+			existing: &dns.ManagedZone{
+				Name:    "sra-block-3322-org-cda9dc4c",
+				DnsName: "3322-org.",
+			},
+			expectedError: true,
+		},
+		{
+			name:   "dry run",
+			dryRun: true,
+		},
+	}
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			svcs, dnsStub := setupBlockDomain()
+			dnsStub.StubbedManagedZone = tt.existing
+			values := &Values{
+				ProjectID: "project-id",
+				Instance:  "instance-name",
+				Zone:      "us-central1-a",
+				Domains:   []string{"3322.org"},
+				DryRun:    tt.dryRun,
+			}
+			if err := Execute(ctx, values, svcs); (err != nil) != tt.expectedError {
+				t.Fatalf("%s failed to block domain: %q", tt.name, err)
+			}
+			if diff := cmp.Diff(tt.expectedSaved, dnsStub.SavedManagedZone); diff != "" {
+				t.Errorf("%v failed, created zone difference: %+v", tt.name, diff)
+			}
+			if diff := cmp.Diff(tt.expectedPatch, dnsStub.PatchedManagedZone); diff != "" {
+				t.Errorf("%v failed, patched zone difference: %+v", tt.name, diff)
+			}
+		})
+	}
+}
+
+func setupBlockDomain() (*Services, *stubs.DNSStub) {
+	loggerStub := &stubs.LoggerStub{}
+	computeStub := &stubs.ComputeStub{
+		StubbedInstance: &compute.Instance{
+			NetworkInterfaces: []*compute.NetworkInterface{{Name: "nic0", Network: network}},
+		},
+	}
+	dnsStub := &stubs.DNSStub{}
+	return &Services{
+		DNS:    services.NewDNS(dnsStub),
+		Host:   services.NewHost(computeStub),
+		Logger: services.NewLogger(loggerStub),
+	}, dnsStub
+}
@@ -0,0 +1,406 @@
+package filter
+
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/googlecloudplatform/security-response-automation/clients"
+	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/filter/internal/storage"
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/bundle"
+	"github.com/open-policy-agent/opa/rego"
+	"github.com/pkg/errors"
+)
+
+// embeddedRevision is the revision of the policies generated into the function.
+const embeddedRevision = "embedded"
+
+// Bundle is a set of Rego filters compiled together.
+type Bundle struct {
+	// Revision identifies the policies the bundle was compiled from.
+	Revision string
+	// Modules holds the policies' sources keyed by file name.
+	Modules map[string][]byte
+	// filters are the names of the filters, the file names without their extension, in lexical
+	// order.
+	filters      []string
+	suppressions []Suppression
+	query        rego.PreparedEvalQuery
+}
+
+// Compile compiles the policies, keyed by file name, into a bundle. The policies are compiled
+// and the query evaluating them prepared once, so matching a finding only evaluates the query.
+// The SuppressionsFile, if any, is parsed along with the policies.
+func Compile(revision string, modules map[string][]byte) (*Bundle, error) {
+	sources := make(map[string]string, len(modules))
+	filters := make([]string, 0, len(modules))
+	var suppressions []Suppression
+	for filename, content := range modules {
+		if path.Base(filename) == SuppressionsFile {
+			s, err := parseSuppressions(content)
+			if err != nil {
+				return nil, err
+			}
+			suppressions = append(suppressions, s...)
+			continue
+		}
+		sources[filename] = string(content)
+		filters = append(filters, strings.Split(filename, ".")[0])
+	}
+	sort.Strings(filters)
+	for _, s := range suppressions {
+		if i := sort.SearchStrings(filters, s.Name); i < len(filters) && filters[i] == s.Name {
+			return nil, fmt.Errorf("%s: suppression %q has the name of a filter", SuppressionsFile, s.Name)
+		}
+	}
+	compiler, err := ast.CompileModules(sources)
+	if err != nil {
+		return nil, err
+	}
+	// Filters are rules of the sra.filter package named after their file, for a rego file with
+	// the name myrule.rego this would expect the content to look like this:
+	// package sra.filter
+	// myrule {...}
+	query, err := rego.New(
+		rego.Query(queryStringPrefix),
+		rego.Compiler(compiler)).PrepareForEval(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return &Bundle{Revision: revision, Modules: modules, filters: filters, suppressions: suppressions, query: query}, nil
+}
+
+var (
+	embeddedOnce sync.Once
+	embedded     *Bundle
+	embeddedErr  error
+)
+
+// Filters returns the names of the bundle's filters and suppressions, in lexical order.
+func (b *Bundle) Filters() []string {
+	names := append([]string(nil), b.filters...)
+	for _, s := range b.suppressions {
+		names = append(names, s.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Embedded returns the policies generated into the function, compiled once per process.
+func Embedded() (*Bundle, error) {
+	embeddedOnce.Do(func() {
+		embedded, embeddedErr = Compile(embeddedRevision, storage.FileStore)
+	})
+	return embedded, embeddedErr
+}
+
+// BundleSource fetches the filter policies at runtime.
+type BundleSource interface {
+	// Fetch returns the policies keyed by file name along with their revision. If the policies
+	// are still at the known revision Fetch may return nil policies and the known revision.
+	Fetch(ctx context.Context, known string) (map[string][]byte, string, error)
+}
+
+// bundlePollInterval is how long a loaded bundle is used before the source's revision is checked
+// again.
+const bundlePollInterval = 30 * time.Second
+
+// BundleLoader loads the filter policies from a BundleSource.
+//
+// The source's revision is checked at most once per poll interval and the compiled policies are
+// only fetched and compiled again when it changes, so suppressions take effect within the
+// interval without redeploying the function. Between polls the bundle is returned without
+// reaching the source or taking the lock. If the policies cannot be fetched or fail to compile
+// the last good bundle is used, or the policies generated into the function if none was loaded
+// yet.
+//
+// A nil *BundleLoader is valid and always returns the embedded policies.
+type BundleLoader struct {
+	source   BundleSource
+	interval time.Duration
+
+	// polled holds the polledBundle returned until the next poll.
+	polled atomic.Value
+
+	mu     sync.Mutex
+	bundle *Bundle
+	// seen is the last revision fetched, which may have been rejected.
+	seen string
+}
+
+// polledBundle is the bundle returned by the last poll of the source along with when the source
+// is polled again.
+type polledBundle struct {
+	bundle *Bundle
+	next   time.Time
+}
+
+// NewBundleLoader returns a loader fetching the policies from the source.
+func NewBundleLoader(source BundleSource) *BundleLoader {
+	return &BundleLoader{source: source, interval: bundlePollInterval}
+}
+
+// InitBundleLoader creates and initializes a new loader fetching the policies from the location,
+// either a Cloud Storage prefix such as gs://bucket/filters/ or the URL of an OPA bundle. The
+// token, if set, is sent as a bearer token to the bundle server.
+func InitBundleLoader(ctx context.Context, location, token string) (*BundleLoader, error) {
+	if !strings.HasPrefix(location, "gs://") {
+		return NewBundleLoader(NewBundleServerSource(&http.Client{Timeout: 30 * time.Second}, location, token)), nil
+	}
+	stg, err := clients.NewStorage(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize storage client: %q", err)
+	}
+	parts := strings.SplitN(strings.TrimPrefix(location, "gs://"), "/", 2)
+	prefix := ""
+	if len(parts) == 2 {
+		prefix = parts[1]
+	}
+	return NewBundleLoader(NewStorageSource(stg, parts[0], prefix)), nil
+}
+
+// Load returns the current bundle.
+func (l *BundleLoader) Load(ctx context.Context) (*Bundle, error) {
+	if l == nil {
+		return Embedded()
+	}
+	if b := l.cached(); b != nil {
+		return b, nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	// Another finding may have polled the source while this one waited for the lock.
+	if b := l.cached(); b != nil {
+		return b, nil
+	}
+	b, err := l.poll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	l.polled.Store(polledBundle{bundle: b, next: time.Now().Add(l.interval)})
+	return b, nil
+}
+
+// cached returns the bundle of the last poll, or nil if the source is due to be polled.
+func (l *BundleLoader) cached() *Bundle {
+	p, ok := l.polled.Load().(polledBundle)
+	if !ok || !time.Now().Before(p.next) {
+		return nil
+	}
+	return p.bundle
+}
+
+// poll returns the bundle at the source's current revision, falling back to the last good bundle
+// or the embedded policies.
+func (l *BundleLoader) poll(ctx context.Context) (*Bundle, error) {
+	b, err := l.fetch(ctx)
+	switch {
+	case err == nil:
+		if l.bundle == nil || b.Revision != l.bundle.Revision {
+			log.Printf("loaded %d filters at revision %q", len(b.Modules), b.Revision)
+		}
+		l.bundle = b
+		return b, nil
+	case l.bundle != nil:
+		log.Printf("using filters at revision %q: %q", l.bundle.Revision, err)
+		return l.bundle, nil
+	default:
+		log.Printf("using embedded filters: %q", err)
+		return Embedded()
+	}
+}
+
+// fetch returns the bundle at the source's current revision. Policies are only fetched and
+// compiled if their revision differs from the last one seen.
+func (l *BundleLoader) fetch(ctx context.Context) (*Bundle, error) {
+	modules, revision, err := l.source.Fetch(ctx, l.seen)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to fetch filters")
+	}
+	if modules == nil && revision == l.seen {
+		if l.bundle != nil && l.bundle.Revision == revision {
+			return l.bundle, nil
+		}
+		return nil, fmt.Errorf("filters at revision %q were previously rejected", revision)
+	}
+	l.seen = revision
+	b, err := Compile(revision, modules)
+	if err != nil {
+		return nil, errors.Wrapf(err, "filters at revision %q", revision)
+	}
+	return b, nil
+}
+
+// StorageClient contains minimum interface required to fetch the policies from Cloud Storage.
+type StorageClient interface {
+	ObjectGenerations(ctx context.Context, bucketName, prefix string) (map[string]int64, error)
+	ReadObjectGeneration(ctx context.Context, bucketName, name string, generation int64) ([]byte, error)
+}
+
+// StorageSource fetches the policies stored under a Cloud Storage prefix. The revision is derived
+// from the objects' generations so adding, updating or removing a policy changes it.
+type StorageSource struct {
+	client StorageClient
+	bucket string
+	prefix string
+}
+
+// NewStorageSource returns a source fetching the policies under the prefix of the bucket. The
+// prefix is a directory, so filters and filters/ both fetch the objects under filters/.
+func NewStorageSource(client StorageClient, bucket, prefix string) *StorageSource {
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return &StorageSource{client: client, bucket: bucket, prefix: prefix}
+}
+
+// Fetch returns the policies under the prefix keyed by their name relative to the prefix.
+// Objects other than .rego files, Rego tests and objects in nested directories are skipped.
+// Finding no policies is an error so a mistyped prefix does not disable filtering.
+func (s *StorageSource) Fetch(ctx context.Context, known string) (map[string][]byte, string, error) {
+	generations, err := s.client.ObjectGenerations(ctx, s.bucket, s.prefix)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "failed to list gs://%s/%s", s.bucket, s.prefix)
+	}
+	var names, nested []string
+	for name := range generations {
+		switch rel := strings.TrimPrefix(name, s.prefix); {
+		case !isPolicy(rel):
+		case strings.Contains(rel, "/"):
+			nested = append(nested, name)
+		default:
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil, "", fmt.Errorf("no filters under gs://%s/%s", s.bucket, s.prefix)
+	}
+	sort.Strings(names)
+	h := sha256.New()
+	for _, name := range names {
+		fmt.Fprintf(h, "%s#%d\n", name, generations[name])
+	}
+	revision := fmt.Sprintf("%x", h.Sum(nil))[:12]
+	if revision == known {
+		return nil, known, nil
+	}
+	sort.Strings(nested)
+	for _, name := range nested {
+		log.Printf("skipping gs://%s/%s, filters must be directly under gs://%s/%s", s.bucket, name, s.bucket, s.prefix)
+	}
+	modules := make(map[string][]byte, len(names))
+	for _, name := range names {
+		b, err := s.client.ReadObjectGeneration(ctx, s.bucket, name, generations[name])
+		if err != nil {
+			return nil, "", errors.Wrapf(err, "failed to read gs://%s/%s", s.bucket, name)
+		}
+		modules[strings.TrimPrefix(name, s.prefix)] = b
+	}
+	return modules, revision, nil
+}
+
+// BundleServerSource fetches the policies from an OPA bundle served over HTTP. The revision is
+// the bundle manifest's revision, or its ETag if the manifest has none.
+type BundleServerSource struct {
+	client *http.Client
+	url    string
+	token  string
+
+	mu sync.Mutex
+	// etag and revision are those of the last bundle fetched, so it is only downloaded again
+	// once the server has a new one.
+	etag     string
+	revision string
+}
+
+// NewBundleServerSource returns a source fetching the bundle at the URL.
+func NewBundleServerSource(client *http.Client, url, token string) *BundleServerSource {
+	return &BundleServerSource{client: client, url: url, token: token}
+}
+
+// Fetch returns the policies of the bundle keyed by their file name. Rego tests are skipped.
+func (s *BundleServerSource) Fetch(ctx context.Context, known string) (map[string][]byte, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	req, err := http.NewRequest(http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	req = req.WithContext(ctx)
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+	if s.etag != "" && s.revision == known {
+		req.Header.Set("If-None-Match", s.etag)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "failed to get %s", s.url)
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return nil, known, nil
+	case http.StatusOK:
+	default:
+		return nil, "", fmt.Errorf("failed to get %s: %s", s.url, resp.Status)
+	}
+	b, err := bundle.NewCustomReader(bundle.NewTarballLoader(resp.Body)).Read()
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "failed to read bundle %s", s.url)
+	}
+	revision := b.Manifest.Revision
+	if revision == "" {
+		revision = resp.Header.Get("ETag")
+	}
+	s.etag, s.revision = resp.Header.Get("ETag"), revision
+	if revision != "" && revision == known {
+		return nil, known, nil
+	}
+	modules := make(map[string][]byte)
+	for _, m := range b.Modules {
+		if isPolicy(m.Path) {
+			modules[path.Base(m.Path)] = m.Raw
+		}
+	}
+	// Bundles only carry policies and data, so suppressions are listed in the data document
+	// at suppressions, such as /suppressions/data.json. JSON is valid YAML.
+	if v, ok := b.Data["suppressions"]; ok {
+		if modules[SuppressionsFile], err = json.Marshal(v); err != nil {
+			return nil, "", err
+		}
+	}
+	return modules, revision, nil
+}
+
+// isPolicy returns if the file holds filters, rather than their tests, or suppressions.
+func isPolicy(name string) bool {
+	if path.Base(name) == SuppressionsFile {
+		return true
+	}
+	return strings.HasSuffix(name, ".rego") && !strings.HasSuffix(name, "_test.rego")
+}
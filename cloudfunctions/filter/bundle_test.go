@@ -0,0 +1,225 @@
+package filter
+
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/google/go-cmp/cmp"
+	"github.com/googlecloudplatform/security-response-automation/clients/fakes"
+	"github.com/open-policy-agent/opa/bundle"
+)
+
+const (
+	activeFinding = `{"finding": {"category": "Malware: Bad IP", "state": "ACTIVE"}}`
+	malwarePolicy = `package sra.filter
+malware {
+	startswith(input.finding.category, "Malware")
+}`
+	activePolicy = `package sra.filter
+active {
+	input.finding.state == "ACTIVE"
+}`
+)
+
+func TestBundleLoader(t *testing.T) {
+	ctx := context.Background()
+	stg := fakes.NewStorage()
+	stg.AddBucket(&storage.BucketAttrs{Name: "filters-bucket"}, nil)
+	loader := NewBundleLoader(NewStorageSource(stg, "filters-bucket", "filters/"))
+	// Poll on every load to see each change.
+	loader.interval = 0
+
+	load := func() *Bundle {
+		b, err := loader.Load(ctx)
+		if err != nil {
+			t.Fatalf("Load() failed: %q", err)
+		}
+		return b
+	}
+	matches := func() string {
+		got, err := load().Matches(ctx, []byte(activeFinding))
+		if err != nil {
+			t.Fatalf("Matches() failed: %q", err)
+		}
+		return strings.Join(got, ",")
+	}
+	write := func(name, policy string) {
+		if err := stg.WriteObject(ctx, "filters-bucket", name, []byte(policy)); err != nil {
+			t.Fatalf("WriteObject() failed: %q", err)
+		}
+	}
+
+	if got := load().Revision; got != embeddedRevision {
+		t.Errorf("no filters: got revision %q want the embedded filters", got)
+	}
+	write("filters/malware.rego", malwarePolicy)
+	write("filters/malware_test.rego", "not rego")
+	write("README.md", "not under the prefix")
+	if got := matches(); got != "malware" {
+		t.Errorf("first revision: got matches %q want malware", got)
+	}
+	first := load()
+	if second := load(); second != first {
+		t.Errorf("unchanged revision: got a new bundle at revision %q", second.Revision)
+	}
+	write("filters/active.rego", activePolicy)
+	if got := matches(); got != "active,malware" {
+		t.Errorf("added filter: got matches %q want active,malware", got)
+	}
+	write("filters/active.rego", "package sra.filter\nactive {")
+	if got := matches(); got != "active,malware" {
+		t.Errorf("invalid filter: got matches %q want last good active,malware", got)
+	}
+	if err := stg.DeleteObject(ctx, "filters-bucket", "filters/active.rego"); err != nil {
+		t.Fatalf("DeleteObject() failed: %q", err)
+	}
+	if got := matches(); got != "malware" {
+		t.Errorf("removed filter: got matches %q want malware", got)
+	}
+}
+
+// countingSource counts the fetches of the policies it serves.
+type countingSource struct {
+	staticSource
+	fetches int32
+}
+
+func (s *countingSource) Fetch(ctx context.Context, known string) (map[string][]byte, string, error) {
+	atomic.AddInt32(&s.fetches, 1)
+	return s.staticSource.Fetch(ctx, known)
+}
+
+func TestBundleLoaderPollInterval(t *testing.T) {
+	ctx := context.Background()
+	source := &countingSource{staticSource: staticSource{"malware.rego": []byte(malwarePolicy)}}
+	loader := NewBundleLoader(source)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if b, err := loader.Load(ctx); err != nil || b.Revision != "static" {
+				t.Errorf("Load() = %v, %v want the static revision", b, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if source.fetches != 1 {
+		t.Errorf("got %d fetches within the poll interval, want 1", source.fetches)
+	}
+	loader.polled.Store(polledBundle{next: time.Now().Add(-time.Second)})
+	if _, err := loader.Load(ctx); err != nil {
+		t.Fatalf("Load() failed: %q", err)
+	}
+	if source.fetches != 2 {
+		t.Errorf("got %d fetches after the poll interval, want 2", source.fetches)
+	}
+}
+
+func TestStorageSourcePrefix(t *testing.T) {
+	ctx := context.Background()
+	stg := fakes.NewStorage()
+	stg.AddBucket(&storage.BucketAttrs{Name: "filters-bucket"}, nil)
+	for name, policy := range map[string]string{
+		"filters/malware.rego":     malwarePolicy,
+		"filters/team/active.rego": activePolicy,
+		"filters-old/active.rego":  activePolicy,
+	} {
+		if err := stg.WriteObject(ctx, "filters-bucket", name, []byte(policy)); err != nil {
+			t.Fatalf("WriteObject() failed: %q", err)
+		}
+	}
+	for _, prefix := range []string{"filters", "filters/"} {
+		modules, _, err := NewStorageSource(stg, "filters-bucket", prefix).Fetch(ctx, "")
+		if err != nil {
+			t.Fatalf("Fetch() with prefix %q failed: %q", prefix, err)
+		}
+		var names []string
+		for name := range modules {
+			names = append(names, name)
+		}
+		if diff := cmp.Diff([]string{"malware.rego"}, names); diff != "" {
+			t.Errorf("Fetch() with prefix %q mismatch (-want +got):\n%s", prefix, diff)
+		}
+	}
+}
+
+func TestBundleServerSource(t *testing.T) {
+	ctx := context.Background()
+	var buf bytes.Buffer
+	if err := bundle.Write(&buf, bundle.Bundle{
+		Manifest: bundle.Manifest{Revision: "rev-1"},
+		Data: map[string]interface{}{
+			"suppressions": []interface{}{
+				map[string]interface{}{"name": "incident", "category": "Malware: Bad IP", "expires": "2999-01-01T00:00:00Z"},
+			},
+		},
+		Modules: []bundle.ModuleFile{
+			{Path: "/sra/filter/malware.rego", Raw: []byte(malwarePolicy)},
+			{Path: "/sra/filter/malware_test.rego", Raw: []byte("package sra.filter\ntest_malware { true }")},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	var requests, downloads int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Header.Get("If-None-Match") == `"abc"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		downloads++
+		w.Header().Set("ETag", `"abc"`)
+		w.Write(buf.Bytes())
+	}))
+	defer srv.Close()
+	loader := NewBundleLoader(NewBundleServerSource(srv.Client(), srv.URL, "secret"))
+	loader.interval = 0
+
+	for i := 0; i < 2; i++ {
+		b, err := loader.Load(ctx)
+		if err != nil {
+			t.Fatalf("Load() failed: %q", err)
+		}
+		if b.Revision != "rev-1" {
+			t.Errorf("got revision %q want rev-1", b.Revision)
+		}
+		got, err := b.Matches(ctx, []byte(activeFinding))
+		if err != nil {
+			t.Fatalf("Matches() failed: %q", err)
+		}
+		if strings.Join(got, ",") != "incident,malware" {
+			t.Errorf("got matches %q want incident,malware", got)
+		}
+	}
+	if requests != 2 || downloads != 1 {
+		t.Errorf("got %d requests and %d downloads, want 2 requests and 1 download", requests, downloads)
+	}
+}
@@ -0,0 +1,237 @@
+package filter
+
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/open-policy-agent/opa/rego"
+)
+
+// Actions a filter's decision may take on a finding.
+const (
+	// Suppress marks the finding with the filter's name and sets it inactive instead of routing it.
+	Suppress = "suppress"
+	// Forward routes the finding as if no filter matched.
+	Forward = "forward"
+	// Tag adds the decision's security marks to the finding and routes it.
+	Tag = "tag"
+	// Route adds the decision's security marks to the finding and sends it to the decision's
+	// topic instead of the router.
+	Route = "route"
+)
+
+// Security marks the filter adds to suppressed findings.
+const (
+	// FilterMark holds the name of the filter that suppressed the finding.
+	FilterMark = "sra-filter"
+	// ReasonMark holds why the finding was suppressed, if the decision said.
+	ReasonMark = "sra-filter-reason"
+	// ExpiresMark holds when the suppression expires, in RFC 3339 format.
+	ExpiresMark = "sra-filter-expires"
+)
+
+// precedence ranks the actions, lowest first, so the decision applied to a finding several
+// filters match does not depend on the filters' names: suppressing wins over routing, routing
+// over tagging and tagging over forwarding.
+var precedence = map[string]int{Suppress: 0, Route: 1, Tag: 2, Forward: 3}
+
+// now returns the current time, replaced in tests.
+var now = time.Now
+
+// severities are the severities a decision may override the finding's with.
+var severities = map[string]bool{"CRITICAL": true, "HIGH": true, "MEDIUM": true, "LOW": true}
+
+// Decision is what a filter decided to do with a finding. A filter rule evaluating to true
+// suppresses the finding. A rule evaluating to an object decides what to do with it:
+//
+//	package sra.filter
+//
+//	dev_folder = {"action": "route", "topic": "threat-findings-notify", "reason": "dev folder"} {
+//		startswith(input.finding.resourceName, "//cloudresourcemanager.googleapis.com/projects/dev-")
+//	}
+type Decision struct {
+	// Filter is the name of the filter that decided.
+	Filter string `json:"-"`
+	// Action is one of suppress, forward, tag or route, suppress if empty.
+	Action string `json:"action"`
+	// Reason explains the decision. It is added to the finding's security marks as
+	// sra-filter-reason.
+	Reason string `json:"reason"`
+	// Marks are additional security marks added to the finding.
+	Marks map[string]string `json:"marks"`
+	// Topic is the Pub/Sub topic findings are sent to by route.
+	Topic string `json:"topic"`
+	// Severity overrides the severity of the finding sent onwards, one of CRITICAL, HIGH,
+	// MEDIUM or LOW.
+	Severity string `json:"severity"`
+	// Expires ends the suppression, either at a time such as 2020-11-01T00:00:00Z or after a
+	// duration such as 72h. The suppression does not expire if empty.
+	Expires string `json:"expires"`
+}
+
+// newDecision returns the decision of the filter given the value its rule evaluated to.
+func newDecision(filter string, value interface{}) (*Decision, error) {
+	d := &Decision{Filter: filter}
+	switch v := value.(type) {
+	case bool:
+		if !v {
+			return nil, nil
+		}
+	case map[string]interface{}:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(b, d); err != nil {
+			return nil, fmt.Errorf("filter %q: invalid decision: %q", filter, err)
+		}
+	case nil:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("filter %q: rule must be true or a decision object, got %v", filter, value)
+	}
+	if d.Action == "" {
+		d.Action = Suppress
+	}
+	d.Severity = strings.ToUpper(d.Severity)
+	if err := d.validate(); err != nil {
+		return nil, fmt.Errorf("filter %q: %v", filter, err)
+	}
+	return d, nil
+}
+
+func (d *Decision) validate() error {
+	switch d.Action {
+	case Suppress, Forward, Tag:
+		if d.Topic != "" {
+			return fmt.Errorf("topic is only used by the %s action", Route)
+		}
+	case Route:
+		if d.Topic == "" {
+			return fmt.Errorf("the %s action requires a topic", Route)
+		}
+	default:
+		return fmt.Errorf("unknown action %q, expected one of %s, %s, %s or %s", d.Action, Suppress, Forward, Tag, Route)
+	}
+	if d.Severity != "" && !severities[d.Severity] {
+		return fmt.Errorf("unknown severity %q, expected one of CRITICAL, HIGH, MEDIUM or LOW", d.Severity)
+	}
+	if d.Expires == "" {
+		return nil
+	}
+	if d.Action != Suppress {
+		return fmt.Errorf("expires is only used by the %s action", Suppress)
+	}
+	if _, err := d.expiry(time.Time{}); err != nil {
+		return err
+	}
+	return nil
+}
+
+// expiry returns when the suppression decided at t expires.
+func (d *Decision) expiry(t time.Time) (time.Time, error) {
+	if e, err := time.Parse(time.RFC3339, d.Expires); err == nil {
+		return e.UTC(), nil
+	}
+	ttl, err := time.ParseDuration(d.Expires)
+	if err != nil || ttl <= 0 {
+		return time.Time{}, fmt.Errorf("expires must be a time such as 2020-11-01T00:00:00Z or a duration such as 72h, got %q", d.Expires)
+	}
+	return t.Add(ttl).UTC(), nil
+}
+
+// marks returns the security marks the decision taken at t adds to the finding.
+func (d *Decision) marks(t time.Time) map[string]string {
+	marks := make(map[string]string, len(d.Marks)+3)
+	for k, v := range d.Marks {
+		marks[k] = v
+	}
+	if d.Action == Suppress {
+		marks[FilterMark] = d.Filter
+	}
+	if d.Reason != "" {
+		marks[ReasonMark] = d.Reason
+	}
+	if e, err := d.expiry(t); err == nil && d.Expires != "" {
+		marks[ExpiresMark] = e.Format(time.RFC3339)
+	}
+	return marks
+}
+
+// Decisions returns the decisions of the given policies, keyed by file name, on the raw finding.
+func Decisions(ctx context.Context, raw []byte, policies map[string][]byte) ([]*Decision, error) {
+	b, err := Compile("", policies)
+	if err != nil {
+		return nil, err
+	}
+	return b.Decisions(ctx, raw)
+}
+
+// Decisions returns the decisions of the bundle's filters and suppressions matching the raw
+// finding, ordered by the precedence of their action and then by name. The first is the one
+// applied. All filters are evaluated at once by the bundle's prepared query.
+// A filter deciding something invalid is logged and skipped so the others still apply.
+func (b *Bundle) Decisions(ctx context.Context, raw []byte) ([]*Decision, error) {
+	// Rego expects a string-keyed map so we can't unmarshal into a Finding yet
+	var input map[string]interface{}
+	if err := json.Unmarshal(raw, &input); err != nil {
+		return nil, err
+	}
+	rs, err := b.query.Eval(ctx, rego.EvalInput(input))
+	if err != nil {
+		return nil, err
+	}
+	// The query evaluates the whole package, so the result holds every rule defined. Only the
+	// rules named after a filter file are decisions.
+	var rules map[string]interface{}
+	if len(rs) > 0 && len(rs[0].Expressions) > 0 {
+		rules, _ = rs[0].Expressions[0].Value.(map[string]interface{})
+	}
+	var decisions []*Decision
+	for _, name := range b.filters {
+		d, err := newDecision(name, rules[name])
+		if err != nil {
+			log.Printf("skipping %v", err)
+			continue
+		}
+		if d != nil {
+			decisions = append(decisions, d)
+		}
+	}
+	var f suppressedFinding
+	if err := json.Unmarshal(raw, &f); err != nil {
+		return nil, err
+	}
+	t := now()
+	for _, s := range b.suppressions {
+		if s.matches(&f.Finding, t) {
+			decisions = append(decisions, s.decision())
+		}
+	}
+	sort.SliceStable(decisions, func(i, j int) bool {
+		if pi, pj := precedence[decisions[i].Action], precedence[decisions[j].Action]; pi != pj {
+			return pi < pj
+		}
+		return decisions[i].Filter < decisions[j].Filter
+	})
+	return decisions, nil
+}
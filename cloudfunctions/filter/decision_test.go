@@ -0,0 +1,251 @@
+package filter
+
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+	"github.com/google/go-cmp/cmp"
+	"github.com/googlecloudplatform/security-response-automation/clients/fakes"
+	"github.com/googlecloudplatform/security-response-automation/clients/stubs"
+	"github.com/googlecloudplatform/security-response-automation/services"
+	sccpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1beta1"
+)
+
+func TestDecisions(t *testing.T) {
+	ctx := context.Background()
+	const name = "organizations/1/sources/2/findings/3"
+	finding := `{"finding": {"name": "` + name + `", "category": "Malware: Bad IP", "state": "ACTIVE", "severity": "HIGH"}}`
+	os.Setenv(outputTopicEnvVar, "threat-findings-router")
+	defer os.Unsetenv(outputTopicEnvVar)
+	now = func() time.Time { return time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+	for _, tt := range []struct {
+		name         string
+		rule         string
+		wantState    sccpb.Finding_State
+		wantMarks    map[string]string
+		wantTopic    string
+		wantSeverity string
+	}{
+		{
+			name:      "true suppresses",
+			rule:      `decide { true }`,
+			wantState: sccpb.Finding_INACTIVE,
+			wantMarks: map[string]string{"sra-filter": "decide"},
+		},
+		{
+			name:      "suppress",
+			rule:      `decide = {"reason": "known scanner", "marks": {"owner": "secops"}} { true }`,
+			wantState: sccpb.Finding_INACTIVE,
+			wantMarks: map[string]string{"sra-filter": "decide", "sra-filter-reason": "known scanner", "owner": "secops"},
+		},
+		{
+			name:      "suppress for a duration",
+			rule:      `decide = {"reason": "incident 4711", "expires": "72h"} { true }`,
+			wantState: sccpb.Finding_INACTIVE,
+			wantMarks: map[string]string{"sra-filter": "decide", "sra-filter-reason": "incident 4711", "sra-filter-expires": "2020-10-04T12:00:00Z"},
+		},
+		{
+			name:      "suppress until",
+			rule:      `decide = {"expires": "2020-11-01T00:00:00+01:00"} { true }`,
+			wantState: sccpb.Finding_INACTIVE,
+			wantMarks: map[string]string{"sra-filter": "decide", "sra-filter-expires": "2020-10-31T23:00:00Z"},
+		},
+		{
+			name:         "forward",
+			rule:         `decide = {"action": "forward"} { true }`,
+			wantState:    sccpb.Finding_ACTIVE,
+			wantTopic:    "threat-findings-router",
+			wantSeverity: "HIGH",
+		},
+		{
+			name:         "tag",
+			rule:         `decide = {"action": "tag", "marks": {"env": "dev"}, "severity": "low"} { true }`,
+			wantState:    sccpb.Finding_ACTIVE,
+			wantMarks:    map[string]string{"env": "dev"},
+			wantTopic:    "threat-findings-router",
+			wantSeverity: "LOW",
+		},
+		{
+			name:         "route",
+			rule:         `decide = {"action": "route", "topic": "threat-findings-notify", "reason": "dev folder"} { true }`,
+			wantState:    sccpb.Finding_ACTIVE,
+			wantMarks:    map[string]string{"sra-filter-reason": "dev folder"},
+			wantTopic:    "threat-findings-notify",
+			wantSeverity: "HIGH",
+		},
+		{
+			name:         "no match",
+			rule:         `decide = {"action": "route", "topic": "threat-findings-notify"} { false }`,
+			wantState:    sccpb.Finding_ACTIVE,
+			wantTopic:    "threat-findings-router",
+			wantSeverity: "HIGH",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			scc := fakes.NewSecurityCommandCenter()
+			scc.AddFinding(&sccpb.Finding{Name: name, State: sccpb.Finding_ACTIVE})
+			psStub := &stubs.PubSubStub{}
+			if err := Execute(ctx, pubsub.Message{Data: []byte(finding)}, &Services{
+				PubSub:                services.NewPubSub(psStub),
+				Logger:                services.NewLogger(&stubs.LoggerStub{}),
+				SecurityCommandCenter: services.NewCommandCenter(scc),
+				Bundles:               NewBundleLoader(staticSource{"decide.rego": []byte("package sra.filter\n" + tt.rule)}),
+			}); err != nil {
+				t.Fatalf("Execute() failed: %q", err)
+			}
+			f := scc.Finding(name)
+			if f.GetState() != tt.wantState {
+				t.Errorf("state = %v, want %v", f.GetState(), tt.wantState)
+			}
+			if diff := cmp.Diff(tt.wantMarks, f.GetSecurityMarks().GetMarks()); len(tt.wantMarks) > 0 && diff != "" {
+				t.Errorf("marks (-want +got):\n%s", diff)
+			}
+			if len(tt.wantMarks) == 0 && len(f.GetSecurityMarks().GetMarks()) > 0 {
+				t.Errorf("marks = %v, want none", f.GetSecurityMarks().GetMarks())
+			}
+			if psStub.PublishedTopic != tt.wantTopic {
+				t.Errorf("published to %q, want %q", psStub.PublishedTopic, tt.wantTopic)
+			}
+			if tt.wantTopic == "" {
+				return
+			}
+			var n struct {
+				Finding struct{ Name, Severity string }
+			}
+			if err := json.Unmarshal(psStub.PublishedMessage.Data, &n); err != nil {
+				t.Fatal(err)
+			}
+			if n.Finding.Name != name || n.Finding.Severity != tt.wantSeverity {
+				t.Errorf("published finding %q with severity %q, want %q with %q", n.Finding.Name, n.Finding.Severity, name, tt.wantSeverity)
+			}
+		})
+	}
+}
+
+func TestDecisionPrecedence(t *testing.T) {
+	decisions, err := Decisions(context.Background(), []byte(`{"finding": {"state": "ACTIVE"}}`), map[string][]byte{
+		"a_forward.rego":  []byte("package sra.filter\n" + `a_forward = {"action": "forward"} { true }`),
+		"b_tag.rego":      []byte("package sra.filter\n" + `b_tag = {"action": "tag", "marks": {"env": "dev"}} { true }`),
+		"c_route.rego":    []byte("package sra.filter\n" + `c_route = {"action": "route", "topic": "threat-findings-notify"} { true }`),
+		"d_suppress.rego": []byte("package sra.filter\n" + `d_suppress { true }`),
+		"e_suppress.rego": []byte("package sra.filter\n" + `e_suppress { true }`),
+	})
+	if err != nil {
+		t.Fatalf("Decisions() failed: %q", err)
+	}
+	var got []string
+	for _, d := range decisions {
+		got = append(got, d.Filter)
+	}
+	want := []string{"d_suppress", "e_suppress", "c_route", "b_tag", "a_forward"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Decisions() order (-want +got):\n%s", diff)
+	}
+}
+
+func TestDecisionRemovesStaleMarks(t *testing.T) {
+	ctx := context.Background()
+	const name = "organizations/1/sources/2/findings/3"
+	os.Setenv(outputTopicEnvVar, "threat-findings-router")
+	defer os.Unsetenv(outputTopicEnvVar)
+	now = func() time.Time { return time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+	scc := fakes.NewSecurityCommandCenter()
+	scc.AddFinding(&sccpb.Finding{Name: name, State: sccpb.Finding_ACTIVE})
+	svcs := &Services{
+		PubSub:                services.NewPubSub(&stubs.PubSubStub{}),
+		Logger:                services.NewLogger(&stubs.LoggerStub{}),
+		SecurityCommandCenter: services.NewCommandCenter(scc),
+	}
+	decide := func(rule string) {
+		svcs.Bundles = NewBundleLoader(staticSource{"decide.rego": []byte("package sra.filter\n" + rule)})
+		b, err := json.Marshal(map[string]interface{}{"finding": map[string]interface{}{
+			"name":          name,
+			"state":         "ACTIVE",
+			"securityMarks": map[string]interface{}{"marks": scc.Finding(name).GetSecurityMarks().GetMarks()},
+		}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := Execute(ctx, pubsub.Message{Data: b}, svcs); err != nil {
+			t.Fatalf("Execute() failed: %q", err)
+		}
+	}
+
+	decide(`decide = {"reason": "incident 4711", "expires": "1h"} { true }`)
+	decide(`decide { true }`)
+	want := map[string]string{"sra-filter": "decide"}
+	if diff := cmp.Diff(want, scc.Finding(name).GetSecurityMarks().GetMarks()); diff != "" {
+		t.Errorf("marks after permanent suppression (-want +got):\n%s", diff)
+	}
+	now = func() time.Time { return time.Date(2020, 10, 2, 0, 0, 0, 0, time.UTC) }
+	if err := Sweep(ctx, "1", svcs); err != nil {
+		t.Fatalf("Sweep() failed: %q", err)
+	}
+	if got := scc.Finding(name).GetState(); got != sccpb.Finding_INACTIVE {
+		t.Errorf("state after sweep = %v, want %v", got, sccpb.Finding_INACTIVE)
+	}
+
+	decide(`decide = {"action": "route", "topic": "threat-findings-notify", "reason": "dev folder"} { true }`)
+	decide(`decide = {"action": "tag", "marks": {"env": "dev"}} { true }`)
+	want = map[string]string{"sra-filter": "decide", "env": "dev"}
+	if diff := cmp.Diff(want, scc.Finding(name).GetSecurityMarks().GetMarks()); diff != "" {
+		t.Errorf("marks after tagging (-want +got):\n%s", diff)
+	}
+}
+
+func TestInvalidDecision(t *testing.T) {
+	finding := []byte(`{"finding": {"state": "ACTIVE"}}`)
+	for _, tt := range []struct {
+		rule string
+		want string
+	}{
+		{rule: `decide = {"action": "mute"} { true }`, want: `filter "decide": unknown action "mute"`},
+		{rule: `decide = {"action": "route"} { true }`, want: `filter "decide": the route action requires a topic`},
+		{rule: `decide = {"action": "tag", "topic": "t"} { true }`, want: `filter "decide": topic is only used by the route action`},
+		{rule: `decide = {"severity": "urgent"} { true }`, want: `filter "decide": unknown severity "URGENT"`},
+		{rule: `decide = "yes" { true }`, want: `filter "decide": rule must be true or a decision object`},
+		{rule: `decide = {"expires": "soon"} { true }`, want: `filter "decide": expires must be a time`},
+		{rule: `decide = {"action": "tag", "expires": "72h"} { true }`, want: `filter "decide": expires is only used by the suppress action`},
+	} {
+		var logged bytes.Buffer
+		log.SetOutput(&logged)
+		decisions, err := Decisions(context.Background(), finding, map[string][]byte{
+			"decide.rego": []byte("package sra.filter\n" + tt.rule),
+			"valid.rego":  []byte("package sra.filter\nvalid = {\"action\": \"tag\"} { true }"),
+		})
+		log.SetOutput(os.Stderr)
+		if err != nil {
+			t.Errorf("Decisions(%s) failed: %q", tt.rule, err)
+			continue
+		}
+		if len(decisions) != 1 || decisions[0].Filter != "valid" {
+			t.Errorf("Decisions(%s) = %+v, want only the valid filter's decision", tt.rule, decisions)
+		}
+		if !strings.Contains(logged.String(), tt.want) {
+			t.Errorf("Decisions(%s) logged %q, want %q", tt.rule, logged.String(), tt.want)
+		}
+	}
+}
@@ -21,10 +21,9 @@ import (
 	"fmt"
 	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/filter/internal/storage"
 	"github.com/googlecloudplatform/security-response-automation/services"
-	"github.com/open-policy-agent/opa/ast"
-	"github.com/open-policy-agent/opa/rego"
 	"os"
-	"strings"
+	"sort"
+	"time"
 )
 
 const outputTopicEnvVar = "OUTPUT_TOPIC"
@@ -35,12 +34,18 @@ type Services struct {
 	PubSub                *services.PubSub
 	Logger                *services.Logger
 	SecurityCommandCenter *services.CommandCenter
+	// Bundles loads the filters at runtime. The filters generated into the function are used
+	// if nil.
+	Bundles *BundleLoader
 }
 
 // Without these two types, unmarshalling breaks since Finding_State
 // is serialized as a string but it's actual value is an integer enum
 type slimFinding struct {
-	Name string
+	Name          string
+	SecurityMarks struct {
+		Marks map[string]string
+	}
 }
 
 type notification struct {
@@ -48,35 +53,37 @@ type notification struct {
 }
 
 // Execute will first check the raw finding against the user-supplied Rego policies
-// then if it should be filtered, update the finding, otherwise pass it along to the
-// router cloud function.
+// then apply the decision taking precedence, see Decisions: suppress the finding, send it
+// to another topic, tag it or forward it. Findings no filter matched are
+// passed along to the router cloud function.
 func Execute(ctx context.Context, m pubsub.Message, svcs *Services) (err error) {
 	raw := m.Data
 	var msg notification
+	d := &Decision{Action: Forward}
 	if err = json.Unmarshal(raw, &msg); err != nil {
 		svcs.Logger.Info("Only SCC Notification format is supported. This message will not be filtered.")
 	} else {
-		// Iterate through rego filenames and content that are generated into code
-		// in the internal/storage package. This happens as part of the terraform
-		// apply automatically.
-		for filename, content := range storage.FileStore {
-			filterName := strings.Split(filename, ".")[0]
-			exception, err := isException(ctx, raw, content, filterName)
-			if err != nil {
-				return err
-			}
-			if exception {
-				err = updateFinding(ctx, svcs, filterName, msg.Finding.Name)
-				if err != nil {
-					svcs.Logger.Error("Failed to update finding %s", msg.Finding.Name)
-					return err
-				}
-				svcs.Logger.Info("Marked finding %s with filter %s", msg.Finding.Name, filterName)
-				return nil
-			}
+		b, err := svcs.Bundles.Load(ctx)
+		if err != nil {
+			return err
+		}
+		decisions, err := b.Decisions(ctx, raw)
+		if err != nil {
+			return err
+		}
+		if len(decisions) > 0 {
+			d = decisions[0]
+		}
+		if err := updateFinding(ctx, svcs, d, &msg.Finding, now()); err != nil {
+			svcs.Logger.Error("Failed to update finding %s", msg.Finding.Name)
+			return err
 		}
 	}
-	topic, err := publish(ctx, svcs, raw)
+	if d.Action == Suppress {
+		svcs.Logger.Info("Marked finding %s with filter %s", msg.Finding.Name, d.Filter)
+		return nil
+	}
+	topic, err := publish(ctx, svcs, d, raw)
 	if err != nil {
 		svcs.Logger.Error("Failed to publish to %s", topic)
 		return err
@@ -86,58 +93,105 @@ func Execute(ctx context.Context, m pubsub.Message, svcs *Services) (err error)
 	return nil
 }
 
-func isException(ctx context.Context, findingJSON, regoSource []byte, filterName string) (bool, error) {
-	compiler, err := ast.CompileModules(map[string]string{
-		filterName: string(regoSource),
-	})
-	if err != nil {
-		return false, err
-	}
+// Policies returns the Rego policies generated into the function, keyed by file name.
+func Policies() map[string][]byte {
+	return storage.FileStore
+}
 
-	// Rego expects a string-keyed map so we can't unmarshal into a Finding yet
-	var input map[string]interface{}
-	if err := json.Unmarshal(findingJSON, &input); err != nil {
-		return false, err
+// Matches returns the names of the given policies, keyed by file name, matching the raw
+// finding.
+func Matches(ctx context.Context, raw []byte, policies map[string][]byte) ([]string, error) {
+	b, err := Compile("", policies)
+	if err != nil {
+		return nil, err
 	}
+	return b.Matches(ctx, raw)
+}
 
-	// Contruct query based on filename without the extension. For a rego file with
-	// the name myrule.rego, this would expect the content to look like this:
-	// package sra.filter
-	// myrule {...}
-	query := fmt.Sprintf("%s.%s", queryStringPrefix, filterName)
-	r := rego.New(
-		rego.Query(query),
-		rego.Compiler(compiler),
-		rego.Input(input))
-	rs, err := r.Eval(ctx)
+// Matches returns the names of the bundle's filters matching the raw finding, in lexical order.
+func (b *Bundle) Matches(ctx context.Context, raw []byte) ([]string, error) {
+	decisions, err := b.Decisions(ctx, raw)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
-
-	// If the finding matches, return true
-	if len(rs) > 0 {
-		return true, nil
+	matches := make([]string, 0, len(decisions))
+	for _, d := range decisions {
+		matches = append(matches, d.Filter)
 	}
-	return false, nil
+	sort.Strings(matches)
+	return matches, nil
 }
 
-func publish(ctx context.Context, svcs *Services, raw []byte) (string, error) {
-	topic := os.Getenv(outputTopicEnvVar)
+// publish sends the finding to the decision's topic, or to the router unless the decision
+// routes it elsewhere, overriding its severity if the decision does.
+func publish(ctx context.Context, svcs *Services, d *Decision, raw []byte) (string, error) {
+	topic := d.Topic
+	if d.Action != Route {
+		topic = os.Getenv(outputTopicEnvVar)
+	}
 	if topic == "" {
 		return "", fmt.Errorf("%s must not be empty", outputTopicEnvVar)
 	}
+	if d.Severity != "" {
+		var err error
+		if raw, err = overrideSeverity(raw, d.Severity); err != nil {
+			return topic, err
+		}
+	}
 	if _, err := svcs.PubSub.Publish(ctx, topic, &pubsub.Message{Data: raw}); err != nil {
-		return "", err
+		return topic, err
 	}
 	return topic, nil
 }
 
-func updateFinding(ctx context.Context, svcs *Services, filterName string, findingName string) error {
-	mark := map[string]string{"sra-filter": filterName}
-	if _, err := svcs.SecurityCommandCenter.AddSecurityMarks(ctx, findingName, mark); err != nil {
-		return err
+// overrideSeverity returns the notification with the finding's severity replaced.
+func overrideSeverity(raw []byte, severity string) ([]byte, error) {
+	var n map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &n); err != nil {
+		return nil, err
+	}
+	var f map[string]interface{}
+	if err := json.Unmarshal(n["finding"], &f); err != nil {
+		return nil, err
+	}
+	f["severity"] = severity
+	b, err := json.Marshal(f)
+	if err != nil {
+		return nil, err
+	}
+	n["finding"] = b
+	return json.Marshal(n)
+}
+
+// updateFinding adds the security marks of the decision taken at t to the finding, and sets it
+// inactive if the decision suppresses it. The reason and expiry marks of an earlier decision
+// are removed if this one does not set them, so a suppression without expiry is not swept.
+func updateFinding(ctx context.Context, svcs *Services, d *Decision, f *slimFinding, t time.Time) error {
+	marks := d.marks(t)
+	var stale []string
+	for _, k := range []string{ReasonMark, ExpiresMark} {
+		if _, ok := marks[k]; ok {
+			continue
+		}
+		// Notifications may predate the finding's marks, so suppressions always remove them.
+		if _, ok := f.SecurityMarks.Marks[k]; ok || d.Action == Suppress {
+			stale = append(stale, k)
+		}
+	}
+	if len(stale) > 0 {
+		if _, err := svcs.SecurityCommandCenter.RemoveSecurityMarks(ctx, f.Name, stale); err != nil {
+			return err
+		}
+	}
+	if len(marks) > 0 {
+		if _, err := svcs.SecurityCommandCenter.AddSecurityMarks(ctx, f.Name, marks); err != nil {
+			return err
+		}
+	}
+	if d.Action != Suppress {
+		return nil
 	}
-	if _, err := svcs.SecurityCommandCenter.SetInactive(ctx, findingName); err != nil {
+	if _, err := svcs.SecurityCommandCenter.SetInactive(ctx, f.Name); err != nil {
 		return err
 	}
 	return nil
@@ -18,6 +18,10 @@ import (
 	"context"
 	"strings"
 	"testing"
+
+	"cloud.google.com/go/pubsub"
+	"github.com/googlecloudplatform/security-response-automation/clients/stubs"
+	"github.com/googlecloudplatform/security-response-automation/services"
 )
 
 type tsException struct {
@@ -32,7 +36,13 @@ func TestIsException(t *testing.T) {
 	ctx := context.Background()
 	for _, ts := range exceptionTestSuites {
 		filterName := strings.Split(ts.regoFilename, ".")[0]
-		actualResult, err := isException(ctx, ts.findingJSON, ts.regoSource, filterName)
+		var actualResult bool
+		b, err := Compile("test", map[string][]byte{ts.regoFilename: ts.regoSource})
+		if err == nil {
+			var matches []string
+			matches, err = b.Matches(ctx, ts.findingJSON)
+			actualResult = len(matches) == 1 && matches[0] == filterName
+		}
 		if err != nil {
 			if !strings.Contains(err.Error(), ts.expectedError) {
 				t.Errorf("unexpected error returned for %s: %s", ts.regoFilename, err)
@@ -44,6 +54,75 @@ func TestIsException(t *testing.T) {
 	}
 }
 
+func TestMatches(t *testing.T) {
+	finding := []byte(`{"finding": {"category": "Malware: Bad IP", "state": "ACTIVE"}}`)
+	policies := map[string][]byte{
+		"inactive.rego": []byte(`package sra.filter
+		inactive {
+			input.finding.state == "INACTIVE"
+		}`),
+		"malware.rego": []byte(`package sra.filter
+		malware {
+			startswith(input.finding.category, "Malware")
+		}`),
+		"helper.rego": []byte(`package sra.filter
+		malware_category {
+			startswith(input.finding.category, "Malware")
+		}
+		helper {
+			malware_category
+			input.finding.state == "INACTIVE"
+		}`),
+		"active.rego": []byte(`package sra.filter
+		active {
+			input.finding.state == "ACTIVE"
+		}`),
+	}
+	got, err := Matches(context.Background(), finding, policies)
+	if err != nil {
+		t.Fatalf("Matches() failed: %q", err)
+	}
+	if want := []string{"active", "malware"}; strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Matches() = %q, want %q", got, want)
+	}
+}
+
+// staticSource serves the same policies at a single revision.
+type staticSource map[string][]byte
+
+func (s staticSource) Fetch(ctx context.Context, known string) (map[string][]byte, string, error) {
+	return s, "static", nil
+}
+
+func TestExecute(t *testing.T) {
+	ctx := context.Background()
+	const finding = `{"finding": {"name": "organizations/1/sources/2/findings/3", "category": "Malware: Bad IP", "state": "ACTIVE"}}`
+	loader := NewBundleLoader(staticSource{
+		"malware.rego": []byte(malwarePolicy),
+		"active.rego":  []byte(activePolicy),
+		"zactive.rego": []byte(strings.Replace(activePolicy, "active", "zactive", 1)),
+	})
+	// Every filter matches, the first in lexical order must always win.
+	for i := 0; i < 10; i++ {
+		sccStub := &stubs.SecurityCommandCenterStub{}
+		psStub := &stubs.PubSubStub{}
+		if err := Execute(ctx, pubsub.Message{Data: []byte(finding)}, &Services{
+			PubSub:                services.NewPubSub(psStub),
+			Logger:                services.NewLogger(&stubs.LoggerStub{}),
+			SecurityCommandCenter: services.NewCommandCenter(sccStub),
+			Bundles:               loader,
+		}); err != nil {
+			t.Fatalf("Execute() failed: %q", err)
+		}
+		if got := sccStub.GetUpdateSecurityMarksRequest.GetSecurityMarks().GetMarks()["sra-filter"]; got != "active" {
+			t.Fatalf("Execute() marked the finding with filter %q, want active", got)
+		}
+		if psStub.PublishedMessage != nil {
+			t.Errorf("Execute() forwarded a filtered finding")
+		}
+	}
+}
+
 var exceptionTestSuites = []tsException{
 	tsException{
 		expectedResult: true,
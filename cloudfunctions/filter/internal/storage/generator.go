@@ -17,6 +17,8 @@ import (
 const (
 	blobFileName string = "blob.go"
 	filtersDir   string = "../../../../config/filters"
+	// suppressionsFile lists expiring suppressions, see filter.SuppressionsFile.
+	suppressionsFile string = "suppressions.yaml"
 )
 
 var conv = map[string]interface{}{"conv": fmtByteSlice}
@@ -90,8 +92,8 @@ func readConfig() map[string][]byte {
 				log.Printf("skipping rego test file %s", relativePath)
 				return nil
 			}
-			if strings.HasSuffix(relativePath, ".rego") {
-				log.Printf("embedding rego file %s", relativePath)
+			if strings.HasSuffix(relativePath, ".rego") || filepath.Base(relativePath) == suppressionsFile {
+				log.Printf("embedding filter file %s", relativePath)
 				content, err := ioutil.ReadFile(path)
 				if err != nil {
 					log.Printf("error reading %s: %s", path, err)
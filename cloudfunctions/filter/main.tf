@@ -28,13 +28,76 @@ resource "google_cloudfunctions_function" "filter" {
   event_trigger {
     event_type = "google.pubsub.topic.publish"
     resource   = var.setup.findings-topic-id
+    failure_policy {
+      retry = true
+    }
   }
   environment_variables = {
-    OUTPUT_TOPIC = var.setup.router-topic-name
-    GCP_PROJECT  = var.setup.automation-project
+    OUTPUT_TOPIC        = var.setup.router-topic-name
+    GCP_PROJECT         = var.setup.automation-project
+    DEAD_LETTER_TOPIC   = var.setup.dead-letter-topic
+    FILTER_BUNDLE       = var.filter-bundle
+    FILTER_BUNDLE_TOKEN = var.filter-bundle-token
   }
 }
 
+# Required to read the filters from Cloud Storage, if the bundle is a gs:// prefix.
+resource "google_storage_bucket_iam_member" "filter-bundle-reader" {
+  count  = length(regexall("^gs://", var.filter-bundle)) > 0 ? 1 : 0
+  bucket = regex("^gs://([^/]+)", var.filter-bundle)[0]
+  role   = "roles/storage.objectViewer"
+  member = "serviceAccount:${var.setup.automation-service-account}"
+}
+
+resource "google_cloudfunctions_function" "sweep-suppressions" {
+  name                  = "SweepSuppressions"
+  description           = "Reactivates findings whose suppression expired and sends them to the router."
+  runtime               = "go113"
+  available_memory_mb   = 128
+  source_archive_bucket = var.setup.gcf-bucket-name
+  source_archive_object = var.setup.gcf-object-name
+  timeout               = 120
+  project               = var.setup.automation-project
+  region                = var.setup.region
+  entry_point           = "SweepSuppressions"
+  service_account_email = var.setup.automation-service-account
+
+  event_trigger {
+    event_type = "google.pubsub.topic.publish"
+    resource   = "threat-findings-filter-sweep"
+  }
+  environment_variables = {
+    OUTPUT_TOPIC    = var.setup.router-topic-name
+    GCP_PROJECT     = var.setup.automation-project
+    ORGANIZATION_ID = var.setup.organization-id
+  }
+}
+
+# PubSub topic triggering the sweep of expired suppressions every fifteen minutes.
+resource "google_pubsub_topic" "sweep-topic" {
+  name    = "threat-findings-filter-sweep"
+  project = var.setup.automation-project
+}
+
+resource "google_cloud_scheduler_job" "sweep-suppressions" {
+  name     = "sra-filter-sweep"
+  project  = var.setup.automation-project
+  region   = var.setup.region
+  schedule = "*/15 * * * *"
+
+  pubsub_target {
+    topic_name = google_pubsub_topic.sweep-topic.id
+    data       = base64encode("{}")
+  }
+}
+
+# Required to list the suppressed findings of the organization.
+resource "google_organization_iam_member" "filter-findings-viewer" {
+  role   = "roles/securitycenter.findingsViewer"
+  org_id = var.setup.organization-id
+  member = "serviceAccount:${var.setup.automation-service-account}"
+}
+
 resource "google_project_iam_member" "filter-pubsub-writer" {
   role    = "roles/pubsub.editor"
   project = var.setup.automation-project
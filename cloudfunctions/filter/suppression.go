@@ -0,0 +1,185 @@
+package filter
+
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+	"github.com/pkg/errors"
+	sccpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1beta1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// SuppressionsFile is the file listing suppressions alongside the filters.
+const SuppressionsFile = "suppressions.yaml"
+
+// sweepFilter narrows the findings listed by Sweep to those that may have expired.
+const sweepFilter = `state="INACTIVE" AND security_marks.marks.` + ExpiresMark + `:""`
+
+// Suppression suppresses the findings matching all of its set fields until it expires, without
+// writing a filter. Suppressions are listed in the SuppressionsFile, see
+// config/filters/suppressions.yaml.sample.
+type Suppression struct {
+	// Name identifies the suppression like a filter's name.
+	Name string
+	// Finding is the name of the finding such as organizations/123/sources/456/findings/789.
+	Finding string
+	// Category is the category of the findings such as PUBLIC_BUCKET_ACL.
+	Category string
+	// Resource is the name of the findings' resource such as //storage.googleapis.com/bucket.
+	Resource string
+	Reason   string
+	// Expires ends the suppression at a time such as 2020-11-01T00:00:00Z. Suppressions listed
+	// must expire.
+	Expires string
+}
+
+// suppressedFinding holds the fields of a notification suppressions match on.
+type suppressedFinding struct {
+	Finding findingFields
+}
+
+type findingFields struct {
+	Name         string
+	Category     string
+	ResourceName string
+}
+
+// parseSuppressions returns the suppressions listed in the file.
+func parseSuppressions(b []byte) ([]Suppression, error) {
+	var suppressions []Suppression
+	if err := yaml.UnmarshalStrict(b, &suppressions); err != nil {
+		return nil, fmt.Errorf("%s: %v", SuppressionsFile, err)
+	}
+	names := make(map[string]bool)
+	for i, s := range suppressions {
+		if err := s.validate(); err != nil {
+			return nil, fmt.Errorf("%s: suppressions[%d]: %v", SuppressionsFile, i, err)
+		}
+		if names[s.Name] {
+			return nil, fmt.Errorf("%s: suppressions[%d]: duplicate name %q", SuppressionsFile, i, s.Name)
+		}
+		names[s.Name] = true
+	}
+	return suppressions, nil
+}
+
+func (s *Suppression) validate() error {
+	if s.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if s.Finding == "" && s.Category == "" && s.Resource == "" {
+		return fmt.Errorf("at least one of finding, category or resource is required")
+	}
+	if _, err := time.Parse(time.RFC3339, s.Expires); err != nil {
+		return fmt.Errorf("expires must be a time such as 2020-11-01T00:00:00Z, got %q", s.Expires)
+	}
+	return nil
+}
+
+// matches returns if the suppression applies to the finding at t.
+func (s *Suppression) matches(f *findingFields, t time.Time) bool {
+	expires, _ := time.Parse(time.RFC3339, s.Expires)
+	return t.Before(expires) &&
+		(s.Finding == "" || s.Finding == f.Name) &&
+		(s.Category == "" || s.Category == f.Category) &&
+		(s.Resource == "" || s.Resource == f.ResourceName)
+}
+
+// decision returns the decision to suppress the findings the suppression matches.
+func (s *Suppression) decision() *Decision {
+	return &Decision{Filter: s.Name, Action: Suppress, Reason: s.Reason, Expires: s.Expires}
+}
+
+// Sweep reactivates the findings of the organization whose suppression expired and sends them
+// through the router again, as if no filter had matched them. The finding is sent before it is
+// reactivated and its suppression marks are removed last, so a failed finding is retried by the
+// next sweep. A failed finding does not stop the others from being reactivated.
+func Sweep(ctx context.Context, organizationID string, svcs *Services) error {
+	if organizationID == "" {
+		return fmt.Errorf("organization ID must not be empty")
+	}
+	topic := os.Getenv(outputTopicEnvVar)
+	if topic == "" {
+		return fmt.Errorf("%s must not be empty", outputTopicEnvVar)
+	}
+	findings, err := svcs.SecurityCommandCenter.Findings(ctx, "organizations/"+organizationID+"/sources/-", sweepFilter)
+	if err != nil {
+		return errors.Wrap(err, "failed to list suppressed findings")
+	}
+	t := now()
+	failed := 0
+	for _, f := range findings {
+		marks := f.GetSecurityMarks().GetMarks()
+		expires, ok := marks[ExpiresMark]
+		if !ok || f.GetState() != sccpb.Finding_INACTIVE {
+			continue
+		}
+		e, err := time.Parse(time.RFC3339, expires)
+		if err != nil {
+			svcs.Logger.Error("Finding %s has an invalid %s mark %q", f.GetName(), ExpiresMark, expires)
+			continue
+		}
+		if t.Before(e) {
+			continue
+		}
+		if err := reactivate(ctx, svcs, f, topic); err != nil {
+			svcs.Logger.Error("failed to reactivate finding %s: %q", f.GetName(), err)
+			failed++
+			continue
+		}
+		svcs.Logger.Info("Reactivated finding %s, suppressed by filter %s until %s", f.GetName(), marks[FilterMark], expires)
+	}
+	if failed > 0 {
+		return errors.Errorf("failed to reactivate %d findings", failed)
+	}
+	return nil
+}
+
+// reactivate sends the finding to the topic, sets it active and removes its suppression marks.
+func reactivate(ctx context.Context, svcs *Services, f *sccpb.Finding, topic string) error {
+	active := proto.Clone(f).(*sccpb.Finding)
+	active.State = sccpb.Finding_ACTIVE
+	if m := active.GetSecurityMarks(); m != nil {
+		for _, k := range []string{FilterMark, ReasonMark, ExpiresMark} {
+			delete(m.Marks, k)
+		}
+	}
+	b, err := protojson.Marshal(active)
+	if err != nil {
+		return err
+	}
+	raw, err := json.Marshal(map[string]json.RawMessage{"finding": b})
+	if err != nil {
+		return err
+	}
+	if _, err := svcs.PubSub.Publish(ctx, topic, &pubsub.Message{Data: raw}); err != nil {
+		return err
+	}
+	if _, err := svcs.SecurityCommandCenter.SetActive(ctx, f.GetName()); err != nil {
+		return err
+	}
+	if _, err := svcs.SecurityCommandCenter.RemoveSecurityMarks(ctx, f.GetName(), []string{FilterMark, ReasonMark, ExpiresMark}); err != nil {
+		return err
+	}
+	return nil
+}
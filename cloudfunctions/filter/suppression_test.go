@@ -0,0 +1,229 @@
+package filter
+
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/googlecloudplatform/security-response-automation/clients/fakes"
+	"github.com/googlecloudplatform/security-response-automation/clients/stubs"
+	"github.com/googlecloudplatform/security-response-automation/services"
+	sccpb "google.golang.org/genproto/googleapis/cloud/securitycenter/v1beta1"
+)
+
+const suppressions = `
+- name: incident_4711
+  category: PUBLIC_BUCKET_ACL
+  resource: //storage.googleapis.com/migrating-bucket
+  reason: Bucket is public while being migrated
+  expires: 2020-11-01T00:00:00Z
+- name: old_incident
+  category: PUBLIC_BUCKET_ACL
+  expires: 2020-09-01T00:00:00Z
+`
+
+func TestSuppressions(t *testing.T) {
+	now = func() time.Time { return time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+	b, err := Compile("", map[string][]byte{SuppressionsFile: []byte(suppressions)})
+	if err != nil {
+		t.Fatalf("Compile() failed: %q", err)
+	}
+	for _, tt := range []struct {
+		name     string
+		finding  string
+		expected []*Decision
+	}{
+		{
+			name:    "match",
+			finding: `{"finding": {"category": "PUBLIC_BUCKET_ACL", "resourceName": "//storage.googleapis.com/migrating-bucket"}}`,
+			expected: []*Decision{
+				{Filter: "incident_4711", Action: Suppress, Reason: "Bucket is public while being migrated", Expires: "2020-11-01T00:00:00Z"},
+			},
+		},
+		{
+			name:    "other resource",
+			finding: `{"finding": {"category": "PUBLIC_BUCKET_ACL", "resourceName": "//storage.googleapis.com/other-bucket"}}`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			decisions, err := b.Decisions(context.Background(), []byte(tt.finding))
+			if err != nil {
+				t.Fatalf("Decisions() failed: %q", err)
+			}
+			if diff := cmp.Diff(tt.expected, decisions); diff != "" {
+				t.Errorf("Decisions() (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestInvalidSuppressions(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		modules map[string][]byte
+		want    string
+	}{
+		{
+			name:    "no expiry",
+			modules: map[string][]byte{SuppressionsFile: []byte("- name: forever\n  category: PUBLIC_BUCKET_ACL")},
+			want:    "expires must be a time",
+		},
+		{
+			name:    "matches everything",
+			modules: map[string][]byte{SuppressionsFile: []byte("- name: all\n  expires: 2020-11-01T00:00:00Z")},
+			want:    "at least one of finding, category or resource is required",
+		},
+		{
+			name:    "unknown field",
+			modules: map[string][]byte{SuppressionsFile: []byte("- name: typo\n  categroy: PUBLIC_BUCKET_ACL\n  expires: 2020-11-01T00:00:00Z")},
+			want:    "field categroy not found",
+		},
+		{
+			name: "filter name",
+			modules: map[string][]byte{
+				SuppressionsFile: []byte("- name: public\n  category: PUBLIC_BUCKET_ACL\n  expires: 2020-11-01T00:00:00Z"),
+				"public.rego":    []byte("package sra.filter\npublic { true }"),
+			},
+			want: `suppression "public" has the name of a filter`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Compile("", tt.modules); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Compile() = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSweep(t *testing.T) {
+	ctx := context.Background()
+	os.Setenv(outputTopicEnvVar, "threat-findings-router")
+	defer os.Unsetenv(outputTopicEnvVar)
+	now = func() time.Time { return time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+	const (
+		expired   = "organizations/1/sources/2/findings/expired"
+		suspended = "organizations/1/sources/2/findings/suspended"
+		permanent = "organizations/1/sources/2/findings/permanent"
+		other     = "organizations/9/sources/2/findings/expired"
+	)
+	scc := fakes.NewSecurityCommandCenter()
+	for name, marks := range map[string]map[string]string{
+		expired:   {FilterMark: "incident_4711", ReasonMark: "migration", ExpiresMark: "2020-10-01T00:00:00Z", "owner": "secops"},
+		suspended: {FilterMark: "incident_4711", ExpiresMark: "2020-11-01T00:00:00Z"},
+		permanent: {FilterMark: "false_positive"},
+		other:     {FilterMark: "incident_4711", ExpiresMark: "2020-10-01T00:00:00Z"},
+	} {
+		scc.AddFinding(&sccpb.Finding{
+			Name:          name,
+			Category:      "PUBLIC_BUCKET_ACL",
+			State:         sccpb.Finding_INACTIVE,
+			SecurityMarks: &sccpb.SecurityMarks{Name: name + "/securityMarks", Marks: marks},
+		})
+	}
+	psStub := &stubs.PubSubStub{}
+	if err := Sweep(ctx, "1", &Services{
+		PubSub:                services.NewPubSub(psStub),
+		Logger:                services.NewLogger(&stubs.LoggerStub{}),
+		SecurityCommandCenter: services.NewCommandCenter(scc),
+	}); err != nil {
+		t.Fatalf("Sweep() failed: %q", err)
+	}
+	for name, want := range map[string]sccpb.Finding_State{
+		expired:   sccpb.Finding_ACTIVE,
+		suspended: sccpb.Finding_INACTIVE,
+		permanent: sccpb.Finding_INACTIVE,
+		other:     sccpb.Finding_INACTIVE,
+	} {
+		if got := scc.Finding(name).GetState(); got != want {
+			t.Errorf("%s state = %v, want %v", name, got, want)
+		}
+	}
+	if diff := cmp.Diff(map[string]string{"owner": "secops"}, scc.Finding(expired).GetSecurityMarks().GetMarks()); diff != "" {
+		t.Errorf("marks (-want +got):\n%s", diff)
+	}
+	if len(psStub.PublishedMessages) != 1 || psStub.PublishedTopic != "threat-findings-router" {
+		t.Fatalf("published %d messages to %q, want 1 to %q", len(psStub.PublishedMessages), psStub.PublishedTopic, "threat-findings-router")
+	}
+	var n struct {
+		Finding struct {
+			Name, Category, State string
+			SecurityMarks         struct{ Marks map[string]string }
+		}
+	}
+	if err := json.Unmarshal(psStub.PublishedMessage.Data, &n); err != nil {
+		t.Fatal(err)
+	}
+	if n.Finding.Name != expired || n.Finding.Category != "PUBLIC_BUCKET_ACL" || n.Finding.State != "ACTIVE" {
+		t.Errorf("published finding %+v, want %s active", n.Finding, expired)
+	}
+	if _, ok := n.Finding.SecurityMarks.Marks[FilterMark]; ok {
+		t.Errorf("published finding marked %v, want the suppression's marks removed", n.Finding.SecurityMarks.Marks)
+	}
+}
+
+// failingCommandCenter fails to set the state of one finding.
+type failingCommandCenter struct {
+	*fakes.SecurityCommandCenter
+	fail string
+}
+
+func (s *failingCommandCenter) SetFindingState(ctx context.Context, request *sccpb.SetFindingStateRequest) (*sccpb.Finding, error) {
+	if request.GetName() == s.fail {
+		return nil, errors.New("permission denied")
+	}
+	return s.SecurityCommandCenter.SetFindingState(ctx, request)
+}
+
+func TestSweepContinues(t *testing.T) {
+	ctx := context.Background()
+	os.Setenv(outputTopicEnvVar, "threat-findings-router")
+	defer os.Unsetenv(outputTopicEnvVar)
+	now = func() time.Time { return time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+	const (
+		failing = "organizations/1/sources/2/findings/a-failing"
+		expired = "organizations/1/sources/2/findings/b-expired"
+	)
+	scc := fakes.NewSecurityCommandCenter()
+	for _, name := range []string{failing, expired} {
+		scc.AddFinding(&sccpb.Finding{
+			Name:          name,
+			State:         sccpb.Finding_INACTIVE,
+			SecurityMarks: &sccpb.SecurityMarks{Name: name + "/securityMarks", Marks: map[string]string{FilterMark: "incident_4711", ExpiresMark: "2020-10-01T00:00:00Z"}},
+		})
+	}
+	err := Sweep(ctx, "1", &Services{
+		PubSub:                services.NewPubSub(&stubs.PubSubStub{}),
+		Logger:                services.NewLogger(&stubs.LoggerStub{}),
+		SecurityCommandCenter: services.NewCommandCenter(&failingCommandCenter{SecurityCommandCenter: scc, fail: failing}),
+	})
+	if err == nil || err.Error() != "failed to reactivate 1 findings" {
+		t.Errorf("Sweep() = %v, want %q", err, "failed to reactivate 1 findings")
+	}
+	for name, want := range map[string]sccpb.Finding_State{failing: sccpb.Finding_INACTIVE, expired: sccpb.Finding_ACTIVE} {
+		if got := scc.Finding(name).GetState(); got != want {
+			t.Errorf("%s state = %v, want %v", name, got, want)
+		}
+	}
+}
@@ -12,3 +12,15 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 variable "setup" {}
+
+variable "filter-bundle" {
+  type        = string
+  default     = ""
+  description = "Cloud Storage prefix such as gs://bucket/filters/ or OPA bundle URL to load the Rego filters from at runtime. The filters deployed with the function are used if empty."
+}
+
+variable "filter-bundle-token" {
+  type        = string
+  default     = ""
+  description = "Bearer token sent to the OPA bundle server, if any."
+}
@@ -16,7 +16,9 @@ package createsnapshot
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"sort"
 	"strings"
 	"time"
 
@@ -59,9 +61,11 @@ type Values struct {
 
 // Services contains the services needed for this function.
 type Services struct {
-	Host     *services.Host
-	Logger   *services.Logger
-	Resource *services.Resource
+	Host        *services.Host
+	Logger      *services.Logger
+	Resource    *services.Resource
+	Audit       *services.Audit
+	Idempotency *services.Idempotency
 }
 
 // Output contains the output of this function.
@@ -79,7 +83,21 @@ type Output struct {
 // In order for the snapshot to be create the service account must be granted the correct
 // role on the affected project. At this time this grant is defined per project but should
 // be changed to support folder and organization level grants.
-func Execute(ctx context.Context, values *Values, services *Services) (*Output, error) {
+func Execute(ctx context.Context, values *Values, services *Services) (_ *Output, err error) {
+	if !services.Idempotency.Begin(ctx, "gce_create_disk_snapshot") {
+		return &Output{}, nil
+	}
+	defer func() { services.Idempotency.End(ctx, "gce_create_disk_snapshot", err) }()
+	remediation := services.Audit.Remediation("gce_create_disk_snapshot", fmt.Sprintf("//compute.googleapis.com/projects/%s/zones/%s/instances/%s", values.ProjectID, values.Zone, values.Instance), values.DryRun)
+	defer func() { services.Audit.Record(ctx, remediation, err) }()
+	instanceLabels, err := services.Host.InstanceLabels(ctx, values.ProjectID, values.Zone, values.Instance)
+	if err != nil {
+		return nil, err
+	}
+	if remediation.Exempt(instanceLabels) {
+		services.Logger.Info("skipped instance %q in project %q, %s", values.Instance, values.ProjectID, remediation.Skipped)
+		return &Output{}, nil
+	}
 	var output Output
 	log.Printf("listing disk names within instance %q, in zone %q and project %q", values.Instance, values.Zone, values.ProjectID)
 	disksCopied := []string{}
@@ -94,6 +112,8 @@ func Execute(ctx context.Context, values *Values, services *Services) (*Output,
 		return nil, errors.Wrap(err, "failed to list snapshots")
 	}
 	log.Printf("got %d existing snapshots for project %q", len(snapshots.Items), values.ProjectID)
+	taken := ruleSnapshots(snapshots, disks, rule)
+	remediation.Before = map[string][]string{"snapshots": sortedNames(taken)}
 
 	for _, disk := range disks {
 		snapshotName := createSnapshotName(rule, disk.Name)
@@ -116,6 +136,7 @@ func Execute(ctx context.Context, values *Values, services *Services) (*Output,
 			if err := services.Host.DeleteDiskSnapshot(ctx, values.ProjectID, k); err != nil {
 				return nil, errors.Wrapf(err, "failed deleting snapshot: %q", k)
 			}
+			delete(taken, k)
 			services.Logger.Info("removed existing snapshot %q from disk %q", k, disk.Name)
 		}
 
@@ -123,6 +144,7 @@ func Execute(ctx context.Context, values *Values, services *Services) (*Output,
 		if err := services.Host.CreateDiskSnapshot(ctx, values.ProjectID, values.Zone, disk.Name, snapshotName); err != nil {
 			return nil, errors.Wrapf(err, "failed creating snapshot: %q", snapshotName)
 		}
+		taken[snapshotName] = true
 		services.Logger.Info("created snapshot for disk %q", disk.Name)
 
 		if err := services.Host.SetSnapshotLabels(ctx, values.ProjectID, snapshotName, disk, labels); err != nil {
@@ -141,6 +163,7 @@ func Execute(ctx context.Context, values *Values, services *Services) (*Output,
 	}
 	log.Printf("completed")
 	output.DiskNames = disksCopied
+	remediation.After = map[string][]string{"snapshots": sortedNames(taken), "copied": disksCopied}
 	return &output, nil
 }
 
@@ -175,6 +198,29 @@ func isSnapshotCreatedWithin(snapshotTime string, window time.Duration) (bool, e
 	return time.Since(t) < window, nil
 }
 
+// ruleSnapshots returns the names of the existing snapshots taken of the disks for the rule.
+func ruleSnapshots(snapshots *compute.SnapshotList, disks []*compute.Disk, rule string) map[string]bool {
+	taken := map[string]bool{}
+	for _, disk := range disks {
+		prefix := createSnapshotName(rule, disk.Name)
+		for _, s := range snapshots.Items {
+			if s.SourceDisk == disk.SelfLink && strings.HasPrefix(s.Name, prefix) {
+				taken[s.Name] = true
+			}
+		}
+	}
+	return taken
+}
+
+func sortedNames(names map[string]bool) []string {
+	out := []string{}
+	for n := range names {
+		out = append(out, n)
+	}
+	sort.Strings(out)
+	return out
+}
+
 func createSnapshotName(rule, disk string) string {
 	return snapshotPrefix + rule + "-" + disk
 }
@@ -226,6 +226,7 @@ func createSnapshotSetup() (*services.Global, *stubs.ComputeStub) {
 	log := services.NewLogger(loggerStub)
 	computeStub := &stubs.ComputeStub{}
 	computeStub.SavedCreateSnapshots = make(map[string]compute.Snapshot)
+	computeStub.StubbedInstance = &compute.Instance{}
 	resourceManagerStub := &stubs.ResourceManagerStub{}
 	storageStub := &stubs.StorageStub{}
 	h := services.NewHost(computeStub)
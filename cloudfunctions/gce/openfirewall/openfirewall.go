@@ -33,29 +33,62 @@ type Values struct {
 
 // Services contains the services needed for this function.
 type Services struct {
-	Firewall *services.Firewall
-	Resource *services.Resource
-	Logger   *services.Logger
+	Firewall    *services.Firewall
+	Resource    *services.Resource
+	Logger      *services.Logger
+	Audit       *services.Audit
+	Snapshots   *services.Snapshots
+	Idempotency *services.Idempotency
 }
 
 // Execute remediates an open firewall.
-func Execute(ctx context.Context, values *Values, services *Services) error {
+func Execute(ctx context.Context, values *Values, services *Services) (err error) {
+	if !services.Idempotency.Begin(ctx, "remediate_firewall") {
+		return nil
+	}
+	defer func() { services.Idempotency.End(ctx, "remediate_firewall", err) }()
+	remediation := services.Audit.Remediation("remediate_firewall", fmt.Sprintf("//compute.googleapis.com/projects/%s/global/firewalls/%s", values.ProjectID, values.FirewallID), values.DryRun)
+	defer func() { services.Audit.Record(ctx, remediation, err) }()
+	labels, err := services.Firewall.RuleLabels(ctx, values.ProjectID, values.FirewallID)
+	if err != nil {
+		return err
+	}
+	if remediation.Exempt(labels) {
+		services.Logger.Info("skipped firewall %q in project %q, %s", values.FirewallID, values.ProjectID, remediation.Skipped)
+		return nil
+	}
+	rule, err := services.Firewall.FirewallRule(ctx, values.ProjectID, values.FirewallID)
+	if err != nil {
+		return err
+	}
+	remediation.Before = rule
 	if values.DryRun {
 		services.Logger.Info("dry_run on, would have remediated firewall %q in project %q with action %q", values.FirewallID, values.ProjectID, values.Action)
 		return nil
 	}
+	if values.Action == "disable" || values.Action == "update_source_range" {
+		if err := services.Snapshots.Save(ctx, remediation, rule); err != nil {
+			return err
+		}
+	}
 	switch action := values.Action; action {
 	case "block_ssh":
-		return blockSSH(ctx, services.Logger, services.Firewall, values)
+		err = blockSSH(ctx, services.Logger, services.Firewall, values)
 	case "disable":
-		return disable(ctx, services.Logger, services.Firewall, values)
+		err = disable(ctx, services.Logger, services.Firewall, values)
 	case "delete":
-		return delete(ctx, services.Logger, services.Firewall, values)
+		err = delete(ctx, services.Logger, services.Firewall, values)
 	case "update_source_range":
-		return updateRange(ctx, services.Logger, services.Firewall, values)
+		err = updateRange(ctx, services.Logger, services.Firewall, values)
 	default:
 		return fmt.Errorf("unknown open firewall remediation action: %q", action)
 	}
+	if err != nil || values.Action == "delete" {
+		// A deleted rule has no state left to record.
+		return err
+	}
+	remediation.After, err = services.Firewall.FirewallRule(ctx, values.ProjectID, values.FirewallID)
+	return err
 }
 
 func blockSSH(ctx context.Context, logr *services.Logger, fw *services.Firewall, values *Values) error {
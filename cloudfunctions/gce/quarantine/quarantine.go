@@ -0,0 +1,158 @@
+package quarantine
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/googlecloudplatform/security-response-automation/services"
+	"github.com/pkg/errors"
+	compute "google.golang.org/api/compute/v1"
+)
+
+const (
+	// DefaultTag is the network tag applied to quarantined instances if none is configured.
+	DefaultTag = "sra-quarantine"
+	// OriginalTagsKey is the instance metadata key holding the comma separated network tags
+	// the instance had before it was quarantined.
+	OriginalTagsKey = "sra-quarantine-original-tags"
+)
+
+// Values contains the required values needed for this function.
+type Values struct {
+	ProjectID    string
+	Instance     string
+	Zone         string
+	Tag          string
+	StopInstance bool
+	DryRun       bool
+}
+
+// Services contains the services needed for this function.
+type Services struct {
+	Firewall    *services.Firewall
+	Host        *services.Host
+	Snapshots   *services.Snapshots
+	Logger      *services.Logger
+	Audit       *services.Audit
+	Idempotency *services.Idempotency
+}
+
+// Execute isolates the instance from the network.
+//
+// Firewall rules denying all ingress and egress traffic are added for the quarantine tag on each
+// network the instance is attached to. The instance's network tags are then replaced by the
+// quarantine tag, with the original tags recorded in the instance's metadata and in a snapshot so
+// Rollback can restore them. Optionally the instance is stopped.
+func Execute(ctx context.Context, values *Values, services *Services) (err error) {
+	if !services.Idempotency.Begin(ctx, "gce_quarantine_instance") {
+		return nil
+	}
+	defer func() { services.Idempotency.End(ctx, "gce_quarantine_instance", err) }()
+	remediation := services.Audit.Remediation("gce_quarantine_instance", fmt.Sprintf("//compute.googleapis.com/projects/%s/zones/%s/instances/%s", values.ProjectID, values.Zone, values.Instance), values.DryRun)
+	defer func() { services.Audit.Record(ctx, remediation, err) }()
+	labels, err := services.Host.InstanceLabels(ctx, values.ProjectID, values.Zone, values.Instance)
+	if err != nil {
+		return err
+	}
+	if remediation.Exempt(labels) {
+		services.Logger.Info("skipped instance %q in project %q, %s", values.Instance, values.ProjectID, remediation.Skipped)
+		return nil
+	}
+	tag := values.Tag
+	if tag == "" {
+		tag = DefaultTag
+	}
+	instance, err := services.Host.Instance(ctx, values.ProjectID, values.Zone, values.Instance)
+	if err != nil {
+		return err
+	}
+	if values.DryRun {
+		services.Logger.Info("dry_run on, would have quarantined instance %q with tag %q in project %q", values.Instance, tag, values.ProjectID)
+		return nil
+	}
+	for _, ni := range instance.NetworkInterfaces {
+		if err := services.Firewall.DenyAllForTag(ctx, values.ProjectID, ni.Network, tag); err != nil {
+			return errors.Wrapf(err, "failed to add quarantine firewall rules to %q", ni.Network)
+		}
+	}
+	tags := instanceTags(instance)
+	remediation.Before = map[string][]string{"tags": tags}
+	remediation.After = map[string][]string{"tags": {tag}}
+	// An instance quarantined before keeps the tags it had before the first quarantine.
+	original, ok := OriginalTags(instance)
+	if !ok {
+		original = tags
+	}
+	if err := services.Snapshots.Save(ctx, remediation, original); err != nil {
+		return err
+	}
+	if len(tags) != 1 || tags[0] != tag {
+		if !ok {
+			if err := services.Host.SetInstanceMetadata(ctx, values.ProjectID, values.Zone, instance, OriginalTagsKey, strings.Join(tags, ",")); err != nil {
+				return errors.Wrap(err, "failed to record original tags")
+			}
+			// Setting metadata changes the instance fingerprint so fetch it again before tagging.
+			if instance, err = services.Host.Instance(ctx, values.ProjectID, values.Zone, values.Instance); err != nil {
+				return err
+			}
+		}
+		if err := services.Host.SetInstanceTags(ctx, values.ProjectID, values.Zone, instance, []string{tag}); err != nil {
+			return errors.Wrap(err, "failed to apply quarantine tag")
+		}
+	}
+	services.Logger.Info("quarantined instance %q with tag %q in project %q, original tags: %q", values.Instance, tag, values.ProjectID, tags)
+	if !values.StopInstance {
+		return nil
+	}
+	if err := services.Host.StopInstance(ctx, values.ProjectID, values.Zone, values.Instance); err != nil {
+		return err
+	}
+	services.Logger.Info("stopped quarantined instance %q in project %q", values.Instance, values.ProjectID)
+	return nil
+}
+
+// OriginalTags returns the network tags the instance had before it was quarantined.
+func OriginalTags(instance *compute.Instance) ([]string, bool) {
+	v, ok := metadata(instance, OriginalTagsKey)
+	if !ok {
+		return nil, false
+	}
+	if v == "" {
+		return []string{}, true
+	}
+	return strings.Split(v, ","), true
+}
+
+func instanceTags(instance *compute.Instance) []string {
+	if instance.Tags == nil {
+		return []string{}
+	}
+	return instance.Tags.Items
+}
+
+func metadata(instance *compute.Instance, key string) (string, bool) {
+	if instance.Metadata == nil {
+		return "", false
+	}
+	for _, item := range instance.Metadata.Items {
+		if item.Key == key && item.Value != nil {
+			return *item.Value, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,196 @@
+package quarantine
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	compute "google.golang.org/api/compute/v1"
+
+	"github.com/googlecloudplatform/security-response-automation/clients/stubs"
+	"github.com/googlecloudplatform/security-response-automation/services"
+)
+
+const network = "https://www.googleapis.com/compute/v1/projects/project-id/global/networks/default"
+
+func TestQuarantine(t *testing.T) {
+	ctx := context.Background()
+	original := "http-server,ssh"
+	quarantined := ""
+
+	test := []struct {
+		name             string
+		instance         *compute.Instance
+		stop             bool
+		dryRun           bool
+		expectedRules    []*compute.Firewall
+		expectedTags     *compute.Tags
+		expectedMetadata *compute.Metadata
+		expectedStopped  string
+	}{
+		{
+			name: "quarantine instance",
+			instance: &compute.Instance{
+				Name:              "instance-name",
+				NetworkInterfaces: []*compute.NetworkInterface{{Network: network}},
+				Tags:              &compute.Tags{Items: []string{"http-server", "ssh"}, Fingerprint: "tags-fp"},
+				Metadata:          &compute.Metadata{Fingerprint: "md-fp"},
+			},
+			expectedRules: []*compute.Firewall{
+				{
+					Name:            "sra-quarantine-deny-ingress-default-9b316437",
+					Description:     `Deny all ingress traffic for instances tagged "sra-quarantine" by Security Response Automation`,
+					Network:         network,
+					Direction:       "INGRESS",
+					Denied:          []*compute.FirewallDenied{{IPProtocol: "all"}},
+					TargetTags:      []string{"sra-quarantine"},
+					SourceRanges:    []string{"0.0.0.0/0"},
+					ForceSendFields: []string{"Priority"},
+				},
+				{
+					Name:              "sra-quarantine-deny-egress-default-ec46c1df",
+					Description:       `Deny all egress traffic for instances tagged "sra-quarantine" by Security Response Automation`,
+					Network:           network,
+					Direction:         "EGRESS",
+					Denied:            []*compute.FirewallDenied{{IPProtocol: "all"}},
+					TargetTags:        []string{"sra-quarantine"},
+					DestinationRanges: []string{"0.0.0.0/0"},
+					ForceSendFields:   []string{"Priority"},
+				},
+			},
+			expectedTags: &compute.Tags{Items: []string{"sra-quarantine"}, Fingerprint: "tags-fp"},
+			expectedMetadata: &compute.Metadata{
+				Fingerprint: "md-fp",
+				Items:       []*compute.MetadataItems{{Key: OriginalTagsKey, Value: &original}},
+			},
+		},
+		{
+			name: "quarantine and stop instance without tags",
+			instance: &compute.Instance{
+				Name: "instance-name",
+			},
+			stop:         true,
+			expectedTags: &compute.Tags{Items: []string{"sra-quarantine"}},
+			expectedMetadata: &compute.Metadata{
+				Items: []*compute.MetadataItems{{Key: OriginalTagsKey, Value: &quarantined}},
+			},
+			expectedStopped: "instance-name",
+		},
+		{
+			name: "already quarantined",
+			instance: &compute.Instance{
+				Name:     "instance-name",
+				Tags:     &compute.Tags{Items: []string{"sra-quarantine"}},
+				Metadata: &compute.Metadata{Items: []*compute.MetadataItems{{Key: OriginalTagsKey, Value: &original}}},
+			},
+		},
+		{
+			name: "dry run",
+			instance: &compute.Instance{
+				Name:              "instance-name",
+				NetworkInterfaces: []*compute.NetworkInterface{{Network: network}},
+				Tags:              &compute.Tags{Items: []string{"http-server"}},
+			},
+			stop:   true,
+			dryRun: true,
+		},
+	}
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			svcs, computeStub := setupQuarantine()
+			computeStub.StubbedInstance = tt.instance
+			values := &Values{
+				ProjectID:    "project-id",
+				Instance:     "instance-name",
+				Zone:         "us-central1-a",
+				StopInstance: tt.stop,
+				DryRun:       tt.dryRun,
+			}
+			if err := Execute(ctx, values, svcs); err != nil {
+				t.Fatalf("%s failed to quarantine instance: %q", tt.name, err)
+			}
+			if diff := cmp.Diff(tt.expectedRules, computeStub.InsertedFirewallRules); diff != "" {
+				t.Errorf("%v failed, firewall rules difference: %+v", tt.name, diff)
+			}
+			if diff := cmp.Diff(tt.expectedTags, computeStub.SavedTags); diff != "" {
+				t.Errorf("%v failed, tags difference: %+v", tt.name, diff)
+			}
+			if diff := cmp.Diff(tt.expectedMetadata, computeStub.SavedMetadata); diff != "" {
+				t.Errorf("%v failed, metadata difference: %+v", tt.name, diff)
+			}
+			if computeStub.StoppedInstance != tt.expectedStopped {
+				t.Errorf("%v failed, stopped instance got:%q want:%q", tt.name, computeStub.StoppedInstance, tt.expectedStopped)
+			}
+		})
+	}
+}
+
+func TestQuarantineSharedVPC(t *testing.T) {
+	ctx := context.Background()
+	const shared = "https://www.googleapis.com/compute/v1/projects/host-project/global/networks/shared"
+	svcs, computeStub := setupQuarantine()
+	storageStub := &stubs.StorageStub{}
+	svcs.Snapshots = services.NewSnapshots(storageStub, "snapshot-bucket")
+	computeStub.StubbedInstance = &compute.Instance{
+		Name:              "instance-name",
+		NetworkInterfaces: []*compute.NetworkInterface{{Network: shared}},
+		Tags:              &compute.Tags{Items: []string{"http-server"}},
+	}
+	if err := Execute(ctx, &Values{ProjectID: "project-id", Instance: "instance-name", Zone: "us-central1-a"}, svcs); err != nil {
+		t.Fatalf("failed to quarantine instance: %q", err)
+	}
+	if diff := cmp.Diff([]string{"host-project", "host-project"}, computeStub.InsertedFirewallProjects); diff != "" {
+		t.Errorf("firewall rule projects difference: %+v", diff)
+	}
+	var names []string
+	for _, rule := range computeStub.InsertedFirewallRules {
+		names = append(names, rule.Name)
+	}
+	if diff := cmp.Diff([]string{"sra-quarantine-deny-ingress-shared-9653586a", "sra-quarantine-deny-egress-shared-a32eb349"}, names); diff != "" {
+		t.Errorf("firewall rule names difference: %+v", diff)
+	}
+	if len(storageStub.Objects["snapshot-bucket"]) != 1 {
+		t.Errorf("got %d snapshots, want the original tags saved for rollback", len(storageStub.Objects["snapshot-bucket"]))
+	}
+}
+
+func TestOriginalTags(t *testing.T) {
+	original := "http-server,ssh"
+	instance := &compute.Instance{
+		Metadata: &compute.Metadata{Items: []*compute.MetadataItems{{Key: OriginalTagsKey, Value: &original}}},
+	}
+	tags, ok := OriginalTags(instance)
+	if !ok {
+		t.Fatalf("OriginalTags() found no tags")
+	}
+	if diff := cmp.Diff([]string{"http-server", "ssh"}, tags); diff != "" {
+		t.Errorf("OriginalTags() difference: %+v", diff)
+	}
+	if _, ok := OriginalTags(&compute.Instance{}); ok {
+		t.Errorf("OriginalTags() found tags on an instance never quarantined")
+	}
+}
+
+func setupQuarantine() (*Services, *stubs.ComputeStub) {
+	loggerStub := &stubs.LoggerStub{}
+	computeStub := &stubs.ComputeStub{}
+	return &Services{
+		Firewall: services.NewFirewall(computeStub),
+		Host:     services.NewHost(computeStub),
+		Logger:   services.NewLogger(loggerStub),
+	}, computeStub
+}
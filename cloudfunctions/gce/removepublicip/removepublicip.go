@@ -16,6 +16,7 @@ package removepublicip
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/googlecloudplatform/security-response-automation/services"
 	"github.com/pkg/errors"
@@ -29,20 +30,48 @@ type Values struct {
 
 // Services contains the services needed for this function.
 type Services struct {
-	Host     *services.Host
-	Resource *services.Resource
-	Logger   *services.Logger
+	Host        *services.Host
+	Resource    *services.Resource
+	Logger      *services.Logger
+	Audit       *services.Audit
+	Snapshots   *services.Snapshots
+	Idempotency *services.Idempotency
 }
 
 // Execute removes the public IP of a GCE instance.
-func Execute(ctx context.Context, values *Values, services *Services) error {
+func Execute(ctx context.Context, values *Values, services *Services) (err error) {
+	if !services.Idempotency.Begin(ctx, "remove_public_ip") {
+		return nil
+	}
+	defer func() { services.Idempotency.End(ctx, "remove_public_ip", err) }()
+	remediation := services.Audit.Remediation("remove_public_ip", fmt.Sprintf("//compute.googleapis.com/projects/%s/zones/%s/instances/%s", values.ProjectID, values.InstanceZone, values.InstanceID), values.DryRun)
+	defer func() { services.Audit.Record(ctx, remediation, err) }()
+	labels, err := services.Host.InstanceLabels(ctx, values.ProjectID, values.InstanceZone, values.InstanceID)
+	if err != nil {
+		return err
+	}
+	if remediation.Exempt(labels) {
+		services.Logger.Info("skipped instance %q in project %q, %s", values.InstanceID, values.ProjectID, remediation.Skipped)
+		return nil
+	}
 	if values.DryRun {
 		services.Logger.Info("dry_run on, would have removed public IP address for instance %q, in zone %q in project %q.", values.InstanceID, values.ProjectID)
 		return nil
 	}
+	configs, err := services.Host.ExternalAccessConfigs(ctx, values.ProjectID, values.InstanceZone, values.InstanceID)
+	if err != nil {
+		return err
+	}
+	remediation.Before = configs
+	if err := services.Snapshots.Save(ctx, remediation, configs); err != nil {
+		return err
+	}
 	if err := services.Host.RemoveExternalIPs(ctx, values.ProjectID, values.InstanceZone, values.InstanceID); err != nil {
 		return errors.Wrap(err, "failed to remove public ip")
 	}
+	if remediation.After, err = services.Host.ExternalAccessConfigs(ctx, values.ProjectID, values.InstanceZone, values.InstanceID); err != nil {
+		return err
+	}
 	services.Logger.Info("removed public IP address for instance %q, in zone %q in project %q.", values.InstanceID, values.InstanceZone, values.ProjectID)
 	return nil
 }
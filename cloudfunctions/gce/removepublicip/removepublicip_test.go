@@ -16,10 +16,12 @@ package removepublicip
 
 import (
 	"context"
+	"net/http"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
 	compute "google.golang.org/api/compute/v1"
+	"google.golang.org/api/googleapi"
 
 	"github.com/googlecloudplatform/security-response-automation/clients/stubs"
 	"github.com/googlecloudplatform/security-response-automation/services"
@@ -84,6 +86,46 @@ func TestRemovePublicIP(t *testing.T) {
 	}
 }
 
+func TestRemovePublicIPTransient(t *testing.T) {
+	ctx := context.Background()
+	test := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{name: "unavailable", err: &googleapi.Error{Code: http.StatusServiceUnavailable}, expected: true},
+		{name: "forbidden", err: &googleapi.Error{Code: http.StatusForbidden}, expected: false},
+	}
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			svcs, computeStub := setupRemovePublicIP()
+			computeStub.StubbedInstance = &compute.Instance{
+				NetworkInterfaces: []*compute.NetworkInterface{
+					{Name: "nic0", AccessConfigs: []*compute.AccessConfig{{Name: "External NAT", NatIP: "35.192.206.126", Type: "ONE_TO_ONE_NAT"}}},
+				},
+			}
+			computeStub.DeleteAccessConfigErr = tt.err
+			values := &Values{
+				ProjectID:    "project-id",
+				InstanceZone: "instance-zone",
+				InstanceID:   "instance-id",
+			}
+
+			err := Execute(ctx, values, &Services{
+				Host:     svcs.Host,
+				Resource: svcs.Resource,
+				Logger:   svcs.Logger,
+			})
+			if err == nil {
+				t.Fatalf("%s failed, expected an error", tt.name)
+			}
+			if got := services.Transient(err); got != tt.expected {
+				t.Errorf("%s failed, Transient(%q) = %v, want %v", tt.name, err, got, tt.expected)
+			}
+		})
+	}
+}
+
 func setupRemovePublicIP() (*services.Global, *stubs.ComputeStub) {
 	loggerStub := &stubs.LoggerStub{}
 	log := services.NewLogger(loggerStub)
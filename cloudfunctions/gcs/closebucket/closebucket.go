@@ -32,19 +32,47 @@ type Values struct {
 
 // Services contains the services needed for this function.
 type Services struct {
-	Resource *services.Resource
-	Logger   *services.Logger
+	Resource    *services.Resource
+	Logger      *services.Logger
+	Audit       *services.Audit
+	Snapshots   *services.Snapshots
+	Idempotency *services.Idempotency
 }
 
 // Execute will remove any public users from buckets found within the provided folders.
-func Execute(ctx context.Context, values *Values, services *Services) error {
+func Execute(ctx context.Context, values *Values, services *Services) (err error) {
+	if !services.Idempotency.Begin(ctx, "close_bucket") {
+		return nil
+	}
+	defer func() { services.Idempotency.End(ctx, "close_bucket", err) }()
+	remediation := services.Audit.Remediation("close_bucket", "//storage.googleapis.com/"+values.BucketName, values.DryRun)
+	defer func() { services.Audit.Record(ctx, remediation, err) }()
+	labels, err := services.Resource.BucketLabels(ctx, values.BucketName)
+	if err != nil {
+		return err
+	}
+	if remediation.Exempt(labels) {
+		services.Logger.Info("skipped bucket %q in project %q, %s", values.BucketName, values.ProjectID, remediation.Skipped)
+		return nil
+	}
 	if values.DryRun {
 		services.Logger.Info("dry_run on, would have removed public members from bucket %q in project %q", values.BucketName, values.ProjectID)
 		return nil
 	}
+	bindings, err := services.Resource.BucketBindings(ctx, values.BucketName)
+	if err != nil {
+		return err
+	}
+	remediation.Before = bindings
+	if err := services.Snapshots.Save(ctx, remediation, bindings); err != nil {
+		return err
+	}
 	if err := services.Resource.RemoveMembersFromBucket(ctx, values.BucketName, publicUsers); err != nil {
 		return err
 	}
+	if remediation.After, err = services.Resource.BucketBindings(ctx, values.BucketName); err != nil {
+		return err
+	}
 	services.Logger.Info("removed public members from bucket %q in project %q", values.BucketName, values.ProjectID)
 	return nil
 }
@@ -19,6 +19,7 @@ import (
 	"testing"
 
 	"cloud.google.com/go/iam"
+	"cloud.google.com/go/storage"
 	"github.com/google/go-cmp/cmp"
 	"github.com/googlecloudplatform/security-response-automation/clients/stubs"
 	"github.com/googlecloudplatform/security-response-automation/services"
@@ -50,9 +51,11 @@ func TestCloseBucket(t *testing.T) {
 				BucketName: "open-bucket-name",
 			}
 
+			bqStub := &stubs.BigQueryStub{}
 			if err := Execute(ctx, required, &Services{
 				Resource: svcs.Resource,
 				Logger:   svcs.Logger,
+				Audit:    services.NewAudit(bqStub, "audit-project", "sra_audit", "remediations"),
 			}); err != nil {
 				t.Errorf("%s test failed want:%q", tt.name, err)
 			}
@@ -63,10 +66,70 @@ func TestCloseBucket(t *testing.T) {
 					t.Errorf("%v failed exp:%v got:%v", tt.name, tt.expected, s)
 				}
 			}
+			if len(bqStub.InsertedRows) != 1 {
+				t.Fatalf("%v failed, got %d audit records want 1", tt.name, len(bqStub.InsertedRows))
+			}
+			row := bqStub.InsertedRows[0].(*services.RemediationRow)
+			if row.Action != "close_bucket" || row.Resource != "//storage.googleapis.com/open-bucket-name" || row.Outcome != services.OutcomeSuccess {
+				t.Errorf("%v failed, unexpected audit record: %+v", tt.name, row)
+			}
 		})
 	}
 }
 
+func TestCloseBucketRedelivery(t *testing.T) {
+	ctx := context.Background()
+	svcs, storageStub := closeBucketSetup()
+	idempotency := services.NewIdempotency(services.NewMemoryStore()).WithFinding("organizations/1/sources/2/findings/3", "2019-11-22T18:34:36.153Z", "0")
+	required := &Values{ProjectID: "project-name", BucketName: "open-bucket-name"}
+	bqStub := &stubs.BigQueryStub{}
+	for i := 0; i < 2; i++ {
+		if err := Execute(ctx, required, &Services{
+			Resource:    svcs.Resource,
+			Logger:      svcs.Logger,
+			Audit:       services.NewAudit(bqStub, "audit-project", "sra_audit", "remediations"),
+			Idempotency: idempotency,
+		}); err != nil {
+			t.Fatalf("delivery %d failed: %q", i, err)
+		}
+	}
+	if len(bqStub.InsertedRows) != 1 {
+		t.Errorf("got %d audit records want 1, redelivery was not skipped", len(bqStub.InsertedRows))
+	}
+	if storageStub.RemoveBucketPolicy == nil {
+		t.Errorf("first delivery did not remove public members")
+	}
+}
+
+func TestCloseBucketExempt(t *testing.T) {
+	ctx := context.Background()
+	for _, labels := range []map[string]string{
+		{"sra-exempt": "true"},
+		{"sra-exempt-actions": "enable_bucket_only_policy-close_bucket"},
+	} {
+		svcs, storageStub := closeBucketSetup()
+		storageStub.BucketPolicyResponse.Add("allUsers", "project/viewer")
+		storageStub.BucketAttrsResponse = &storage.BucketAttrs{Name: "open-bucket-name", Labels: labels}
+		bqStub := &stubs.BigQueryStub{}
+		if err := Execute(ctx, &Values{ProjectID: "project-name", BucketName: "open-bucket-name"}, &Services{
+			Resource: svcs.Resource,
+			Logger:   svcs.Logger,
+			Audit:    services.NewAudit(bqStub, "audit-project", "sra_audit", "remediations"),
+		}); err != nil {
+			t.Fatalf("labels %q: Execute() failed: %q", labels, err)
+		}
+		if storageStub.RemoveBucketPolicy != nil {
+			t.Errorf("labels %q: public members removed from exempt bucket", labels)
+		}
+		if len(bqStub.InsertedRows) != 1 {
+			t.Fatalf("labels %q: got %d audit records want 1", labels, len(bqStub.InsertedRows))
+		}
+		if row := bqStub.InsertedRows[0].(*services.RemediationRow); row.Outcome != services.OutcomeSkipped || row.Reason == "" {
+			t.Errorf("labels %q: unexpected audit record: %+v", labels, row)
+		}
+	}
+}
+
 func closeBucketSetup() (*services.Global, *stubs.StorageStub) {
 	loggerStub := &stubs.LoggerStub{}
 	log := services.NewLogger(loggerStub)
@@ -29,12 +29,33 @@ type Values struct {
 
 // Services contains the services needed for this function.
 type Services struct {
-	Resource *services.Resource
-	Logger   *services.Logger
+	Resource    *services.Resource
+	Logger      *services.Logger
+	Audit       *services.Audit
+	Idempotency *services.Idempotency
 }
 
 // Execute will enable bucket only policy on buckets found within the provided folders.
-func Execute(ctx context.Context, values *Values, services *Services) error {
+func Execute(ctx context.Context, values *Values, services *Services) (err error) {
+	if !services.Idempotency.Begin(ctx, "enable_bucket_only_policy") {
+		return nil
+	}
+	defer func() { services.Idempotency.End(ctx, "enable_bucket_only_policy", err) }()
+	remediation := services.Audit.Remediation("enable_bucket_only_policy", "//storage.googleapis.com/"+values.BucketName, values.DryRun)
+	defer func() { services.Audit.Record(ctx, remediation, err) }()
+	labels, err := services.Resource.BucketLabels(ctx, values.BucketName)
+	if err != nil {
+		return err
+	}
+	if remediation.Exempt(labels) {
+		services.Logger.Info("skipped bucket %q in project %q, %s", values.BucketName, values.ProjectID, remediation.Skipped)
+		return nil
+	}
+	enabled, err := services.Resource.BucketPolicyOnly(ctx, values.BucketName)
+	if err != nil {
+		return err
+	}
+	remediation.Before = map[string]bool{"bucket_policy_only": enabled}
 	if values.DryRun {
 		services.Logger.Info("dry_run on, would have enabled Bucket only policy on bucket %q in project %q.", values.BucketName, values.ProjectID)
 		return nil
@@ -42,6 +63,10 @@ func Execute(ctx context.Context, values *Values, services *Services) error {
 	if err := services.Resource.EnableBucketOnlyPolicy(ctx, values.BucketName); err != nil {
 		return err
 	}
+	if enabled, err = services.Resource.BucketPolicyOnly(ctx, values.BucketName); err != nil {
+		return err
+	}
+	remediation.After = map[string]bool{"bucket_policy_only": enabled}
 	services.Logger.Info("Bucket only policy enabled on bucket %q in project %q.", values.BucketName, values.ProjectID)
 	return nil
 }
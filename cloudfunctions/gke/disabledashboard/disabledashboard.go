@@ -16,6 +16,7 @@ package disabledashboard
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/googlecloudplatform/security-response-automation/services"
 )
@@ -28,13 +29,26 @@ type Values struct {
 
 // Services contains the services needed for this function.
 type Services struct {
-	Container *services.Container
-	Resource  *services.Resource
-	Logger    *services.Logger
+	Container   *services.Container
+	Resource    *services.Resource
+	Logger      *services.Logger
+	Audit       *services.Audit
+	Idempotency *services.Idempotency
 }
 
 // Execute disables the Kubernetes dashboard.
-func Execute(ctx context.Context, values *Values, service *Services) error {
+func Execute(ctx context.Context, values *Values, service *Services) (err error) {
+	if !service.Idempotency.Begin(ctx, "disable_dashboard") {
+		return nil
+	}
+	defer func() { service.Idempotency.End(ctx, "disable_dashboard", err) }()
+	remediation := service.Audit.Remediation("disable_dashboard", fmt.Sprintf("//container.googleapis.com/projects/%s/zones/%s/clusters/%s", values.ProjectID, values.Zone, values.ClusterID), values.DryRun)
+	defer func() { service.Audit.Record(ctx, remediation, err) }()
+	disabled, err := service.Container.DashboardDisabled(ctx, values.ProjectID, values.Zone, values.ClusterID)
+	if err != nil {
+		return err
+	}
+	remediation.Before = map[string]bool{"kubernetes_dashboard_disabled": disabled}
 	if values.DryRun {
 		service.Logger.Info("dry_run on, would have disabled dashboard from custer %q in zone %q in project %q", values.ClusterID, values.Zone, values.ProjectID)
 		return nil
@@ -42,6 +56,8 @@ func Execute(ctx context.Context, values *Values, service *Services) error {
 	if _, err := service.Container.DisableDashboard(ctx, values.ProjectID, values.Zone, values.ClusterID); err != nil {
 		return err
 	}
+	// The update completes asynchronously so the requested state is recorded.
+	remediation.After = map[string]bool{"kubernetes_dashboard_disabled": true}
 	service.Logger.Info("successfully disabled dashboard from cluster %q in project %q", values.ClusterID, values.ProjectID)
 	return nil
 }
@@ -49,16 +49,25 @@ func TestDisableDashboard(t *testing.T) {
 			Zone:      "us-central1-a",
 			ClusterID: "test-cluster",
 		}
+		bqStub := &stubs.BigQueryStub{}
 		if err := Execute(ctx, values, &Services{
 			Container: svcs.Container,
 			Resource:  svcs.Resource,
 			Logger:    svcs.Logger,
+			Audit:     services.NewAudit(bqStub, "audit-project", "sra_audit", "remediations"),
 		}); err != nil {
 			t.Errorf("%s test failed want:%q", tt.name, err)
 		}
 		if diff := cmp.Diff(contStub.UpdatedAddonsConfig, tt.expectedRequest); diff != "" {
 			t.Errorf("%v failed\n exp:%v\n got:%v", tt.name, tt.expectedRequest, contStub.UpdatedAddonsConfig)
 		}
+		if len(bqStub.InsertedRows) != 1 {
+			t.Fatalf("%v failed, got %d audit records want 1", tt.name, len(bqStub.InsertedRows))
+		}
+		row := bqStub.InsertedRows[0].(*services.RemediationRow)
+		if row.Before != `{"kubernetes_dashboard_disabled":false}` || row.After != `{"kubernetes_dashboard_disabled":true}` {
+			t.Errorf("%v failed, unexpected audit record: %+v", tt.name, row)
+		}
 	}
 }
 
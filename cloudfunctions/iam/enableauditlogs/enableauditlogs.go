@@ -27,8 +27,10 @@ type Required struct {
 
 // Services contains the services needed for this function.
 type Services struct {
-	Resource *services.Resource
-	Logger   *services.Logger
+	Resource    *services.Resource
+	Logger      *services.Logger
+	Audit       *services.Audit
+	Idempotency *services.Idempotency
 }
 
 // Values contains the required values needed for this function.
@@ -38,14 +40,27 @@ type Values struct {
 }
 
 // Execute is the entry point for the Cloud Function to enable audit logs for a specific project.
-func Execute(ctx context.Context, values *Values, services *Services) error {
+func Execute(ctx context.Context, values *Values, services *Services) (err error) {
+	if !services.Idempotency.Begin(ctx, "enable_audit_logs") {
+		return nil
+	}
+	defer func() { services.Idempotency.End(ctx, "enable_audit_logs", err) }()
+	remediation := services.Audit.Remediation("enable_audit_logs", "//cloudresourcemanager.googleapis.com/projects/"+values.ProjectID, values.DryRun)
+	defer func() { services.Audit.Record(ctx, remediation, err) }()
+	configs, err := services.Resource.AuditConfigs(ctx, values.ProjectID)
+	if err != nil {
+		return err
+	}
+	remediation.Before = map[string]interface{}{"audit_configs": configs}
 	if values.DryRun {
 		services.Logger.Info("dry_run on, would have enabled data access audit logs in project %q", values.ProjectID)
 		return nil
 	}
-	if _, err := services.Resource.EnableAuditLogs(ctx, values.ProjectID); err != nil {
+	policy, err := services.Resource.EnableAuditLogs(ctx, values.ProjectID)
+	if err != nil {
 		return err
 	}
+	remediation.After = map[string]interface{}{"audit_configs": policy.AuditConfigs}
 	services.Logger.Info("audit logs was enabled on %q", values.ProjectID)
 	return nil
 }
@@ -29,20 +29,40 @@ type Values struct {
 
 // Services contains the services needed for this function.
 type Services struct {
-	Logger   *services.Logger
-	Resource *services.Resource
+	Logger      *services.Logger
+	Resource    *services.Resource
+	Audit       *services.Audit
+	Snapshots   *services.Snapshots
+	Idempotency *services.Idempotency
 }
 
 // Execute removes all users from a specific project not in allowed domain list.
-func Execute(ctx context.Context, values *Values, services *Services) error {
+func Execute(ctx context.Context, values *Values, services *Services) (err error) {
+	if !services.Idempotency.Begin(ctx, "remove_non_org_members") {
+		return nil
+	}
+	defer func() { services.Idempotency.End(ctx, "remove_non_org_members", err) }()
+	remediation := services.Audit.Remediation("remove_non_org_members", "//cloudresourcemanager.googleapis.com/projects/"+values.ProjectID, values.DryRun)
+	defer func() { services.Audit.Record(ctx, remediation, err) }()
 	if values.DryRun {
 		services.Logger.Info("dry run, would have removed users not from %q in %q", values.AllowDomains, values.ProjectID)
 		return nil
 	}
+	bindings, err := services.Resource.ProjectBindings(ctx, values.ProjectID)
+	if err != nil {
+		return err
+	}
+	remediation.Before = bindings
+	if err := services.Snapshots.Save(ctx, remediation, bindings); err != nil {
+		return err
+	}
 	removed, err := services.Resource.ProjectOnlyKeepUsersFromDomains(ctx, values.ProjectID, values.AllowDomains)
 	if err != nil {
 		return err
 	}
+	if remediation.After, err = services.Resource.ProjectBindings(ctx, values.ProjectID); err != nil {
+		return err
+	}
 	services.Logger.Info("successfully removed %q from %s", removed, values.ProjectID)
 	return nil
 }
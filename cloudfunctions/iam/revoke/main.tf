@@ -29,9 +29,16 @@ resource "google_cloudfunctions_function" "revoke_member_function" {
   event_trigger {
     event_type = "google.pubsub.topic.publish"
     resource   = "threat-findings-iam-revoke"
+    failure_policy {
+      retry = true
+    }
   }
   environment_variables = {
-    GCP_PROJECT = var.setup.automation-project
+    GCP_PROJECT            = var.setup.automation-project
+    AUDIT_DATASET          = var.setup.audit-dataset
+    AUDIT_TABLE            = var.setup.audit-table
+    IDEMPOTENCY_COLLECTION = var.setup.idempotency-collection
+    DEAD_LETTER_TOPIC      = var.setup.dead-letter-topic
   }
 }
 
@@ -34,8 +34,10 @@ type Values struct {
 
 // Services contains the services needed for this function.
 type Services struct {
-	Resource *services.Resource
-	Logger   *services.Logger
+	Resource    *services.Resource
+	Logger      *services.Logger
+	Audit       *services.Audit
+	Idempotency *services.Idempotency
 }
 
 // Execute is the entry point for the IAM revoker Cloud Function.
@@ -45,11 +47,20 @@ type Services struct {
 // - The project where the external users were found are within the set configured resources.
 // - The users do not match the list of allowed domains.
 //
-func Execute(ctx context.Context, values *Values, services *Services) error {
+func Execute(ctx context.Context, values *Values, services *Services) (err error) {
+	if !services.Idempotency.Begin(ctx, "iam_revoke") {
+		return nil
+	}
+	defer func() { services.Idempotency.End(ctx, "iam_revoke", err) }()
+	remediation := services.Audit.Remediation("iam_revoke", "//cloudresourcemanager.googleapis.com/projects/"+values.ProjectID, values.DryRun)
+	defer func() { services.Audit.Record(ctx, remediation, err) }()
 	members, err := toRemove(values.ExternalMembers, values.AllowDomains)
 	if err != nil {
 		return err
 	}
+	if remediation.Before, err = services.Resource.ProjectBindings(ctx, values.ProjectID); err != nil {
+		return err
+	}
 	if values.DryRun {
 		services.Logger.Info("dry_run on, would have removed %q from %q", members, values.ProjectID)
 		return nil
@@ -57,6 +68,9 @@ func Execute(ctx context.Context, values *Values, services *Services) error {
 	if err := services.Resource.RemoveUsersProject(ctx, values.ProjectID, members); err != nil {
 		return err
 	}
+	if remediation.After, err = services.Resource.ProjectBindings(ctx, values.ProjectID); err != nil {
+		return err
+	}
 	services.Logger.Info("successfully removed %q from %s", members, values.ProjectID)
 	return nil
 }
@@ -0,0 +1,108 @@
+package email
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+
+	"github.com/googlecloudplatform/security-response-automation/services"
+	"github.com/pkg/errors"
+)
+
+// DefaultTemplate is the template used when none is configured.
+const DefaultTemplate = "notify_email.tmpl"
+
+// Values contains the required values needed for this function.
+type Values struct {
+	ProjectID string
+	Rule      string
+	Finding   string
+	Actions   []Action
+	// Data is the finding as received by the router.
+	Data     json.RawMessage
+	To       []string
+	From     string
+	Subject  string
+	Template string
+	DryRun   bool
+}
+
+// Action is an automation the finding was dispatched to.
+type Action struct {
+	Action string
+	DryRun bool
+	// State is dispatched, pending approval or scheduled.
+	State string
+}
+
+// Details returns the finding as received by the router, indented for reading. Emails are sent
+// as plain text so it is not escaped by the HTML templates emails are rendered with.
+func (v *Values) Details() template.HTML {
+	var b bytes.Buffer
+	if err := json.Indent(&b, v.Data, "", "  "); err != nil {
+		return template.HTML(v.Data)
+	}
+	return template.HTML(b.String())
+}
+
+// Services contains the services needed for this function.
+type Services struct {
+	Email       *services.Email
+	Logger      *services.Logger
+	Audit       *services.Audit
+	Idempotency *services.Idempotency
+}
+
+// Execute sends an email summarizing the finding and the automations it was dispatched to.
+func Execute(ctx context.Context, values *Values, services *Services) (err error) {
+	if !services.Idempotency.Begin(ctx, "notify_email") {
+		return nil
+	}
+	defer func() { services.Idempotency.End(ctx, "notify_email", err) }()
+	remediation := services.Audit.Remediation("notify_email", fmt.Sprintf("//cloudresourcemanager.googleapis.com/projects/%s", values.ProjectID), values.DryRun)
+	defer func() { services.Audit.Record(ctx, remediation, err) }()
+	if len(values.To) == 0 {
+		return errors.New("no recipients to notify")
+	}
+	if services.Email == nil {
+		return errors.New("email is not configured")
+	}
+	template := values.Template
+	if template == "" {
+		template = DefaultTemplate
+	}
+	subject := values.Subject
+	if subject == "" {
+		subject = fmt.Sprintf("Security Response Automation: %s in %s", values.Rule, values.ProjectID)
+	}
+	body, err := services.Email.RenderTemplate(template, values)
+	if err != nil {
+		return errors.Wrapf(err, "failed to render template %q", template)
+	}
+	remediation.After = values.To
+	if values.DryRun {
+		services.Logger.Info("dry_run on, would have emailed %q about %q in project %q", values.To, values.Rule, values.ProjectID)
+		return nil
+	}
+	if _, err := services.Email.Send(subject, values.From, body, values.To); err != nil {
+		return errors.Wrapf(err, "failed to email %q", values.To)
+	}
+	services.Logger.Info("emailed %q about %q in project %q", values.To, values.Rule, values.ProjectID)
+	return nil
+}
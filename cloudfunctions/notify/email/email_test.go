@@ -0,0 +1,100 @@
+package email
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/googlecloudplatform/security-response-automation/clients"
+	"github.com/googlecloudplatform/security-response-automation/clients/stubs"
+	"github.com/googlecloudplatform/security-response-automation/services"
+	"github.com/sendgrid/rest"
+)
+
+func TestNotifyEmail(t *testing.T) {
+	ctx := context.Background()
+	const want = `Security Response Automation received a public_bucket_acl finding in project test-project.
+
+Finding: organizations/123/sources/456/findings/789
+
+Automations dispatched:
+  - close_bucket: pending approval
+  - enable_bucket_only_policy: dispatched (dry run, no changes will be made)
+  - close_public_dataset: scheduled
+
+Finding details:
+{
+  "finding": {
+    "state": "ACTIVE"
+  }
+}
+`
+	test := []struct {
+		name     string
+		values   *Values
+		wantSent bool
+		wantErr  bool
+	}{
+		{
+			name: "send",
+			values: &Values{
+				ProjectID: "test-project",
+				Rule:      "public_bucket_acl",
+				Finding:   "organizations/123/sources/456/findings/789",
+				Actions:   []Action{{Action: "close_bucket", State: "pending approval"}, {Action: "enable_bucket_only_policy", DryRun: true, State: "dispatched"}, {Action: "close_public_dataset", State: "scheduled"}},
+				Data:      json.RawMessage(`{"finding":{"state":"ACTIVE"}}`),
+				To:        []string{"owner@example.com"},
+				From:      "sra@example.com",
+			},
+			wantSent: true,
+		},
+		{
+			name:    "no recipients",
+			values:  &Values{ProjectID: "test-project", Rule: "public_bucket_acl"},
+			wantErr: true,
+		},
+		{
+			name:   "dry run",
+			values: &Values{ProjectID: "test-project", Rule: "public_bucket_acl", To: []string{"owner@example.com"}, DryRun: true},
+		},
+	}
+	for _, tt := range test {
+		t.Run(tt.name, func(t *testing.T) {
+			sgStub := &stubs.SendGridStub{StubbedSend: &rest.Response{StatusCode: 202}}
+			sg := &clients.SendGrid{Service: sgStub}
+			err := Execute(ctx, tt.values, &Services{
+				Email:  services.NewEmailWithTemplates(sg, "../../../templates"),
+				Logger: services.NewLogger(&stubs.LoggerStub{}),
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("%s failed, got err %v want err %t", tt.name, err, tt.wantErr)
+			}
+			if got := sgStub.SentMail != nil; got != tt.wantSent {
+				t.Fatalf("%s failed, got sent %t want %t", tt.name, got, tt.wantSent)
+			}
+			if !tt.wantSent {
+				return
+			}
+			if got := sgStub.SentMail.Subject; got != "Security Response Automation: public_bucket_acl in test-project" {
+				t.Errorf("%s failed, unexpected subject %q", tt.name, got)
+			}
+			if got := sgStub.SentMail.Content[0].Value; got != want {
+				t.Errorf("%s failed, got body:\n%s\nwant:\n%s", tt.name, got, want)
+			}
+		})
+	}
+}
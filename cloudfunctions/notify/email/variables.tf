@@ -0,0 +1,6 @@
+variable "setup" {}
+
+variable "sendgrid-api-key" {
+  type        = string
+  description = "SendGrid API key used to send notification emails."
+}
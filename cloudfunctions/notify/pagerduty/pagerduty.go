@@ -0,0 +1,125 @@
+package pagerduty
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/googlecloudplatform/security-response-automation/services"
+	"github.com/pkg/errors"
+)
+
+// Values contains the required values needed for this function.
+type Values struct {
+	ProjectID string
+	Rule      string
+	Finding   string
+	Severity  string
+	Actions   []Action
+	ServiceID string
+	From      string
+	Urgency   string
+	DedupKey  string
+	DryRun    bool
+}
+
+// Action is an automation the finding was dispatched to.
+type Action struct {
+	Action string
+	DryRun bool
+	// State is dispatched, pending approval or scheduled.
+	State string
+}
+
+// Services contains the services needed for this function.
+type Services struct {
+	PagerDuty   *services.PagerDuty
+	Logger      *services.Logger
+	Audit       *services.Audit
+	Idempotency *services.Idempotency
+}
+
+// Execute opens a PagerDuty incident for the finding, or updates the open incident for it.
+func Execute(ctx context.Context, values *Values, services *Services) (err error) {
+	if !services.Idempotency.Begin(ctx, "pagerduty_incident") {
+		return nil
+	}
+	defer func() { services.Idempotency.End(ctx, "pagerduty_incident", err) }()
+	remediation := services.Audit.Remediation("pagerduty_incident", fmt.Sprintf("//cloudresourcemanager.googleapis.com/projects/%s", values.ProjectID), values.DryRun)
+	defer func() { services.Audit.Record(ctx, remediation, err) }()
+	if values.ServiceID == "" {
+		return errors.New("no PagerDuty service ID configured")
+	}
+	if values.DedupKey == "" {
+		return errors.New("no deduplication key")
+	}
+	if services.PagerDuty == nil {
+		return errors.New("PagerDuty is not configured")
+	}
+	if values.DryRun {
+		services.Logger.Info("dry_run on, would have opened a %q urgency incident on service %q for %q", values.Urgency, values.ServiceID, values.DedupKey)
+		return nil
+	}
+	id, created, err := services.PagerDuty.TriggerIncident(ctx, values.From, values.ServiceID, title(values), body(values), values.DedupKey, values.Urgency)
+	if err != nil {
+		return err
+	}
+	remediation.After = map[string]interface{}{"incident": id, "created": created}
+	if !created {
+		services.Logger.Info("updated incident %q on service %q for %q", id, values.ServiceID, values.DedupKey)
+		return nil
+	}
+	services.Logger.Info("opened incident %q on service %q for %q", id, values.ServiceID, values.DedupKey)
+	return nil
+}
+
+// title returns the title of the incident.
+func title(values *Values) string {
+	t := fmt.Sprintf("Security Response Automation: %s in %s", values.Rule, values.ProjectID)
+	if values.Severity != "" {
+		t += fmt.Sprintf(" (%s)", values.Severity)
+	}
+	return t
+}
+
+// body returns a summary of the finding and how the automations it was dispatched to were
+// dispatched.
+func body(values *Values) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Rule: %s\nProject: %s\n", values.Rule, values.ProjectID)
+	if values.Finding != "" {
+		fmt.Fprintf(&b, "Finding: %s\n", values.Finding)
+	}
+	if values.Severity != "" {
+		fmt.Fprintf(&b, "Severity: %s\n", values.Severity)
+	}
+	b.WriteString("Automations dispatched:\n")
+	if len(values.Actions) == 0 {
+		b.WriteString("  none\n")
+	}
+	for _, a := range values.Actions {
+		fmt.Fprintf(&b, "  - %s", a.Action)
+		if a.State != "" {
+			fmt.Fprintf(&b, ": %s", a.State)
+		}
+		if a.DryRun {
+			b.WriteString(" (dry run, no changes will be made)")
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,84 @@
+package pagerduty
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/googlecloudplatform/security-response-automation/clients/stubs"
+	"github.com/googlecloudplatform/security-response-automation/services"
+)
+
+func TestPagerDutyIncident(t *testing.T) {
+	ctx := context.Background()
+	pdStub := &stubs.PagerDutyStub{}
+	svcs := &Services{
+		PagerDuty: services.NewPagerDuty(pdStub),
+		Logger:    services.NewLogger(&stubs.LoggerStub{}),
+	}
+	values := &Values{
+		ProjectID: "test-project",
+		Rule:      "bad_ip",
+		Finding:   "organizations/123/sources/456/findings/789",
+		Severity:  "HIGH",
+		Actions:   []Action{{Action: "gce_create_disk_snapshot", State: "pending approval"}},
+		ServiceID: "PSERVICE",
+		From:      "oncall@example.com",
+		Urgency:   "high",
+		DedupKey:  "organizations/123/sources/456/findings/789",
+	}
+	// The first notification opens an incident and later ones are added to it as notes.
+	for i := 0; i < 3; i++ {
+		if err := Execute(ctx, values, svcs); err != nil {
+			t.Fatalf("Execute() failed: %q", err)
+		}
+	}
+	if len(pdStub.Incidents) != 1 {
+		t.Fatalf("got %d incidents want 1", len(pdStub.Incidents))
+	}
+	incident := pdStub.Incidents[values.DedupKey]
+	if incident == nil {
+		t.Fatalf("no incident opened for %q", values.DedupKey)
+	}
+	if incident.Urgency != "high" || incident.Service.ID != "PSERVICE" {
+		t.Errorf("unexpected incident: %+v", incident)
+	}
+	if want := "Security Response Automation: bad_ip in test-project (HIGH)"; incident.Title != want {
+		t.Errorf("got title %q want %q", incident.Title, want)
+	}
+	notes := pdStub.Notes[incident.ID]
+	if len(notes) != 2 {
+		t.Fatalf("got %d notes want 2", len(notes))
+	}
+	if want := "Automations dispatched:\n  - gce_create_disk_snapshot: pending approval\n"; !strings.Contains(notes[0], want) {
+		t.Errorf("got note %q want it to contain %q", notes[0], want)
+	}
+}
+
+func TestPagerDutyIncidentDryRun(t *testing.T) {
+	ctx := context.Background()
+	pdStub := &stubs.PagerDutyStub{}
+	if err := Execute(ctx, &Values{ProjectID: "test-project", ServiceID: "PSERVICE", DedupKey: "key", DryRun: true}, &Services{
+		PagerDuty: services.NewPagerDuty(pdStub),
+		Logger:    services.NewLogger(&stubs.LoggerStub{}),
+	}); err != nil {
+		t.Fatalf("Execute() failed: %q", err)
+	}
+	if len(pdStub.Incidents) != 0 {
+		t.Errorf("dry run opened %d incidents", len(pdStub.Incidents))
+	}
+}
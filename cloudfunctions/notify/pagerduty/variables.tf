@@ -0,0 +1,6 @@
+variable "setup" {}
+
+variable "pagerduty-api-key" {
+  type        = string
+  description = "PagerDuty REST API key used to open incidents."
+}
@@ -0,0 +1,11 @@
+variable "setup" {}
+
+variable "webhook-secret" {
+  type        = string
+  description = "Secret used to sign webhook payloads. Payloads are not signed if empty."
+}
+
+variable "webhook-urls" {
+  type        = map(string)
+  description = "Webhook URLs keyed by webhook name."
+}
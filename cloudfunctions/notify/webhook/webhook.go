@@ -0,0 +1,141 @@
+package webhook
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/googlecloudplatform/security-response-automation/services"
+	"github.com/pkg/errors"
+)
+
+// DefaultTemplate is the template used when neither a body nor a template is configured. It
+// renders a payload accepted by both Slack and Microsoft Teams incoming webhooks.
+const DefaultTemplate = "notify_webhook.tmpl"
+
+// Values contains the required values needed for this function.
+type Values struct {
+	ProjectID string
+	Rule      string
+	Finding   string
+	Severity  string
+	Actions   []Action
+	Data      json.RawMessage
+	// Webhook is the name of the webhook to post to. Its URL is only known to the webhook service.
+	Webhook  string
+	Body     string
+	Template string
+	DryRun   bool
+}
+
+// Action is an automation the finding was dispatched to.
+type Action struct {
+	Action string
+	DryRun bool
+	// State is dispatched, pending approval or scheduled.
+	State string
+}
+
+// Services contains the services needed for this function.
+type Services struct {
+	Webhook     *services.Webhook
+	Logger      *services.Logger
+	Audit       *services.Audit
+	Idempotency *services.Idempotency
+}
+
+// Payload is the data webhook templates are executed with.
+type Payload struct {
+	*Values
+	// Finding holds the parsed finding as received by the router.
+	Finding interface{}
+	// FindingName is the Security Command Center name of the finding, if it has one.
+	FindingName string
+}
+
+// Summary returns a plain text summary of the finding and the automations it was dispatched to.
+// The router only knows whether an automation was dispatched, parked until approved or queued
+// until its maintenance window, not whether it succeeded once run.
+func (p *Payload) Summary() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Security Response Automation received a %s finding in project %s.", p.Rule, p.ProjectID)
+	if p.Severity != "" {
+		fmt.Fprintf(&b, " Severity: %s.", p.Severity)
+	}
+	actions := make([]string, 0, len(p.Actions))
+	for _, a := range p.Actions {
+		var notes []string
+		if a.State != "" {
+			notes = append(notes, a.State)
+		}
+		if a.DryRun {
+			notes = append(notes, "dry run")
+		}
+		if len(notes) == 0 {
+			actions = append(actions, a.Action)
+			continue
+		}
+		actions = append(actions, fmt.Sprintf("%s (%s)", a.Action, strings.Join(notes, ", ")))
+	}
+	if len(actions) == 0 {
+		actions = append(actions, "none")
+	}
+	fmt.Fprintf(&b, " Automations dispatched: %s.", strings.Join(actions, ", "))
+	return b.String()
+}
+
+// Execute posts a templated JSON payload describing the finding to a webhook.
+func Execute(ctx context.Context, values *Values, services *Services) (err error) {
+	if !services.Idempotency.Begin(ctx, "notify_webhook") {
+		return nil
+	}
+	defer func() { services.Idempotency.End(ctx, "notify_webhook", err) }()
+	remediation := services.Audit.Remediation("notify_webhook", fmt.Sprintf("//cloudresourcemanager.googleapis.com/projects/%s", values.ProjectID), values.DryRun)
+	defer func() { services.Audit.Record(ctx, remediation, err) }()
+	if values.Webhook == "" {
+		return errors.New("no webhook configured")
+	}
+	payload := &Payload{Values: values, FindingName: values.Finding}
+	if len(values.Data) > 0 {
+		if err := json.Unmarshal(values.Data, &payload.Finding); err != nil {
+			return errors.Wrap(err, "failed to unmarshal finding")
+		}
+	}
+	var body []byte
+	switch {
+	case values.Body != "":
+		body, err = services.Webhook.Render(values.Body, payload)
+	case values.Template != "":
+		body, err = services.Webhook.RenderTemplate(values.Template, payload)
+	default:
+		body, err = services.Webhook.RenderTemplate(DefaultTemplate, payload)
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to render payload")
+	}
+	remediation.After = map[string]string{"webhook": values.Webhook}
+	if values.DryRun {
+		services.Logger.Info("dry_run on, would have posted %s to webhook %q", body, values.Webhook)
+		return nil
+	}
+	if err := services.Webhook.Post(ctx, values.Webhook, body); err != nil {
+		return errors.Wrapf(err, "failed to post to webhook %q", values.Webhook)
+	}
+	services.Logger.Info("posted notification about %q in project %q to webhook %q", values.Rule, values.ProjectID, values.Webhook)
+	return nil
+}
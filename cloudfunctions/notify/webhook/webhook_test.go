@@ -0,0 +1,78 @@
+package webhook
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/googlecloudplatform/security-response-automation/clients/stubs"
+	"github.com/googlecloudplatform/security-response-automation/services"
+)
+
+func TestNotifyWebhook(t *testing.T) {
+	ctx := context.Background()
+	finding := []byte(`{"finding": {"category": "PUBLIC_BUCKET_ACL", "resourceName": "//storage.googleapis.com/bucket"}}`)
+	for _, tt := range []struct {
+		name   string
+		values *Values
+		want   string
+	}{
+		{
+			name: "default template",
+			values: &Values{
+				ProjectID: "test-project",
+				Rule:      "public_bucket_acl",
+				Severity:  "HIGH",
+				Actions:   []Action{{Action: "close_bucket", State: "pending approval"}, {Action: "enable_bucket_only_policy", DryRun: true, State: "dispatched"}},
+			},
+			want: `{"text": "Security Response Automation received a public_bucket_acl finding in project test-project. Severity: HIGH. Automations dispatched: close_bucket (pending approval), enable_bucket_only_policy (dispatched, dry run)."}` + "\n",
+		},
+		{
+			name: "inline body over the parsed finding",
+			values: &Values{
+				ProjectID: "test-project",
+				Rule:      "public_bucket_acl",
+				Finding:   "organizations/1/sources/2/findings/3",
+				Data:      finding,
+				Body:      `{"resource": {{json .Finding.finding.resourceName}}, "name": {{json .FindingName}}}`,
+			},
+			want: `{"resource": "//storage.googleapis.com/bucket", "name": "organizations/1/sources/2/findings/3"}`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+				b, _ := ioutil.ReadAll(r.Body)
+				got = string(b)
+			}))
+			defer srv.Close()
+			tt.values.Webhook = "secops"
+			urls := map[string]string{"secops": srv.URL + "/services/T000/B000/XXXX"}
+			if err := Execute(ctx, tt.values, &Services{
+				Webhook: services.NewWebhook(srv.Client(), "../../../templates", nil, urls),
+				Logger:  services.NewLogger(&stubs.LoggerStub{}),
+			}); err != nil {
+				t.Fatalf("%s failed: %q", tt.name, err)
+			}
+			if got != tt.want {
+				t.Errorf("%s failed, got body:\n%s\nwant:\n%s", tt.name, got, tt.want)
+			}
+		})
+	}
+}
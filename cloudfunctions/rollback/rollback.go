@@ -0,0 +1,165 @@
+package rollback
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/gce/quarantine"
+	"github.com/googlecloudplatform/security-response-automation/services"
+	"github.com/pkg/errors"
+	compute "google.golang.org/api/compute/v1"
+)
+
+var (
+	// extractBucket is used to extract the bucket name from a remediated resource.
+	extractBucket = regexp.MustCompile(`^//storage\.googleapis\.com/(.+)$`)
+	// extractProject is used to extract the project ID from a remediated resource.
+	extractProject = regexp.MustCompile(`^//[^/]+/projects/([^/]+)`)
+	// extractInstance is used to extract the zone and instance name from a remediated resource.
+	extractInstance = regexp.MustCompile(`/zones/([^/]+)/instances/([^/]+)$`)
+)
+
+// Values contains the required values needed for this function.
+type Values struct {
+	RemediationID string
+	DryRun        bool
+}
+
+// Services contains the services needed for this function.
+type Services struct {
+	Firewall  *services.Firewall
+	Host      *services.Host
+	Resource  *services.Resource
+	Snapshots *services.Snapshots
+	Logger    *services.Logger
+	Audit     *services.Audit
+}
+
+// Execute restores the state of the resource changed by the given remediation.
+func Execute(ctx context.Context, values *Values, services *Services) (err error) {
+	snapshot, err := services.Snapshots.Load(ctx, values.RemediationID)
+	if err != nil {
+		return err
+	}
+	remediation := services.Audit.Remediation("rollback", snapshot.Resource, values.DryRun)
+	remediation.After = snapshot
+	defer func() { services.Audit.Record(ctx, remediation, err) }()
+	if values.DryRun {
+		services.Logger.Info("dry_run on, would have rolled back %q on %q by remediation %q", snapshot.Action, snapshot.Resource, values.RemediationID)
+		return nil
+	}
+	if err := restore(ctx, snapshot, services); err != nil {
+		return errors.Wrapf(err, "failed to roll back remediation %q", values.RemediationID)
+	}
+	services.Logger.Info("rolled back %q on %q by remediation %q", snapshot.Action, snapshot.Resource, values.RemediationID)
+	return nil
+}
+
+// restore applies the snapshot to the resource it was taken from.
+func restore(ctx context.Context, snapshot *services.Snapshot, svcs *Services) error {
+	switch snapshot.Action {
+	case "close_bucket":
+		var bindings services.Bindings
+		if err := snapshot.Decode(&bindings); err != nil {
+			return err
+		}
+		bucket, err := match(extractBucket, snapshot.Resource)
+		if err != nil {
+			return err
+		}
+		return svcs.Resource.RestoreBucketBindings(ctx, bucket[0], bindings)
+	case "remove_non_org_members":
+		var bindings services.Bindings
+		if err := snapshot.Decode(&bindings); err != nil {
+			return err
+		}
+		project, err := match(extractProject, snapshot.Resource)
+		if err != nil {
+			return err
+		}
+		return svcs.Resource.RestoreProjectBindings(ctx, project[0], bindings)
+	case "remediate_firewall":
+		var rule compute.Firewall
+		if err := snapshot.Decode(&rule); err != nil {
+			return err
+		}
+		project, err := match(extractProject, snapshot.Resource)
+		if err != nil {
+			return err
+		}
+		return svcs.Firewall.RestoreFirewallRule(ctx, project[0], &rule)
+	case "remove_public_ip":
+		var configs []services.AccessConfig
+		if err := snapshot.Decode(&configs); err != nil {
+			return err
+		}
+		project, err := match(extractProject, snapshot.Resource)
+		if err != nil {
+			return err
+		}
+		instance, err := match(extractInstance, snapshot.Resource)
+		if err != nil {
+			return err
+		}
+		return svcs.Host.RestoreAccessConfigs(ctx, project[0], instance[0], instance[1], configs)
+	case "gce_quarantine_instance":
+		var tags []string
+		if err := snapshot.Decode(&tags); err != nil {
+			return err
+		}
+		project, err := match(extractProject, snapshot.Resource)
+		if err != nil {
+			return err
+		}
+		instance, err := match(extractInstance, snapshot.Resource)
+		if err != nil {
+			return err
+		}
+		return restoreTags(ctx, svcs.Host, project[0], instance[0], instance[1], tags)
+	default:
+		return fmt.Errorf("action %q can not be rolled back", snapshot.Action)
+	}
+}
+
+// restoreTags restores the network tags of a quarantined instance and removes the record of its
+// original tags. The tags recorded on the instance are preferred over the snapshot's, since a
+// repeated quarantine snapshots the tags of the first. The quarantine firewall rules only apply
+// to the quarantine tag and are left in place, and a stopped instance is not started.
+func restoreTags(ctx context.Context, host *services.Host, project, zone, name string, tags []string) error {
+	instance, err := host.Instance(ctx, project, zone, name)
+	if err != nil {
+		return err
+	}
+	if original, ok := quarantine.OriginalTags(instance); ok {
+		tags = original
+	}
+	if err := host.SetInstanceTags(ctx, project, zone, instance, tags); err != nil {
+		return err
+	}
+	// Setting tags does not change the metadata fingerprint, so the instance is still current.
+	return host.RemoveInstanceMetadata(ctx, project, zone, instance, quarantine.OriginalTagsKey)
+}
+
+// match returns the submatches of the expression in the resource.
+func match(re *regexp.Regexp, resource string) ([]string, error) {
+	m := re.FindStringSubmatch(resource)
+	if m == nil {
+		return nil, fmt.Errorf("unexpected resource %q", resource)
+	}
+	return m[1:], nil
+}
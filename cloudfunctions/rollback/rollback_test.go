@@ -0,0 +1,159 @@
+package rollback
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/iam"
+	"github.com/google/go-cmp/cmp"
+	"github.com/googlecloudplatform/security-response-automation/clients/stubs"
+	"github.com/googlecloudplatform/security-response-automation/services"
+	compute "google.golang.org/api/compute/v1"
+)
+
+func TestRollbackBucket(t *testing.T) {
+	ctx := context.Background()
+	storageStub := &stubs.StorageStub{BucketPolicyResponse: &iam.Policy{}}
+	storageStub.BucketPolicyResponse.Add("member:tom@tom.com", "project/viewer")
+	snapshots := services.NewSnapshots(storageStub, "snapshot-bucket")
+	remediation := &services.Remediation{ID: "abc", Action: "close_bucket", Resource: "//storage.googleapis.com/open-bucket-name"}
+	if err := snapshots.Save(ctx, remediation, services.Bindings{"project/viewer": {"allUsers", "member:tom@tom.com"}}); err != nil {
+		t.Fatalf("failed to save snapshot: %q", err)
+	}
+	bqStub := &stubs.BigQueryStub{}
+	if err := Execute(ctx, &Values{RemediationID: "abc"}, &Services{
+		Resource:  services.NewResource(&stubs.ResourceManagerStub{}, storageStub),
+		Snapshots: snapshots,
+		Logger:    services.NewLogger(&stubs.LoggerStub{}),
+		Audit:     services.NewAudit(bqStub, "audit-project", "sra_audit", "remediations"),
+	}); err != nil {
+		t.Fatalf("rollback failed: %q", err)
+	}
+	want := []string{"member:tom@tom.com", "allUsers"}
+	if diff := cmp.Diff(want, storageStub.BucketPolicyResponse.Members("project/viewer")); diff != "" {
+		t.Errorf("unexpected members (-want +got):\n%s", diff)
+	}
+	if len(bqStub.InsertedRows) != 1 {
+		t.Fatalf("got %d audit records want 1", len(bqStub.InsertedRows))
+	}
+	row := bqStub.InsertedRows[0].(*services.RemediationRow)
+	if row.Action != "rollback" || row.Resource != remediation.Resource || row.Outcome != services.OutcomeSuccess {
+		t.Errorf("unexpected audit record: %+v", row)
+	}
+}
+
+func TestRollbackFirewall(t *testing.T) {
+	ctx := context.Background()
+	computeStub := &stubs.ComputeStub{}
+	snapshots := services.NewSnapshots(&stubs.StorageStub{}, "snapshot-bucket")
+	rule := &compute.Firewall{Name: "open-ssh", Disabled: false, SourceRanges: []string{"0.0.0.0/0"}}
+	remediation := &services.Remediation{ID: "def", Action: "remediate_firewall", Resource: "//compute.googleapis.com/projects/sec-proj/global/firewalls/123"}
+	if err := snapshots.Save(ctx, remediation, rule); err != nil {
+		t.Fatalf("failed to save snapshot: %q", err)
+	}
+	if err := Execute(ctx, &Values{RemediationID: "def"}, &Services{
+		Firewall:  services.NewFirewall(computeStub),
+		Snapshots: snapshots,
+		Logger:    services.NewLogger(&stubs.LoggerStub{}),
+	}); err != nil {
+		t.Fatalf("rollback failed: %q", err)
+	}
+	got := computeStub.SavedFirewallRule
+	if got == nil || got.Disabled || !cmp.Equal(got.SourceRanges, rule.SourceRanges) {
+		t.Errorf("unexpected restored rule: %+v", got)
+	}
+}
+
+func TestRollbackFirewallSourceTags(t *testing.T) {
+	ctx := context.Background()
+	computeStub := &stubs.ComputeStub{}
+	snapshots := services.NewSnapshots(&stubs.StorageStub{}, "snapshot-bucket")
+	rule := &compute.Firewall{Name: "bastion-ssh", SourceTags: []string{"bastion"}}
+	remediation := &services.Remediation{ID: "def", Action: "remediate_firewall", Resource: "//compute.googleapis.com/projects/sec-proj/global/firewalls/123"}
+	if err := snapshots.Save(ctx, remediation, rule); err != nil {
+		t.Fatalf("failed to save snapshot: %q", err)
+	}
+	if err := Execute(ctx, &Values{RemediationID: "def"}, &Services{
+		Firewall:  services.NewFirewall(computeStub),
+		Snapshots: snapshots,
+		Logger:    services.NewLogger(&stubs.LoggerStub{}),
+	}); err != nil {
+		t.Fatalf("rollback failed: %q", err)
+	}
+	b, err := json.Marshal(computeStub.SavedFirewallRule)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"sourceRanges":[]`, `"sourceTags":["bastion"]`} {
+		if !strings.Contains(string(b), want) {
+			t.Errorf("restored rule %s does not contain %s", b, want)
+		}
+	}
+}
+
+func TestRollbackQuarantine(t *testing.T) {
+	ctx := context.Background()
+	original := "http-server,ssh"
+	computeStub := &stubs.ComputeStub{
+		StubbedInstance: &compute.Instance{
+			Name:     "instance-name",
+			Tags:     &compute.Tags{Items: []string{"sra-quarantine"}, Fingerprint: "tags-fp"},
+			Metadata: &compute.Metadata{Fingerprint: "md-fp", Items: []*compute.MetadataItems{{Key: "sra-quarantine-original-tags", Value: &original}}},
+		},
+	}
+	snapshots := services.NewSnapshots(&stubs.StorageStub{}, "snapshot-bucket")
+	remediation := &services.Remediation{ID: "jkl", Action: "gce_quarantine_instance", Resource: "//compute.googleapis.com/projects/p/zones/z/instances/instance-name"}
+	if err := snapshots.Save(ctx, remediation, []string{"ssh"}); err != nil {
+		t.Fatalf("failed to save snapshot: %q", err)
+	}
+	if err := Execute(ctx, &Values{RemediationID: "jkl"}, &Services{
+		Host:      services.NewHost(computeStub),
+		Snapshots: snapshots,
+		Logger:    services.NewLogger(&stubs.LoggerStub{}),
+	}); err != nil {
+		t.Fatalf("rollback failed: %q", err)
+	}
+	if diff := cmp.Diff(&compute.Tags{Items: []string{"http-server", "ssh"}, Fingerprint: "tags-fp"}, computeStub.SavedTags); diff != "" {
+		t.Errorf("restored tags difference: %+v", diff)
+	}
+	if diff := cmp.Diff(&compute.Metadata{Fingerprint: "md-fp"}, computeStub.SavedMetadata); diff != "" {
+		t.Errorf("metadata difference: %+v", diff)
+	}
+}
+
+func TestRollbackUnknown(t *testing.T) {
+	ctx := context.Background()
+	snapshots := services.NewSnapshots(&stubs.StorageStub{}, "snapshot-bucket")
+	if err := Execute(ctx, &Values{RemediationID: "missing"}, &Services{
+		Snapshots: snapshots,
+		Logger:    services.NewLogger(&stubs.LoggerStub{}),
+	}); err == nil {
+		t.Errorf("expected an error for a missing snapshot")
+	}
+	remediation := &services.Remediation{ID: "ghi", Action: "gce_create_disk_snapshot", Resource: "//compute.googleapis.com/projects/p/zones/z/instances/i"}
+	if err := snapshots.Save(ctx, remediation, nil); err != nil {
+		t.Fatalf("failed to save snapshot: %q", err)
+	}
+	if err := Execute(ctx, &Values{RemediationID: "ghi"}, &Services{
+		Snapshots: snapshots,
+		Logger:    services.NewLogger(&stubs.LoggerStub{}),
+	}); err == nil {
+		t.Errorf("expected an error for an action that can not be rolled back")
+	}
+}
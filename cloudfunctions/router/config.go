@@ -0,0 +1,124 @@
+package router
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/googlecloudplatform/security-response-automation/clients"
+	"github.com/pkg/errors"
+)
+
+// ConfigClient contains minimum interface required to load the configuration from Cloud Storage.
+type ConfigClient interface {
+	ObjectGeneration(ctx context.Context, bucketName, name string) (int64, error)
+	ReadObjectGeneration(ctx context.Context, bucketName, name string, generation int64) ([]byte, error)
+}
+
+// ConfigLoader loads the router's configuration from a Cloud Storage object.
+//
+// The configuration is cached and only re-read when the object's generation changes, so updating
+// the object takes effect on the next finding without redeploying the function. If the object
+// cannot be read or holds an invalid configuration the last good configuration is used, or the
+// configuration bundled with the function if none was loaded yet.
+//
+// A nil *ConfigLoader is valid and always returns the bundled configuration.
+type ConfigLoader struct {
+	client   ConfigClient
+	bucket   string
+	object   string
+	fallback func() (*Configuration, error)
+
+	mu         sync.Mutex
+	generation int64
+	config     *Configuration
+	// rejected is the last generation found to be invalid so it is not read again.
+	rejected int64
+}
+
+// NewConfigLoader returns a loader reading the configuration from the given object.
+func NewConfigLoader(client ConfigClient, bucket, object string) *ConfigLoader {
+	return &ConfigLoader{client: client, bucket: bucket, object: object, fallback: Config}
+}
+
+// InitConfigLoader creates and initializes a new loader reading the configuration from the given object.
+func InitConfigLoader(ctx context.Context, bucket, object string) (*ConfigLoader, error) {
+	stg, err := clients.NewStorage(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize storage client: %q", err)
+	}
+	return NewConfigLoader(stg, bucket, object), nil
+}
+
+// Load returns the current configuration.
+func (l *ConfigLoader) Load(ctx context.Context) (*Configuration, error) {
+	if l == nil {
+		return Config()
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	c, generation, err := l.read(ctx)
+	switch {
+	case err == nil:
+		if generation != l.generation {
+			log.Printf("loaded configuration from gs://%s/%s generation %d", l.bucket, l.object, generation)
+		}
+		l.config, l.generation = c, generation
+		return c, nil
+	case l.config != nil:
+		log.Printf("using configuration generation %d: %q", l.generation, err)
+		return l.config, nil
+	default:
+		log.Printf("using bundled configuration: %q", err)
+		return l.fallback()
+	}
+}
+
+// read returns the configuration held by the object along with its generation. The object is
+// only read if its generation differs from the cached configuration's.
+func (l *ConfigLoader) read(ctx context.Context) (*Configuration, int64, error) {
+	generation, err := l.client.ObjectGeneration(ctx, l.bucket, l.object)
+	if err != nil {
+		return nil, 0, errors.Wrapf(err, "failed to get generation of gs://%s/%s", l.bucket, l.object)
+	}
+	if l.config != nil && generation == l.generation {
+		return l.config, generation, nil
+	}
+	if generation == l.rejected {
+		return nil, 0, fmt.Errorf("gs://%s/%s generation %d was previously rejected", l.bucket, l.object, generation)
+	}
+	b, err := l.client.ReadObjectGeneration(ctx, l.bucket, l.object, generation)
+	if err != nil {
+		return nil, 0, errors.Wrapf(err, "failed to read gs://%s/%s", l.bucket, l.object)
+	}
+	c, err := ParseConfig(b)
+	if err != nil {
+		l.rejected = generation
+		return nil, 0, errors.Wrapf(err, "gs://%s/%s generation %d", l.bucket, l.object, generation)
+	}
+	if errs := c.Validate(); len(errs) > 0 {
+		l.rejected = generation
+		msgs := make([]string, 0, len(errs))
+		for _, err := range errs {
+			msgs = append(msgs, err.Error())
+		}
+		return nil, 0, fmt.Errorf("gs://%s/%s generation %d is invalid: %s", l.bucket, l.object, generation, strings.Join(msgs, "; "))
+	}
+	return c, generation, nil
+}
@@ -0,0 +1,99 @@
+package router
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/googlecloudplatform/security-response-automation/clients/stubs"
+)
+
+const (
+	liveConfig = `
+apiVersion: security-response-automation.cloud.google.com/v1alpha1
+kind: Remediation
+metadata:
+  name: router
+spec:
+  parameters:
+    etd:
+      bad_ip:
+      - action: gce_quarantine_instance
+        target:
+        - organizations/1037840971520/folders/*
+        properties:
+          dry_run: %s
+`
+	invalidConfig = `
+spec:
+  parameters:
+    etd:
+      bad_ip:
+      - action: gce_quarantine_instance
+        properties:
+          dryrun: true
+`
+)
+
+func TestConfigLoader(t *testing.T) {
+	ctx := context.Background()
+	bundled := &Configuration{}
+	bundled.Metadata.Name = "bundled"
+	stg := &stubs.StorageStub{}
+	loader := NewConfigLoader(stg, "config-bucket", "sra.yaml")
+	loader.fallback = func() (*Configuration, error) { return bundled, nil }
+
+	dryRun := func() interface{} {
+		c, err := loader.Load(ctx)
+		if err != nil {
+			t.Fatalf("Load() failed: %q", err)
+		}
+		if c == bundled {
+			return "bundled"
+		}
+		return c.Automations("etd", "bad_ip")[0].Properties.DryRun
+	}
+	write := func(config string) {
+		if err := stg.WriteObject(ctx, "config-bucket", "sra.yaml", []byte(config)); err != nil {
+			t.Fatalf("WriteObject() failed: %q", err)
+		}
+	}
+
+	if got := dryRun(); got != "bundled" {
+		t.Errorf("missing object: got %v want bundled configuration", got)
+	}
+	write(fmt.Sprintf(liveConfig, "true"))
+	if got := dryRun(); got != true {
+		t.Errorf("first generation: got dry_run %v want true", got)
+	}
+	// Changing the object without a new generation is not picked up.
+	stg.Objects["config-bucket"]["sra.yaml"] = []byte(fmt.Sprintf(liveConfig, "false"))
+	if got := dryRun(); got != true {
+		t.Errorf("cached generation: got dry_run %v want true", got)
+	}
+	write(fmt.Sprintf(liveConfig, "false"))
+	if got := dryRun(); got != false {
+		t.Errorf("new generation: got dry_run %v want false", got)
+	}
+	write(invalidConfig)
+	if got := dryRun(); got != false {
+		t.Errorf("invalid generation: got dry_run %v want last good value false", got)
+	}
+	if loader.rejected != stg.Generations["config-bucket"]["sra.yaml"] {
+		t.Errorf("invalid generation was not rejected")
+	}
+}
@@ -0,0 +1,156 @@
+package router
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/googlecloudplatform/security-response-automation/providers/registry"
+	"github.com/googlecloudplatform/security-response-automation/services"
+	"github.com/pkg/errors"
+)
+
+// Plan describes how Execute would route a finding.
+type Plan struct {
+	// Rule is the name of the rule the finding matched.
+	Rule string
+	// Remediated is true if this event of the finding was already remediated, Execute skips it.
+	Remediated bool
+	// Automations are the automations configured for the finding, in configuration order.
+	Automations []PlannedAutomation
+}
+
+// PlannedAutomation is an automation configured for a finding.
+type PlannedAutomation struct {
+	Action string
+	// Topic is the topic the automation would be published to. Automations awaiting approval or
+	// a maintenance window are published to the approval or schedule topic with their own topic
+	// and values wrapped.
+	Topic     string
+	ProjectID string
+	// Values is the payload the automation would be published with.
+	Values interface{}
+	// DryRunValues is the automation's payload with dry_run on, to try it without making
+	// changes. It is nil for notifications and automations already configured with dry_run.
+	DryRunValues interface{}
+	// Checked is true if the project was checked against the automation's target and exclude
+	// patterns, Matches holding the result.
+	Checked bool
+	Matches bool
+	// Limits are the keys of the configured limits the automation would be counted against.
+	Limits []string
+	// Err is the reason the automation would not be published, if any.
+	Err error
+}
+
+// Explain returns how Execute would route the finding with the given configuration, without
+// publishing anything or marking the finding. Automations are routed as configured, along with
+// the payload they would have with dry_run on. Limits are reported but not counted and previous
+// deliveries of the finding are not looked up. Projects are only checked against the target and
+// exclude patterns if resource is not nil.
+func Explain(ctx context.Context, b []byte, conf *Configuration, resource *services.Resource) (*Plan, error) {
+	f, err := parse(b)
+	if err != nil {
+		return nil, err
+	}
+	automations := conf.Automations(f.rule.Provider, f.rule.Key)
+	plan := &Plan{
+		Rule:        f.name,
+		Remediated:  f.remediated(),
+		Automations: make([]PlannedAutomation, len(automations)),
+	}
+	for i, automation := range automations {
+		plan.Automations[i].Action = automation.Action
+		plan.Automations[i].Topic, _ = registry.Topic(automation.Action)
+	}
+	if err := walk(ctx, f, automations, &explanation{plan: plan, conf: conf, resource: resource}); err != nil {
+		return nil, err
+	}
+	for i, automation := range automations {
+		p := &plan.Automations[i]
+		if _, ok := registry.LookupNotifier(automation.Action); ok || p.Values == nil || automation.Properties.DryRun {
+			continue
+		}
+		automation.Properties.DryRun = true
+		if _, values, err := f.finding.Values(&automation); err == nil {
+			p.DryRunValues = values
+		}
+	}
+	return plan, nil
+}
+
+// explanation records how automations would be dispatched into a plan.
+type explanation struct {
+	plan     *Plan
+	conf     *Configuration
+	resource *services.Resource
+}
+
+func (e *explanation) skip(i int, automation *Automation, err error) error {
+	if u, ok := err.(unroutable); ok {
+		err = u.error
+	}
+	e.plan.Automations[i].Err = err
+	return nil
+}
+
+func (e *explanation) claim(ctx context.Context, i int, automation *Automation, findingName, eventTime string) (bool, func(error)) {
+	return true, func(error) {}
+}
+
+func (e *explanation) inTarget(ctx context.Context, i int, automation *Automation, projectID string) error {
+	p := &e.plan.Automations[i]
+	p.ProjectID = projectID
+	if e.resource == nil {
+		return nil
+	}
+	ok, err := e.resource.CheckMatches(ctx, projectID, automation.Target, automation.Exclude)
+	if err != nil {
+		return errors.Wrapf(err, "failed to check if project %q is within the target or is excluded", projectID)
+	}
+	p.Checked, p.Matches = true, ok
+	if !ok {
+		return fmt.Errorf("project %q is not within the target or is excluded", projectID)
+	}
+	return nil
+}
+
+func (e *explanation) throttle(ctx context.Context, i int, automation *Automation, projectID string, attributes map[string]string) bool {
+	e.limits(i, automation, projectID)
+	return true
+}
+
+func (e *explanation) limits(i int, automation *Automation, projectID string) []services.Limit {
+	p := &e.plan.Automations[i]
+	var limits []services.Limit
+	for _, l := range e.conf.Spec.Limits {
+		if !l.Matches(automation.Action) {
+			continue
+		}
+		// Invalid limits are ignored, as throttle does.
+		if limit, err := l.limit(projectID); err == nil {
+			p.Limits = append(p.Limits, limit.Key)
+			limits = append(limits, limit)
+		}
+	}
+	return limits
+}
+
+func (e *explanation) publish(ctx context.Context, i int, automation *Automation, topic, projectID string, values interface{}, attributes map[string]string) error {
+	p := &e.plan.Automations[i]
+	p.Topic, p.ProjectID, p.Values = topic, projectID, values
+	return nil
+}
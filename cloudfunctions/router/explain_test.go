@@ -0,0 +1,83 @@
+package router
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/googlecloudplatform/security-response-automation/clients/stubs"
+	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/approval"
+	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/gcs/closebucket"
+	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/notify/email"
+	"github.com/googlecloudplatform/security-response-automation/providers/registry"
+	"github.com/googlecloudplatform/security-response-automation/services"
+)
+
+func TestExplain(t *testing.T) {
+	ctx := context.Background()
+	closeBucket := Automation{Action: "close_bucket", Target: []string{"organizations/456/folders/123/projects/test-project"}}
+	excluded := Automation{Action: "close_bucket", Target: []string{"organizations/456/*"}, Exclude: []string{"organizations/456/folders/123/*"}}
+	notify := Automation{Action: "notify_email", Target: []string{"organizations/456/*"}}
+	notify.Properties.NotifyEmail.To = []string{"owner@example.com"}
+	approved := closeBucket
+	approved.Properties.Approval.Email = []string{"approver@example.com"}
+	conf := &Configuration{}
+	conf.Spec.Parameters = map[string]map[string][]Automation{"sha": {"public_bucket_acl": {notify, closeBucket, excluded, approved}}}
+	conf.Spec.Limits = []Limit{{Action: "close_bucket", Max: 1, Window: "1h"}, {Action: "close_firewall", Max: 1, Window: "1h"}}
+	crmStub := &stubs.ResourceManagerStub{}
+	crmStub.GetAncestryResponse = services.CreateAncestors([]string{"project/test-project", "folder/123", "organization/456"})
+
+	plan, err := Explain(ctx, testData(t, "public_bucket_acl.json"), conf, services.NewResource(crmStub, &stubs.StorageStub{}))
+	if err != nil {
+		t.Fatalf("Explain() failed: %q", err)
+	}
+	if plan.Rule != "public_bucket_acl" || plan.Remediated || len(plan.Automations) != 4 {
+		t.Fatalf("Explain() = %+v, want 4 automations of public_bucket_acl", plan)
+	}
+	got := plan.Automations[1]
+	if got.Topic != "threat-findings-close-bucket" || !got.Checked || !got.Matches || got.Err != nil {
+		t.Errorf("close_bucket = %+v, want it published", got)
+	}
+	if diff := cmp.Diff([]string{"close_bucket/organization/1h"}, got.Limits); diff != "" {
+		t.Errorf("close_bucket limits mismatch (-want +got):\n%s", diff)
+	}
+	want := &closebucket.Values{ProjectID: "test-project", BucketName: "this-is-public-on-purpose"}
+	if diff := cmp.Diff(want, got.Values); diff != "" {
+		t.Errorf("close_bucket values mismatch (-want +got):\n%s", diff)
+	}
+	want.DryRun = true
+	if diff := cmp.Diff(want, got.DryRunValues); diff != "" {
+		t.Errorf("close_bucket dry run values mismatch (-want +got):\n%s", diff)
+	}
+	if plan.Automations[0].DryRunValues != nil {
+		t.Errorf("notify_email dry run values = %+v, want none", plan.Automations[0].DryRunValues)
+	}
+	if got := plan.Automations[2]; !got.Checked || got.Matches || got.Err == nil {
+		t.Errorf("excluded close_bucket = %+v, want it excluded", got)
+	}
+	if got := plan.Automations[3]; got.Topic != approval.Topic || got.Err != nil {
+		t.Errorf("close_bucket requiring approval = %+v, want it parked", got)
+	}
+	values, ok := plan.Automations[0].Values.(*email.Values)
+	if !ok {
+		t.Fatalf("notify_email values = %T, want *email.Values", plan.Automations[0].Values)
+	}
+	wantActions := []email.Action{{Action: "close_bucket", State: registry.Dispatched}, {Action: "close_bucket", State: registry.PendingApproval}}
+	if diff := cmp.Diff(wantActions, values.Actions); diff != "" {
+		t.Errorf("notify_email actions mismatch (-want +got):\n%s", diff)
+	}
+}
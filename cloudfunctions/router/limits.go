@@ -0,0 +1,161 @@
+package router
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+	"github.com/googlecloudplatform/security-response-automation/services"
+)
+
+// BreakerTopic is the PubSub topic notified when a limit's circuit breaker opens.
+const BreakerTopic = "threat-findings-circuit-breaker"
+
+const (
+	// PerOrganization counts automations across the organization.
+	PerOrganization = "organization"
+	// PerProject counts automations of each project separately.
+	PerProject = "project"
+)
+
+// Limit caps how many automations the router dispatches within a window.
+//
+// Once exceeded the limit's circuit breaker opens and matching automations are dispatched with
+// dry_run on until the cooldown has passed.
+type Limit struct {
+	// Action is the limited action, or every action if empty.
+	Action string
+	// Per is PerProject or PerOrganization, the default.
+	Per string
+	Max int64
+	// Window and Cooldown are durations such as "1h". Cooldown defaults to Window.
+	Window   string
+	Cooldown string
+}
+
+// Matches returns whether the limit applies to the action.
+func (l *Limit) Matches(action string) bool {
+	return l.Action == "" || l.Action == action
+}
+
+// BreakerNotice is published to BreakerTopic when a limit's circuit breaker opens.
+type BreakerNotice struct {
+	// Limit is the exceeded limit.
+	Limit Limit
+	// Action and ProjectID are those of the automation that exceeded the limit.
+	Action    string
+	ProjectID string
+	Rule      string
+	Finding   string
+	Until     time.Time
+}
+
+// throttle counts the automation against the configured limits and returns false if it must be
+// dispatched with dry_run on. A notice is published for each circuit breaker it opens.
+func throttle(ctx context.Context, services *Services, action, projectID string, attributes map[string]string) bool {
+	configured, limits := matchingLimits(services.Configuration, services.Logger, action, projectID)
+	if services.Limiter == nil {
+		if len(limits) > 0 {
+			services.Logger.Warning("limits on %q are not enforced, no limits collection is configured", action)
+		}
+		return true
+	}
+	ok, opened := services.Limiter.Allow(ctx, limits)
+	for _, o := range opened {
+		for i, l := range limits {
+			if l.Key != o.Key {
+				continue
+			}
+			notice := &BreakerNotice{
+				Limit:     configured[i],
+				Action:    action,
+				ProjectID: projectID,
+				Rule:      attributes[RuleAttribute],
+				Finding:   attributes[FindingAttribute],
+				Until:     time.Now().Add(l.Cooldown).UTC(),
+			}
+			services.Logger.Error("circuit breaker opened, more than %d automations matching %q within %s, dispatching with dry_run on until %s", l.Max, l.Key, configured[i].Window, notice.Until.Format(time.RFC3339))
+			if err := notify(ctx, services.PubSub, notice); err != nil {
+				services.Logger.Error("failed to publish circuit breaker notice: %q", err)
+			}
+		}
+	}
+	if !ok {
+		log.Printf("limit exceeded, dispatching %q in project %q with dry_run on", action, projectID)
+	}
+	return ok
+}
+
+// matchingLimits returns the configured limits applying to the action along with how they are
+// counted for the project.
+func matchingLimits(c *Configuration, logger *services.Logger, action, projectID string) ([]Limit, []services.Limit) {
+	var configured []Limit
+	var limits []services.Limit
+	for _, l := range c.Spec.Limits {
+		if !l.Matches(action) {
+			continue
+		}
+		limit, err := l.limit(projectID)
+		if err != nil {
+			logger.Error("ignoring limit on %q: %q", action, err)
+			continue
+		}
+		configured = append(configured, l)
+		limits = append(limits, limit)
+	}
+	return configured, limits
+}
+
+// limit returns the limit as counted for the project.
+func (l *Limit) limit(projectID string) (services.Limit, error) {
+	window, err := time.ParseDuration(l.Window)
+	if err != nil || window <= 0 {
+		return services.Limit{}, fmt.Errorf("invalid window %q", l.Window)
+	}
+	cooldown := window
+	if l.Cooldown != "" {
+		if cooldown, err = time.ParseDuration(l.Cooldown); err != nil {
+			return services.Limit{}, fmt.Errorf("invalid cooldown %q", l.Cooldown)
+		}
+	}
+	scope := PerOrganization
+	if l.Per == PerProject {
+		scope = "projects/" + projectID
+	}
+	counted := l.Action
+	if counted == "" {
+		counted = "*"
+	}
+	return services.Limit{
+		Key:      fmt.Sprintf("%s/%s/%s", counted, scope, l.Window),
+		Max:      l.Max,
+		Window:   window,
+		Cooldown: cooldown,
+	}, nil
+}
+
+// notify publishes the notice to BreakerTopic.
+func notify(ctx context.Context, ps *services.PubSub, notice *BreakerNotice) error {
+	b, err := json.Marshal(notice)
+	if err != nil {
+		return err
+	}
+	return publishMessage(ctx, ps, BreakerTopic, &pubsub.Message{Data: b})
+}
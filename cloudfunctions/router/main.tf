@@ -27,12 +27,28 @@ resource "google_cloudfunctions_function" "router" {
   event_trigger {
     event_type = "google.pubsub.topic.publish"
     resource   = var.setup.router-topic-id
+    failure_policy {
+      retry = true
+    }
   }
   environment_variables = {
-    GCP_PROJECT = var.setup.automation-project
+    GCP_PROJECT            = var.setup.automation-project
+    CONFIG_BUCKET          = var.config-bucket
+    CONFIG_OBJECT          = var.config-object
+    IDEMPOTENCY_COLLECTION = var.setup.idempotency-collection
+    LIMITS_COLLECTION      = var.setup.limits-collection
+    DEAD_LETTER_TOPIC      = var.setup.dead-letter-topic
   }
 }
 
+# Required to read the configuration from the config bucket, if set.
+resource "google_storage_bucket_iam_member" "router-config-reader" {
+  count  = var.config-bucket == "" ? 0 : 1
+  bucket = var.config-bucket
+  role   = "roles/storage.objectViewer"
+  member = "serviceAccount:${var.setup.automation-service-account}"
+}
+
 resource "google_project_iam_member" "router-pubsub-writer" {
   role    = "roles/pubsub.editor"
   project = var.setup.automation-project
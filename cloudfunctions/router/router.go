@@ -20,46 +20,61 @@ import (
 	"fmt"
 	"io/ioutil"
 	"log"
+	"strconv"
+	"time"
 
 	"cloud.google.com/go/pubsub"
-	"github.com/googlecloudplatform/security-response-automation/providers/etd/anomalousiam"
-	"github.com/googlecloudplatform/security-response-automation/providers/etd/badip"
-	"github.com/googlecloudplatform/security-response-automation/providers/etd/sshbruteforce"
-	"github.com/googlecloudplatform/security-response-automation/providers/sha/computeinstancescanner"
-	"github.com/googlecloudplatform/security-response-automation/providers/sha/containerscanner"
-	"github.com/googlecloudplatform/security-response-automation/providers/sha/datasetscanner"
-	"github.com/googlecloudplatform/security-response-automation/providers/sha/firewallscanner"
-	"github.com/googlecloudplatform/security-response-automation/providers/sha/iamscanner"
-	"github.com/googlecloudplatform/security-response-automation/providers/sha/loggingscanner"
-	"github.com/googlecloudplatform/security-response-automation/providers/sha/sqlscanner"
-	"github.com/googlecloudplatform/security-response-automation/providers/sha/storagescanner"
+	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/approval"
+	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/schedule"
+	"github.com/googlecloudplatform/security-response-automation/providers/registry"
 	"github.com/googlecloudplatform/security-response-automation/services"
 	"github.com/pkg/errors"
 	"gopkg.in/yaml.v2"
-)
 
-var findings = []Namer{
-	&anomalousiam.Finding{},
-	&badip.Finding{},
-	&sshbruteforce.Finding{},
-	&storagescanner.Finding{},
-	&sqlscanner.Finding{},
-	&containerscanner.Finding{},
-	&computeinstancescanner.Finding{},
-	&firewallscanner.Finding{},
-	&datasetscanner.Finding{},
-	&loggingscanner.Finding{},
-	&iamscanner.Finding{},
-}
+	// Built-in findings and notifications register themselves with the registry.
+	_ "github.com/googlecloudplatform/security-response-automation/providers/etd/anomalousiam"
+	_ "github.com/googlecloudplatform/security-response-automation/providers/etd/baddomain"
+	_ "github.com/googlecloudplatform/security-response-automation/providers/etd/badip"
+	_ "github.com/googlecloudplatform/security-response-automation/providers/etd/sshbruteforce"
+	_ "github.com/googlecloudplatform/security-response-automation/providers/notify"
+	_ "github.com/googlecloudplatform/security-response-automation/providers/sha/computeinstancescanner"
+	_ "github.com/googlecloudplatform/security-response-automation/providers/sha/containerscanner"
+	_ "github.com/googlecloudplatform/security-response-automation/providers/sha/datasetscanner"
+	_ "github.com/googlecloudplatform/security-response-automation/providers/sha/firewallscanner"
+	_ "github.com/googlecloudplatform/security-response-automation/providers/sha/iamscanner"
+	_ "github.com/googlecloudplatform/security-response-automation/providers/sha/loggingscanner"
+	_ "github.com/googlecloudplatform/security-response-automation/providers/sha/sqlscanner"
+	_ "github.com/googlecloudplatform/security-response-automation/providers/sha/storagescanner"
+)
 
 // originalEventTime is the security mark key name used to hold the finding's event time.
 const originalEventTime = "sra-remediated-event-time"
 const configPath = "./serverless_function_source_code/config/sra.yaml"
 
+const (
+	// publishAttempts is the number of times publishing an automation is attempted.
+	publishAttempts = 3
+	// publishBackoff is the time waited before retrying a failed publish. It doubles on each retry.
+	publishBackoff = time.Second
+)
+
+const (
+	// FindingAttribute is the PubSub message attribute holding the name of the routed finding.
+	FindingAttribute = "finding"
+	// RuleAttribute is the PubSub message attribute holding the rule name of the routed finding.
+	RuleAttribute = "rule"
+	// EventTimeAttribute is the PubSub message attribute holding the event time of the routed finding.
+	EventTimeAttribute = "event_time"
+	// IndexAttribute is the PubSub message attribute holding the position of the automation
+	// within the rule's configuration.
+	IndexAttribute = "index"
+)
+
 // Namer represents findings that export their name.
-type Namer interface {
-	Name([]byte) string
-}
+type Namer = registry.Namer
+
+// Automation represents configuration for an automation.
+type Automation = registry.Automation
 
 // Services contains the services needed for this function.
 type Services struct {
@@ -68,6 +83,9 @@ type Services struct {
 	Logger                *services.Logger
 	Resource              *services.Resource
 	SecurityCommandCenter *services.CommandCenter
+	Idempotency           *services.Idempotency
+	DeadLetter            *services.DeadLetter
+	Limiter               *services.Limiter
 }
 
 // Values contains the required values for this function.
@@ -75,81 +93,29 @@ type Values struct {
 	Finding []byte
 }
 
-// topics maps automation targets to PubSub topics.
-var topics = map[string]struct{ Topic string }{
-	"gce_create_disk_snapshot":  {Topic: "threat-findings-create-disk-snapshot"},
-	"iam_revoke":                {Topic: "threat-findings-iam-revoke"},
-	"close_bucket":              {Topic: "threat-findings-close-bucket"},
-	"enable_bucket_only_policy": {Topic: "threat-findings-enable-bucket-only-policy"},
-	"close_cloud_sql":           {Topic: "threat-findings-remove-public-sql"},
-	"cloud_sql_require_ssl":     {Topic: "threat-findings-require-ssl"},
-	"cloud_sql_update_password": {Topic: "threat-findings-update-password"},
-	"disable_dashboard":         {Topic: "threat-findings-disable-dashboard"},
-	"remove_public_ip":          {Topic: "threat-findings-remove-public-ip"},
-	"remediate_firewall":        {Topic: "threat-findings-open-firewall"},
-	"close_public_dataset":      {Topic: "threat-findings-close-public-dataset"},
-	"enable_audit_logs":         {Topic: "threat-findings-enable-audit-logs"},
-	"remove_non_org_members":    {Topic: "threat-findings-remove-non-org-members"},
-}
-
-// Automation represents configuration for an automation.
-type Automation struct {
-	Action     string
-	Target     []string
-	Exclude    []string
-	Properties struct {
-		DryRun    bool `yaml:"dry_run"`
-		RevokeIAM struct {
-			AllowDomains []string `yaml:"allow_domains"`
-		} `yaml:"revoke_iam"`
-		CreateSnapshot struct {
-			TargetSnapshotProjectID string `yaml:"target_snapshot_project_id"`
-			TargetSnapshotZone      string `yaml:"target_snapshot_zone"`
-			Output                  []string
-			Turbinia                struct {
-				ProjectID string
-				Topic     string
-				Zone      string
-			}
-		} `yaml:"gce_create_snapshot"`
-		OpenFirewall struct {
-			SourceRanges      []string `yaml:"source_ranges"`
-			RemediationAction string   `yaml:"remediation_action"`
-		} `yaml:"open_firewall"`
-		NonOrgMembers struct {
-			AllowDomains []string `yaml:"allow_domains"`
-		} `yaml:"non_org_members"`
-	}
-}
-
 // Configuration maps findings to automations.
+//
+// Parameters are keyed by finding provider (such as "etd" or "sha") then by the
+// finding's configuration key.
 type Configuration struct {
-	APIVersion string
-	Spec       struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string
+	Metadata   struct {
+		Name string
+	}
+	Spec struct {
 		Name       string
-		Parameters struct {
-			ETD struct {
-				BadIP         []Automation `yaml:"bad_ip"`
-				AnomalousIAM  []Automation `yaml:"anomalous_iam"`
-				SSHBruteForce []Automation `yaml:"ssh_brute_force"`
-			}
-			SHA struct {
-				PublicBucketACL         []Automation `yaml:"public_bucket_acl"`
-				BucketPolicyOnlyDisable []Automation `yaml:"bucket_policy_only_disabled"`
-				PublicSQLInstance       []Automation `yaml:"public_sql_instance"`
-				SSLNotEnforced          []Automation `yaml:"ssl_not_enforced"`
-				SQLNoRootPassword       []Automation `yaml:"sql_no_root_password"`
-				PublicIPAddress         []Automation `yaml:"public_ip_address"`
-				OpenFirewall            []Automation `yaml:"open_firewall"`
-				PublicDataset           []Automation `yaml:"bigquery_public_dataset"`
-				AuditLoggingDisabled    []Automation `yaml:"audit_logging_disabled"`
-				WebUIEnabled            []Automation `yaml:"web_ui_enabled"`
-				NonOrgMembers           []Automation `yaml:"non_org_members"`
-			}
-		}
+		Parameters map[string]map[string][]Automation
+		// Limits cap how many automations are dispatched, see Limit.
+		Limits []Limit
 	}
 }
 
+// Automations returns the automations configured for the given provider and finding key.
+func (c *Configuration) Automations(provider, key string) []Automation {
+	return c.Spec.Parameters[provider][key]
+}
+
 // Config will return the router's configuration.
 func Config() (*Configuration, error) {
 	var c Configuration
@@ -163,615 +129,434 @@ func Config() (*Configuration, error) {
 	return &c, nil
 }
 
-// ruleName will attempt to deserialize all findings until a name is extracted.
-func ruleName(b []byte) string {
-	for _, finding := range findings {
-		if n := finding.Name(b); n != "" {
-			return n
-		}
-	}
-	return ""
-}
-
 func markAsRemediated(ctx context.Context, name, eventTime string, services *Services) error {
-	m := map[string]string{"sra-remediated-event-time": eventTime}
+	m := map[string]string{originalEventTime: eventTime}
 	if _, err := services.SecurityCommandCenter.AddSecurityMarks(ctx, name, m); err != nil {
 		return err
 	}
 	return nil
 }
 
-// Execute will route the incoming finding to the appropriate remediations.
-func Execute(ctx context.Context, values *Values, services *Services) error {
-	switch name := ruleName(values.Finding); name {
-	case "bad_ip":
-		return executeBadIP(ctx, name, values, services)
-	case "iam_anomalous_grant":
-		return executeIamAnomalousGrant(ctx, name, values, services)
-	case "ssh_brute_force":
-		return executeSSHBruteForce(ctx, name, values, services)
-	case "public_bucket_acl":
-		return executePublicBucketACL(ctx, name, values, services)
-	case "bucket_policy_only_disabled":
-		return executeBucketPolicyOnlyDisabled(ctx, name, values, services)
-	case "public_sql_instance":
-		return executePublicSQLInstance(ctx, name, values, services)
-	case "ssl_not_enforced":
-		return executeSSLNotEnforced(ctx, name, values, services)
-	case "sql_no_root_password":
-		return executeSQLNoRootPassword(ctx, name, values, services)
-	case "public_ip_address":
-		return executePublicIPAddress(ctx, name, values, services)
-	case "open_firewall":
-		return executeOpenFirewall(ctx, name, values, services)
-	case "open_ssh_port":
-		return executeOpenSSHPort(ctx, name, values, services)
-	case "open_rdp_port":
-		return executeOpenRDPPort(ctx, name, values, services)
-	case "public_dataset":
-		return executePublicDataset(ctx, name, values, services)
-	case "audit_logging_disabled":
-		return executeAuditLoggingDisabled(ctx, name, values, services)
-	case "web_ui_enabled":
-		return executeWebUIEnabled(ctx, name, values, services)
-	case "non_org_iam_member":
-		return executeNonOrgIamMember(ctx, name, values, services)
-	default:
-		return fmt.Errorf("rule %q not found", name)
+// sccAttributes returns the Security Command Center attributes of the finding if it has any.
+func sccAttributes(finding registry.Finding) *registry.SCC {
+	f, ok := finding.(registry.SCCFinding)
+	if !ok {
+		return nil
 	}
+	return f.SCC()
 }
 
-func executeBadIP(ctx context.Context, name string, values *Values, services *Services) error {
-	automations := services.Configuration.Spec.Parameters.ETD.BadIP
-	badIP, err := badip.New(values.Finding)
+// Execute will route the incoming finding to the appropriate remediations.
+func Execute(ctx context.Context, values *Values, services *Services) error {
+	f, err := parse(values.Finding)
 	if err != nil {
 		return err
 	}
-	if badIP.UseCSCC {
-		securityMarks := badIP.BadIPCSCC.GetFinding().GetSecurityMarks().GetMarks()
-		remediated := securityMarks[originalEventTime] == badIP.BadIPCSCC.GetFinding().GetEventTime()
-		if remediated {
-			log.Printf("finding already remediated")
-			return nil
-		}
+	if f.remediated() {
+		log.Printf("finding already remediated")
+		return nil
 	}
-	log.Printf("got rule %q with %d automations", name, len(automations))
-	for _, automation := range automations {
-		switch automation.Action {
-		case "gce_create_disk_snapshot":
-			values := badIP.CreateSnapshot()
-			values.DryRun = automation.Properties.DryRun
-			values.Output = automation.Properties.CreateSnapshot.Output
-			values.DestProjectID = automation.Properties.CreateSnapshot.TargetSnapshotProjectID
-			values.DestZone = automation.Properties.CreateSnapshot.TargetSnapshotZone
-			values.Turbinia.ProjectID = automation.Properties.CreateSnapshot.Turbinia.ProjectID
-			values.Turbinia.Topic = automation.Properties.CreateSnapshot.Turbinia.Topic
-			values.Turbinia.Zone = automation.Properties.CreateSnapshot.Turbinia.Zone
-			topic := topics[automation.Action].Topic
-			if err := publish(ctx, services, automation.Action, topic, values.ProjectID, automation.Target, automation.Exclude, values); err != nil {
-				services.Logger.Error("failed to publish: %q", err)
-				continue
-			}
-		default:
-			return fmt.Errorf("action %q not found", automation.Action)
-		}
+	automations := services.Configuration.Automations(f.rule.Provider, f.rule.Key)
+	log.Printf("got rule %q with %d automations", f.name, len(automations))
+	if err := walk(ctx, f, automations, &execution{services: services}); err != nil {
+		return err
 	}
-	if badIP.UseCSCC {
-		if err := markAsRemediated(ctx, badIP.BadIPCSCC.GetFinding().GetName(), badIP.BadIPCSCC.GetFinding().GetEventTime(), services); err != nil {
+	if f.scc != nil {
+		if err := markAsRemediated(ctx, f.scc.Name, f.scc.EventTime, services); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func executeIamAnomalousGrant(ctx context.Context, name string, values *Values, services *Services) error {
-	automations := services.Configuration.Spec.Parameters.ETD.AnomalousIAM
-	anomalousIAM, err := anomalousiam.New(values.Finding)
-	if err != nil {
-		return err
-	}
-	log.Printf("got rule %q with %d automations", name, len(automations))
-	for _, automation := range automations {
-		switch automation.Action {
-		case "iam_revoke":
-			values := anomalousIAM.IAMRevoke()
-			values.DryRun = automation.Properties.DryRun
-			values.AllowDomains = automation.Properties.RevokeIAM.AllowDomains
-			topic := topics[automation.Action].Topic
-			if err := publish(ctx, services, automation.Action, topic, values.ProjectID, automation.Target, automation.Exclude, values); err != nil {
-				services.Logger.Error("failed to publish: %q", err)
-				continue
-			}
-		default:
-			return fmt.Errorf("action %q not found", automation.Action)
-		}
-	}
-	return nil
+// parsed is a raw finding along with the rule it matched.
+type parsed struct {
+	b       []byte
+	rule    *registry.Rule
+	name    string
+	finding registry.Finding
+	scc     *registry.SCC
 }
 
-func executeSSHBruteForce(ctx context.Context, name string, values *Values, services *Services) error {
-	automations := services.Configuration.Spec.Parameters.ETD.SSHBruteForce
-	sshBruteForce, err := sshbruteforce.New(values.Finding)
-	if err != nil {
-		return err
+// parse returns the finding parsed by the rule it matches.
+func parse(b []byte) (*parsed, error) {
+	rule, name := registry.Lookup(b)
+	if rule == nil {
+		return nil, fmt.Errorf("rule %q not found", name)
 	}
-	log.Printf("got rule %q with %d automations", name, len(automations))
-	for _, automation := range automations {
-		switch automation.Action {
-		case "remediate_firewall":
-			values := sshBruteForce.OpenFirewall()
-			values.DryRun = automation.Properties.DryRun
-			values.Action = "block_ssh"
-			topic := topics[automation.Action].Topic
-			if err := publish(ctx, services, automation.Action, topic, values.ProjectID, automation.Target, automation.Exclude, values); err != nil {
-				services.Logger.Error("failed to publish: %q", err)
-				continue
-			}
-		default:
-			return fmt.Errorf("action %q not found", automation.Action)
-		}
+	finding, err := rule.New(b)
+	if err != nil {
+		return nil, err
 	}
-	return nil
+	return &parsed{b: b, rule: rule, name: name, finding: finding, scc: sccAttributes(finding)}, nil
 }
 
-func executePublicBucketACL(ctx context.Context, name string, values *Values, services *Services) error {
-	automations := services.Configuration.Spec.Parameters.SHA.PublicBucketACL
-	storageScanner, err := storagescanner.New(values.Finding)
-	if err != nil {
-		return err
-	}
-	securityMarks := storageScanner.StorageScanner.GetFinding().GetSecurityMarks().GetMarks()
-	remediated := securityMarks[originalEventTime] == storageScanner.StorageScanner.GetFinding().GetEventTime()
-	if remediated {
-		log.Printf("finding already remediated")
-		return nil
-	}
-	log.Printf("got rule %q with %d automations", name, len(automations))
-	for _, automation := range automations {
-		switch automation.Action {
-		case "close_bucket":
-			values := storageScanner.CloseBucket()
-			values.DryRun = automation.Properties.DryRun
-			topic := topics[automation.Action].Topic
-			if err := publish(ctx, services, automation.Action, topic, values.ProjectID, automation.Target, automation.Exclude, values); err != nil {
-				services.Logger.Error("failed to publish: %q", err)
-				continue
-			}
-		default:
-			return fmt.Errorf("action %q not found", automation.Action)
-		}
-	}
-	if err := markAsRemediated(ctx, storageScanner.StorageScanner.GetFinding().GetName(), storageScanner.StorageScanner.GetFinding().GetEventTime(), services); err != nil {
-		return err
-	}
-	return nil
+// remediated returns if this event of the finding was already remediated.
+func (f *parsed) remediated() bool {
+	return f.scc != nil && f.scc.SecurityMarks[originalEventTime] == f.scc.EventTime
 }
 
-func executeBucketPolicyOnlyDisabled(ctx context.Context, name string, values *Values, services *Services) error {
-	automations := services.Configuration.Spec.Parameters.SHA.BucketPolicyOnlyDisable
-	storageScanner, err := storagescanner.New(values.Finding)
-	if err != nil {
-		return err
-	}
-	securityMarks := storageScanner.StorageScanner.GetFinding().GetSecurityMarks().GetMarks()
-	remediated := securityMarks[originalEventTime] == storageScanner.StorageScanner.GetFinding().GetEventTime()
-	if remediated {
-		log.Printf("finding already remediated")
-		return nil
-	}
-	log.Printf("got rule %q with %d automations", name, len(automations))
-	for _, automation := range automations {
-		switch automation.Action {
-		case "enable_bucket_only_policy":
-			values := storageScanner.EnableBucketOnlyPolicy()
-			values.DryRun = automation.Properties.DryRun
-			topic := topics[automation.Action].Topic
-			if err := publish(ctx, services, automation.Action, topic, values.ProjectID, automation.Target, automation.Exclude, values); err != nil {
-				services.Logger.Error("failed to publish: %q", err)
-				continue
-			}
-		default:
-			return fmt.Errorf("action %q not found", automation.Action)
-		}
-	}
-	if err := markAsRemediated(ctx, storageScanner.StorageScanner.GetFinding().GetName(), storageScanner.StorageScanner.GetFinding().GetEventTime(), services); err != nil {
-		return err
-	}
-	return nil
+// unroutable is the error of an automation the configuration cannot route, such as an action
+// the finding's rule does not support.
+type unroutable struct{ error }
+
+// route carries out the dispatch of the automations visited by walk. Execute publishes them
+// while Explain records what would be published.
+type route interface {
+	// skip is called with why the i-th automation was not dispatched. The walk stops if it
+	// returns an error.
+	skip(i int, automation *Automation, err error) error
+	// claim returns false if a previous delivery of the finding already dispatched the i-th
+	// automation, along with the function to call once it is dispatched.
+	claim(ctx context.Context, i int, automation *Automation, findingName, eventTime string) (bool, func(error))
+	// inTarget returns an error if the project is not within the automation's target or is
+	// excluded.
+	inTarget(ctx context.Context, i int, automation *Automation, projectID string) error
+	// throttle counts the automation against the configured limits and returns false if it must
+	// be dispatched with dry_run on.
+	throttle(ctx context.Context, i int, automation *Automation, projectID string, attributes map[string]string) bool
+	// limits returns the configured limits an automation parked until approved or until its
+	// maintenance window opens is counted against once released.
+	limits(i int, automation *Automation, projectID string) []services.Limit
+	// publish publishes the values of the i-th automation to the topic.
+	publish(ctx context.Context, i int, automation *Automation, topic, projectID string, values interface{}, attributes map[string]string) error
 }
 
-func executePublicSQLInstance(ctx context.Context, name string, values *Values, services *Services) error {
-	automations := services.Configuration.Spec.Parameters.SHA.PublicSQLInstance
-	sqlScanner, err := sqlscanner.New(values.Finding)
-	if err != nil {
-		return err
-	}
-	securityMarks := sqlScanner.SQLScanner.GetFinding().GetSecurityMarks().GetMarks()
-	remediated := securityMarks[originalEventTime] == sqlScanner.SQLScanner.GetFinding().GetEventTime()
-	if remediated {
-		log.Printf("finding already remediated")
-		return nil
-	}
-	log.Printf("got rule %q with %d automations", name, len(automations))
-	for _, automation := range automations {
-		switch automation.Action {
-		case "close_cloud_sql":
-			values := sqlScanner.RemovePublic()
-			values.DryRun = automation.Properties.DryRun
-			topic := topics[automation.Action].Topic
-			if err := publish(ctx, services, automation.Action, topic, values.ProjectID, automation.Target, automation.Exclude, values); err != nil {
-				services.Logger.Error("failed to publish: %q", err)
-				continue
-			}
-		default:
-			return fmt.Errorf("action %q not found", automation.Action)
+// walk dispatches the finding's automations in configuration order. Notifications are dispatched
+// last so they can report how the other automations were dispatched.
+func walk(ctx context.Context, f *parsed, automations []Automation, r route) error {
+	findingName, eventTime := identity(f.b, f.scc)
+	attributes := map[string]string{RuleAttribute: f.name}
+	if findingName != "" {
+		attributes[FindingAttribute] = findingName
+		attributes[EventTimeAttribute] = eventTime
+	}
+	notification := newNotification(f.rule, f.name, f.scc, f.b)
+	var notifications []int
+	for i := range automations {
+		automation := automations[i]
+		if _, ok := registry.LookupNotifier(automation.Action); ok {
+			notifications = append(notifications, i)
+			continue
+		}
+		var err error
+		var state string
+		if !f.rule.Supports(automation.Action) {
+			err = unroutable{fmt.Errorf("action %q not found", automation.Action)}
+		} else if topic, ok := registry.Topic(automation.Action); !ok {
+			err = unroutable{fmt.Errorf("no topic registered for action %q", automation.Action)}
+		} else if projectID, values, verr := f.finding.Values(&automation); verr != nil {
+			err = errors.Wrapf(verr, "failed to get values for %q", automation.Action)
+		} else if state, err = dispatch(ctx, r, i, &automation, f.finding, topic, projectID, values, attributes, findingName, eventTime); err == nil {
+			notification.ProjectID = projectID
+			notification.Actions = append(notification.Actions, registry.ActionTaken{Action: automation.Action, DryRun: automation.Properties.DryRun, State: state})
+			continue
+		}
+		if err := r.skip(i, &automation, err); err != nil {
+			return err
 		}
 	}
-	if err := markAsRemediated(ctx, sqlScanner.SQLScanner.GetFinding().GetName(), sqlScanner.SQLScanner.GetFinding().GetEventTime(), services); err != nil {
-		return err
+	if len(notifications) > 0 && notification.ProjectID == "" {
+		notification.ProjectID = findingProject(f.finding, f.rule)
+	}
+	for _, i := range notifications {
+		automation := automations[i]
+		notifier, _ := registry.LookupNotifier(automation.Action)
+		topic, _ := registry.Topic(automation.Action)
+		values, err := notifier(notification, &automation)
+		if err != nil {
+			err = errors.Wrapf(err, "failed to get values for %q", automation.Action)
+		} else if _, err = dispatch(ctx, r, i, &automation, nil, topic, notification.ProjectID, values, attributes, findingName, eventTime); err == nil {
+			continue
+		}
+		if err := r.skip(i, &automation, err); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
-func executeSSLNotEnforced(ctx context.Context, name string, values *Values, services *Services) error {
-	automations := services.Configuration.Spec.Parameters.SHA.SSLNotEnforced
-	sqlScanner, err := sqlscanner.New(values.Finding)
-	if err != nil {
-		return err
-	}
-	securityMarks := sqlScanner.SQLScanner.GetFinding().GetSecurityMarks().GetMarks()
-	remediated := securityMarks[originalEventTime] == sqlScanner.SQLScanner.GetFinding().GetEventTime()
-	if remediated {
-		log.Printf("finding already remediated")
-		return nil
-	}
-	log.Printf("got rule %q with %d automations", name, len(automations))
-	for _, automation := range automations {
-		switch automation.Action {
-		case "cloud_sql_require_ssl":
-			values := sqlScanner.RequireSSL()
-			values.DryRun = automation.Properties.DryRun
-			topic := topics[automation.Action].Topic
-			if err := publish(ctx, services, automation.Action, topic, values.ProjectID, automation.Target, automation.Exclude, values); err != nil {
-				services.Logger.Error("failed to publish: %q", err)
-				continue
-			}
-		default:
-			return fmt.Errorf("action %q not found", automation.Action)
-		}
-	}
-	if err := markAsRemediated(ctx, sqlScanner.SQLScanner.GetFinding().GetName(), sqlScanner.SQLScanner.GetFinding().GetEventTime(), services); err != nil {
-		return err
-	}
-	return nil
+// execution dispatches automations by publishing them.
+type execution struct {
+	services *Services
 }
 
-func executeSQLNoRootPassword(ctx context.Context, name string, values *Values, services *Services) error {
-	automations := services.Configuration.Spec.Parameters.SHA.SQLNoRootPassword
-	sqlScanner, err := sqlscanner.New(values.Finding)
-	if err != nil {
-		return err
-	}
-	securityMarks := sqlScanner.SQLScanner.GetFinding().GetSecurityMarks().GetMarks()
-	remediated := securityMarks[originalEventTime] == sqlScanner.SQLScanner.GetFinding().GetEventTime()
-	if remediated {
-		log.Printf("finding already remediated")
-		return nil
-	}
-	log.Printf("got rule %q with %d automations", name, len(automations))
-	for _, automation := range automations {
-		switch automation.Action {
-		case "cloud_sql_update_password":
-			values, err := sqlScanner.UpdatePassword()
-			if err != nil {
-				services.Logger.Error("failed to get values for %q: %q", automation.Action, err)
-				continue
-			}
-			values.DryRun = automation.Properties.DryRun
-			topic := topics[automation.Action].Topic
-			if err := publish(ctx, services, automation.Action, topic, values.ProjectID, automation.Target, automation.Exclude, values); err != nil {
-				services.Logger.Error("failed to publish: %q", err)
-				continue
-			}
-		default:
-			return fmt.Errorf("action %q not found", automation.Action)
-		}
-	}
-	if err := markAsRemediated(ctx, sqlScanner.SQLScanner.GetFinding().GetName(), sqlScanner.SQLScanner.GetFinding().GetEventTime(), services); err != nil {
-		return err
+func (e *execution) skip(i int, automation *Automation, err error) error {
+	if u, ok := err.(unroutable); ok {
+		return u.error
 	}
+	e.services.Logger.Error("failed to dispatch %q: %q", automation.Action, err)
 	return nil
 }
 
-func executePublicIPAddress(ctx context.Context, name string, values *Values, services *Services) error {
-	automations := services.Configuration.Spec.Parameters.SHA.PublicIPAddress
-	computeInstanceScanner, err := computeinstancescanner.New(values.Finding)
-	if err != nil {
-		return err
-	}
-	securityMarks := computeInstanceScanner.ComputeInstanceScanner.GetFinding().GetSecurityMarks().GetMarks()
-	remediated := securityMarks[originalEventTime] == computeInstanceScanner.ComputeInstanceScanner.GetFinding().GetEventTime()
-	if remediated {
-		log.Printf("finding already remediated")
-		return nil
+func (e *execution) claim(ctx context.Context, i int, automation *Automation, findingName, eventTime string) (bool, func(error)) {
+	idempotency := e.services.Idempotency.WithFinding(findingName, eventTime, strconv.Itoa(i))
+	step := "route:" + automation.Action
+	if !idempotency.Begin(ctx, step) {
+		return false, nil
 	}
-	log.Printf("got rule %q with %d automations", name, len(automations))
-	for _, automation := range automations {
-		switch automation.Action {
-		case "remove_public_ip":
-			values := computeInstanceScanner.RemovePublicIP()
-			values.DryRun = automation.Properties.DryRun
-			topic := topics[automation.Action].Topic
-			if err := publish(ctx, services, automation.Action, topic, values.ProjectID, automation.Target, automation.Exclude, values); err != nil {
-				services.Logger.Error("failed to publish: %q", err)
-				continue
-			}
-		default:
-			return fmt.Errorf("action %q not found", automation.Action)
-		}
-	}
-	if err := markAsRemediated(ctx, computeInstanceScanner.ComputeInstanceScanner.GetFinding().GetName(), computeInstanceScanner.ComputeInstanceScanner.GetFinding().GetEventTime(), services); err != nil {
-		return err
-	}
-	return nil
+	return true, func(err error) { idempotency.End(ctx, step, err) }
 }
 
-func executeOpenFirewall(ctx context.Context, name string, values *Values, services *Services) error {
-	automations := services.Configuration.Spec.Parameters.SHA.OpenFirewall
-	firewallScanner, err := firewallscanner.New(values.Finding)
+func (e *execution) inTarget(ctx context.Context, i int, automation *Automation, projectID string) error {
+	ok, err := e.services.Resource.CheckMatches(ctx, projectID, automation.Target, automation.Exclude)
 	if err != nil {
-		return err
-	}
-	securityMarks := firewallScanner.FirewallScanner.GetFinding().GetSecurityMarks().GetMarks()
-	remediated := securityMarks[originalEventTime] == firewallScanner.FirewallScanner.GetFinding().GetEventTime()
-	if remediated {
-		log.Printf("finding already remediated")
-		return nil
-	}
-	log.Printf("got rule %q with %d automations", name, len(automations))
-	for _, automation := range automations {
-		switch automation.Action {
-		case "remediate_firewall":
-			values := firewallScanner.OpenFirewall()
-			values.DryRun = automation.Properties.DryRun
-			values.SourceRanges = automation.Properties.OpenFirewall.SourceRanges
-			values.Action = automation.Properties.OpenFirewall.RemediationAction
-			topic := topics[automation.Action].Topic
-			if err := publish(ctx, services, automation.Action, topic, values.ProjectID, automation.Target, automation.Exclude, values); err != nil {
-				services.Logger.Error("failed to publish: %q", err)
-				continue
-			}
-		default:
-			return fmt.Errorf("action %q not found", automation.Action)
-		}
+		return errors.Wrapf(err, "failed to check if project %q is within the target or is excluded", projectID)
 	}
-	if err := markAsRemediated(ctx, firewallScanner.FirewallScanner.GetFinding().GetName(), firewallScanner.FirewallScanner.GetFinding().GetEventTime(), services); err != nil {
-		return err
+	if !ok {
+		return fmt.Errorf("project %q is not within the target or is excluded", projectID)
 	}
 	return nil
 }
 
-func executeOpenSSHPort(ctx context.Context, name string, values *Values, services *Services) error {
-	automations := services.Configuration.Spec.Parameters.SHA.OpenFirewall
-	firewallScanner, err := firewallscanner.New(values.Finding)
-	if err != nil {
-		return err
+func (e *execution) throttle(ctx context.Context, i int, automation *Automation, projectID string, attributes map[string]string) bool {
+	return throttle(ctx, e.services, automation.Action, projectID, attributes)
+}
+
+func (e *execution) limits(i int, automation *Automation, projectID string) []services.Limit {
+	_, limits := matchingLimits(e.services.Configuration, e.services.Logger, automation.Action, projectID)
+	return limits
+}
+
+func (e *execution) publish(ctx context.Context, i int, automation *Automation, topic, projectID string, values interface{}, attributes map[string]string) error {
+	return publish(ctx, e.services, automation.Action, topic, values, attributes)
+}
+
+// newNotification returns the notification about the finding, before any automation is taken.
+func newNotification(rule *registry.Rule, name string, scc *registry.SCC, b []byte) *registry.Notification {
+	notification := &registry.Notification{
+		Provider: rule.Provider,
+		Rule:     name,
+		Severity: severity(b),
+		Data:     b,
 	}
-	securityMarks := firewallScanner.FirewallScanner.GetFinding().GetSecurityMarks().GetMarks()
-	remediated := securityMarks[originalEventTime] == firewallScanner.FirewallScanner.GetFinding().GetEventTime()
-	if remediated {
-		log.Printf("finding already remediated")
-		return nil
+	if scc != nil {
+		notification.Finding = scc.Name
 	}
-	log.Printf("got rule %q with %d automations", name, len(automations))
-	for _, automation := range automations {
-		switch automation.Action {
-		case "remediate_firewall":
-			values := firewallScanner.OpenFirewall()
-			values.DryRun = automation.Properties.DryRun
-			values.SourceRanges = automation.Properties.OpenFirewall.SourceRanges
-			values.Action = automation.Properties.OpenFirewall.RemediationAction
-			topic := topics[automation.Action].Topic
-			if err := publish(ctx, services, automation.Action, topic, values.ProjectID, automation.Target, automation.Exclude, values); err != nil {
-				services.Logger.Error("failed to publish: %q", err)
-				continue
+	return notification
+}
+
+// severity returns the severity reported by the raw finding. Security Command Center findings
+// report a severity while Event Threat Detection findings report a detection priority.
+func severity(b []byte) string {
+	var f struct {
+		Finding struct {
+			Severity         string
+			SourceProperties struct {
+				DetectionPriority string
 			}
-		default:
-			return fmt.Errorf("action %q not found", automation.Action)
+		}
+		JSONPayload struct {
+			DetectionPriority string
 		}
 	}
-	if err := markAsRemediated(ctx, firewallScanner.FirewallScanner.GetFinding().GetName(), firewallScanner.FirewallScanner.GetFinding().GetEventTime(), services); err != nil {
-		return err
+	if err := json.Unmarshal(b, &f); err != nil {
+		return ""
+	}
+	switch {
+	case f.Finding.Severity != "":
+		return f.Finding.Severity
+	case f.Finding.SourceProperties.DetectionPriority != "":
+		return f.Finding.SourceProperties.DetectionPriority
+	default:
+		return f.JSONPayload.DetectionPriority
 	}
-	return nil
 }
 
-func executeOpenRDPPort(ctx context.Context, name string, values *Values, services *Services) error {
-	automations := services.Configuration.Spec.Parameters.SHA.OpenFirewall
-	firewallScanner, err := firewallscanner.New(values.Finding)
-	if err != nil {
-		return err
-	}
-	securityMarks := firewallScanner.FirewallScanner.GetFinding().GetSecurityMarks().GetMarks()
-	remediated := securityMarks[originalEventTime] == firewallScanner.FirewallScanner.GetFinding().GetEventTime()
-	if remediated {
-		log.Printf("finding already remediated")
-		return nil
-	}
-	log.Printf("got rule %q with %d automations", name, len(automations))
-	for _, automation := range automations {
-		switch automation.Action {
-		case "remediate_firewall":
-			values := firewallScanner.OpenFirewall()
-			values.DryRun = automation.Properties.DryRun
-			values.SourceRanges = automation.Properties.OpenFirewall.SourceRanges
-			values.Action = automation.Properties.OpenFirewall.RemediationAction
-			topic := topics[automation.Action].Topic
-			if err := publish(ctx, services, automation.Action, topic, values.ProjectID, automation.Target, automation.Exclude, values); err != nil {
-				services.Logger.Error("failed to publish: %q", err)
-				continue
-			}
-		default:
-			return fmt.Errorf("action %q not found", automation.Action)
+// findingProject returns the ID of the project affected by the finding. It is used when none of
+// the finding's automations were dispatched so notifications can still be targeted.
+func findingProject(finding registry.Finding, rule *registry.Rule) string {
+	for _, action := range rule.Actions {
+		projectID, _, err := finding.Values(&Automation{Action: action})
+		if err == nil && projectID != "" {
+			return projectID
 		}
 	}
-	if err := markAsRemediated(ctx, firewallScanner.FirewallScanner.GetFinding().GetName(), firewallScanner.FirewallScanner.GetFinding().GetEventTime(), services); err != nil {
-		return err
-	}
-	return nil
+	return ""
 }
 
-func executePublicDataset(ctx context.Context, name string, values *Values, services *Services) error {
-	automations := services.Configuration.Spec.Parameters.SHA.PublicDataset
-	publicDataset, err := datasetscanner.New(values.Finding)
-	if err != nil {
-		return err
-	}
-	securityMarks := publicDataset.DatasetScanner.GetFinding().GetSecurityMarks().GetMarks()
-	remediated := securityMarks[originalEventTime] == publicDataset.DatasetScanner.GetFinding().GetEventTime()
-	if remediated {
-		log.Printf("finding already remediated")
-		return nil
+// identity returns the name and event time identifying a delivery of the finding. Findings
+// delivered as log entries are identified by their insert ID and timestamp.
+func identity(b []byte, scc *registry.SCC) (string, string) {
+	if scc != nil {
+		return scc.Name, scc.EventTime
 	}
-	log.Printf("got rule %q with %d automations", name, len(automations))
-	for _, automation := range automations {
-		switch automation.Action {
-		case "close_public_dataset":
-			values := publicDataset.ClosePublicDataset()
-			values.DryRun = automation.Properties.DryRun
-			topic := topics[automation.Action].Topic
-			if err := publish(ctx, services, automation.Action, topic, values.ProjectID, automation.Target, automation.Exclude, values); err != nil {
-				services.Logger.Error("failed to publish: %q", err)
-				continue
-			}
-		default:
-			return fmt.Errorf("action %q not found", automation.Action)
-		}
+	var entry struct {
+		LogName   string
+		InsertID  string `json:"insertId"`
+		Timestamp string
 	}
-	if err := markAsRemediated(ctx, publicDataset.DatasetScanner.GetFinding().GetName(), publicDataset.DatasetScanner.GetFinding().GetEventTime(), services); err != nil {
-		return err
+	if err := json.Unmarshal(b, &entry); err != nil || entry.InsertID == "" {
+		return "", ""
 	}
-	return nil
+	return entry.LogName + "/" + entry.InsertID, entry.Timestamp
 }
 
-func executeAuditLoggingDisabled(ctx context.Context, name string, values *Values, services *Services) error {
-	automations := services.Configuration.Spec.Parameters.SHA.AuditLoggingDisabled
-	loggingScanner, err := loggingscanner.New(values.Finding)
-	if err != nil {
-		return err
-	}
-	securityMarks := loggingScanner.Loggingscanner.GetFinding().GetSecurityMarks().GetMarks()
-	remediated := securityMarks[originalEventTime] == loggingScanner.Loggingscanner.GetFinding().GetEventTime()
-	if remediated {
-		log.Printf("finding already remediated")
-		return nil
-	}
-	log.Printf("got rule %q with %d automations", name, len(automations))
-	for _, automation := range automations {
-		switch automation.Action {
-		case "enable_audit_logs":
-			values := loggingScanner.EnableAuditLogs()
-			values.DryRun = automation.Properties.DryRun
-			topic := topics[automation.Action].Topic
-			if err := publish(ctx, services, automation.Action, topic, values.ProjectID, automation.Target, automation.Exclude, values); err != nil {
-				services.Logger.Error("failed to publish: %q", err)
-				continue
+// dispatch publishes the automation unless a previous delivery of the finding already did. The
+// automation's index within the rule's configuration is sent along so automations configured
+// more than once for the same finding are told apart.
+//
+// Automations of the finding are counted against the configured limits once the project is
+// known to be targeted, and dispatched with dry_run on if a limit was exceeded. Notifications
+// pass a nil finding and are not counted. Automations requiring approval are parked until
+// approved, and those outside their maintenance windows are queued until one opens. Parked
+// automations are counted when released instead, so a window opening or a batch of approvals
+// cannot run more than the limits allow, and carry their dry run values in case one is exceeded.
+//
+// The state of the automation is returned, registry.Dispatched if a previous delivery already
+// dispatched it.
+func dispatch(ctx context.Context, r route, i int, automation *Automation, finding registry.Finding, topic, projectID string, values interface{}, attributes map[string]string, findingName, eventTime string) (state string, err error) {
+	ok, done := r.claim(ctx, i, automation, findingName, eventTime)
+	if !ok {
+		return registry.Dispatched, nil
+	}
+	defer func() { done(err) }()
+	attrs := map[string]string{IndexAttribute: strconv.Itoa(i)}
+	for k, v := range attributes {
+		attrs[k] = v
+	}
+	if err := r.inTarget(ctx, i, automation, projectID); err != nil {
+		return "", err
+	}
+	p := &automation.Properties
+	open := !p.Schedule.Configured() || inWindow(&p.Schedule)
+	var released *release
+	if finding != nil && !p.DryRun {
+		if p.Approval.Required() || !open {
+			if released, err = parked(r, i, automation, finding, projectID); err != nil {
+				return "", err
+			}
+		} else if !r.throttle(ctx, i, automation, projectID, attributes) {
+			p.DryRun = true
+			if projectID, values, err = finding.Values(automation); err != nil {
+				return "", errors.Wrapf(err, "failed to get values for %q", automation.Action)
 			}
-		default:
-			return fmt.Errorf("action %q not found", automation.Action)
 		}
 	}
-	if err := markAsRemediated(ctx, loggingScanner.Loggingscanner.GetFinding().GetName(), loggingScanner.Loggingscanner.GetFinding().GetEventTime(), services); err != nil {
-		return err
+	state = registry.Dispatched
+	// Approved automations go through their schedule so the window is checked once approved,
+	// and are counted there.
+	if p.Schedule.Configured() && !p.DryRun && (p.Approval.Required() || !open) {
+		if topic, values, err = scheduleAutomation(automation, topic, projectID, values, attrs, released); err != nil {
+			return "", err
+		}
+		released, state = nil, registry.Scheduled
 	}
-	return nil
+	if p.Approval.Required() && !p.DryRun {
+		if topic, values, err = requestApproval(automation, topic, projectID, values, attrs, released); err != nil {
+			return "", err
+		}
+		state = registry.PendingApproval
+	}
+	return state, r.publish(ctx, i, automation, topic, projectID, values, attrs)
 }
 
-func executeWebUIEnabled(ctx context.Context, name string, values *Values, services *Services) error {
-	automations := services.Configuration.Spec.Parameters.SHA.WebUIEnabled
-	containerScanner, err := containerscanner.New(values.Finding)
-	if err != nil {
-		return err
-	}
-	securityMarks := containerScanner.Containerscanner.GetFinding().GetSecurityMarks().GetMarks()
-	remediated := securityMarks[originalEventTime] == containerScanner.Containerscanner.GetFinding().GetEventTime()
-	if remediated {
-		log.Printf("finding already remediated")
-		return nil
+// release is how a parked automation is counted against the limits once released.
+type release struct {
+	limits []services.Limit
+	dryRun json.RawMessage
+}
+
+// parked returns how the parked automation is counted once released, along with the values it
+// is run with if a limit is exceeded then. It returns nil if no limit applies.
+func parked(r route, i int, automation *Automation, finding registry.Finding, projectID string) (*release, error) {
+	limits := r.limits(i, automation, projectID)
+	if len(limits) == 0 {
+		return nil, nil
 	}
-	log.Printf("got rule %q with %d automations", name, len(automations))
-	for _, automation := range automations {
-		switch automation.Action {
-		case "disable_dashboard":
-			values := containerScanner.DisableDashboard()
-			values.DryRun = automation.Properties.DryRun
-			topic := topics[automation.Action].Topic
-			if err := publish(ctx, services, automation.Action, topic, values.ProjectID, automation.Target, automation.Exclude, values); err != nil {
-				services.Logger.Error("failed to publish: %q", err)
-				continue
-			}
-		default:
-			return fmt.Errorf("action %q not found", automation.Action)
-		}
+	dryRun := *automation
+	dryRun.Properties.DryRun = true
+	_, values, err := finding.Values(&dryRun)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get values for %q", automation.Action)
 	}
-	if err := markAsRemediated(ctx, containerScanner.Containerscanner.GetFinding().GetName(), containerScanner.Containerscanner.GetFinding().GetEventTime(), services); err != nil {
-		return err
+	b, err := json.Marshal(values)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to marshal when running %q", automation.Action)
 	}
-	return nil
+	return &release{limits: limits, dryRun: b}, nil
 }
 
-func executeNonOrgIamMember(ctx context.Context, name string, values *Values, services *Services) error {
-	automations := services.Configuration.Spec.Parameters.SHA.NonOrgMembers
-	iamScanner, err := iamscanner.New(values.Finding)
+// inWindow returns if one of the schedule's maintenance windows is open.
+func inWindow(s *registry.Schedule) bool {
+	t := time.Now()
+	next, err := s.Next(t)
+	// Invalid schedules are left for the schedule function to report.
+	return err == nil && !next.After(t)
+}
+
+// scheduleAutomation returns the topic and values queuing the automation until one of its
+// maintenance windows opens. The automation's own topic, values and attributes are published
+// then.
+func scheduleAutomation(automation *Automation, topic, projectID string, values interface{}, attributes map[string]string, released *release) (string, interface{}, error) {
+	b, err := json.Marshal(values)
 	if err != nil {
-		return err
-	}
-	securityMarks := iamScanner.IAMScanner.GetFinding().GetSecurityMarks().GetMarks()
-	remediated := securityMarks[originalEventTime] == iamScanner.IAMScanner.GetFinding().GetEventTime()
-	if remediated {
-		log.Printf("finding already remediated")
-		return nil
-	}
-	log.Printf("got rule %q with %d automations", name, len(automations))
-	for _, automation := range automations {
-		switch automation.Action {
-		case "remove_non_org_members":
-			values := iamScanner.RemoveNonOrgMembers()
-			values.DryRun = automation.Properties.DryRun
-			values.AllowDomains = automation.Properties.NonOrgMembers.AllowDomains
-			topic := topics[automation.Action].Topic
-			if err := publish(ctx, services, automation.Action, topic, values.ProjectID, automation.Target, automation.Exclude, values); err != nil {
-				services.Logger.Error("failed to publish: %q", err)
-				continue
-			}
-		default:
-			return fmt.Errorf("action %q not found", automation.Action)
-		}
-	}
-	if err := markAsRemediated(ctx, iamScanner.IAMScanner.GetFinding().GetName(), iamScanner.IAMScanner.GetFinding().GetEventTime(), services); err != nil {
-		return err
-	}
-	return nil
+		return "", nil, errors.Wrapf(err, "failed to marshal when running %q", automation.Action)
+	}
+	v := &schedule.Values{
+		Action:     automation.Action,
+		ProjectID:  projectID,
+		Rule:       attributes[RuleAttribute],
+		Finding:    attributes[FindingAttribute],
+		Topic:      topic,
+		Values:     b,
+		Attributes: attributes,
+		Schedule:   automation.Properties.Schedule,
+	}
+	if released != nil {
+		v.Limits, v.DryRunValues = released.limits, released.dryRun
+	}
+	return schedule.Topic, v, nil
 }
 
-func publish(ctx context.Context, services *Services, action, topic, projectID string, target, exclude []string, values interface{}) error {
-	ok, err := services.Resource.CheckMatches(ctx, projectID, target, exclude)
+// requestApproval returns the topic and values parking the automation until it is approved.
+// The automation's own topic, values and attributes are published once approved.
+func requestApproval(automation *Automation, topic, projectID string, values interface{}, attributes map[string]string, released *release) (string, interface{}, error) {
+	b, err := json.Marshal(values)
 	if err != nil {
-		return errors.Wrapf(err, "failed to check if project %q is within the target or is excluded", projectID)
-	}
-	if !ok {
-		return fmt.Errorf("project %q is not within the target or is excluded", projectID)
-	}
+		return "", nil, errors.Wrapf(err, "failed to marshal when running %q", automation.Action)
+	}
+	a := automation.Properties.Approval
+	v := &approval.Values{
+		Action:      automation.Action,
+		ProjectID:   projectID,
+		Rule:        attributes[RuleAttribute],
+		Finding:     attributes[FindingAttribute],
+		Topic:       topic,
+		Values:      b,
+		Attributes:  attributes,
+		Email:       a.Email,
+		From:        a.From,
+		Webhook:     a.Webhook,
+		AutoApprove: a.AutoApprove,
+	}
+	if released != nil {
+		v.Limits, v.DryRunValues = released.limits, released.dryRun
+	}
+	return approval.Topic, v, nil
+}
+
+func publish(ctx context.Context, services *Services, action, topic string, values interface{}, attributes map[string]string) error {
 	b, err := json.Marshal(&values)
 	if err != nil {
 		return errors.Wrapf(err, "failed to marshal when running %q", action)
 	}
-	if _, err := services.PubSub.Publish(ctx, topic, &pubsub.Message{
-		Data: b,
-	}); err != nil {
+	message := &pubsub.Message{Data: b, Attributes: attributes}
+	if err := publishMessage(ctx, services.PubSub, topic, message); err != nil {
 		services.Logger.Error("failed to publish to %q for action %q", topic, action)
+		if derr := services.DeadLetter.Publish(ctx, "Router", topic, message, err); derr != nil {
+			services.Logger.Error("failed to send to dead-letter topic: %q", derr)
+		}
 		return err
 	}
 	log.Printf("sent to pubsub topic: %q", topic)
 	return nil
 }
+
+// publishMessage publishes the message, retrying transient failures.
+func publishMessage(ctx context.Context, ps *services.PubSub, topic string, message *pubsub.Message) error {
+	return services.Retry(ctx, publishAttempts, publishBackoff, func() error {
+		_, err := ps.Publish(ctx, topic, message)
+		return err
+	})
+}
@@ -18,17 +18,25 @@ import (
 	"context"
 	"encoding/json"
 	"io/ioutil"
+	"net/http"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/googlecloudplatform/security-response-automation/clients/stubs"
+	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/approval"
 	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/bigquery/closepublicdataset"
+	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/dns/blockdomain"
 	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/gce/createsnapshot"
 	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/gcs/closebucket"
 	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/iam/enableauditlogs"
 	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/iam/removenonorgmembers"
+	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/notify/email"
+	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/schedule"
+	"github.com/googlecloudplatform/security-response-automation/providers/registry"
 	"github.com/googlecloudplatform/security-response-automation/services"
+	"google.golang.org/api/googleapi"
 	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/testing/protocmp"
 	"google.golang.org/protobuf/types/known/fieldmaskpb"
@@ -51,8 +59,9 @@ func testData(t *testing.T, filename string) []byte {
 
 func TestRouter(t *testing.T) {
 	conf := &Configuration{}
+	conf.Spec.Parameters = map[string]map[string][]Automation{"etd": {}, "sha": {}}
 	// BadIP findings should map to "gce_create_disk_snapshot".
-	conf.Spec.Parameters.ETD.BadIP = []Automation{
+	conf.Spec.Parameters["etd"]["bad_ip"] = []Automation{
 		{Action: "gce_create_disk_snapshot", Target: []string{"organizations/456/folders/123/projects/test-project"}},
 	}
 	createSnapshotValues := &createsnapshot.Values{
@@ -72,7 +81,26 @@ func TestRouter(t *testing.T) {
 	}
 	sccCreateSnapshot, _ := json.Marshal(sccCreateSnapshotValues)
 
-	conf.Spec.Parameters.SHA.PublicBucketACL = []Automation{
+	conf.Spec.Parameters["etd"]["bad_domain"] = []Automation{
+		{Action: "block_domain", Target: []string{"organizations/456/folders/123/projects/test-project"}},
+	}
+	blockDomainValues := &blockdomain.Values{
+		ProjectID: "test-project",
+		Instance:  "source-instance-name",
+		Zone:      "zone-name",
+		Domains:   []string{"3322.org"},
+	}
+	blockDomain, _ := json.Marshal(blockDomainValues)
+
+	sccBlockDomainValues := &blockdomain.Values{
+		ProjectID: "test-project-15511551515",
+		Instance:  "bad-domain-caller",
+		Zone:      "us-central1-a",
+		Domains:   []string{"3322.org"},
+	}
+	sccBlockDomain, _ := json.Marshal(sccBlockDomainValues)
+
+	conf.Spec.Parameters["sha"]["public_bucket_acl"] = []Automation{
 		{Action: "close_bucket", Target: []string{"organizations/456/folders/123/projects/test-project"}},
 	}
 	closeBucketValues := &closebucket.Values{
@@ -89,7 +117,7 @@ func TestRouter(t *testing.T) {
 
 	r := services.NewResource(crmStub, storageStub)
 
-	conf.Spec.Parameters.SHA.PublicDataset = []Automation{
+	conf.Spec.Parameters["sha"]["bigquery_public_dataset"] = []Automation{
 		{Action: "close_public_dataset", Target: []string{"organizations/456/folders/123/projects/test-project"}},
 	}
 	closePublicDatasetValues := &closepublicdataset.Values{
@@ -99,7 +127,7 @@ func TestRouter(t *testing.T) {
 	}
 	closePublicDataset, _ := json.Marshal(closePublicDatasetValues)
 
-	conf.Spec.Parameters.SHA.AuditLoggingDisabled = []Automation{
+	conf.Spec.Parameters["sha"]["audit_logging_disabled"] = []Automation{
 		{Action: "enable_audit_logs", Target: []string{"organizations/456/folders/123/projects/test-project"}},
 	}
 	enableAuditLogsValues := &enableauditlogs.Values{
@@ -108,7 +136,7 @@ func TestRouter(t *testing.T) {
 	}
 	enableAuditLog, _ := json.Marshal(enableAuditLogsValues)
 
-	conf.Spec.Parameters.SHA.NonOrgMembers = []Automation{
+	conf.Spec.Parameters["sha"]["non_org_members"] = []Automation{
 		{Action: "remove_non_org_members", Target: []string{"organizations/456/folders/123/projects/test-project"}},
 	}
 	removeNonOrgMembersValues := &removenonorgmembers.Values{
@@ -128,6 +156,17 @@ func TestRouter(t *testing.T) {
 			finding: testData(t, "audit_logging_disabled.json"),
 			mapTo:   enableAuditLog,
 		},
+		{
+			name:    "bad_domain",
+			finding: testData(t, "bad_domain.json"),
+			nonSCC:  true,
+			mapTo:   blockDomain,
+		},
+		{
+			name:    "bad_domain_scc",
+			finding: testData(t, "bad_domain_scc.json"),
+			mapTo:   sccBlockDomain,
+		},
 		{
 			name:    "bad_ip",
 			finding: testData(t, "bad_ip.json"),
@@ -185,8 +224,20 @@ func TestRouter(t *testing.T) {
 				t.Errorf("%q failed, difference:%+v", tt.name, diff)
 			}
 
+			if nm == nil {
+				var entry struct{ LogName, InsertID string }
+				if err := json.Unmarshal(tt.finding, &entry); err != nil {
+					t.Fatal(err)
+				}
+				if got, want := psStub.PublishedMessage.Attributes[FindingAttribute], entry.LogName+"/"+entry.InsertID; entry.InsertID == "" || got != want {
+					t.Errorf("%q failed, finding attribute got:%q want:%q", tt.name, got, want)
+				}
+			}
 			if nm != nil {
 				f := nm.GetFinding()
+				if got := psStub.PublishedMessage.Attributes[FindingAttribute]; got != f.GetName() {
+					t.Errorf("%q failed, finding attribute got:%q want:%q", tt.name, got, f.GetName())
+				}
 				want := &sccpb.UpdateSecurityMarksRequest{
 					SecurityMarks: &sccpb.SecurityMarks{
 						Name: f.GetName() + "/securityMarks",
@@ -212,6 +263,7 @@ func TestRemediated(t *testing.T) {
 		finding string // file name under testdata/
 	}{
 		{name: "audit_logging_disabled", finding: "audit_logging_disabled-remediated.json"},
+		{name: "bad_domain_scc", finding: "bad_domain_scc-remediated.json"},
 		{name: "bad_ip_scc", finding: "bad_ip_scc-remediated.json"},
 		{name: "bucket_policy_only_disabled", finding: "bucket_policy_only_disabled-remediated.json"},
 		{name: "iam_anomalous_grant", finding: "iam_anomalous_grant-remediated.json"},
@@ -263,3 +315,381 @@ func TestRemediated(t *testing.T) {
 		})
 	}
 }
+
+func TestNotify(t *testing.T) {
+	ctx := context.Background()
+	notify := Automation{Action: "notify_email", Target: []string{"organizations/456/folders/123/projects/test-project"}}
+	notify.Properties.NotifyEmail.To = []string{"owner@example.com"}
+	notify.Properties.NotifyEmail.From = "sra@example.com"
+	closeBucket := Automation{Action: "close_bucket", Target: []string{"organizations/456/folders/123/projects/test-project"}}
+	closeBucket.Properties.DryRun = true
+
+	finding := testData(t, "public_bucket_acl.json")
+	var nm sccv1pb.NotificationMessage
+	if err := protojson.Unmarshal(finding, &nm); err != nil {
+		t.Fatalf("Unmarshal(finding) = %v, want nil", err)
+	}
+
+	for _, tt := range []struct {
+		name        string
+		automations []Automation
+		want        *email.Values
+	}{
+		{
+			name:        "notify after remediation",
+			automations: []Automation{notify, closeBucket},
+			want: &email.Values{
+				ProjectID: "test-project",
+				Rule:      "public_bucket_acl",
+				Finding:   nm.GetFinding().GetName(),
+				Actions:   []email.Action{{Action: "close_bucket", DryRun: true, State: registry.Dispatched}},
+				To:        []string{"owner@example.com"},
+				From:      "sra@example.com",
+			},
+		},
+		{
+			name:        "notify only",
+			automations: []Automation{notify},
+			want: &email.Values{
+				ProjectID: "test-project",
+				Rule:      "public_bucket_acl",
+				Finding:   nm.GetFinding().GetName(),
+				Actions:   []email.Action{},
+				To:        []string{"owner@example.com"},
+				From:      "sra@example.com",
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := &Configuration{}
+			conf.Spec.Parameters = map[string]map[string][]Automation{"sha": {"public_bucket_acl": tt.automations}}
+			crmStub := &stubs.ResourceManagerStub{}
+			crmStub.GetAncestryResponse = services.CreateAncestors([]string{"project/test-project", "folder/123", "organization/456"})
+			psStub := &stubs.PubSubStub{}
+			if err := Execute(ctx, &Values{Finding: finding}, &Services{
+				PubSub:                services.NewPubSub(psStub),
+				Logger:                services.NewLogger(&stubs.LoggerStub{}),
+				Configuration:         conf,
+				Resource:              services.NewResource(crmStub, &stubs.StorageStub{}),
+				SecurityCommandCenter: services.NewCommandCenter(&stubs.SecurityCommandCenterStub{}),
+			}); err != nil {
+				t.Fatalf("%q failed: %q", tt.name, err)
+			}
+			var got email.Values
+			if err := json.Unmarshal(psStub.PublishedMessage.Data, &got); err != nil {
+				t.Fatalf("%q failed to unmarshal published message: %q", tt.name, err)
+			}
+			if !strings.Contains(string(got.Data), nm.GetFinding().GetName()) {
+				t.Errorf("%q failed, notification does not hold the finding: %s", tt.name, got.Data)
+			}
+			got.Data = nil
+			if diff := cmp.Diff(tt.want, &got); diff != "" {
+				t.Errorf("%q failed, difference (-want +got):\n%s", tt.name, diff)
+			}
+		})
+	}
+}
+
+func TestSeverity(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		finding []byte
+		want    string
+	}{
+		{name: "scc finding", finding: testData(t, "ssh_brute_force-remediated.json"), want: "HIGH"},
+		{name: "etd log finding", finding: []byte(`{"jsonPayload": {"detectionPriority": "LOW"}}`), want: "LOW"},
+		{name: "etd scc finding", finding: []byte(`{"finding": {"sourceProperties": {"detectionPriority": "MEDIUM"}}}`), want: "MEDIUM"},
+		{name: "none", finding: []byte(`{}`), want: ""},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := severity(tt.finding); got != tt.want {
+				t.Errorf("severity() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedelivery(t *testing.T) {
+	ctx := context.Background()
+	closeBucket := Automation{Action: "close_bucket", Target: []string{"organizations/456/folders/123/projects/test-project"}}
+	conf := &Configuration{}
+	conf.Spec.Parameters = map[string]map[string][]Automation{"sha": {"public_bucket_acl": {closeBucket, closeBucket}}}
+	finding := testData(t, "public_bucket_acl.json")
+	var nm sccv1pb.NotificationMessage
+	if err := protojson.Unmarshal(finding, &nm); err != nil {
+		t.Fatalf("Unmarshal(finding) = %v, want nil", err)
+	}
+	crmStub := &stubs.ResourceManagerStub{}
+	crmStub.GetAncestryResponse = services.CreateAncestors([]string{"project/test-project", "folder/123", "organization/456"})
+	psStub := &stubs.PubSubStub{}
+	svcs := &Services{
+		PubSub:                services.NewPubSub(psStub),
+		Logger:                services.NewLogger(&stubs.LoggerStub{}),
+		Configuration:         conf,
+		Resource:              services.NewResource(crmStub, &stubs.StorageStub{}),
+		SecurityCommandCenter: services.NewCommandCenter(&stubs.SecurityCommandCenterStub{}),
+		Idempotency:           services.NewIdempotency(services.NewMemoryStore()),
+	}
+
+	if err := Execute(ctx, &Values{Finding: finding}, svcs); err != nil {
+		t.Fatalf("first delivery failed: %q", err)
+	}
+	if psStub.PublishedMessage == nil {
+		t.Fatalf("first delivery was not published")
+	}
+	want := map[string]string{
+		RuleAttribute:      "public_bucket_acl",
+		FindingAttribute:   nm.GetFinding().GetName(),
+		EventTimeAttribute: nm.GetFinding().GetEventTime().AsTime().UTC().Format(time.RFC3339Nano),
+		IndexAttribute:     "1",
+	}
+	if diff := cmp.Diff(want, psStub.PublishedMessage.Attributes); diff != "" {
+		t.Errorf("attributes differ (-want +got):\n%s", diff)
+	}
+
+	psStub.PublishedMessage = nil
+	if err := Execute(ctx, &Values{Finding: finding}, svcs); err != nil {
+		t.Fatalf("redelivery failed: %q", err)
+	}
+	if psStub.PublishedMessage != nil {
+		t.Errorf("redelivery was published again: %+v", psStub.PublishedMessage.Attributes)
+	}
+}
+
+func TestLimits(t *testing.T) {
+	ctx := context.Background()
+	closeBucket := Automation{Action: "close_bucket", Target: []string{"organizations/456/folders/123/projects/test-project"}}
+	conf := &Configuration{}
+	conf.Spec.Parameters = map[string]map[string][]Automation{"sha": {"public_bucket_acl": {closeBucket}}}
+	conf.Spec.Limits = []Limit{{Action: "close_bucket", Per: PerProject, Max: 2, Window: "1h", Cooldown: "6h"}}
+	crmStub := &stubs.ResourceManagerStub{}
+	crmStub.GetAncestryResponse = services.CreateAncestors([]string{"project/test-project", "folder/123", "organization/456"})
+	psStub := &stubs.PubSubStub{}
+	svcs := &Services{
+		PubSub:                services.NewPubSub(psStub),
+		Logger:                services.NewLogger(&stubs.LoggerStub{}),
+		Configuration:         conf,
+		Resource:              services.NewResource(crmStub, &stubs.StorageStub{}),
+		SecurityCommandCenter: services.NewCommandCenter(&stubs.SecurityCommandCenterStub{}),
+		Limiter:               services.NewLimiter(services.NewMemoryLimitStore()),
+	}
+	finding := testData(t, "public_bucket_acl.json")
+	for i := 0; i < 4; i++ {
+		if err := Execute(ctx, &Values{Finding: finding}, svcs); err != nil {
+			t.Fatalf("finding %d failed: %q", i, err)
+		}
+	}
+	var dryRun []bool
+	var notices []BreakerNotice
+	for _, m := range psStub.PublishedMessages {
+		var v struct {
+			DryRun bool
+			Limit  *Limit
+		}
+		if err := json.Unmarshal(m.Data, &v); err != nil {
+			t.Fatalf("failed to unmarshal %s: %q", m.Data, err)
+		}
+		if v.Limit == nil {
+			dryRun = append(dryRun, v.DryRun)
+			continue
+		}
+		var n BreakerNotice
+		if err := json.Unmarshal(m.Data, &n); err != nil {
+			t.Fatalf("failed to unmarshal notice: %q", err)
+		}
+		notices = append(notices, n)
+	}
+	if diff := cmp.Diff([]bool{false, false, true, true}, dryRun); diff != "" {
+		t.Errorf("dry_run of dispatched automations differs (-want +got):\n%s", diff)
+	}
+	if len(notices) != 1 || notices[0].Action != "close_bucket" || notices[0].ProjectID != "test-project" {
+		t.Errorf("got circuit breaker notices %+v, want one for close_bucket in test-project", notices)
+	}
+}
+
+func TestLimitsCountTargeted(t *testing.T) {
+	ctx := context.Background()
+	excluded := Automation{Action: "close_bucket", Target: []string{"organizations/456/folders/123/projects/test-project"}, Exclude: []string{"organizations/456/folders/123/projects/test-project"}}
+	conf := &Configuration{}
+	conf.Spec.Parameters = map[string]map[string][]Automation{"sha": {"public_bucket_acl": {excluded}}}
+	conf.Spec.Limits = []Limit{{Action: "close_bucket", Max: 1, Window: "1h"}}
+	crmStub := &stubs.ResourceManagerStub{}
+	crmStub.GetAncestryResponse = services.CreateAncestors([]string{"project/test-project", "folder/123", "organization/456"})
+	psStub := &stubs.PubSubStub{}
+	svcs := &Services{
+		PubSub:                services.NewPubSub(psStub),
+		Logger:                services.NewLogger(&stubs.LoggerStub{}),
+		Configuration:         conf,
+		Resource:              services.NewResource(crmStub, &stubs.StorageStub{}),
+		SecurityCommandCenter: services.NewCommandCenter(&stubs.SecurityCommandCenterStub{}),
+		Limiter:               services.NewLimiter(services.NewMemoryLimitStore()),
+	}
+	finding := testData(t, "public_bucket_acl.json")
+	for i := 0; i < 3; i++ {
+		if err := Execute(ctx, &Values{Finding: finding}, svcs); err != nil {
+			t.Fatalf("finding %d failed: %q", i, err)
+		}
+	}
+	if len(psStub.PublishedMessages) != 0 {
+		t.Fatalf("excluded automation was published: %d messages", len(psStub.PublishedMessages))
+	}
+	conf.Spec.Parameters["sha"]["public_bucket_acl"][0].Exclude = nil
+	if err := Execute(ctx, &Values{Finding: finding}, svcs); err != nil {
+		t.Fatalf("Execute() failed: %q", err)
+	}
+	if len(psStub.PublishedMessages) != 1 {
+		t.Fatalf("got %d published messages, want 1", len(psStub.PublishedMessages))
+	}
+	var v struct{ DryRun bool }
+	if err := json.Unmarshal(psStub.PublishedMessages[0].Data, &v); err != nil {
+		t.Fatalf("failed to unmarshal %s: %q", psStub.PublishedMessages[0].Data, err)
+	}
+	if v.DryRun {
+		t.Errorf("automation was dispatched with dry_run on, excluded automations were counted")
+	}
+}
+
+func TestPublishDeadLetter(t *testing.T) {
+	ctx := context.Background()
+	closeBucket := Automation{Action: "close_bucket", Target: []string{"organizations/456/folders/123/projects/test-project"}}
+	conf := &Configuration{}
+	conf.Spec.Parameters = map[string]map[string][]Automation{"sha": {"public_bucket_acl": {closeBucket}}}
+	crmStub := &stubs.ResourceManagerStub{}
+	crmStub.GetAncestryResponse = services.CreateAncestors([]string{"project/test-project", "folder/123", "organization/456"})
+	psStub := &stubs.PubSubStub{PublishError: &googleapi.Error{Code: http.StatusForbidden}}
+	dlStub := &stubs.PubSubStub{}
+	if err := Execute(ctx, &Values{Finding: testData(t, "public_bucket_acl.json")}, &Services{
+		PubSub:                services.NewPubSub(psStub),
+		Logger:                services.NewLogger(&stubs.LoggerStub{}),
+		Configuration:         conf,
+		Resource:              services.NewResource(crmStub, &stubs.StorageStub{}),
+		SecurityCommandCenter: services.NewCommandCenter(&stubs.SecurityCommandCenterStub{}),
+		DeadLetter:            services.NewDeadLetter(services.NewPubSub(dlStub), "threat-findings-dead-letter"),
+	}); err != nil {
+		t.Fatalf("Execute() failed: %q", err)
+	}
+	if dlStub.PublishedMessage == nil {
+		t.Fatalf("failed publish was not sent to the dead-letter topic")
+	}
+	var got services.DeadLetterMessage
+	if err := json.Unmarshal(dlStub.PublishedMessage.Data, &got); err != nil {
+		t.Fatalf("failed to unmarshal dead letter: %q", err)
+	}
+	if got.Function != "Router" || got.Topic != "threat-findings-close-bucket" || got.Transient {
+		t.Errorf("unexpected dead letter: %+v", got)
+	}
+	var values closebucket.Values
+	if err := json.Unmarshal(got.Data, &values); err != nil || values.BucketName == "" {
+		t.Errorf("dead letter does not hold the automation's values: %s", got.Data)
+	}
+}
+
+func TestApproval(t *testing.T) {
+	ctx := context.Background()
+	closeBucket := Automation{Action: "close_bucket", Target: []string{"organizations/456/folders/123/projects/test-project"}}
+	closeBucket.Properties.Approval.Webhook = "secops"
+	closeBucket.Properties.Approval.AutoApprove = "4h"
+	conf := &Configuration{}
+	conf.Spec.Parameters = map[string]map[string][]Automation{"sha": {"public_bucket_acl": {closeBucket}}}
+	crmStub := &stubs.ResourceManagerStub{}
+	crmStub.GetAncestryResponse = services.CreateAncestors([]string{"project/test-project", "folder/123", "organization/456"})
+	psStub := &stubs.PubSubStub{}
+	if err := Execute(ctx, &Values{Finding: testData(t, "public_bucket_acl.json")}, &Services{
+		PubSub:                services.NewPubSub(psStub),
+		Logger:                services.NewLogger(&stubs.LoggerStub{}),
+		Configuration:         conf,
+		Resource:              services.NewResource(crmStub, &stubs.StorageStub{}),
+		SecurityCommandCenter: services.NewCommandCenter(&stubs.SecurityCommandCenterStub{}),
+	}); err != nil {
+		t.Fatalf("Execute() failed: %q", err)
+	}
+	var got approval.Values
+	if err := json.Unmarshal(psStub.PublishedMessage.Data, &got); err != nil {
+		t.Fatalf("failed to unmarshal approval request: %q", err)
+	}
+	if got.Action != "close_bucket" || got.Topic != "threat-findings-close-bucket" || got.ProjectID != "test-project" || got.Webhook != closeBucket.Properties.Approval.Webhook || got.AutoApprove != "4h" {
+		t.Errorf("unexpected approval request: %+v", got)
+	}
+	if got.Attributes[RuleAttribute] != "public_bucket_acl" || got.Attributes[IndexAttribute] != "0" {
+		t.Errorf("approval request attributes = %v, want the automation's attributes", got.Attributes)
+	}
+	var values closebucket.Values
+	if err := json.Unmarshal(got.Values, &values); err != nil || values.BucketName == "" {
+		t.Errorf("approval request does not hold the automation's values: %s", got.Values)
+	}
+}
+
+func TestSchedule(t *testing.T) {
+	ctx := context.Background()
+	// A daily window opening in two hours is closed now.
+	start := time.Now().UTC().Add(2 * time.Hour)
+	closeBucket := Automation{Action: "close_bucket", Target: []string{"organizations/456/folders/123/projects/test-project"}}
+	closeBucket.Properties.Schedule.Windows = []registry.Window{{Start: start.Format("15:04"), End: start.Add(time.Hour).Format("15:04")}}
+	conf := &Configuration{}
+	conf.Spec.Parameters = map[string]map[string][]Automation{"sha": {"public_bucket_acl": {closeBucket}}}
+	crmStub := &stubs.ResourceManagerStub{}
+	crmStub.GetAncestryResponse = services.CreateAncestors([]string{"project/test-project", "folder/123", "organization/456"})
+	psStub := &stubs.PubSubStub{}
+	if err := Execute(ctx, &Values{Finding: testData(t, "public_bucket_acl.json")}, &Services{
+		PubSub:                services.NewPubSub(psStub),
+		Logger:                services.NewLogger(&stubs.LoggerStub{}),
+		Configuration:         conf,
+		Resource:              services.NewResource(crmStub, &stubs.StorageStub{}),
+		SecurityCommandCenter: services.NewCommandCenter(&stubs.SecurityCommandCenterStub{}),
+	}); err != nil {
+		t.Fatalf("Execute() failed: %q", err)
+	}
+	var got schedule.Values
+	if err := json.Unmarshal(psStub.PublishedMessage.Data, &got); err != nil {
+		t.Fatalf("failed to unmarshal scheduled automation: %q", err)
+	}
+	if got.Action != "close_bucket" || got.Topic != "threat-findings-close-bucket" || len(got.Schedule.Windows) != 1 {
+		t.Errorf("unexpected scheduled automation: %+v", got)
+	}
+	var values closebucket.Values
+	if err := json.Unmarshal(got.Values, &values); err != nil || values.BucketName == "" {
+		t.Errorf("scheduled automation does not hold the automation's values: %s", got.Values)
+	}
+}
+
+func TestParkedLimits(t *testing.T) {
+	ctx := context.Background()
+	// A daily window opening in two hours is closed now.
+	start := time.Now().UTC().Add(2 * time.Hour)
+	closeBucket := Automation{Action: "close_bucket", Target: []string{"organizations/456/folders/123/projects/test-project"}}
+	closeBucket.Properties.Schedule.Windows = []registry.Window{{Start: start.Format("15:04"), End: start.Add(time.Hour).Format("15:04")}}
+	conf := &Configuration{}
+	conf.Spec.Parameters = map[string]map[string][]Automation{"sha": {"public_bucket_acl": {closeBucket}}}
+	// Already exceeded by the first automation counted.
+	conf.Spec.Limits = []Limit{{Action: "close_bucket", Max: 0, Window: "1h"}}
+	crmStub := &stubs.ResourceManagerStub{}
+	crmStub.GetAncestryResponse = services.CreateAncestors([]string{"project/test-project", "folder/123", "organization/456"})
+	psStub := &stubs.PubSubStub{}
+	if err := Execute(ctx, &Values{Finding: testData(t, "public_bucket_acl.json")}, &Services{
+		PubSub:                services.NewPubSub(psStub),
+		Logger:                services.NewLogger(&stubs.LoggerStub{}),
+		Configuration:         conf,
+		Resource:              services.NewResource(crmStub, &stubs.StorageStub{}),
+		SecurityCommandCenter: services.NewCommandCenter(&stubs.SecurityCommandCenterStub{}),
+		Limiter:               services.NewLimiter(services.NewMemoryLimitStore()),
+	}); err != nil {
+		t.Fatalf("Execute() failed: %q", err)
+	}
+	if psStub.PublishedTopic != schedule.Topic {
+		t.Fatalf("published to %q, want the automation queued on %q", psStub.PublishedTopic, schedule.Topic)
+	}
+	var got schedule.Values
+	if err := json.Unmarshal(psStub.PublishedMessage.Data, &got); err != nil {
+		t.Fatalf("failed to unmarshal scheduled automation: %q", err)
+	}
+	if len(got.Limits) != 1 || got.Limits[0].Key != "close_bucket/organization/1h" {
+		t.Errorf("scheduled automation limits = %+v, want the close_bucket limit", got.Limits)
+	}
+	var live, dryRun closebucket.Values
+	if err := json.Unmarshal(got.Values, &live); err != nil || live.DryRun {
+		t.Errorf("scheduled automation values = %s, want dry_run off until released", got.Values)
+	}
+	if err := json.Unmarshal(got.DryRunValues, &dryRun); err != nil || !dryRun.DryRun || dryRun.BucketName != live.BucketName {
+		t.Errorf("scheduled automation dry run values = %s, want dry_run on", got.DryRunValues)
+	}
+}
@@ -0,0 +1,264 @@
+package router
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/googlecloudplatform/security-response-automation/providers/registry"
+	"github.com/googlecloudplatform/security-response-automation/services"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// webhookName matches the names of webhooks configured with webhook-urls.
+var webhookName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// propertyChecks validate the properties required by an action.
+var propertyChecks = map[string]func(a *Automation) error{
+	"iam_revoke":               checkRevokeIAM,
+	"remove_non_org_members":   checkNonOrgMembers,
+	"remediate_firewall":       checkOpenFirewall,
+	"gce_create_disk_snapshot": checkCreateSnapshot,
+	"notify_email":             checkNotifyEmail,
+	"pagerduty_incident":       checkPagerDutyIncident,
+	"notify_webhook":           checkNotifyWebhook,
+}
+
+// ParseConfig strictly parses a router configuration. Unlike Config, unknown keys are rejected.
+func ParseConfig(b []byte) (*Configuration, error) {
+	var c Configuration
+	if err := yaml.UnmarshalStrict(b, &c); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal config")
+	}
+	return &c, nil
+}
+
+// Validate checks every configured finding is registered, every action is supported by its
+// finding, target and exclude patterns are valid and the properties required by each action are
+// set, as well as the configured limits. All problems found are returned.
+func (c *Configuration) Validate() []error {
+	var errs []error
+	providers := make([]string, 0, len(c.Spec.Parameters))
+	for provider := range c.Spec.Parameters {
+		providers = append(providers, provider)
+	}
+	sort.Strings(providers)
+	for _, provider := range providers {
+		keys := make([]string, 0, len(c.Spec.Parameters[provider]))
+		for key := range c.Spec.Parameters[provider] {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			rule := findRule(provider, key)
+			if rule == nil {
+				errs = append(errs, fmt.Errorf("%s.%s: unknown finding", provider, key))
+				continue
+			}
+			for i, automation := range c.Spec.Parameters[provider][key] {
+				automation := automation
+				for _, err := range validateAutomation(rule, &automation) {
+					errs = append(errs, fmt.Errorf("%s.%s[%d]: %v", provider, key, i, err))
+				}
+			}
+		}
+	}
+	for i, limit := range c.Spec.Limits {
+		if err := checkLimit(&limit); err != nil {
+			errs = append(errs, fmt.Errorf("limits[%d]: %v", i, err))
+		}
+	}
+	return errs
+}
+
+// validateAutomation returns the problems found with a single automation.
+func validateAutomation(rule *registry.Rule, automation *Automation) []error {
+	var errs []error
+	_, notification := registry.LookupNotifier(automation.Action)
+	if !notification && !rule.Supports(automation.Action) {
+		errs = append(errs, fmt.Errorf("action %q is not supported by %q, expected one of %q or a notification", automation.Action, rule.Name, rule.Actions))
+	}
+	if len(automation.Target) == 0 {
+		errs = append(errs, errors.New("no target, the automation will never run"))
+	}
+	for _, pattern := range append(append([]string{}, automation.Target...), automation.Exclude...) {
+		if err := services.ValidateAncestryPattern(pattern); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := checkApproval(automation); err != nil {
+		errs = append(errs, err)
+	}
+	if err := checkSchedule(automation); err != nil {
+		errs = append(errs, err)
+	}
+	if check, ok := propertyChecks[automation.Action]; ok {
+		if err := check(automation); err != nil {
+			errs = append(errs, errors.Wrapf(err, "action %q", automation.Action))
+		}
+	}
+	return errs
+}
+
+// findRule returns the rule registered under the provider and configuration key.
+func findRule(provider, key string) *registry.Rule {
+	for _, r := range registry.Rules() {
+		if r.Provider == provider && r.Key == key {
+			return r
+		}
+	}
+	return nil
+}
+
+func checkRevokeIAM(a *Automation) error {
+	if len(a.Properties.RevokeIAM.AllowDomains) == 0 {
+		return errors.New("revoke_iam.allow_domains is required")
+	}
+	return nil
+}
+
+func checkNonOrgMembers(a *Automation) error {
+	if len(a.Properties.NonOrgMembers.AllowDomains) == 0 {
+		return errors.New("non_org_members.allow_domains is required")
+	}
+	return nil
+}
+
+func checkOpenFirewall(a *Automation) error {
+	p := a.Properties.OpenFirewall
+	switch p.RemediationAction {
+	case "disable", "delete":
+		return nil
+	case "update_source_range":
+		if len(p.SourceRanges) == 0 {
+			return errors.New("open_firewall.source_ranges is required with update_source_range")
+		}
+		for _, r := range p.SourceRanges {
+			if _, _, err := net.ParseCIDR(r); err != nil {
+				return errors.Errorf("open_firewall.source_ranges: %q is not in CIDR notation", r)
+			}
+		}
+		return nil
+	default:
+		return errors.Errorf("open_firewall.remediation_action must be one of disable, delete or update_source_range, got %q", p.RemediationAction)
+	}
+}
+
+func checkCreateSnapshot(a *Automation) error {
+	p := a.Properties.CreateSnapshot
+	for _, output := range p.Output {
+		if output != "turbinia" {
+			return errors.Errorf("gce_create_snapshot.output: unknown output %q", output)
+		}
+		if p.Turbinia.ProjectID == "" || p.Turbinia.Topic == "" || p.Turbinia.Zone == "" {
+			return errors.New("gce_create_snapshot.turbinia projectid, topic and zone are required with the turbinia output")
+		}
+	}
+	return nil
+}
+
+func checkNotifyEmail(a *Automation) error {
+	if len(a.Properties.NotifyEmail.To) == 0 {
+		return errors.New("notify_email.to is required")
+	}
+	return nil
+}
+
+func checkPagerDutyIncident(a *Automation) error {
+	p := a.Properties.PagerDutyIncident
+	if p.ServiceID == "" {
+		return errors.New("pagerduty_incident.service_id is required")
+	}
+	if p.From == "" {
+		return errors.New("pagerduty_incident.from is required")
+	}
+	for severity, urgency := range p.Urgency {
+		if urgency != "high" && urgency != "low" {
+			return errors.Errorf("pagerduty_incident.urgency: %q must map to high or low, got %q", severity, urgency)
+		}
+	}
+	return nil
+}
+
+func checkNotifyWebhook(a *Automation) error {
+	if !webhookName.MatchString(a.Properties.NotifyWebhook.Webhook) {
+		return errors.Errorf("notify_webhook.webhook must name a webhook from webhook-urls, got %q", a.Properties.NotifyWebhook.Webhook)
+	}
+	return nil
+}
+
+func checkApproval(a *Automation) error {
+	p := a.Properties.Approval
+	if !p.Required() {
+		if p.AutoApprove != "" || p.From != "" {
+			return errors.New("approval requires email or webhook approvers")
+		}
+		return nil
+	}
+	if len(p.Email) > 0 && p.From == "" {
+		return errors.New("approval.from is required with email approvers")
+	}
+	if p.Webhook != "" && !webhookName.MatchString(p.Webhook) {
+		return errors.Errorf("approval.webhook must name a webhook from webhook-urls, got %q", p.Webhook)
+	}
+	if p.AutoApprove != "" {
+		if d, err := time.ParseDuration(p.AutoApprove); err != nil || d <= 0 {
+			return errors.Errorf("approval.auto_approve must be a positive duration such as 4h, got %q", p.AutoApprove)
+		}
+	}
+	return nil
+}
+
+func checkSchedule(a *Automation) error {
+	s := a.Properties.Schedule
+	if !s.Configured() {
+		if s.Timezone != "" {
+			return errors.New("schedule requires windows")
+		}
+		return nil
+	}
+	return errors.Wrap(s.Validate(), "schedule")
+}
+
+func checkLimit(l *Limit) error {
+	if l.Action != "" {
+		if _, ok := registry.LookupNotifier(l.Action); ok {
+			return errors.Errorf("notification %q can not be limited", l.Action)
+		}
+		if _, ok := registry.Topic(l.Action); !ok {
+			return errors.Errorf("unknown action %q", l.Action)
+		}
+	}
+	if l.Per != "" && l.Per != PerOrganization && l.Per != PerProject {
+		return errors.Errorf("per must be %s or %s, got %q", PerOrganization, PerProject, l.Per)
+	}
+	if l.Max <= 0 {
+		return errors.New("max must be positive")
+	}
+	if d, err := time.ParseDuration(l.Window); err != nil || d <= 0 {
+		return errors.Errorf("window must be a positive duration such as 1h, got %q", l.Window)
+	}
+	if l.Cooldown != "" {
+		if d, err := time.ParseDuration(l.Cooldown); err != nil || d <= 0 {
+			return errors.Errorf("cooldown must be a positive duration such as 6h, got %q", l.Cooldown)
+		}
+	}
+	return nil
+}
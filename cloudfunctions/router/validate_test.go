@@ -0,0 +1,235 @@
+package router
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+const configHeader = `apiVersion: security-response-automation.cloud.google.com/v1alpha1
+kind: Remediation
+metadata:
+  name: router
+spec:
+  parameters:
+`
+
+func TestValidateSample(t *testing.T) {
+	b, err := ioutil.ReadFile("../../config/sra.yaml.sample")
+	if err != nil {
+		t.Fatalf("failed to read sample: %q", err)
+	}
+	c, err := ParseConfig(b)
+	if err != nil {
+		t.Fatalf("ParseConfig() failed: %q", err)
+	}
+	if errs := c.Validate(); len(errs) != 0 {
+		t.Errorf("Validate() = %q, want no errors", errs)
+	}
+}
+
+func TestParseConfigStrict(t *testing.T) {
+	config := configHeader + `    sha:
+      public_bucket_acl:
+        - action: close_bucket
+          target:
+            - organizations/456/*
+          properties:
+            dryrun: true
+`
+	if _, err := ParseConfig([]byte(config)); err == nil || !strings.Contains(err.Error(), "dryrun") {
+		t.Errorf("ParseConfig() = %v, want error about unknown key dryrun", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		config  string
+		wantErr []string
+	}{
+		{
+			name: "valid",
+			config: `    etd:
+      anomalous_iam:
+        - action: iam_revoke
+          target:
+            - organizations/456/folders/123/*
+          exclude:
+            - organizations/456/folders/123/projects/sandbox
+          properties:
+            revoke_iam:
+              allow_domains:
+                - example.com
+        - action: notify_email
+          target:
+            - organizations/456/*
+          properties:
+            notify_email:
+              to:
+                - owner@example.com
+`,
+		},
+		{
+			name: "unknown finding",
+			config: `    sha:
+      public_bucket:
+        - action: close_bucket
+          target:
+            - organizations/456/*
+`,
+			wantErr: []string{`sha.public_bucket: unknown finding`},
+		},
+		{
+			name: "unsupported action",
+			config: `    sha:
+      public_bucket_acl:
+        - action: close_cloud_sql
+          target:
+            - organizations/456/*
+`,
+			wantErr: []string{`sha.public_bucket_acl[0]: action "close_cloud_sql" is not supported`},
+		},
+		{
+			name: "invalid patterns",
+			config: `    sha:
+      public_bucket_acl:
+        - action: close_bucket
+          exclude:
+            - folders/123/*
+`,
+			wantErr: []string{
+				`sha.public_bucket_acl[0]: no target`,
+				`sha.public_bucket_acl[0]: "folders/123/*" is not an ancestry pattern`,
+			},
+		},
+		{
+			name: "missing properties",
+			config: `    etd:
+      anomalous_iam:
+        - action: iam_revoke
+          target:
+            - organizations/456/*
+    sha:
+      open_firewall:
+        - action: remediate_firewall
+          target:
+            - organizations/456/*
+          properties:
+            open_firewall:
+              remediation_action: update_source_range
+              source_ranges:
+                - 10.0.0.0
+`,
+			wantErr: []string{
+				`etd.anomalous_iam[0]: action "iam_revoke": revoke_iam.allow_domains is required`,
+				`sha.open_firewall[0]: action "remediate_firewall": open_firewall.source_ranges: "10.0.0.0" is not in CIDR notation`,
+			},
+		},
+		{
+			name: "invalid approval",
+			config: `    sha:
+      open_firewall:
+        - action: remediate_firewall
+          target:
+            - organizations/456/*
+          properties:
+            open_firewall:
+              remediation_action: delete
+            approval:
+              email:
+                - secops@example.com
+              auto_approve: 4 hours
+`,
+			wantErr: []string{`sha.open_firewall[0]: approval.from is required with email approvers`},
+		},
+		{
+			name: "webhook URL instead of name",
+			config: `    sha:
+      public_bucket_acl:
+        - action: notify_webhook
+          target:
+            - organizations/456/*
+          properties:
+            notify_webhook:
+              webhook: https://hooks.slack.com/services/T000/B000/XXXX
+        - action: close_bucket
+          target:
+            - organizations/456/*
+          properties:
+            approval:
+              webhook: https://hooks.slack.com/services/T000/B000/XXXX
+`,
+			wantErr: []string{
+				`sha.public_bucket_acl[0]: action "notify_webhook": notify_webhook.webhook must name a webhook from webhook-urls, got "https://hooks.slack.com/services/T000/B000/XXXX"`,
+				`sha.public_bucket_acl[1]: approval.webhook must name a webhook from webhook-urls, got "https://hooks.slack.com/services/T000/B000/XXXX"`,
+			},
+		},
+		{
+			name: "invalid schedule",
+			config: `    sha:
+      public_bucket_acl:
+        - action: close_bucket
+          target:
+            - organizations/456/*
+          properties:
+            schedule:
+              timezone: Europe/London
+              windows:
+                - days:
+                    - someday
+                  start: "22:00"
+                  end: "02:00"
+`,
+			wantErr: []string{`sha.public_bucket_acl[0]: schedule: windows[0]: unknown day "someday"`},
+		},
+		{
+			name: "invalid limits",
+			config: `    sha:
+      public_bucket_acl:
+        - action: close_bucket
+          target:
+            - organizations/456/*
+  limits:
+    - action: close_bucket
+      max: 20
+      window: 1h
+    - action: notify_email
+      per: folder
+      max: 0
+      window: 1h
+`,
+			wantErr: []string{`limits[1]: notification "notify_email" can not be limited`},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := ParseConfig([]byte(configHeader + tt.config))
+			if err != nil {
+				t.Fatalf("ParseConfig() failed: %q", err)
+			}
+			errs := c.Validate()
+			if len(errs) != len(tt.wantErr) {
+				t.Fatalf("Validate() = %q, want %d errors", errs, len(tt.wantErr))
+			}
+			for i, want := range tt.wantErr {
+				if !strings.HasPrefix(errs[i].Error(), want) {
+					t.Errorf("Validate()[%d] = %q, want prefix %q", i, errs[i], want)
+				}
+			}
+		})
+	}
+}
@@ -4,3 +4,15 @@ variable "folder-ids" {
   type        = list(string)
   description = "Folder IDs to grant the necessary permissions for this Cloud Function execution."
 }
+
+variable "config-bucket" {
+  type        = string
+  default     = ""
+  description = "Bucket holding the router configuration. The configuration bundled with the function is used if empty."
+}
+
+variable "config-object" {
+  type        = string
+  default     = "sra.yaml"
+  description = "Name of the router configuration object within the config bucket."
+}
@@ -0,0 +1,105 @@
+# Copyright 2019 Google LLC
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+# 	https://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+resource "google_cloudfunctions_function" "schedule-automation" {
+  name                  = "ScheduleAutomation"
+  description           = "Runs an automation within its maintenance window or queues it until the next one."
+  runtime               = "go113"
+  available_memory_mb   = 128
+  source_archive_bucket = var.setup.gcf-bucket-name
+  source_archive_object = var.setup.gcf-object-name
+  timeout               = 60
+  project               = var.setup.automation-project
+  region                = var.setup.region
+  entry_point           = "ScheduleAutomation"
+  service_account_email = var.setup.automation-service-account
+
+  event_trigger {
+    event_type = "google.pubsub.topic.publish"
+    resource   = "threat-findings-schedule"
+    failure_policy {
+      retry = true
+    }
+  }
+  environment_variables = {
+    GCP_PROJECT            = var.setup.automation-project
+    AUDIT_DATASET          = var.setup.audit-dataset
+    AUDIT_TABLE            = var.setup.audit-table
+    SCHEDULE_BUCKET        = google_storage_bucket.schedule.name
+    LIMITS_COLLECTION      = var.setup.limits-collection
+    IDEMPOTENCY_COLLECTION = var.setup.idempotency-collection
+    DEAD_LETTER_TOPIC      = var.setup.dead-letter-topic
+  }
+}
+
+resource "google_cloudfunctions_function" "replay-scheduled" {
+  name                  = "ReplayScheduled"
+  description           = "Runs queued automations whose maintenance window has opened."
+  runtime               = "go113"
+  available_memory_mb   = 128
+  source_archive_bucket = var.setup.gcf-bucket-name
+  source_archive_object = var.setup.gcf-object-name
+  timeout               = 120
+  project               = var.setup.automation-project
+  region                = var.setup.region
+  entry_point           = "ReplayScheduled"
+  service_account_email = var.setup.automation-service-account
+
+  event_trigger {
+    event_type = "google.pubsub.topic.publish"
+    resource   = "threat-findings-schedule-replay"
+  }
+  environment_variables = {
+    GCP_PROJECT       = var.setup.automation-project
+    AUDIT_DATASET     = var.setup.audit-dataset
+    AUDIT_TABLE       = var.setup.audit-table
+    SCHEDULE_BUCKET   = google_storage_bucket.schedule.name
+    LIMITS_COLLECTION = var.setup.limits-collection
+  }
+}
+
+# GCS bucket holding automations waiting for their maintenance window.
+resource "google_storage_bucket" "schedule" {
+  name    = "${var.setup.automation-project}-sra-schedule"
+  project = var.setup.automation-project
+}
+
+resource "google_storage_bucket_iam_member" "schedule-writer" {
+  bucket = google_storage_bucket.schedule.name
+  role   = "roles/storage.objectAdmin"
+  member = "serviceAccount:${var.setup.automation-service-account}"
+}
+
+# PubSub topic to trigger this automation.
+resource "google_pubsub_topic" "topic" {
+  name    = "threat-findings-schedule"
+  project = var.setup.automation-project
+}
+
+# PubSub topic triggering the replay of queued automations every five minutes.
+resource "google_pubsub_topic" "replay-topic" {
+  name    = "threat-findings-schedule-replay"
+  project = var.setup.automation-project
+}
+
+resource "google_cloud_scheduler_job" "replay-scheduled" {
+  name     = "sra-schedule-replay"
+  project  = var.setup.automation-project
+  region   = var.setup.region
+  schedule = "*/5 * * * *"
+
+  pubsub_target {
+    topic_name = google_pubsub_topic.replay-topic.id
+    data       = base64encode("{}")
+  }
+}
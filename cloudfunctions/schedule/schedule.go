@@ -0,0 +1,192 @@
+package schedule
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+	"github.com/googlecloudplatform/security-response-automation/providers/registry"
+	"github.com/googlecloudplatform/security-response-automation/services"
+	"github.com/pkg/errors"
+)
+
+// Topic is the PubSub topic the router publishes automations restricted to maintenance windows to.
+const Topic = "threat-findings-schedule"
+
+// now returns the current time, replaced in tests.
+var now = time.Now
+
+// Values contains the required values needed for this function.
+type Values struct {
+	Action    string
+	ProjectID string
+	Rule      string
+	Finding   string
+	// Topic, Values and Attributes are the message published to run the automation.
+	Topic      string
+	Values     json.RawMessage
+	Attributes map[string]string
+	Schedule   registry.Schedule
+	// Limits are counted when the automation is run, DryRunValues are published instead of
+	// Values if one is exceeded.
+	Limits       []services.Limit
+	DryRunValues json.RawMessage
+}
+
+// Services contains the services needed for this function.
+type Services struct {
+	Queue       *services.Queue
+	PubSub      *services.PubSub
+	Limiter     *services.Limiter
+	Logger      *services.Logger
+	Audit       *services.Audit
+	Idempotency *services.Idempotency
+}
+
+// Execute runs the automation right away if one of its maintenance windows is open. Otherwise
+// it is queued until the next window opens.
+func Execute(ctx context.Context, values *Values, services *Services) (err error) {
+	if !services.Idempotency.Begin(ctx, "schedule:"+values.Action) {
+		return nil
+	}
+	defer func() { services.Idempotency.End(ctx, "schedule:"+values.Action, err) }()
+	t := now()
+	next, err := values.Schedule.Next(t)
+	if err != nil {
+		return errors.Wrapf(err, "invalid schedule for %q", values.Action)
+	}
+	if !next.After(t) {
+		b, ok := services.Limiter.Release(ctx, values.Limits, values.Values, values.DryRunValues)
+		if !ok {
+			services.Logger.Warning("limit exceeded, running %q in project %q with dry_run on", values.Action, values.ProjectID)
+		}
+		if err := publish(ctx, services.PubSub, values.Topic, b, values.Attributes); err != nil {
+			return err
+		}
+		services.Logger.Info("ran %q in project %q within its maintenance window", values.Action, values.ProjectID)
+		return nil
+	}
+	remediation := services.Audit.Remediation("schedule", fmt.Sprintf("//cloudresourcemanager.googleapis.com/projects/%s", values.ProjectID), false)
+	defer func() { services.Audit.Record(ctx, remediation, err) }()
+	if services.Queue == nil {
+		return errors.New("schedules are not configured")
+	}
+	a, err := newQueued(values, next)
+	if err != nil {
+		return err
+	}
+	if err := services.Queue.Push(ctx, a); err != nil {
+		return err
+	}
+	remediation.After = a
+	services.Logger.Info("queued %q in project %q until its maintenance window opens at %s", a.Action, a.ProjectID, a.Due.Format(time.RFC3339))
+	return nil
+}
+
+func newQueued(values *Values, due time.Time) (*services.QueuedAutomation, error) {
+	schedule, err := json.Marshal(values.Schedule)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to marshal schedule for %q", values.Action)
+	}
+	a := services.NewQueuedAutomation()
+	a.Action = values.Action
+	a.ProjectID = values.ProjectID
+	a.Rule = values.Rule
+	a.Finding = values.Finding
+	a.Topic = values.Topic
+	a.Values = values.Values
+	a.Attributes = values.Attributes
+	a.Due = due.UTC()
+	a.Schedule = schedule
+	a.Limits = values.Limits
+	a.DryRunValues = values.DryRunValues
+	return a, nil
+}
+
+// Replay runs the queued automations whose maintenance window has opened. Automations whose
+// window closed before they were replayed are queued again until the next one opens. An
+// automation that fails is logged and left queued for the next replay. Automations are counted
+// against the limits they were queued with as they are run, so those exceeding a limit when a
+// window opens are run with dry_run on.
+func Replay(ctx context.Context, services *Services) error {
+	if services.Queue == nil {
+		return errors.New("schedules are not configured")
+	}
+	t := now()
+	due, err := services.Queue.Due(ctx, t)
+	if err != nil {
+		return err
+	}
+	failed := 0
+	for _, a := range due {
+		if err := replay(ctx, a, t, services); err != nil {
+			services.Logger.Error("failed to replay %q queued for %q in project %q: %q", a.ID, a.Action, a.ProjectID, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return errors.Errorf("failed to replay %d queued automations", failed)
+	}
+	return nil
+}
+
+// replay runs the queued automation if its maintenance window is still open at t, otherwise it
+// is queued until the next window.
+func replay(ctx context.Context, a *services.QueuedAutomation, t time.Time, services *Services) error {
+	// Automations queued without their schedule are run as soon as they are due.
+	if len(a.Schedule) > 0 {
+		var s registry.Schedule
+		if err := json.Unmarshal(a.Schedule, &s); err != nil {
+			return errors.Wrapf(err, "failed to unmarshal schedule for %q", a.Action)
+		}
+		next, err := s.Next(t)
+		if err != nil {
+			return errors.Wrapf(err, "invalid schedule for %q", a.Action)
+		}
+		if next.After(t) {
+			a.Due = next.UTC()
+			if err := services.Queue.Push(ctx, a); err != nil {
+				return err
+			}
+			services.Logger.Info("queued %q in project %q again, its maintenance window closed, until %s", a.Action, a.ProjectID, a.Due.Format(time.RFC3339))
+			return nil
+		}
+	}
+	b, ok := services.Limiter.Release(ctx, a.Limits, a.Values, a.DryRunValues)
+	if !ok {
+		services.Logger.Warning("limit exceeded, running %q in project %q with dry_run on", a.Action, a.ProjectID)
+	}
+	// Automations skip messages they already handled so publishing again after failing to
+	// remove the automation from the queue is safe.
+	if err := publish(ctx, services.PubSub, a.Topic, b, a.Attributes); err != nil {
+		return err
+	}
+	if err := services.Queue.Remove(ctx, a.ID); err != nil {
+		return err
+	}
+	services.Logger.Info("ran %q in project %q queued at %s", a.Action, a.ProjectID, a.Queued.Format(time.RFC3339))
+	return nil
+}
+
+func publish(ctx context.Context, ps *services.PubSub, topic string, values json.RawMessage, attributes map[string]string) error {
+	if _, err := ps.Publish(ctx, topic, &pubsub.Message{Data: values, Attributes: attributes}); err != nil {
+		return errors.Wrapf(err, "failed to publish to %q", topic)
+	}
+	return nil
+}
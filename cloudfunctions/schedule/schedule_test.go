@@ -0,0 +1,205 @@
+package schedule
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/googlecloudplatform/security-response-automation/clients/stubs"
+	"github.com/googlecloudplatform/security-response-automation/providers/registry"
+	"github.com/googlecloudplatform/security-response-automation/services"
+	"github.com/pkg/errors"
+)
+
+func TestSchedule(t *testing.T) {
+	ctx := context.Background()
+	defer func() { now = time.Now }()
+	values := &Values{
+		Action:     "remove_public_ip",
+		ProjectID:  "test-project",
+		Topic:      "threat-findings-remove-public-ip",
+		Values:     json.RawMessage(`{"ProjectID":"test-project"}`),
+		Attributes: map[string]string{"finding": "organizations/1/sources/2/findings/3"},
+		Schedule: registry.Schedule{
+			Windows: []registry.Window{{Days: []string{"sat"}, Start: "02:00", End: "06:00"}},
+		},
+	}
+	for _, tt := range []struct {
+		name       string
+		at         time.Time
+		wantQueued bool
+	}{
+		{name: "in window", at: time.Date(2020, 1, 4, 3, 0, 0, 0, time.UTC)},
+		{name: "out of window", at: time.Date(2020, 1, 6, 9, 0, 0, 0, time.UTC), wantQueued: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			storageStub := &stubs.StorageStub{}
+			psStub := &stubs.PubSubStub{}
+			svcs := &Services{
+				Queue:  services.NewQueue(storageStub, "schedule-bucket"),
+				PubSub: services.NewPubSub(psStub),
+				Logger: services.NewLogger(&stubs.LoggerStub{}),
+			}
+			now = func() time.Time { return tt.at }
+			if err := Execute(ctx, values, svcs); err != nil {
+				t.Fatalf("Execute() failed: %q", err)
+			}
+			if queued := len(storageStub.Objects["schedule-bucket"]) == 1; queued != tt.wantQueued {
+				t.Fatalf("queued = %t, want %t", queued, tt.wantQueued)
+			}
+			if published := psStub.PublishedMessage != nil; published == tt.wantQueued {
+				t.Fatalf("published = %t, want %t", published, !tt.wantQueued)
+			}
+			if !tt.wantQueued {
+				return
+			}
+			// The next window opens on Saturday 11th.
+			now = func() time.Time { return time.Date(2020, 1, 11, 1, 55, 0, 0, time.UTC) }
+			if err := Replay(ctx, svcs); err != nil {
+				t.Fatalf("Replay() failed: %q", err)
+			}
+			if psStub.PublishedMessage != nil {
+				t.Fatalf("replayed before the window opened")
+			}
+			now = func() time.Time { return time.Date(2020, 1, 11, 2, 5, 0, 0, time.UTC) }
+			if err := Replay(ctx, svcs); err != nil {
+				t.Fatalf("Replay() failed: %q", err)
+			}
+			if psStub.PublishedMessage == nil || string(psStub.PublishedMessage.Data) != string(values.Values) {
+				t.Fatalf("replay published %+v, want the queued automation", psStub.PublishedMessage)
+			}
+			if len(storageStub.Objects["schedule-bucket"]) != 0 {
+				t.Errorf("replayed automation was not removed from the queue")
+			}
+		})
+	}
+}
+
+func TestReplayWindowClosed(t *testing.T) {
+	ctx := context.Background()
+	defer func() { now = time.Now }()
+	storageStub := &stubs.StorageStub{}
+	psStub := &stubs.PubSubStub{}
+	svcs := &Services{
+		Queue:  services.NewQueue(storageStub, "schedule-bucket"),
+		PubSub: services.NewPubSub(psStub),
+		Logger: services.NewLogger(&stubs.LoggerStub{}),
+	}
+	values := &Values{
+		Action:    "remove_public_ip",
+		ProjectID: "test-project",
+		Topic:     "threat-findings-remove-public-ip",
+		Values:    json.RawMessage(`{"ProjectID":"test-project"}`),
+		Schedule: registry.Schedule{
+			Windows: []registry.Window{{Days: []string{"sat"}, Start: "02:00", End: "06:00"}},
+		},
+	}
+	now = func() time.Time { return time.Date(2020, 1, 6, 9, 0, 0, 0, time.UTC) }
+	if err := Execute(ctx, values, svcs); err != nil {
+		t.Fatalf("Execute() failed: %q", err)
+	}
+	// The replay due on Saturday 11th only runs after the window closed.
+	now = func() time.Time { return time.Date(2020, 1, 11, 7, 0, 0, 0, time.UTC) }
+	if err := Replay(ctx, svcs); err != nil {
+		t.Fatalf("Replay() failed: %q", err)
+	}
+	if psStub.PublishedMessage != nil {
+		t.Fatalf("replayed after the window closed")
+	}
+	due, err := svcs.Queue.Due(ctx, time.Date(2020, 1, 18, 2, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Due() failed: %q", err)
+	}
+	if len(due) != 1 || !due[0].Due.Equal(time.Date(2020, 1, 18, 2, 0, 0, 0, time.UTC)) {
+		t.Errorf("Due() = %+v, want the automation queued until the next window", due)
+	}
+}
+
+func TestReplayContinues(t *testing.T) {
+	ctx := context.Background()
+	defer func() { now = time.Now }()
+	storageStub := &stubs.StorageStub{}
+	psStub := &stubs.PubSubStub{}
+	svcs := &Services{
+		Queue:  services.NewQueue(storageStub, "schedule-bucket"),
+		PubSub: services.NewPubSub(psStub),
+		Logger: services.NewLogger(&stubs.LoggerStub{}),
+	}
+	for _, id := range []string{"first", "second"} {
+		a := services.NewQueuedAutomation()
+		a.ID = id
+		a.Topic = "threat-findings-remove-public-ip"
+		a.Values = json.RawMessage(`{"ProjectID":"test-project"}`)
+		a.Due = time.Date(2020, 1, 11, 2, 0, 0, 0, time.UTC)
+		if err := svcs.Queue.Push(ctx, a); err != nil {
+			t.Fatalf("Push() failed: %q", err)
+		}
+	}
+	now = func() time.Time { return time.Date(2020, 1, 11, 2, 5, 0, 0, time.UTC) }
+	psStub.PublishError = errors.New("unavailable")
+	if err := Replay(ctx, svcs); err == nil {
+		t.Fatalf("Replay() succeeded, want an error")
+	}
+	if len(storageStub.Objects["schedule-bucket"]) != 2 {
+		t.Fatalf("failed automations were removed from the queue")
+	}
+	psStub.PublishError = nil
+	if err := Replay(ctx, svcs); err != nil {
+		t.Fatalf("Replay() failed: %q", err)
+	}
+	if len(psStub.PublishedMessages) != 2 || len(storageStub.Objects["schedule-bucket"]) != 0 {
+		t.Errorf("published %d automations leaving %d queued, want 2 published and none queued", len(psStub.PublishedMessages), len(storageStub.Objects["schedule-bucket"]))
+	}
+}
+
+func TestReplayLimits(t *testing.T) {
+	ctx := context.Background()
+	defer func() { now = time.Now }()
+	psStub := &stubs.PubSubStub{}
+	svcs := &Services{
+		Queue:   services.NewQueue(&stubs.StorageStub{}, "schedule-bucket"),
+		PubSub:  services.NewPubSub(psStub),
+		Limiter: services.NewLimiter(services.NewMemoryLimitStore()),
+		Logger:  services.NewLogger(&stubs.LoggerStub{}),
+	}
+	for _, id := range []string{"first", "second", "third"} {
+		a := services.NewQueuedAutomation()
+		a.ID = id
+		a.Topic = "threat-findings-close-bucket"
+		a.Values = json.RawMessage(`{"DryRun":false}`)
+		a.DryRunValues = json.RawMessage(`{"DryRun":true}`)
+		a.Limits = []services.Limit{{Key: "close_bucket/organization/1h", Max: 1, Window: time.Hour}}
+		a.Due = time.Date(2020, 1, 11, 2, 0, 0, 0, time.UTC)
+		if err := svcs.Queue.Push(ctx, a); err != nil {
+			t.Fatalf("Push() failed: %q", err)
+		}
+	}
+	now = func() time.Time { return time.Date(2020, 1, 11, 2, 5, 0, 0, time.UTC) }
+	if err := Replay(ctx, svcs); err != nil {
+		t.Fatalf("Replay() failed: %q", err)
+	}
+	var got []string
+	for _, m := range psStub.PublishedMessages {
+		got = append(got, string(m.Data))
+	}
+	want := []string{`{"DryRun":false}`, `{"DryRun":true}`, `{"DryRun":true}`}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("published (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,309 @@
+// Command sra-filter-test tests the Rego filters before they are deployed.
+//
+// The filters and suppressions in the directory, config/filters by default, are compiled as the
+// filter function compiles them and their *_test.rego files are run with OPA. Every filter is
+// then evaluated against the sample findings in the testdata directory next to the filters. Each
+// sample is a finding notification saved in a subdirectory named after what the filter function
+// should do with it:
+//
+//	config/filters/testdata/suppressed/ntp_bad_ip.json
+//	config/filters/testdata/forwarded/bad_ip.json
+//	config/filters/testdata/routed/dev_project.json
+//
+// The filters no sample finding matched are reported. The command exits non-zero if a test
+// fails, a sample finding is not handled as expected or, with -strict, a filter never matched:
+//
+//	go run ./cmd/sra-filter-test -strict config/filters
+//
+// Pass -samples to also load the *.sample files shipped in config/filters.
+package main
+
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/filter"
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/tester"
+	"github.com/pkg/errors"
+)
+
+// Outcomes of a finding, named after the subdirectories of the sample findings.
+const (
+	suppressed = "suppressed"
+	forwarded  = "forwarded"
+	routed     = "routed"
+)
+
+// options configure a run.
+type options struct {
+	// samples loads the *.sample files as if they were not samples.
+	samples bool
+	// strict fails the run if a filter or suppression matched no sample finding.
+	strict bool
+	// verbose prints every test and the trace of failing tests.
+	verbose bool
+}
+
+func main() {
+	findings := flag.String("findings", "", "directory of sample findings, defaults to testdata in the filters directory")
+	samples := flag.Bool("samples", false, "also load the *.sample files, such as those in config/filters")
+	strict := flag.Bool("strict", false, "fail if a filter or suppression matched no sample finding")
+	verbose := flag.Bool("v", false, "print every test and the trace of failing tests")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] [filters directory]\n\nRuns the Rego filter tests and checks the filters against sample findings.\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	dir := "config/filters"
+	switch flag.NArg() {
+	case 0:
+	case 1:
+		dir = flag.Arg(0)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *findings == "" {
+		*findings = filepath.Join(dir, "testdata")
+	}
+	ok, err := run(context.Background(), os.Stdout, dir, *findings, options{samples: *samples, strict: *strict, verbose: *verbose})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	if !ok {
+		os.Exit(1)
+	}
+}
+
+// run tests the filters in the directory and checks them against the sample findings, returning
+// if all checks passed.
+func run(ctx context.Context, w io.Writer, dir, findings string, opts options) (bool, error) {
+	policies, tests, err := readFilters(dir, opts.samples)
+	if err != nil {
+		return false, err
+	}
+	b, err := filter.Compile("", policies)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to compile filters")
+	}
+	passed, err := runTests(ctx, w, policies, tests, opts.verbose)
+	if err != nil {
+		return false, err
+	}
+	samples, err := readFindings(findings)
+	if err != nil {
+		return false, err
+	}
+	matched := make(map[string]bool)
+	if len(samples) == 0 {
+		fmt.Fprintf(w, "Findings: no sample findings in %s\n", findings)
+	} else {
+		fmt.Fprintln(w, "Findings:")
+	}
+	for _, s := range samples {
+		got, decision, err := evaluate(ctx, b, s.raw, matched)
+		switch {
+		case err != nil:
+			passed = false
+			fmt.Fprintf(w, "  FAIL %s: %v\n", s.path, err)
+		case got != s.want:
+			passed = false
+			fmt.Fprintf(w, "  FAIL %s: %s%s, want %s\n", s.path, got, by(decision), s.want)
+		default:
+			fmt.Fprintf(w, "  ok   %s: %s%s\n", s.path, got, by(decision))
+		}
+	}
+	var never []string
+	for _, name := range b.Filters() {
+		if !matched[name] {
+			never = append(never, name)
+		}
+	}
+	fmt.Fprintf(w, "Coverage: %d of %d filters matched a sample finding\n", len(b.Filters())-len(never), len(b.Filters()))
+	if len(never) > 0 {
+		fmt.Fprintf(w, "  never matched: %s\n", strings.Join(never, ", "))
+		if opts.strict {
+			passed = false
+		}
+	}
+	return passed, nil
+}
+
+// readFilters returns the filters and suppressions in the directory, and the tests of the
+// filters, keyed by file name.
+func readFilters(dir string, samples bool) (map[string][]byte, map[string][]byte, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	policies := make(map[string][]byte)
+	tests := make(map[string][]byte)
+	for _, f := range files {
+		name := f.Name()
+		sample := strings.HasSuffix(name, ".sample")
+		if sample {
+			if !samples {
+				continue
+			}
+			name = strings.TrimSuffix(name, ".sample")
+		}
+		var m map[string][]byte
+		switch {
+		case f.IsDir():
+			continue
+		case strings.HasSuffix(name, "_test.rego"):
+			m = tests
+		case strings.HasSuffix(name, ".rego"), name == filter.SuppressionsFile:
+			m = policies
+		default:
+			continue
+		}
+		// Files sort before their samples, so a sample does not replace the file made from it.
+		if _, ok := m[name]; ok && sample {
+			continue
+		}
+		b, err := ioutil.ReadFile(filepath.Join(dir, f.Name()))
+		if err != nil {
+			return nil, nil, err
+		}
+		m[name] = b
+	}
+	return policies, tests, nil
+}
+
+// runTests runs the OPA tests of the policies and prints the results, returning if all passed.
+func runTests(ctx context.Context, w io.Writer, policies, tests map[string][]byte, verbose bool) (bool, error) {
+	if len(tests) == 0 {
+		fmt.Fprintln(w, "Tests: none")
+		return true, nil
+	}
+	modules := make(map[string]*ast.Module, len(policies)+len(tests))
+	for _, files := range []map[string][]byte{policies, tests} {
+		for name, b := range files {
+			if !strings.HasSuffix(name, ".rego") {
+				continue
+			}
+			m, err := ast.ParseModule(name, string(b))
+			if err != nil {
+				return false, err
+			}
+			modules[name] = m
+		}
+	}
+	ch, err := tester.NewRunner().SetModules(modules).EnableTracing(verbose).RunTests(ctx, nil)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to run tests")
+	}
+	var results []*tester.Result
+	passed := true
+	for r := range ch {
+		results = append(results, r)
+		passed = passed && r.Pass()
+	}
+	report := make(chan *tester.Result, len(results))
+	for _, r := range results {
+		report <- r
+	}
+	close(report)
+	fmt.Fprintln(w, "Tests:")
+	if err := (tester.PrettyReporter{Output: w, Verbose: verbose}).Report(report); err != nil {
+		return false, err
+	}
+	return passed, nil
+}
+
+// sample is a sample finding and what the filter function should do with it.
+type sample struct {
+	path string
+	raw  []byte
+	want string
+}
+
+// readFindings returns the sample findings in the outcome subdirectories of the directory, none
+// if it does not exist.
+func readFindings(dir string) ([]sample, error) {
+	dirs, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var samples []sample
+	for _, d := range dirs {
+		if !d.IsDir() {
+			continue
+		}
+		switch d.Name() {
+		case suppressed, forwarded, routed:
+		default:
+			return nil, fmt.Errorf("%s: unknown outcome %q, expected %s, %s or %s", dir, d.Name(), suppressed, forwarded, routed)
+		}
+		files, err := filepath.Glob(filepath.Join(dir, d.Name(), "*.json"))
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			b, err := ioutil.ReadFile(f)
+			if err != nil {
+				return nil, err
+			}
+			samples = append(samples, sample{path: filepath.Join(d.Name(), filepath.Base(f)), raw: b, want: d.Name()})
+		}
+	}
+	return samples, nil
+}
+
+// evaluate returns what the filter function would do with the finding and the decision it would
+// apply, recording the filters that matched it.
+func evaluate(ctx context.Context, b *filter.Bundle, raw []byte, matched map[string]bool) (string, *filter.Decision, error) {
+	decisions, err := b.Decisions(ctx, raw)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(decisions) == 0 {
+		return forwarded, nil, nil
+	}
+	for _, d := range decisions {
+		matched[d.Filter] = true
+	}
+	d := decisions[0]
+	switch d.Action {
+	case filter.Suppress:
+		return suppressed, d, nil
+	case filter.Route:
+		return routed, d, nil
+	default:
+		return forwarded, d, nil
+	}
+}
+
+// by describes the filter that decided, if any.
+func by(d *filter.Decision) string {
+	if d == nil {
+		return ""
+	}
+	return " by " + d.Filter
+}
@@ -0,0 +1,171 @@
+package main
+
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	publicFilter = `package sra.filter
+public {
+	input.finding.category == "PUBLIC_BUCKET_ACL"
+}`
+	publicTest = `package sra.filter
+test_public {
+	public with input as {"finding": {"category": "PUBLIC_BUCKET_ACL"}}
+}`
+	devFilter = `package sra.filter
+dev = {"action": "route", "topic": "threat-findings-notify"} {
+	startswith(input.finding.resourceName, "//cloudresourcemanager.googleapis.com/projects/dev-")
+}`
+	publicFinding = `{"finding": {"category": "PUBLIC_BUCKET_ACL", "resourceName": "//storage.googleapis.com/bucket"}}`
+	badIPFinding  = `{"finding": {"category": "Malware: Bad IP", "resourceName": "//cloudresourcemanager.googleapis.com/projects/prod-1"}}`
+)
+
+func TestRun(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		files   map[string]string
+		opts    options
+		want    []string
+		wantErr string
+		passed  bool
+	}{
+		{
+			name: "pass",
+			files: map[string]string{
+				"public.rego":                     publicFilter,
+				"public_test.rego":                publicTest,
+				"testdata/suppressed/public.json": publicFinding,
+				"testdata/forwarded/bad_ip.json":  badIPFinding,
+			},
+			want: []string{
+				"PASS: 1/1",
+				"ok   forwarded/bad_ip.json: forwarded",
+				"ok   suppressed/public.json: suppressed by public",
+				"Coverage: 1 of 1 filters matched a sample finding",
+			},
+			passed: true,
+		},
+		{
+			name: "failing test",
+			files: map[string]string{
+				"public.rego":      publicFilter,
+				"public_test.rego": "package sra.filter\ntest_public {\n\tpublic with input as {\"finding\": {}}\n}",
+			},
+			want: []string{"FAIL: 1/1", "Findings: no sample findings"},
+		},
+		{
+			name: "unexpected outcome",
+			files: map[string]string{
+				"public.rego":                    publicFilter,
+				"testdata/forwarded/public.json": publicFinding,
+			},
+			want: []string{"FAIL forwarded/public.json: suppressed by public, want forwarded"},
+		},
+		{
+			name: "never matched",
+			files: map[string]string{
+				"public.rego":                     publicFilter,
+				"dev.rego":                        devFilter,
+				"testdata/suppressed/public.json": publicFinding,
+			},
+			want:   []string{"Coverage: 1 of 2 filters matched a sample finding", "never matched: dev"},
+			passed: true,
+		},
+		{
+			name: "never matched strict",
+			files: map[string]string{
+				"public.rego":                     publicFilter,
+				"dev.rego":                        devFilter,
+				"testdata/suppressed/public.json": publicFinding,
+			},
+			opts: options{strict: true},
+			want: []string{"never matched: dev"},
+		},
+		{
+			name: "samples",
+			files: map[string]string{
+				"public.rego.sample":              publicFilter,
+				"public_test.rego.sample":         publicTest,
+				"testdata/suppressed/public.json": publicFinding,
+			},
+			opts:   options{samples: true},
+			want:   []string{"PASS: 1/1", "ok   suppressed/public.json: suppressed by public"},
+			passed: true,
+		},
+		{
+			name: "suppression",
+			files: map[string]string{
+				"suppressions.yaml":               "- name: incident\n  category: PUBLIC_BUCKET_ACL\n  expires: 2999-01-01T00:00:00Z",
+				"testdata/suppressed/public.json": publicFinding,
+				"testdata/forwarded/bad_ip.json":  badIPFinding,
+			},
+			want:   []string{"Tests: none", "ok   suppressed/public.json: suppressed by incident"},
+			passed: true,
+		},
+		{
+			name: "unknown outcome",
+			files: map[string]string{
+				"public.rego":                  publicFilter,
+				"testdata/ignored/public.json": publicFinding,
+			},
+			wantErr: `unknown outcome "ignored"`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "sra-filter-test")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+			for name, content := range tt.files {
+				path := filepath.Join(dir, filepath.FromSlash(name))
+				if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+					t.Fatal(err)
+				}
+				if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			var out bytes.Buffer
+			passed, err := run(context.Background(), &out, dir, filepath.Join(dir, "testdata"), tt.opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("run() = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("run() failed: %q", err)
+			}
+			if passed != tt.passed {
+				t.Errorf("run() = %t, want %t", passed, tt.passed)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("run() printed:\n%s\nwant it to contain %q", out.String(), want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,247 @@
+// Command sra-replay shows how a finding would be handled, without changing anything.
+//
+// The finding is read from a JSON file, such as those in cloudfunctions/router/testdata, or
+// fetched from Security Command Center by name. It is run through the Rego filters and the
+// router as configured, including approvals, maintenance windows and limits. The matching
+// filters, the automations that would fire with the topic and values they would be published
+// with, the limits they would be counted against and whether each automation's project is
+// within its target are printed:
+//
+//	go run ./cmd/sra-replay -config config/sra.yaml cloudfunctions/router/testdata/public_bucket_acl.json
+//	go run ./cmd/sra-replay organizations/123/sources/456/findings/789
+//
+// The configuration defaults to config/sra.yaml, or config/sra.yaml.sample until it is created.
+// Checking projects against targets and fetching findings use Application Default Credentials.
+// Pass -offline to skip target checks.
+package main
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/filter"
+	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/router"
+	"github.com/googlecloudplatform/security-response-automation/services"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const (
+	// defaultConfig is the configuration deployed with the router.
+	defaultConfig = "config/sra.yaml"
+	// sampleConfig is the configuration shipped with the repository, copied to defaultConfig
+	// before installing.
+	sampleConfig = "config/sra.yaml.sample"
+)
+
+func main() {
+	config := flag.String("config", "", "router configuration to route the finding with, defaults to "+defaultConfig+" or "+sampleConfig+" if it does not exist")
+	filters := flag.String("filters", "", "directory of .rego filters and suppressions.yaml, defaults to the filters generated into the filter function")
+	offline := flag.Bool("offline", false, "do not check projects against targets")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] <finding.json | finding name>\n\nShows how a finding would be filtered and routed, without changing anything.\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *config == "" {
+		*config = defaultConfig
+		if _, err := os.Stat(defaultConfig); os.IsNotExist(err) {
+			fmt.Fprintf(os.Stderr, "%s does not exist, using %s\n", defaultConfig, sampleConfig)
+			*config = sampleConfig
+		}
+	}
+	if err := replay(context.Background(), os.Stdout, flag.Arg(0), *config, *filters, *offline); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+}
+
+// replay prints how the finding would be filtered and routed.
+func replay(ctx context.Context, w io.Writer, finding, config, filters string, offline bool) error {
+	b, err := readFinding(ctx, finding)
+	if err != nil {
+		return err
+	}
+	conf, err := readConfig(config)
+	if err != nil {
+		return err
+	}
+	policies := filter.Policies()
+	if filters != "" {
+		if policies, err = readPolicies(filters); err != nil {
+			return err
+		}
+	}
+	var resource *services.Resource
+	if !offline {
+		if resource, err = services.InitResource(ctx); err != nil {
+			return err
+		}
+	}
+	decisions, err := filter.Decisions(ctx, b, policies)
+	if err != nil {
+		return errors.Wrap(err, "failed to evaluate filters")
+	}
+	plan, err := router.Explain(ctx, b, conf, resource)
+	if err != nil {
+		return err
+	}
+	report(w, len(policies), decisions, plan)
+	return nil
+}
+
+// readFinding returns the finding in the file or, if no such file exists, the Security Command
+// Center finding with that name as a notification.
+func readFinding(ctx context.Context, finding string) ([]byte, error) {
+	b, err := ioutil.ReadFile(finding)
+	if err == nil || !os.IsNotExist(err) || !strings.Contains(finding, "/findings/") {
+		return b, err
+	}
+	scc, err := services.InitSecurityCommandCenter(ctx)
+	if err != nil {
+		return nil, err
+	}
+	f, err := scc.Finding(ctx, finding)
+	if err != nil {
+		return nil, err
+	}
+	fb, err := protojson.Marshal(f)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to marshal finding %q", finding)
+	}
+	return json.Marshal(map[string]json.RawMessage{"finding": fb})
+}
+
+func readConfig(config string) (*router.Configuration, error) {
+	b, err := ioutil.ReadFile(config)
+	if err != nil {
+		return nil, err
+	}
+	return router.ParseConfig(b)
+}
+
+// readPolicies returns the Rego filters and suppressions in the directory keyed by file name,
+// skipping tests.
+func readPolicies(dir string) (map[string][]byte, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.rego"))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(filepath.Join(dir, filter.SuppressionsFile)); err == nil {
+		files = append(files, filepath.Join(dir, filter.SuppressionsFile))
+	}
+	policies := make(map[string][]byte)
+	for _, f := range files {
+		if strings.HasSuffix(f, "_test.rego") {
+			continue
+		}
+		b, err := ioutil.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		policies[filepath.Base(f)] = b
+	}
+	return policies, nil
+}
+
+// outcome describes what the filter function would do with the finding.
+func outcome(d *filter.Decision) string {
+	var s string
+	switch d.Action {
+	case filter.Suppress:
+		return "suppress it, the finding would be marked and set inactive instead of routed"
+	case filter.Forward:
+		s = "forward it to the router"
+	case filter.Tag:
+		s = "tag it and forward it to the router"
+	case filter.Route:
+		s = fmt.Sprintf("send it to topic %s instead of the router", d.Topic)
+	}
+	if d.Severity != "" {
+		s += " with severity " + d.Severity
+	}
+	return s
+}
+
+func report(w io.Writer, filters int, decisions []*filter.Decision, plan *router.Plan) {
+	fmt.Fprintf(w, "Rule: %s\n", plan.Rule)
+	switch {
+	case len(decisions) > 0:
+		matches := make([]string, 0, len(decisions))
+		for _, d := range decisions {
+			matches = append(matches, d.Filter)
+		}
+		fmt.Fprintf(w, "Filters: matched %s of %d, %s decided to %s\n", strings.Join(matches, ", "), filters, decisions[0].Filter, outcome(decisions[0]))
+	default:
+		fmt.Fprintf(w, "Filters: none of %d matched\n", filters)
+	}
+	if plan.Remediated {
+		fmt.Fprintln(w, "Already remediated: the router would skip this event of the finding")
+	}
+	if len(plan.Automations) == 0 {
+		fmt.Fprintln(w, "Automations: none configured")
+		return
+	}
+	fmt.Fprintln(w, "Automations:")
+	for i, a := range plan.Automations {
+		status := "would fire"
+		if a.Err != nil {
+			status = "would not fire: " + a.Err.Error()
+		}
+		fmt.Fprintf(w, "  [%d] %s -> %s, %s\n", i, a.Action, a.Topic, status)
+		if a.ProjectID != "" {
+			fmt.Fprintf(w, "      project: %s\n", a.ProjectID)
+		}
+		if len(a.Limits) > 0 {
+			fmt.Fprintf(w, "      limits: %s\n", strings.Join(a.Limits, ", "))
+		}
+		switch {
+		case !a.Checked:
+			fmt.Fprintln(w, "      target: not checked")
+		case a.Matches:
+			fmt.Fprintln(w, "      target: matches")
+		default:
+			fmt.Fprintln(w, "      target: not within target or excluded")
+		}
+		if a.Values != nil {
+			fmt.Fprintf(w, "      values: %s\n", indent(a.Values))
+		}
+		if a.DryRunValues != nil {
+			fmt.Fprintf(w, "      values with dry_run on, to try it without making changes: %s\n", indent(a.DryRunValues))
+		}
+	}
+}
+
+// indent returns the values as indented JSON, or the error marshalling them.
+func indent(values interface{}) []byte {
+	b, err := json.MarshalIndent(values, "      ", "  ")
+	if err != nil {
+		return []byte(err.Error())
+	}
+	return b
+}
@@ -0,0 +1,76 @@
+package main
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sra-replay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	config := filepath.Join(dir, "sra.yaml")
+	if err := ioutil.WriteFile(config, []byte(`apiVersion: security-response-automation.cloud.google.com/v1alpha1
+kind: Remediation
+metadata:
+  name: router
+spec:
+  parameters:
+    sha:
+      public_bucket_acl:
+        - action: close_bucket
+          target:
+            - organizations/456/*
+  limits:
+    - action: close_bucket
+      max: 10
+      window: 1h
+`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "public.rego"), []byte(`package sra.filter
+public {
+	input.finding.category == "PUBLIC_BUCKET_ACL"
+}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := replay(context.Background(), &out, "../../cloudfunctions/router/testdata/public_bucket_acl.json", config, dir, true); err != nil {
+		t.Fatalf("replay() failed: %q", err)
+	}
+	for _, want := range []string{
+		"Rule: public_bucket_acl",
+		"Filters: matched public of 1, public decided to suppress it",
+		"[0] close_bucket -> threat-findings-close-bucket, would fire",
+		`"BucketName": "this-is-public-on-purpose"`,
+		"limits: close_bucket/organization/1h",
+		`"DryRun": false`,
+		"values with dry_run on, to try it without making changes",
+		`"DryRun": true`,
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("replay() printed:\n%s\nwant it to contain %q", out.String(), want)
+		}
+	}
+}
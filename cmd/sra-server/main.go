@@ -0,0 +1,150 @@
+// Command sra-server hosts the filter, router and every automation as HTTP handlers so Security
+// Response Automation can run as a single deployable on Cloud Run or GKE instead of one Cloud
+// Function per entry point.
+//
+// Each entry point is served at /<name>, such as /Filter or /CloseBucket, and accepts Pub/Sub
+// push requests. Create a push subscription on the entry point's topic pointing at its path:
+//
+//	gcloud pubsub subscriptions create sra-close-bucket --topic threat-findings-close-bucket \
+//		--push-endpoint https://sra-server-xyz.a.run.app/CloseBucket
+//
+// The server is configured with the same environment variables as the Cloud Functions.
+// GCP_PROJECT is required. Clients are created on the first request rather than at startup.
+package main
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"cloud.google.com/go/functions/metadata"
+	"cloud.google.com/go/pubsub"
+	sra "github.com/googlecloudplatform/security-response-automation"
+)
+
+// entryPoint is a Pub/Sub triggered entry point.
+type entryPoint func(context.Context, pubsub.Message) error
+
+// entryPoints maps the path each entry point is served at to the entry point.
+var entryPoints = map[string]entryPoint{
+	"Filter":                       sra.Filter,
+	"SweepSuppressions":            sra.SweepSuppressions,
+	"Router":                       sra.Router,
+	"IAMRevoke":                    sra.IAMRevoke,
+	"SnapshotDisk":                 sra.SnapshotDisk,
+	"CloseBucket":                  sra.CloseBucket,
+	"OpenFirewall":                 sra.OpenFirewall,
+	"RemoveNonOrganizationMembers": sra.RemoveNonOrganizationMembers,
+	"QuarantineInstance":           sra.QuarantineInstance,
+	"RemovePublicIP":               sra.RemovePublicIP,
+	"Rollback":                     sra.Rollback,
+	"NotifyEmail":                  sra.NotifyEmail,
+	"PagerDutyIncident":            sra.PagerDutyIncident,
+	"NotifyWebhook":                sra.NotifyWebhook,
+	"RequestApproval":              sra.RequestApproval,
+	"ApprovalTimeout":              sra.ApprovalTimeout,
+	"ScheduleAutomation":           sra.ScheduleAutomation,
+	"ReplayScheduled":              sra.ReplayScheduled,
+	"ClosePublicDataset":           sra.ClosePublicDataset,
+	"EnableBucketOnlyPolicy":       sra.EnableBucketOnlyPolicy,
+	"CloseCloudSQL":                sra.CloseCloudSQL,
+	"CloudSQLRequireSSL":           sra.CloudSQLRequireSSL,
+	"DisableDashboard":             sra.DisableDashboard,
+	"EnableAuditLogs":              sra.EnableAuditLogs,
+	"UpdatePassword":               sra.UpdatePassword,
+	"BlockDomain":                  sra.BlockDomain,
+}
+
+// pushRequest is the body of a Pub/Sub push request.
+type pushRequest struct {
+	Message struct {
+		ID          string            `json:"messageId"`
+		Data        []byte            `json:"data"`
+		Attributes  map[string]string `json:"attributes"`
+		PublishTime time.Time         `json:"publishTime"`
+	} `json:"message"`
+	Subscription string `json:"subscription"`
+}
+
+func main() {
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8080"
+	}
+	addr := flag.String("addr", ":"+port, "address to listen on, defaults to $PORT as set by Cloud Run")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags]\n\nServes every entry point as a Pub/Sub push endpoint.\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	log.Printf("listening on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, newMux()))
+}
+
+// newMux returns a mux serving every entry point, the approval links and a health check.
+func newMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	for name, fn := range entryPoints {
+		mux.Handle("/"+name, push(name, fn))
+	}
+	mux.HandleFunc("/Approve", sra.Approve)
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "ok")
+	})
+	return mux
+}
+
+// push returns a handler calling the entry point with the message of a Pub/Sub push request.
+// Errors are answered with a server error so Pub/Sub redelivers the message, as Cloud Functions
+// retry failed executions.
+func push(name string, fn entryPoint) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Pub/Sub push requests must be POST.", http.StatusMethodNotAllowed)
+			return
+		}
+		var req pushRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid Pub/Sub push request.", http.StatusBadRequest)
+			return
+		}
+		m := pubsub.Message{
+			ID:          req.Message.ID,
+			Data:        req.Message.Data,
+			Attributes:  req.Message.Attributes,
+			PublishTime: req.Message.PublishTime,
+		}
+		// Cloud Functions pass the event's metadata along, entry points use it to stop retrying
+		// old messages.
+		ctx := metadata.NewContext(r.Context(), &metadata.Metadata{
+			EventID:   req.Message.ID,
+			Timestamp: req.Message.PublishTime,
+			EventType: "google.pubsub.topic.publish",
+		})
+		if err := fn(ctx, m); err != nil {
+			log.Printf("%s failed on message %q: %q", name, m.ID, err)
+			http.Error(w, "Failed to handle message.", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/functions/metadata"
+	"cloud.google.com/go/pubsub"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPush(t *testing.T) {
+	const body = `{"message":{"messageId":"1","data":"eyJQcm9qZWN0SUQiOiJmb28ifQ==","attributes":{"finding":"f"},"publishTime":"2019-11-12T16:16:08.000Z"},"subscription":"projects/p/subscriptions/s"}`
+	for _, tt := range []struct {
+		name     string
+		method   string
+		body     string
+		err      error
+		wantCode int
+		wantData string
+	}{
+		{name: "handled", method: http.MethodPost, body: body, wantCode: http.StatusNoContent, wantData: `{"ProjectID":"foo"}`},
+		{name: "failed", method: http.MethodPost, body: body, err: errors.New("failed"), wantCode: http.StatusInternalServerError, wantData: `{"ProjectID":"foo"}`},
+		{name: "invalid request", method: http.MethodPost, body: "{", wantCode: http.StatusBadRequest},
+		{name: "not post", method: http.MethodGet, wantCode: http.StatusMethodNotAllowed},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *pubsub.Message
+			var meta *metadata.Metadata
+			h := push("Test", func(ctx context.Context, m pubsub.Message) error {
+				got = &m
+				meta, _ = metadata.FromContext(ctx)
+				return tt.err
+			})
+			w := httptest.NewRecorder()
+			h(w, httptest.NewRequest(tt.method, "/Test", strings.NewReader(tt.body)))
+			if w.Code != tt.wantCode {
+				t.Errorf("push() = %d, want %d", w.Code, tt.wantCode)
+			}
+			if tt.wantData == "" {
+				if got != nil {
+					t.Errorf("entry point called with %+v, want no call", got)
+				}
+				return
+			}
+			if got == nil {
+				t.Fatalf("entry point not called")
+			}
+			if string(got.Data) != tt.wantData || got.ID != "1" || got.PublishTime.IsZero() {
+				t.Errorf("entry point called with %+v", got)
+			}
+			if diff := cmp.Diff(map[string]string{"finding": "f"}, got.Attributes); diff != "" {
+				t.Errorf("attributes mismatch (-want +got):\n%s", diff)
+			}
+			if meta == nil || meta.EventID != "1" || !meta.Timestamp.Equal(got.PublishTime) {
+				t.Errorf("entry point called with metadata %+v, want the message ID and publish time", meta)
+			}
+		})
+	}
+}
+
+func TestMux(t *testing.T) {
+	mux := newMux()
+	for name := range entryPoints {
+		if _, pattern := mux.Handler(httptest.NewRequest(http.MethodPost, "/"+name, nil)); pattern != "/"+name {
+			t.Errorf("%s is served at %q", name, pattern)
+		}
+	}
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("/healthz = %d, want %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestUnconfigured(t *testing.T) {
+	defer os.Setenv("GCP_PROJECT", os.Getenv("GCP_PROJECT"))
+	os.Unsetenv("GCP_PROJECT")
+	w := httptest.NewRecorder()
+	body := `{"message":{"messageId":"1","data":"e30=","publishTime":"2019-11-12T16:16:08.000Z"}}`
+	newMux().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/Router", strings.NewReader(body)))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("/Router without GCP_PROJECT = %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+}
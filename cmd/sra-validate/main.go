@@ -0,0 +1,80 @@
+// Command sra-validate checks router configuration files before they are deployed.
+//
+// Each file is strictly parsed, rejecting unknown keys, then every configured finding, action,
+// target and exclude pattern and the properties required by each action are validated. The
+// command exits with a non-zero status if any file is invalid so it can be used in presubmits:
+//
+//	go run ./cmd/sra-validate config/sra.yaml
+package main
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/router"
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [file ...]\n\nValidates router configuration files, config/sra.yaml by default.\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	files := flag.Args()
+	if len(files) == 0 {
+		files = []string{"config/sra.yaml"}
+	}
+	os.Exit(run(os.Stdout, os.Stderr, files))
+}
+
+// run validates the files, printing the valid ones to stdout and the problems found to stderr,
+// and returns the exit status: 0 if every file is valid and 1 otherwise.
+func run(stdout, stderr io.Writer, files []string) int {
+	status := 0
+	for _, f := range files {
+		if !validate(stdout, stderr, f) {
+			status = 1
+		}
+	}
+	return status
+}
+
+// validate prints the problems found in the file and returns if it is valid.
+func validate(stdout, stderr io.Writer, file string) bool {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		fmt.Fprintf(stderr, "%s: %v\n", file, err)
+		return false
+	}
+	c, err := router.ParseConfig(b)
+	if err != nil {
+		fmt.Fprintf(stderr, "%s: %v\n", file, err)
+		return false
+	}
+	errs := c.Validate()
+	for _, err := range errs {
+		fmt.Fprintf(stderr, "%s: %v\n", file, err)
+	}
+	if len(errs) > 0 {
+		return false
+	}
+	fmt.Fprintf(stdout, "%s: ok\n", file)
+	return true
+}
@@ -0,0 +1,114 @@
+package main
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sra-validate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	invalid := filepath.Join(dir, "invalid.yaml")
+	if err := ioutil.WriteFile(invalid, []byte(`apiVersion: security-response-automation.cloud.google.com/v1alpha1
+kind: Remediation
+metadata:
+  name: router
+spec:
+  parameters:
+    sha:
+      public_bucket_acl:
+        - action: iam_revoke
+          target:
+            - organizations/456/*
+        - action: close_bucket
+    etd:
+      no_such_finding:
+        - action: close_bucket
+          target:
+            - organizations/456/*
+`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	unknownKey := filepath.Join(dir, "unknown.yaml")
+	if err := ioutil.WriteFile(unknownKey, []byte("spec:\n  paramters: {}\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		name       string
+		files      []string
+		wantStatus int
+		wantStdout []string
+		wantStderr []string
+	}{
+		{
+			name:       "valid",
+			files:      []string{"../../config/sra.yaml.sample"},
+			wantStatus: 0,
+			wantStdout: []string{"../../config/sra.yaml.sample: ok"},
+		},
+		{
+			name:       "invalid",
+			files:      []string{"../../config/sra.yaml.sample", invalid},
+			wantStatus: 1,
+			wantStdout: []string{"../../config/sra.yaml.sample: ok"},
+			wantStderr: []string{
+				invalid + `: sha.public_bucket_acl[0]: action "iam_revoke" is not supported by "public_bucket_acl"`,
+				invalid + ": sha.public_bucket_acl[1]: no target, the automation will never run",
+				invalid + ": etd.no_such_finding: unknown finding",
+			},
+		},
+		{
+			name:       "unknown key",
+			files:      []string{unknownKey},
+			wantStatus: 1,
+			wantStderr: []string{unknownKey + ": "},
+		},
+		{
+			name:       "missing file",
+			files:      []string{filepath.Join(dir, "missing.yaml")},
+			wantStatus: 1,
+			wantStderr: []string{filepath.Join(dir, "missing.yaml") + ": "},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if got := run(&stdout, &stderr, tt.files); got != tt.wantStatus {
+				t.Errorf("run() = %d, want %d; stderr:\n%s", got, tt.wantStatus, stderr.String())
+			}
+			for _, want := range tt.wantStdout {
+				if !strings.Contains(stdout.String(), want) {
+					t.Errorf("run() printed:\n%s\nwant it to contain %q", stdout.String(), want)
+				}
+			}
+			for _, want := range tt.wantStderr {
+				if !strings.Contains(stderr.String(), want) {
+					t.Errorf("run() reported:\n%s\nwant it to contain %q", stderr.String(), want)
+				}
+			}
+			if len(tt.wantStderr) == 0 && stderr.Len() > 0 {
+				t.Errorf("run() reported %q, want no errors", stderr.String())
+			}
+		})
+	}
+}
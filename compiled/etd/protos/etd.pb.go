@@ -20,12 +20,13 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
 
-// TODO: Finish.
 type BadDomain struct {
-	IpAddress            string   `protobuf:"bytes,1,opt,name=ip_address,json=ipAddress,proto3" json:"ip_address,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+	InsertId             string                 `protobuf:"bytes,1,opt,name=insertId,proto3" json:"insertId,omitempty"`
+	LogName              string                 `protobuf:"bytes,2,opt,name=logName,proto3" json:"logName,omitempty"`
+	JsonPayload          *BadDomain_JSONPayload `protobuf:"bytes,3,opt,name=jsonPayload,proto3" json:"jsonPayload,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}               `json:"-"`
+	XXX_unrecognized     []byte                 `json:"-"`
+	XXX_sizecache        int32                  `json:"-"`
 }
 
 func (m *BadDomain) Reset()         { *m = BadDomain{} }
@@ -53,13 +54,254 @@ func (m *BadDomain) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_BadDomain proto.InternalMessageInfo
 
-func (m *BadDomain) GetIpAddress() string {
+func (m *BadDomain) GetInsertId() string {
+	if m != nil {
+		return m.InsertId
+	}
+	return ""
+}
+
+func (m *BadDomain) GetLogName() string {
+	if m != nil {
+		return m.LogName
+	}
+	return ""
+}
+
+func (m *BadDomain) GetJsonPayload() *BadDomain_JSONPayload {
+	if m != nil {
+		return m.JsonPayload
+	}
+	return nil
+}
+
+type BadDomain_Network struct {
+	Project              string   `protobuf:"bytes,1,opt,name=project,proto3" json:"project,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BadDomain_Network) Reset()         { *m = BadDomain_Network{} }
+func (m *BadDomain_Network) String() string { return proto.CompactTextString(m) }
+func (*BadDomain_Network) ProtoMessage()    {}
+func (*BadDomain_Network) Descriptor() ([]byte, []int) {
+	return fileDescriptor_7762cc4b80af3525, []int{0, 0}
+}
+
+func (m *BadDomain_Network) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BadDomain_Network.Unmarshal(m, b)
+}
+func (m *BadDomain_Network) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BadDomain_Network.Marshal(b, m, deterministic)
+}
+func (m *BadDomain_Network) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BadDomain_Network.Merge(m, src)
+}
+func (m *BadDomain_Network) XXX_Size() int {
+	return xxx_messageInfo_BadDomain_Network.Size(m)
+}
+func (m *BadDomain_Network) XXX_DiscardUnknown() {
+	xxx_messageInfo_BadDomain_Network.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BadDomain_Network proto.InternalMessageInfo
+
+func (m *BadDomain_Network) GetProject() string {
+	if m != nil {
+		return m.Project
+	}
+	return ""
+}
+
+type BadDomain_Properties struct {
+	Network              *BadDomain_Network `protobuf:"bytes,1,opt,name=network,proto3" json:"network,omitempty"`
+	InstanceDetails      string             `protobuf:"bytes,2,opt,name=instanceDetails,proto3" json:"instanceDetails,omitempty"`
+	Domain               []string           `protobuf:"bytes,3,rep,name=domain,proto3" json:"domain,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
+}
+
+func (m *BadDomain_Properties) Reset()         { *m = BadDomain_Properties{} }
+func (m *BadDomain_Properties) String() string { return proto.CompactTextString(m) }
+func (*BadDomain_Properties) ProtoMessage()    {}
+func (*BadDomain_Properties) Descriptor() ([]byte, []int) {
+	return fileDescriptor_7762cc4b80af3525, []int{0, 1}
+}
+
+func (m *BadDomain_Properties) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BadDomain_Properties.Unmarshal(m, b)
+}
+func (m *BadDomain_Properties) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BadDomain_Properties.Marshal(b, m, deterministic)
+}
+func (m *BadDomain_Properties) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BadDomain_Properties.Merge(m, src)
+}
+func (m *BadDomain_Properties) XXX_Size() int {
+	return xxx_messageInfo_BadDomain_Properties.Size(m)
+}
+func (m *BadDomain_Properties) XXX_DiscardUnknown() {
+	xxx_messageInfo_BadDomain_Properties.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BadDomain_Properties proto.InternalMessageInfo
+
+func (m *BadDomain_Properties) GetNetwork() *BadDomain_Network {
+	if m != nil {
+		return m.Network
+	}
+	return nil
+}
+
+func (m *BadDomain_Properties) GetInstanceDetails() string {
+	if m != nil {
+		return m.InstanceDetails
+	}
+	return ""
+}
+
+func (m *BadDomain_Properties) GetDomain() []string {
+	if m != nil {
+		return m.Domain
+	}
+	return nil
+}
+
+type BadDomain_AffectedResource struct {
+	GcpResourceName      string   `protobuf:"bytes,1,opt,name=gcpResourceName,proto3" json:"gcpResourceName,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BadDomain_AffectedResource) Reset()         { *m = BadDomain_AffectedResource{} }
+func (m *BadDomain_AffectedResource) String() string { return proto.CompactTextString(m) }
+func (*BadDomain_AffectedResource) ProtoMessage()    {}
+func (*BadDomain_AffectedResource) Descriptor() ([]byte, []int) {
+	return fileDescriptor_7762cc4b80af3525, []int{0, 2}
+}
+
+func (m *BadDomain_AffectedResource) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BadDomain_AffectedResource.Unmarshal(m, b)
+}
+func (m *BadDomain_AffectedResource) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BadDomain_AffectedResource.Marshal(b, m, deterministic)
+}
+func (m *BadDomain_AffectedResource) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BadDomain_AffectedResource.Merge(m, src)
+}
+func (m *BadDomain_AffectedResource) XXX_Size() int {
+	return xxx_messageInfo_BadDomain_AffectedResource.Size(m)
+}
+func (m *BadDomain_AffectedResource) XXX_DiscardUnknown() {
+	xxx_messageInfo_BadDomain_AffectedResource.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BadDomain_AffectedResource proto.InternalMessageInfo
+
+func (m *BadDomain_AffectedResource) GetGcpResourceName() string {
+	if m != nil {
+		return m.GcpResourceName
+	}
+	return ""
+}
+
+type BadDomain_DetectionCategory struct {
+	RuleName             string   `protobuf:"bytes,1,opt,name=ruleName,proto3" json:"ruleName,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BadDomain_DetectionCategory) Reset()         { *m = BadDomain_DetectionCategory{} }
+func (m *BadDomain_DetectionCategory) String() string { return proto.CompactTextString(m) }
+func (*BadDomain_DetectionCategory) ProtoMessage()    {}
+func (*BadDomain_DetectionCategory) Descriptor() ([]byte, []int) {
+	return fileDescriptor_7762cc4b80af3525, []int{0, 3}
+}
+
+func (m *BadDomain_DetectionCategory) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BadDomain_DetectionCategory.Unmarshal(m, b)
+}
+func (m *BadDomain_DetectionCategory) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BadDomain_DetectionCategory.Marshal(b, m, deterministic)
+}
+func (m *BadDomain_DetectionCategory) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BadDomain_DetectionCategory.Merge(m, src)
+}
+func (m *BadDomain_DetectionCategory) XXX_Size() int {
+	return xxx_messageInfo_BadDomain_DetectionCategory.Size(m)
+}
+func (m *BadDomain_DetectionCategory) XXX_DiscardUnknown() {
+	xxx_messageInfo_BadDomain_DetectionCategory.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BadDomain_DetectionCategory proto.InternalMessageInfo
+
+func (m *BadDomain_DetectionCategory) GetRuleName() string {
 	if m != nil {
-		return m.IpAddress
+		return m.RuleName
 	}
 	return ""
 }
 
+type BadDomain_JSONPayload struct {
+	AffectedResources    []*BadDomain_AffectedResource `protobuf:"bytes,1,rep,name=affectedResources,proto3" json:"affectedResources,omitempty"`
+	Properties           *BadDomain_Properties         `protobuf:"bytes,2,opt,name=properties,proto3" json:"properties,omitempty"`
+	DetectionCategory    *BadDomain_DetectionCategory  `protobuf:"bytes,3,opt,name=detectionCategory,proto3" json:"detectionCategory,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                      `json:"-"`
+	XXX_unrecognized     []byte                        `json:"-"`
+	XXX_sizecache        int32                         `json:"-"`
+}
+
+func (m *BadDomain_JSONPayload) Reset()         { *m = BadDomain_JSONPayload{} }
+func (m *BadDomain_JSONPayload) String() string { return proto.CompactTextString(m) }
+func (*BadDomain_JSONPayload) ProtoMessage()    {}
+func (*BadDomain_JSONPayload) Descriptor() ([]byte, []int) {
+	return fileDescriptor_7762cc4b80af3525, []int{0, 4}
+}
+
+func (m *BadDomain_JSONPayload) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BadDomain_JSONPayload.Unmarshal(m, b)
+}
+func (m *BadDomain_JSONPayload) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BadDomain_JSONPayload.Marshal(b, m, deterministic)
+}
+func (m *BadDomain_JSONPayload) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BadDomain_JSONPayload.Merge(m, src)
+}
+func (m *BadDomain_JSONPayload) XXX_Size() int {
+	return xxx_messageInfo_BadDomain_JSONPayload.Size(m)
+}
+func (m *BadDomain_JSONPayload) XXX_DiscardUnknown() {
+	xxx_messageInfo_BadDomain_JSONPayload.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BadDomain_JSONPayload proto.InternalMessageInfo
+
+func (m *BadDomain_JSONPayload) GetAffectedResources() []*BadDomain_AffectedResource {
+	if m != nil {
+		return m.AffectedResources
+	}
+	return nil
+}
+
+func (m *BadDomain_JSONPayload) GetProperties() *BadDomain_Properties {
+	if m != nil {
+		return m.Properties
+	}
+	return nil
+}
+
+func (m *BadDomain_JSONPayload) GetDetectionCategory() *BadDomain_DetectionCategory {
+	if m != nil {
+		return m.DetectionCategory
+	}
+	return nil
+}
+
 type AnomalousIAMGrant struct {
 	InsertId             string                         `protobuf:"bytes,1,opt,name=insertId,proto3" json:"insertId,omitempty"`
 	LogName              string                         `protobuf:"bytes,2,opt,name=logName,proto3" json:"logName,omitempty"`
@@ -2165,8 +2407,366 @@ func (m *SshBruteForceSCC_Finding) GetName() string {
 	return ""
 }
 
+type BadDomainSCC struct {
+	NotificationConfigName string                `protobuf:"bytes,1,opt,name=notificationConfigName,proto3" json:"notificationConfigName,omitempty"`
+	Finding                *BadDomainSCC_Finding `protobuf:"bytes,2,opt,name=finding,proto3" json:"finding,omitempty"`
+	XXX_NoUnkeyedLiteral   struct{}              `json:"-"`
+	XXX_unrecognized       []byte                `json:"-"`
+	XXX_sizecache          int32                 `json:"-"`
+}
+
+func (m *BadDomainSCC) Reset()         { *m = BadDomainSCC{} }
+func (m *BadDomainSCC) String() string { return proto.CompactTextString(m) }
+func (*BadDomainSCC) ProtoMessage()    {}
+func (*BadDomainSCC) Descriptor() ([]byte, []int) {
+	return fileDescriptor_7762cc4b80af3525, []int{7}
+}
+
+func (m *BadDomainSCC) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BadDomainSCC.Unmarshal(m, b)
+}
+func (m *BadDomainSCC) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BadDomainSCC.Marshal(b, m, deterministic)
+}
+func (m *BadDomainSCC) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BadDomainSCC.Merge(m, src)
+}
+func (m *BadDomainSCC) XXX_Size() int {
+	return xxx_messageInfo_BadDomainSCC.Size(m)
+}
+func (m *BadDomainSCC) XXX_DiscardUnknown() {
+	xxx_messageInfo_BadDomainSCC.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BadDomainSCC proto.InternalMessageInfo
+
+func (m *BadDomainSCC) GetNotificationConfigName() string {
+	if m != nil {
+		return m.NotificationConfigName
+	}
+	return ""
+}
+
+func (m *BadDomainSCC) GetFinding() *BadDomainSCC_Finding {
+	if m != nil {
+		return m.Finding
+	}
+	return nil
+}
+
+type BadDomainSCC_SecurityMarks struct {
+	Marks                map[string]string `protobuf:"bytes,1,rep,name=marks,proto3" json:"marks,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *BadDomainSCC_SecurityMarks) Reset()         { *m = BadDomainSCC_SecurityMarks{} }
+func (m *BadDomainSCC_SecurityMarks) String() string { return proto.CompactTextString(m) }
+func (*BadDomainSCC_SecurityMarks) ProtoMessage()    {}
+func (*BadDomainSCC_SecurityMarks) Descriptor() ([]byte, []int) {
+	return fileDescriptor_7762cc4b80af3525, []int{7, 0}
+}
+
+func (m *BadDomainSCC_SecurityMarks) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BadDomainSCC_SecurityMarks.Unmarshal(m, b)
+}
+func (m *BadDomainSCC_SecurityMarks) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BadDomainSCC_SecurityMarks.Marshal(b, m, deterministic)
+}
+func (m *BadDomainSCC_SecurityMarks) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BadDomainSCC_SecurityMarks.Merge(m, src)
+}
+func (m *BadDomainSCC_SecurityMarks) XXX_Size() int {
+	return xxx_messageInfo_BadDomainSCC_SecurityMarks.Size(m)
+}
+func (m *BadDomainSCC_SecurityMarks) XXX_DiscardUnknown() {
+	xxx_messageInfo_BadDomainSCC_SecurityMarks.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BadDomainSCC_SecurityMarks proto.InternalMessageInfo
+
+func (m *BadDomainSCC_SecurityMarks) GetMarks() map[string]string {
+	if m != nil {
+		return m.Marks
+	}
+	return nil
+}
+
+type BadDomainSCC_Network struct {
+	Project              string   `protobuf:"bytes,1,opt,name=project,proto3" json:"project,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BadDomainSCC_Network) Reset()         { *m = BadDomainSCC_Network{} }
+func (m *BadDomainSCC_Network) String() string { return proto.CompactTextString(m) }
+func (*BadDomainSCC_Network) ProtoMessage()    {}
+func (*BadDomainSCC_Network) Descriptor() ([]byte, []int) {
+	return fileDescriptor_7762cc4b80af3525, []int{7, 1}
+}
+
+func (m *BadDomainSCC_Network) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BadDomainSCC_Network.Unmarshal(m, b)
+}
+func (m *BadDomainSCC_Network) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BadDomainSCC_Network.Marshal(b, m, deterministic)
+}
+func (m *BadDomainSCC_Network) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BadDomainSCC_Network.Merge(m, src)
+}
+func (m *BadDomainSCC_Network) XXX_Size() int {
+	return xxx_messageInfo_BadDomainSCC_Network.Size(m)
+}
+func (m *BadDomainSCC_Network) XXX_DiscardUnknown() {
+	xxx_messageInfo_BadDomainSCC_Network.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BadDomainSCC_Network proto.InternalMessageInfo
+
+func (m *BadDomainSCC_Network) GetProject() string {
+	if m != nil {
+		return m.Project
+	}
+	return ""
+}
+
+type BadDomainSCC_Properties struct {
+	Network              *BadDomainSCC_Network `protobuf:"bytes,1,opt,name=network,proto3" json:"network,omitempty"`
+	InstanceDetails      string                `protobuf:"bytes,2,opt,name=instanceDetails,proto3" json:"instanceDetails,omitempty"`
+	Domain               []string              `protobuf:"bytes,3,rep,name=domain,proto3" json:"domain,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}              `json:"-"`
+	XXX_unrecognized     []byte                `json:"-"`
+	XXX_sizecache        int32                 `json:"-"`
+}
+
+func (m *BadDomainSCC_Properties) Reset()         { *m = BadDomainSCC_Properties{} }
+func (m *BadDomainSCC_Properties) String() string { return proto.CompactTextString(m) }
+func (*BadDomainSCC_Properties) ProtoMessage()    {}
+func (*BadDomainSCC_Properties) Descriptor() ([]byte, []int) {
+	return fileDescriptor_7762cc4b80af3525, []int{7, 2}
+}
+
+func (m *BadDomainSCC_Properties) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BadDomainSCC_Properties.Unmarshal(m, b)
+}
+func (m *BadDomainSCC_Properties) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BadDomainSCC_Properties.Marshal(b, m, deterministic)
+}
+func (m *BadDomainSCC_Properties) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BadDomainSCC_Properties.Merge(m, src)
+}
+func (m *BadDomainSCC_Properties) XXX_Size() int {
+	return xxx_messageInfo_BadDomainSCC_Properties.Size(m)
+}
+func (m *BadDomainSCC_Properties) XXX_DiscardUnknown() {
+	xxx_messageInfo_BadDomainSCC_Properties.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BadDomainSCC_Properties proto.InternalMessageInfo
+
+func (m *BadDomainSCC_Properties) GetNetwork() *BadDomainSCC_Network {
+	if m != nil {
+		return m.Network
+	}
+	return nil
+}
+
+func (m *BadDomainSCC_Properties) GetInstanceDetails() string {
+	if m != nil {
+		return m.InstanceDetails
+	}
+	return ""
+}
+
+func (m *BadDomainSCC_Properties) GetDomain() []string {
+	if m != nil {
+		return m.Domain
+	}
+	return nil
+}
+
+type BadDomainSCC_DetectionCategory struct {
+	RuleName             string   `protobuf:"bytes,1,opt,name=ruleName,proto3" json:"ruleName,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BadDomainSCC_DetectionCategory) Reset()         { *m = BadDomainSCC_DetectionCategory{} }
+func (m *BadDomainSCC_DetectionCategory) String() string { return proto.CompactTextString(m) }
+func (*BadDomainSCC_DetectionCategory) ProtoMessage()    {}
+func (*BadDomainSCC_DetectionCategory) Descriptor() ([]byte, []int) {
+	return fileDescriptor_7762cc4b80af3525, []int{7, 3}
+}
+
+func (m *BadDomainSCC_DetectionCategory) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BadDomainSCC_DetectionCategory.Unmarshal(m, b)
+}
+func (m *BadDomainSCC_DetectionCategory) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BadDomainSCC_DetectionCategory.Marshal(b, m, deterministic)
+}
+func (m *BadDomainSCC_DetectionCategory) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BadDomainSCC_DetectionCategory.Merge(m, src)
+}
+func (m *BadDomainSCC_DetectionCategory) XXX_Size() int {
+	return xxx_messageInfo_BadDomainSCC_DetectionCategory.Size(m)
+}
+func (m *BadDomainSCC_DetectionCategory) XXX_DiscardUnknown() {
+	xxx_messageInfo_BadDomainSCC_DetectionCategory.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BadDomainSCC_DetectionCategory proto.InternalMessageInfo
+
+func (m *BadDomainSCC_DetectionCategory) GetRuleName() string {
+	if m != nil {
+		return m.RuleName
+	}
+	return ""
+}
+
+type BadDomainSCC_SourceProperties struct {
+	Properties           *BadDomainSCC_Properties        `protobuf:"bytes,1,opt,name=properties,proto3" json:"properties,omitempty"`
+	DetectionCategory    *BadDomainSCC_DetectionCategory `protobuf:"bytes,2,opt,name=detectionCategory,proto3" json:"detectionCategory,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                        `json:"-"`
+	XXX_unrecognized     []byte                          `json:"-"`
+	XXX_sizecache        int32                           `json:"-"`
+}
+
+func (m *BadDomainSCC_SourceProperties) Reset()         { *m = BadDomainSCC_SourceProperties{} }
+func (m *BadDomainSCC_SourceProperties) String() string { return proto.CompactTextString(m) }
+func (*BadDomainSCC_SourceProperties) ProtoMessage()    {}
+func (*BadDomainSCC_SourceProperties) Descriptor() ([]byte, []int) {
+	return fileDescriptor_7762cc4b80af3525, []int{7, 4}
+}
+
+func (m *BadDomainSCC_SourceProperties) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BadDomainSCC_SourceProperties.Unmarshal(m, b)
+}
+func (m *BadDomainSCC_SourceProperties) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BadDomainSCC_SourceProperties.Marshal(b, m, deterministic)
+}
+func (m *BadDomainSCC_SourceProperties) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BadDomainSCC_SourceProperties.Merge(m, src)
+}
+func (m *BadDomainSCC_SourceProperties) XXX_Size() int {
+	return xxx_messageInfo_BadDomainSCC_SourceProperties.Size(m)
+}
+func (m *BadDomainSCC_SourceProperties) XXX_DiscardUnknown() {
+	xxx_messageInfo_BadDomainSCC_SourceProperties.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BadDomainSCC_SourceProperties proto.InternalMessageInfo
+
+func (m *BadDomainSCC_SourceProperties) GetProperties() *BadDomainSCC_Properties {
+	if m != nil {
+		return m.Properties
+	}
+	return nil
+}
+
+func (m *BadDomainSCC_SourceProperties) GetDetectionCategory() *BadDomainSCC_DetectionCategory {
+	if m != nil {
+		return m.DetectionCategory
+	}
+	return nil
+}
+
+type BadDomainSCC_Finding struct {
+	SourceProperties     *BadDomainSCC_SourceProperties `protobuf:"bytes,1,opt,name=sourceProperties,proto3" json:"sourceProperties,omitempty"`
+	Category             string                         `protobuf:"bytes,2,opt,name=category,proto3" json:"category,omitempty"`
+	ResourceName         string                         `protobuf:"bytes,3,opt,name=resourceName,proto3" json:"resourceName,omitempty"`
+	State                string                         `protobuf:"bytes,4,opt,name=state,proto3" json:"state,omitempty"`
+	SecurityMarks        *BadDomainSCC_SecurityMarks    `protobuf:"bytes,5,opt,name=securityMarks,proto3" json:"securityMarks,omitempty"`
+	EventTime            string                         `protobuf:"bytes,6,opt,name=eventTime,proto3" json:"eventTime,omitempty"`
+	Name                 string                         `protobuf:"bytes,7,opt,name=name,proto3" json:"name,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                       `json:"-"`
+	XXX_unrecognized     []byte                         `json:"-"`
+	XXX_sizecache        int32                          `json:"-"`
+}
+
+func (m *BadDomainSCC_Finding) Reset()         { *m = BadDomainSCC_Finding{} }
+func (m *BadDomainSCC_Finding) String() string { return proto.CompactTextString(m) }
+func (*BadDomainSCC_Finding) ProtoMessage()    {}
+func (*BadDomainSCC_Finding) Descriptor() ([]byte, []int) {
+	return fileDescriptor_7762cc4b80af3525, []int{7, 5}
+}
+
+func (m *BadDomainSCC_Finding) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BadDomainSCC_Finding.Unmarshal(m, b)
+}
+func (m *BadDomainSCC_Finding) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BadDomainSCC_Finding.Marshal(b, m, deterministic)
+}
+func (m *BadDomainSCC_Finding) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BadDomainSCC_Finding.Merge(m, src)
+}
+func (m *BadDomainSCC_Finding) XXX_Size() int {
+	return xxx_messageInfo_BadDomainSCC_Finding.Size(m)
+}
+func (m *BadDomainSCC_Finding) XXX_DiscardUnknown() {
+	xxx_messageInfo_BadDomainSCC_Finding.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BadDomainSCC_Finding proto.InternalMessageInfo
+
+func (m *BadDomainSCC_Finding) GetSourceProperties() *BadDomainSCC_SourceProperties {
+	if m != nil {
+		return m.SourceProperties
+	}
+	return nil
+}
+
+func (m *BadDomainSCC_Finding) GetCategory() string {
+	if m != nil {
+		return m.Category
+	}
+	return ""
+}
+
+func (m *BadDomainSCC_Finding) GetResourceName() string {
+	if m != nil {
+		return m.ResourceName
+	}
+	return ""
+}
+
+func (m *BadDomainSCC_Finding) GetState() string {
+	if m != nil {
+		return m.State
+	}
+	return ""
+}
+
+func (m *BadDomainSCC_Finding) GetSecurityMarks() *BadDomainSCC_SecurityMarks {
+	if m != nil {
+		return m.SecurityMarks
+	}
+	return nil
+}
+
+func (m *BadDomainSCC_Finding) GetEventTime() string {
+	if m != nil {
+		return m.EventTime
+	}
+	return ""
+}
+
+func (m *BadDomainSCC_Finding) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
 func init() {
 	proto.RegisterType((*BadDomain)(nil), "BadDomain")
+	proto.RegisterType((*BadDomain_Network)(nil), "BadDomain.Network")
+	proto.RegisterType((*BadDomain_Properties)(nil), "BadDomain.Properties")
+	proto.RegisterType((*BadDomain_AffectedResource)(nil), "BadDomain.AffectedResource")
+	proto.RegisterType((*BadDomain_DetectionCategory)(nil), "BadDomain.DetectionCategory")
+	proto.RegisterType((*BadDomain_JSONPayload)(nil), "BadDomain.JSONPayload")
 	proto.RegisterType((*AnomalousIAMGrant)(nil), "AnomalousIAMGrant")
 	proto.RegisterType((*AnomalousIAMGrant_SensitiveRoleGrant)(nil), "AnomalousIAMGrant.SensitiveRoleGrant")
 	proto.RegisterType((*AnomalousIAMGrant_Properties)(nil), "AnomalousIAMGrant.Properties")
@@ -2213,87 +2813,105 @@ func init() {
 	proto.RegisterType((*SshBruteForceSCC_DetectionCategory)(nil), "SshBruteForceSCC.DetectionCategory")
 	proto.RegisterType((*SshBruteForceSCC_SourceProperties)(nil), "SshBruteForceSCC.SourceProperties")
 	proto.RegisterType((*SshBruteForceSCC_Finding)(nil), "SshBruteForceSCC.Finding")
+	proto.RegisterType((*BadDomainSCC)(nil), "BadDomainSCC")
+	proto.RegisterType((*BadDomainSCC_SecurityMarks)(nil), "BadDomainSCC.SecurityMarks")
+	proto.RegisterMapType((map[string]string)(nil), "BadDomainSCC.SecurityMarks.MarksEntry")
+	proto.RegisterType((*BadDomainSCC_Network)(nil), "BadDomainSCC.Network")
+	proto.RegisterType((*BadDomainSCC_Properties)(nil), "BadDomainSCC.Properties")
+	proto.RegisterType((*BadDomainSCC_DetectionCategory)(nil), "BadDomainSCC.DetectionCategory")
+	proto.RegisterType((*BadDomainSCC_SourceProperties)(nil), "BadDomainSCC.SourceProperties")
+	proto.RegisterType((*BadDomainSCC_Finding)(nil), "BadDomainSCC.Finding")
 }
 
 func init() { proto.RegisterFile("etd/protos/etd.proto", fileDescriptor_7762cc4b80af3525) }
 
 var fileDescriptor_7762cc4b80af3525 = []byte{
-	// 1228 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xcc, 0x58, 0xcf, 0x6f, 0x1b, 0x45,
-	0x14, 0x96, 0xe3, 0x24, 0x8e, 0x9f, 0x1b, 0x48, 0x46, 0x51, 0x6b, 0xb6, 0x6d, 0x62, 0x1c, 0x7e,
-	0x58, 0x8d, 0xe4, 0x88, 0xb4, 0xa2, 0xa1, 0x4a, 0xa5, 0x38, 0x4e, 0x82, 0x8c, 0x92, 0x34, 0x1d,
-	0x53, 0x89, 0x5b, 0xd9, 0xec, 0x8e, 0xdd, 0x6d, 0xec, 0x1d, 0x6b, 0x67, 0x1c, 0x14, 0x0e, 0x1c,
-	0xe0, 0xc8, 0x81, 0x03, 0x17, 0xee, 0x48, 0x88, 0x23, 0x77, 0xfe, 0x01, 0xc4, 0x85, 0x33, 0x47,
-	0x4e, 0xfc, 0x05, 0x1c, 0x91, 0xd0, 0xee, 0xce, 0xda, 0xb3, 0xbb, 0x33, 0x65, 0x13, 0x2b, 0x84,
-	0x4b, 0x34, 0x6f, 0x66, 0xde, 0x9b, 0x37, 0x6f, 0xbe, 0xf7, 0xed, 0xe7, 0xc0, 0x12, 0xe1, 0xf6,
-	0xfa, 0xc0, 0xa3, 0x9c, 0xb2, 0x75, 0xc2, 0xed, 0x7a, 0x30, 0xac, 0xde, 0x83, 0xe2, 0x8e, 0x69,
-	0xef, 0xd2, 0xbe, 0xe9, 0xb8, 0xe8, 0x2e, 0x80, 0x33, 0x78, 0x6e, 0xda, 0xb6, 0x47, 0x18, 0x2b,
-	0xe7, 0x2a, 0xb9, 0x5a, 0x11, 0x17, 0x9d, 0x41, 0x23, 0x9c, 0xa8, 0xfe, 0x3a, 0x03, 0x8b, 0x0d,
-	0x97, 0xf6, 0xcd, 0x1e, 0x1d, 0xb2, 0x56, 0xe3, 0xf0, 0x43, 0xcf, 0x74, 0x39, 0x32, 0x60, 0xce,
-	0x71, 0x19, 0xf1, 0x78, 0xcb, 0x16, 0x2e, 0x23, 0x1b, 0x95, 0xa1, 0xd0, 0xa3, 0xdd, 0x23, 0xb3,
-	0x4f, 0xca, 0x53, 0xc1, 0x52, 0x64, 0xa2, 0x6d, 0x28, 0xbd, 0x64, 0xd4, 0x3d, 0x36, 0xcf, 0x7b,
-	0xd4, 0xb4, 0xcb, 0xf9, 0x4a, 0xae, 0x56, 0xda, 0x58, 0xae, 0xa7, 0xc2, 0xd7, 0x3f, 0x6a, 0x3f,
-	0x39, 0x12, 0xbb, 0xb0, 0xec, 0x62, 0xd4, 0x01, 0xb5, 0x89, 0xcb, 0x1c, 0xee, 0x9c, 0x11, 0x4c,
-	0x7b, 0x24, 0xcc, 0xa6, 0x0c, 0x85, 0x3e, 0xe9, 0x9f, 0x10, 0xcf, 0xcf, 0x3f, 0xef, 0x9f, 0x28,
-	0x4c, 0xc3, 0x02, 0x38, 0xf6, 0xe8, 0x80, 0x78, 0xdc, 0x21, 0x0c, 0x3d, 0x03, 0xc4, 0x52, 0xde,
-	0x41, 0xfe, 0xa5, 0x8d, 0xb7, 0x15, 0x69, 0xa4, 0x8f, 0xc2, 0x8a, 0x00, 0xc6, 0x1a, 0x94, 0xda,
-	0x74, 0xe8, 0x59, 0xe4, 0x80, 0x76, 0x5b, 0x36, 0xba, 0x03, 0xc5, 0x81, 0x47, 0x5f, 0x12, 0x6b,
-	0x5c, 0x9c, 0xf1, 0x84, 0x71, 0x00, 0x73, 0x7b, 0x67, 0x8e, 0x4d, 0x5c, 0x2b, 0xa8, 0x07, 0x1b,
-	0x3b, 0x8a, 0x44, 0x54, 0xf5, 0x90, 0xc2, 0x63, 0xd9, 0xc5, 0x78, 0x0a, 0x8b, 0xbb, 0x84, 0x13,
-	0x8b, 0x3b, 0xd4, 0x6d, 0x9a, 0x9c, 0x74, 0xa9, 0x77, 0xee, 0x3f, 0x8e, 0x37, 0xec, 0x91, 0xe0,
-	0x05, 0xc4, 0xe3, 0x44, 0x36, 0xaa, 0x40, 0x89, 0x0d, 0x4f, 0x70, 0xb4, 0x1c, 0x3e, 0x90, 0x3c,
-	0x65, 0xfc, 0x9e, 0x83, 0x92, 0x54, 0x7f, 0xf4, 0x18, 0x60, 0x30, 0x2a, 0xa1, 0xc8, 0xf1, 0xae,
-	0x22, 0xc7, 0x71, 0x9d, 0xb1, 0xe4, 0x80, 0x30, 0x2c, 0xda, 0xc9, 0x0c, 0x83, 0x63, 0x4b, 0x1b,
-	0x6f, 0x29, 0xa2, 0xa4, 0x6e, 0x83, 0xd3, 0xee, 0xe8, 0x21, 0xcc, 0x11, 0x51, 0xc3, 0x72, 0xbe,
-	0x92, 0xaf, 0x95, 0x36, 0x6e, 0x2b, 0x42, 0x45, 0x65, 0xc6, 0xa3, 0xcd, 0xd5, 0x9f, 0xa7, 0x61,
-	0x66, 0xc7, 0xb4, 0x5b, 0xc7, 0x97, 0x04, 0xf0, 0x03, 0x15, 0x80, 0x51, 0x3d, 0x08, 0xa9, 0x07,
-	0xed, 0x2a, 0x14, 0x8e, 0x08, 0xff, 0x8c, 0x7a, 0xa7, 0x7e, 0x68, 0x01, 0x05, 0x71, 0x6a, 0x64,
-	0x1a, 0x9f, 0xc6, 0x90, 0x5a, 0x83, 0x82, 0x1b, 0xba, 0x88, 0x8a, 0xbf, 0x26, 0x0e, 0x11, 0x81,
-	0x70, 0xb4, 0x8c, 0x6a, 0xf0, 0xba, 0xe3, 0x32, 0x6e, 0xba, 0x16, 0xd9, 0x25, 0xdc, 0x74, 0x7a,
-	0x4c, 0x24, 0x9d, 0x9c, 0x36, 0xb6, 0x60, 0xa1, 0xd1, 0xe9, 0x10, 0x8b, 0x13, 0x1b, 0x93, 0x10,
-	0x44, 0xbe, 0x77, 0xd7, 0x1a, 0x44, 0xa6, 0x84, 0x98, 0xe4, 0xb4, 0xb1, 0x7e, 0x41, 0xa4, 0x19,
-	0xbf, 0x25, 0x70, 0xb4, 0x07, 0x8b, 0x66, 0xe2, 0xf8, 0xb0, 0x5d, 0x4b, 0x1b, 0xb7, 0xc4, 0xe5,
-	0x92, 0xe9, 0xe1, 0xb4, 0x07, 0x7a, 0x2f, 0x06, 0xc7, 0x10, 0x48, 0x8b, 0xc2, 0x5f, 0x03, 0xc1,
-	0x7d, 0x15, 0x04, 0xc3, 0xb7, 0x2b, 0x0b, 0xcf, 0x2c, 0xb0, 0xab, 0x7e, 0x39, 0x0b, 0xf3, 0x6d,
-	0xf6, 0x62, 0xc7, 0x1b, 0x72, 0xb2, 0x4f, 0xfd, 0xf2, 0x5d, 0x0e, 0x45, 0x5b, 0x2a, 0x14, 0x19,
-	0xf5, 0x58, 0x68, 0x3d, 0x9a, 0xbe, 0x80, 0x1b, 0x07, 0xb4, 0xeb, 0xb8, 0x0d, 0xce, 0x49, 0x7f,
-	0xc0, 0xd1, 0x32, 0x80, 0x39, 0xe4, 0x2f, 0x30, 0x61, 0xc3, 0x5e, 0x84, 0x2a, 0x69, 0xc6, 0xcf,
-	0x31, 0xac, 0x5d, 0x6b, 0x20, 0x12, 0x19, 0xd9, 0xfe, 0xda, 0x90, 0x11, 0x2f, 0x48, 0x32, 0x1f,
-	0xae, 0x45, 0x36, 0xba, 0x09, 0xb3, 0x67, 0xfd, 0x60, 0x65, 0x3a, 0x58, 0x11, 0x96, 0xf1, 0x7d,
-	0x2e, 0x86, 0xd4, 0x15, 0x28, 0x45, 0x40, 0x7b, 0xee, 0x44, 0x55, 0x80, 0x68, 0xaa, 0x65, 0xfb,
-	0xdf, 0x17, 0x81, 0x71, 0x7f, 0x7d, 0x2a, 0xc1, 0x87, 0x08, 0xc1, 0xf4, 0xe7, 0xd4, 0x8d, 0x8e,
-	0x0f, 0xc6, 0xa8, 0x01, 0xf3, 0xf2, 0x15, 0x59, 0x79, 0x5a, 0x34, 0x79, 0xbc, 0x44, 0xf2, 0x1e,
-	0x1c, 0xf7, 0xf8, 0xaf, 0xc1, 0xfe, 0x67, 0x02, 0xec, 0x87, 0x7a, 0xb0, 0xaf, 0x24, 0x6e, 0x91,
-	0x05, 0xf4, 0x1f, 0x28, 0x40, 0xff, 0x46, 0x22, 0x8e, 0x06, 0xfc, 0x47, 0x7a, 0xf0, 0x57, 0x12,
-	0x11, 0x32, 0x35, 0xc1, 0x1f, 0xb3, 0x30, 0x17, 0xf4, 0x4c, 0xbb, 0xd9, 0x44, 0xef, 0xc3, 0x4d,
-	0x97, 0x72, 0xa7, 0xe3, 0x58, 0x66, 0xb0, 0x89, 0xba, 0x1d, 0xa7, 0x2b, 0x15, 0x48, 0xb3, 0x8a,
-	0xd6, 0xa0, 0xd0, 0x71, 0x5c, 0xdb, 0x71, 0xbb, 0xf1, 0x0e, 0x6e, 0x37, 0x9b, 0xf5, 0xfd, 0x70,
-	0x01, 0x47, 0x3b, 0x8c, 0xaf, 0x72, 0x30, 0xdf, 0x26, 0xd6, 0xd0, 0x73, 0xf8, 0xf9, 0xa1, 0xe9,
-	0x9d, 0x32, 0xb4, 0x09, 0x33, 0x7d, 0x7f, 0x20, 0x2a, 0x5a, 0x1d, 0x3b, 0xc7, 0xf6, 0xd5, 0x83,
-	0xbf, 0x7b, 0x2e, 0xf7, 0xce, 0x71, 0xe8, 0x60, 0x6c, 0x02, 0x8c, 0x27, 0xd1, 0x02, 0xe4, 0x4f,
-	0xc9, 0xb9, 0xc8, 0xd5, 0x1f, 0xa2, 0x25, 0x98, 0x39, 0x33, 0x7b, 0xc3, 0xa8, 0x65, 0x43, 0xe3,
-	0xd1, 0xd4, 0x66, 0x2e, 0x1b, 0x89, 0xc7, 0xe5, 0xc6, 0x5a, 0x92, 0xc4, 0xa5, 0x5b, 0x4e, 0xc0,
-	0xe3, 0x17, 0x06, 0xe7, 0xb7, 0x39, 0x58, 0x08, 0x15, 0x84, 0x94, 0xdc, 0x03, 0xc5, 0x67, 0x7d,
-	0x69, 0x9c, 0x9f, 0x06, 0x4d, 0x2d, 0xfd, 0xd7, 0xfc, 0xf6, 0xd8, 0x39, 0x0b, 0x90, 0x8c, 0xef,
-	0xa6, 0xa0, 0x20, 0xde, 0x1a, 0xed, 0xc3, 0x02, 0x4b, 0x24, 0x28, 0x52, 0x32, 0xa4, 0xb7, 0x4d,
-	0xec, 0xc0, 0x29, 0x1f, 0xbf, 0x0a, 0x96, 0x9c, 0x55, 0x11, 0x8f, 0x6c, 0x54, 0x85, 0x1b, 0x9e,
-	0xdc, 0xfa, 0x21, 0xe1, 0xc4, 0xe6, 0xfc, 0xe7, 0x67, 0xdc, 0xe4, 0x11, 0xe5, 0x85, 0x06, 0x7a,
-	0x0c, 0xf3, 0x4c, 0xc6, 0x55, 0x79, 0x26, 0x48, 0xed, 0x96, 0x06, 0x76, 0x38, 0xbe, 0xdb, 0xd7,
-	0x83, 0xe4, 0x8c, 0xb8, 0xfc, 0x63, 0xa7, 0x4f, 0xca, 0xb3, 0x21, 0xff, 0x8d, 0x26, 0x7c, 0xfe,
-	0x73, 0xfd, 0x74, 0x0a, 0x21, 0xff, 0xf9, 0xe3, 0xea, 0xdf, 0x73, 0xb0, 0x94, 0xd2, 0x33, 0x93,
-	0xf4, 0xdb, 0xc3, 0x64, 0xbf, 0x29, 0x04, 0x9c, 0xb2, 0xf7, 0xbe, 0x49, 0xf5, 0xde, 0x6e, 0xbc,
-	0xf7, 0xea, 0xea, 0x40, 0x57, 0xd7, 0x87, 0x17, 0x12, 0xdb, 0x4f, 0x24, 0xb1, 0xdd, 0x54, 0x89,
-	0xed, 0x37, 0x35, 0xe9, 0xeb, 0xf4, 0xf6, 0x45, 0x7f, 0x7f, 0x74, 0x62, 0x84, 0xf0, 0xc9, 0x2b,
-	0x7e, 0x7f, 0xd4, 0x74, 0x85, 0xcc, 0xf4, 0x13, 0xe4, 0x32, 0x1f, 0xac, 0x34, 0x27, 0x6c, 0x2b,
-	0x38, 0xa1, 0xa2, 0xce, 0x4b, 0xc3, 0x0f, 0xcf, 0xf4, 0xfc, 0xf0, 0xae, 0x3a, 0x50, 0x26, 0xc1,
-	0xff, 0x28, 0x25, 0xf8, 0x97, 0xd5, 0xd1, 0xd2, 0x9a, 0xdf, 0xf8, 0x49, 0xe2, 0x19, 0xac, 0xe5,
-	0x99, 0x77, 0x5e, 0x05, 0x84, 0x6b, 0xe0, 0x9c, 0x96, 0x9a, 0x73, 0x56, 0x33, 0xb4, 0xdb, 0xe4,
-	0xfc, 0xf3, 0x4b, 0x11, 0x16, 0x62, 0xd2, 0x60, 0x12, 0xee, 0xb9, 0x9f, 0xe4, 0x9e, 0x84, 0x70,
-	0x51, 0xf2, 0xce, 0xd7, 0x29, 0xde, 0xd9, 0x8e, 0xf3, 0xce, 0xbd, 0x74, 0x90, 0xab, 0xe3, 0x9c,
-	0xeb, 0x96, 0xdc, 0x3f, 0x5c, 0xbd, 0xe4, 0xde, 0x55, 0x4b, 0xee, 0xe5, 0x74, 0x99, 0xff, 0x47,
-	0xaa, 0xfb, 0x2f, 0x15, 0x89, 0x1d, 0xeb, 0xa5, 0x77, 0x35, 0x7d, 0x9b, 0x2c, 0xea, 0x7b, 0x4b,
-	0xa1, 0xbe, 0xef, 0xa4, 0x43, 0x69, 0x28, 0xf1, 0xa9, 0x5e, 0x80, 0xaf, 0xa6, 0x83, 0x64, 0x92,
-	0x4e, 0x3f, 0x4a, 0x94, 0x76, 0xa4, 0xa5, 0x34, 0xc5, 0x6d, 0xaf, 0x8d, 0xce, 0xf6, 0xd4, 0x74,
-	0xb6, 0xf2, 0x2f, 0x5d, 0x3c, 0x31, 0x95, 0x9d, 0xcc, 0x06, 0xff, 0xf1, 0xbc, 0xff, 0x4f, 0x00,
-	0x00, 0x00, 0xff, 0xff, 0x46, 0xbb, 0xbf, 0x77, 0x09, 0x15, 0x00, 0x00,
+	// 1377 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xcc, 0x59, 0xcf, 0x4f, 0x24, 0x45,
+	0x14, 0xce, 0x30, 0xc0, 0x30, 0x6f, 0x40, 0xa1, 0x82, 0xd8, 0x36, 0x2c, 0x8c, 0x83, 0xae, 0x13,
+	0x31, 0x43, 0x64, 0x57, 0x17, 0x37, 0x6c, 0xc2, 0x30, 0x80, 0x19, 0x02, 0x2c, 0x5b, 0xe3, 0x26,
+	0xde, 0xb4, 0xe9, 0xae, 0x99, 0xed, 0x65, 0xa6, 0x7b, 0xd2, 0x5d, 0x83, 0xc1, 0x18, 0x4d, 0xf4,
+	0xe0, 0xc1, 0x83, 0x07, 0x2f, 0x1a, 0x2f, 0x26, 0x26, 0xc6, 0xa3, 0x77, 0xff, 0x01, 0xe3, 0xc5,
+	0xb3, 0xf1, 0xe4, 0xc9, 0x83, 0x67, 0x8f, 0x26, 0xa6, 0x7f, 0xcd, 0x54, 0x57, 0x57, 0x61, 0xc3,
+	0x84, 0x65, 0x2f, 0xa4, 0xeb, 0xc7, 0x7b, 0xfd, 0xea, 0xf5, 0xf7, 0x7d, 0xfd, 0xf5, 0x00, 0xb3,
+	0x84, 0x1a, 0xab, 0x5d, 0xc7, 0xa6, 0xb6, 0xbb, 0x4a, 0xa8, 0x51, 0xf1, 0x2f, 0x4b, 0x7f, 0x8f,
+	0x42, 0x7e, 0x4b, 0x33, 0xb6, 0xed, 0x8e, 0x66, 0x5a, 0x48, 0x85, 0x09, 0xd3, 0x72, 0x89, 0x43,
+	0xeb, 0x86, 0x92, 0x29, 0x66, 0xca, 0x79, 0xdc, 0x1f, 0x23, 0x05, 0x72, 0x6d, 0xbb, 0x75, 0xa8,
+	0x75, 0x88, 0x32, 0xe2, 0x2f, 0x45, 0x43, 0xb4, 0x0e, 0x85, 0xc7, 0xae, 0x6d, 0x1d, 0x69, 0x67,
+	0x6d, 0x5b, 0x33, 0x94, 0x6c, 0x31, 0x53, 0x2e, 0xac, 0xcd, 0x55, 0xfa, 0x69, 0x2b, 0x7b, 0x8d,
+	0xfb, 0x87, 0xe1, 0x2a, 0x66, 0xb7, 0xaa, 0xcb, 0x90, 0x3b, 0x24, 0xf4, 0x03, 0xdb, 0x39, 0xf1,
+	0xd2, 0x77, 0x1d, 0xfb, 0x31, 0xd1, 0x69, 0x78, 0xe7, 0x68, 0xa8, 0x7e, 0x04, 0x70, 0xe4, 0xd8,
+	0x5d, 0xe2, 0x50, 0x93, 0xb8, 0xe8, 0x35, 0xc8, 0x59, 0x41, 0x88, 0xbf, 0xaf, 0xb0, 0x86, 0x98,
+	0x1b, 0x85, 0xc9, 0x70, 0xb4, 0x05, 0x95, 0xe1, 0x59, 0xd3, 0x72, 0xa9, 0x66, 0xe9, 0x64, 0x9b,
+	0x50, 0xcd, 0x6c, 0xbb, 0x61, 0xf1, 0xfc, 0x34, 0x9a, 0x83, 0x71, 0xc3, 0x4f, 0xa2, 0x64, 0x8b,
+	0xd9, 0x72, 0x1e, 0x87, 0x23, 0x75, 0x03, 0xa6, 0xab, 0xcd, 0x26, 0xd1, 0x29, 0x31, 0x30, 0x71,
+	0xed, 0x9e, 0xa3, 0x13, 0x2f, 0x6b, 0x4b, 0xef, 0x46, 0x43, 0xbf, 0x25, 0x41, 0xcd, 0xfc, 0xb4,
+	0xba, 0x0a, 0x33, 0xdb, 0x84, 0x12, 0x9d, 0x9a, 0xb6, 0x55, 0xd3, 0x28, 0x69, 0xd9, 0xce, 0x99,
+	0xd7, 0x65, 0xa7, 0xd7, 0x66, 0xe3, 0xfa, 0x63, 0xf5, 0x8f, 0x0c, 0x14, 0x98, 0x76, 0xa1, 0x3a,
+	0xcc, 0x68, 0xdc, 0xed, 0x5d, 0x25, 0x53, 0xcc, 0x96, 0x0b, 0x6b, 0xf3, 0xcc, 0xc1, 0xf9, 0x12,
+	0x71, 0x32, 0x0a, 0xbd, 0x01, 0xd0, 0xed, 0xf7, 0xd1, 0x6f, 0x43, 0x61, 0xed, 0x39, 0x26, 0xc7,
+	0xa0, 0xc9, 0x98, 0xd9, 0x88, 0xf6, 0x60, 0xc6, 0xe0, 0x8f, 0x10, 0x3e, 0xe3, 0x05, 0x26, 0x3a,
+	0x71, 0x4c, 0x9c, 0x0c, 0x2b, 0xfd, 0x3a, 0x06, 0x33, 0x55, 0xcb, 0xee, 0x68, 0x6d, 0xbb, 0xe7,
+	0xd6, 0xab, 0x07, 0x6f, 0x3b, 0x9a, 0x45, 0x2f, 0x89, 0xba, 0x4d, 0x11, 0xea, 0x16, 0x2b, 0x89,
+	0xf4, 0x72, 0xf4, 0x55, 0x00, 0x35, 0x88, 0xe5, 0x9a, 0xd4, 0x3c, 0x25, 0xd8, 0x6e, 0x93, 0xa0,
+	0x1a, 0x05, 0x72, 0x1d, 0xd2, 0x39, 0x26, 0x4e, 0xd0, 0xe7, 0x3c, 0x8e, 0x86, 0xaa, 0x1e, 0x03,
+	0xe2, 0x43, 0x40, 0x6e, 0x22, 0x3a, 0xc4, 0xe4, 0xcb, 0x82, 0x32, 0x92, 0xb7, 0xc2, 0x82, 0x04,
+	0xea, 0x0a, 0x14, 0x1a, 0xfe, 0x03, 0xdb, 0xb7, 0x5b, 0x75, 0x03, 0x2d, 0x40, 0x3e, 0xe4, 0x41,
+	0xbf, 0x39, 0x83, 0x09, 0x75, 0x1f, 0x26, 0x76, 0x4e, 0x4d, 0x83, 0x58, 0xba, 0xdf, 0x0f, 0x77,
+	0x10, 0xa8, 0x64, 0xa4, 0xfd, 0x60, 0xd2, 0x63, 0x36, 0x44, 0x7d, 0x70, 0x41, 0xb0, 0xa2, 0x22,
+	0x14, 0xdc, 0xde, 0x31, 0x8e, 0x96, 0x83, 0x07, 0xc4, 0x4e, 0xa9, 0xbf, 0x73, 0x70, 0xbe, 0x17,
+	0xc3, 0x60, 0x50, 0xe3, 0x0d, 0x41, 0x8d, 0x12, 0x2c, 0x62, 0x11, 0x16, 0x03, 0x24, 0xbf, 0x24,
+	0xc8, 0x92, 0x06, 0x93, 0xe8, 0x0e, 0x4c, 0x90, 0xb0, 0x87, 0x4a, 0x36, 0x24, 0x56, 0x32, 0x55,
+	0xd4, 0x66, 0xdc, 0xdf, 0x5c, 0xfa, 0x79, 0x14, 0xc6, 0xb6, 0x34, 0xa3, 0x7e, 0x74, 0x49, 0x00,
+	0xdf, 0x16, 0x01, 0xd8, 0x57, 0xb3, 0xfa, 0xd1, 0x90, 0x92, 0xf9, 0x7e, 0x0c, 0xa9, 0x65, 0x5e,
+	0x32, 0x9f, 0x09, 0x6f, 0x72, 0x79, 0xb9, 0x7c, 0xd2, 0xb2, 0xf8, 0x1b, 0x87, 0xa3, 0x1d, 0xb9,
+	0x2c, 0x3e, 0x1f, 0x1e, 0x2e, 0x8d, 0x24, 0xbe, 0x2e, 0x90, 0xc4, 0x99, 0x30, 0x5e, 0x02, 0xc1,
+	0x5d, 0xb9, 0x1c, 0x2a, 0x61, 0x64, 0x2a, 0x29, 0xfc, 0x74, 0x1c, 0xa6, 0x1a, 0xee, 0xa3, 0x2d,
+	0xa7, 0x47, 0xc9, 0xae, 0xed, 0xb5, 0xef, 0x72, 0x28, 0xda, 0x10, 0xa1, 0x48, 0xad, 0xc4, 0x52,
+	0xcb, 0xd1, 0xf4, 0x31, 0x4c, 0xee, 0xdb, 0x2d, 0xd3, 0xaa, 0x52, 0x4a, 0x3a, 0x5d, 0x8a, 0x16,
+	0x01, 0xb4, 0x1e, 0x7d, 0x84, 0x89, 0xdb, 0x6b, 0x47, 0xa8, 0x62, 0x66, 0xbc, 0x1a, 0x83, 0xde,
+	0xd5, 0xbb, 0x61, 0x21, 0xfd, 0xb1, 0xb7, 0xd6, 0x73, 0x89, 0xe3, 0x17, 0x99, 0x0d, 0xd6, 0xa2,
+	0xb1, 0xf7, 0x76, 0x3d, 0xed, 0xf8, 0x2b, 0xa3, 0xfe, 0x4a, 0x38, 0x52, 0xbf, 0xcf, 0xc4, 0x90,
+	0xba, 0x04, 0x85, 0x08, 0x68, 0xef, 0x99, 0x51, 0x17, 0x20, 0x9a, 0xaa, 0x1b, 0xe8, 0x06, 0x40,
+	0x88, 0x71, 0x6f, 0x7d, 0x84, 0xd3, 0x43, 0x84, 0x60, 0xf4, 0x43, 0xdb, 0x8a, 0x6e, 0xef, 0x5f,
+	0xa3, 0x2a, 0x4c, 0xb1, 0x47, 0x74, 0x95, 0xd1, 0x90, 0xe4, 0xf1, 0x16, 0xb1, 0x7b, 0x70, 0x3c,
+	0xe2, 0x49, 0x83, 0xfd, 0x2f, 0x0e, 0xec, 0x07, 0x72, 0xb0, 0x2f, 0x71, 0xa7, 0x48, 0x03, 0xfa,
+	0xb7, 0x04, 0xa0, 0x7f, 0x81, 0xcb, 0x23, 0x01, 0xff, 0xa1, 0x1c, 0xfc, 0x45, 0x2e, 0x43, 0x2a,
+	0x12, 0xfc, 0x39, 0x0e, 0x13, 0x3e, 0x67, 0x1a, 0xb5, 0x1a, 0x7a, 0x13, 0xe6, 0x2c, 0x9b, 0x9a,
+	0x4d, 0x53, 0xd7, 0xfc, 0x4d, 0xb6, 0xd5, 0x34, 0x5b, 0x4c, 0x83, 0x24, 0xab, 0x68, 0x05, 0x72,
+	0x4d, 0xd3, 0x32, 0x4c, 0xab, 0x15, 0x67, 0x70, 0xa3, 0x56, 0xab, 0xec, 0x06, 0x0b, 0x38, 0xda,
+	0xa1, 0x7e, 0x96, 0x81, 0xa9, 0x06, 0xd1, 0x7b, 0x8e, 0x49, 0xcf, 0x0e, 0x34, 0xe7, 0xc4, 0x45,
+	0xeb, 0x30, 0xd6, 0xf1, 0x2e, 0xc2, 0x8e, 0x96, 0x06, 0xc1, 0xb1, 0x7d, 0x15, 0xff, 0xef, 0x8e,
+	0x45, 0x9d, 0x33, 0x1c, 0x04, 0xa8, 0xeb, 0x00, 0x83, 0x49, 0x34, 0x0d, 0xd9, 0x13, 0x72, 0x16,
+	0xd6, 0xea, 0x5d, 0xa2, 0x59, 0x18, 0x3b, 0xd5, 0xda, 0xbd, 0x88, 0xb2, 0xc1, 0xe0, 0xee, 0xc8,
+	0x7a, 0x26, 0x9d, 0x88, 0xc7, 0xed, 0xc6, 0x0a, 0x2f, 0xe2, 0xcc, 0x29, 0x87, 0xd0, 0xf1, 0x0b,
+	0x83, 0xf3, 0xab, 0x0c, 0x4c, 0x07, 0x0e, 0x82, 0x29, 0xee, 0xb6, 0xe0, 0xb5, 0x3e, 0x3b, 0xa8,
+	0x4f, 0x82, 0xa6, 0xba, 0xfc, 0x6d, 0x3e, 0x3f, 0x08, 0x4e, 0x03, 0x24, 0xf5, 0xeb, 0x11, 0xc8,
+	0x85, 0xcf, 0x1a, 0xed, 0xc2, 0xb4, 0xcb, 0x15, 0x18, 0x96, 0xa4, 0x32, 0xcf, 0x96, 0xdb, 0x81,
+	0x13, 0x31, 0x5e, 0x17, 0x74, 0xb6, 0xaa, 0x3c, 0xee, 0x8f, 0x51, 0x09, 0x26, 0x1d, 0x96, 0xfa,
+	0x81, 0xe0, 0xc4, 0xe6, 0xbc, 0xc7, 0xef, 0x52, 0x8d, 0x46, 0x92, 0x17, 0x0c, 0xd0, 0x3d, 0x98,
+	0x72, 0x59, 0x5c, 0x29, 0x63, 0xc5, 0xcc, 0xe0, 0xad, 0x95, 0x80, 0x1d, 0x8e, 0xef, 0xf6, 0xfc,
+	0x20, 0x39, 0x25, 0x16, 0x7d, 0xc7, 0xec, 0x10, 0x65, 0x3c, 0xd0, 0xbf, 0xfe, 0x84, 0xa7, 0x7f,
+	0x96, 0x57, 0x4e, 0x2e, 0xd0, 0x3f, 0xef, 0xba, 0xf4, 0xef, 0x04, 0xcc, 0x26, 0xfc, 0xcc, 0x30,
+	0x7c, 0xbb, 0xc3, 0xf3, 0x4d, 0x60, 0xe0, 0x84, 0xdc, 0xfb, 0x32, 0xc1, 0xbd, 0xed, 0x38, 0xf7,
+	0x2a, 0xe2, 0x44, 0x57, 0xc7, 0xc3, 0x0b, 0x99, 0xed, 0xfb, 0x8c, 0xd9, 0xae, 0x89, 0xcc, 0xf6,
+	0x8b, 0x92, 0xf2, 0x65, 0x7e, 0xfb, 0xa2, 0xdf, 0x1f, 0xcd, 0x98, 0x20, 0xbc, 0x7b, 0xce, 0xf7,
+	0x47, 0x59, 0xd6, 0xc8, 0x54, 0x9f, 0x20, 0x97, 0x79, 0x61, 0x25, 0x35, 0x61, 0x53, 0xa0, 0x09,
+	0x45, 0x71, 0x5d, 0x12, 0x7d, 0x78, 0x28, 0xd7, 0x87, 0x57, 0xc4, 0x89, 0x52, 0x19, 0xfe, 0xbb,
+	0x09, 0xc3, 0xbf, 0x28, 0xce, 0x96, 0xf4, 0xfc, 0xea, 0x4f, 0x8c, 0xce, 0x60, 0xa9, 0xce, 0xdc,
+	0x3c, 0x0f, 0x08, 0xd7, 0xa0, 0x39, 0x75, 0xb1, 0xe6, 0x2c, 0xa7, 0xa0, 0xdb, 0xf0, 0xfa, 0xf3,
+	0x4b, 0x1e, 0xa6, 0x63, 0xd6, 0x60, 0x18, 0xed, 0xb9, 0xc5, 0x6b, 0x0f, 0x67, 0x5c, 0x84, 0xba,
+	0xf3, 0x45, 0x42, 0x77, 0x36, 0xe3, 0xba, 0xf3, 0x6a, 0x32, 0xc9, 0xd5, 0x69, 0xce, 0x75, 0x5b,
+	0xee, 0x1f, 0xae, 0xde, 0x72, 0x6f, 0x8b, 0x2d, 0xf7, 0x62, 0xb2, 0xcd, 0x4f, 0x91, 0xeb, 0xfe,
+	0x47, 0x24, 0x62, 0x47, 0x72, 0xeb, 0x5d, 0x4a, 0x9e, 0x26, 0x8d, 0xfb, 0xde, 0x10, 0xb8, 0xef,
+	0x85, 0x64, 0x2a, 0x89, 0x24, 0x3e, 0x90, 0x1b, 0xf0, 0xe5, 0x64, 0x92, 0x54, 0xd6, 0xe9, 0x47,
+	0x46, 0xd2, 0x0e, 0xa5, 0x92, 0x26, 0x38, 0xed, 0xb5, 0xc9, 0xd9, 0x8e, 0x58, 0xce, 0x96, 0xfe,
+	0x87, 0xc5, 0xc3, 0x4b, 0xd9, 0x37, 0x39, 0x98, 0xec, 0xff, 0xe2, 0x39, 0x8c, 0x8c, 0xad, 0xf2,
+	0x32, 0xc6, 0xfc, 0x0e, 0x2b, 0x94, 0xb0, 0xcf, 0x13, 0x12, 0xb6, 0x11, 0x97, 0xb0, 0x9b, 0xf1,
+	0x04, 0xd7, 0xfc, 0xe9, 0xf2, 0x49, 0x4c, 0x62, 0x56, 0xf9, 0x4f, 0x17, 0xee, 0xb4, 0x57, 0xf0,
+	0xab, 0xfd, 0x85, 0xd9, 0xff, 0xad, 0x88, 0xfd, 0xeb, 0x02, 0x0b, 0xa3, 0xc4, 0x6b, 0x97, 0xf0,
+	0xf4, 0x40, 0x6e, 0x5d, 0x96, 0xe2, 0x09, 0x52, 0x71, 0xf4, 0x3b, 0x86, 0xa3, 0x7b, 0x52, 0x8e,
+	0x2e, 0x72, 0x18, 0xb8, 0x2e, 0x7e, 0x56, 0xc5, 0xfc, 0x9c, 0x3f, 0x07, 0xa2, 0x43, 0x73, 0xf3,
+	0x78, 0xdc, 0xff, 0x7f, 0xd6, 0xad, 0xff, 0x06, 0x00, 0x0a, 0x85, 0x45, 0xaa, 0xe7, 0x1a, 0x00,
+	0x00,
 }
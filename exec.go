@@ -18,17 +18,25 @@ package exec
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"html"
 	"log"
+	"net/http"
 	"os"
+	"strings"
+	"sync"
 
 	"cloud.google.com/go/pubsub"
+	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/approval"
 	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/bigquery/closepublicdataset"
 	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/cloud-sql/removepublic"
 	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/cloud-sql/requiressl"
 	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/cloud-sql/updatepassword"
+	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/dns/blockdomain"
 	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/filter"
 	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/gce/createsnapshot"
 	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/gce/openfirewall"
+	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/gce/quarantine"
 	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/gce/removepublicip"
 	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/gcs/closebucket"
 	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/gcs/enablebucketonlypolicy"
@@ -36,34 +44,299 @@ import (
 	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/iam/enableauditlogs"
 	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/iam/removenonorgmembers"
 	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/iam/revoke"
+	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/notify/email"
+	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/notify/pagerduty"
+	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/notify/webhook"
+	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/rollback"
 	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/router"
+	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/schedule"
+	"github.com/googlecloudplatform/security-response-automation/providers/registry"
 	"github.com/googlecloudplatform/security-response-automation/services"
+	"github.com/pkg/errors"
 )
 
+// templatesPath is the directory holding notification templates within the deployed source.
+const templatesPath = "./serverless_function_source_code/templates/"
+
+// inProcess is the PUBSUB_MODE delivering messages to the entry points within the process
+// instead of publishing them to Pub/Sub.
+const inProcess = "in-process"
+
 var (
 	svcs      *services.Global
-	projectID = os.Getenv("GCP_PROJECT")
+	projectID string
+	// routerConfig loads the router's configuration from Cloud Storage if CONFIG_BUCKET is set.
+	routerConfig *router.ConfigLoader
+	// filterBundles loads the filters from Cloud Storage or a bundle server if FILTER_BUNDLE is set.
+	filterBundles *filter.BundleLoader
+	// dispatcher delivers published messages to the entry points if PUBSUB_MODE is in-process.
+	dispatcher *services.Dispatcher
+)
+
+// lazy initializes part of the services on first use rather than on import, so binaries hosting
+// the entry points such as sra-server start without credentials and each entry point only
+// creates the clients it uses. Failed initialization is returned and tried again on the next
+// use.
+type lazy struct {
+	mu   sync.Mutex
+	done bool
+	init func(ctx context.Context) error
+}
+
+func (l *lazy) do() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.done {
+		return nil
+	}
+	// Clients outlive the message that created them.
+	if err := l.init(context.Background()); err != nil {
+		return err
+	}
+	l.done = true
+	return nil
+}
+
+var (
+	// lazyBase creates the services every entry point uses, see initialize.
+	lazyBase     = &lazy{}
+	lazyResource = &lazy{init: func(ctx context.Context) (err error) {
+		svcs.Resource, err = services.InitResource(ctx)
+		return err
+	}}
+	lazyHost = &lazy{init: func(ctx context.Context) (err error) {
+		svcs.Host, err = services.InitHost(ctx)
+		return err
+	}}
+	lazyFirewall = &lazy{init: func(ctx context.Context) (err error) {
+		svcs.Firewall, err = services.InitFirewall(ctx)
+		return err
+	}}
+	lazyContainer = &lazy{init: func(ctx context.Context) (err error) {
+		svcs.Container, err = services.InitContainer(ctx)
+		return err
+	}}
+	lazyCloudSQL = &lazy{init: func(ctx context.Context) (err error) {
+		svcs.CloudSQL, err = services.InitCloudSQL(ctx)
+		return err
+	}}
+	lazySecurityCommandCenter = &lazy{init: func(ctx context.Context) (err error) {
+		svcs.SecurityCommandCenter, err = services.InitSecurityCommandCenter(ctx)
+		return err
+	}}
+	lazyDNS = &lazy{init: func(ctx context.Context) (err error) {
+		svcs.DNS, err = services.InitDNS(ctx)
+		return err
+	}}
 )
 
 func init() {
+	// Set here as initialize refers to the entry points through newDispatcher.
+	lazyBase.init = initialize
+}
+
+// ready initializes the services every entry point uses followed by the given ones.
+func ready(deps ...*lazy) error {
+	if err := lazyBase.do(); err != nil {
+		return err
+	}
+	for _, d := range deps {
+		if err := d.do(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deadLetter handles the error the function failed with, see services.DeadLetter.Handle.
+// Entry points defer it before calling ready so messages failing on a misconfiguration are
+// dead-lettered rather than retried until Pub/Sub drops them. If the services every entry point
+// uses could not be initialized, the dead-letter topic is reached with its own client.
+func deadLetter(ctx context.Context, function string, m *pubsub.Message, err error) error {
+	if err == nil {
+		return nil
+	}
+	if svcs != nil {
+		return svcs.DeadLetter.Handle(ctx, function, m, err)
+	}
+	topic := os.Getenv("DEAD_LETTER_TOPIC")
+	if topic == "" {
+		return err
+	}
+	d, derr := services.InitDeadLetter(ctx, os.Getenv("GCP_PROJECT"), topic)
+	if derr != nil {
+		log.Printf("failed to initialize dead-letter topic %q: %q", topic, derr)
+		return err
+	}
+	return d.Handle(ctx, function, m, err)
+}
+
+// initialize creates the services every entry point uses from the environment.
+func initialize(ctx context.Context) error {
 	log.SetFlags(log.LstdFlags | log.Llongfile)
-	ctx := context.Background()
-	var err error
+	projectID = os.Getenv("GCP_PROJECT")
 	if projectID == "" {
-		log.Fatalf("GCP_PROJECT environment variable not set")
+		return errors.New("GCP_PROJECT environment variable not set")
 	}
-	svcs, err = services.New(ctx)
+	logger, err := services.InitLogger(ctx)
 	if err != nil {
-		log.Fatalf("failed to initialize services: %q", err)
+		return errors.Wrap(err, "failed to initialize logger")
+	}
+	s := &services.Global{Logger: logger}
+	if dataset := os.Getenv("AUDIT_DATASET"); dataset != "" {
+		if s.Audit, err = services.InitAudit(ctx, projectID, dataset, os.Getenv("AUDIT_TABLE")); err != nil {
+			return errors.Wrap(err, "failed to initialize audit trail")
+		}
+	}
+	if bucket := os.Getenv("SNAPSHOT_BUCKET"); bucket != "" {
+		if s.Snapshots, err = services.InitSnapshots(ctx, bucket); err != nil {
+			return errors.Wrap(err, "failed to initialize snapshots")
+		}
+	}
+	if key := os.Getenv("SENDGRID_API_KEY"); key != "" {
+		s.Email = services.InitEmail(key, templatesPath)
+	}
+	if key := os.Getenv("PAGERDUTY_API_KEY"); key != "" {
+		s.PagerDuty = services.InitPagerDuty(key)
+	}
+	if s.Webhook, err = services.InitWebhook(templatesPath, os.Getenv("WEBHOOK_SECRET"), os.Getenv("WEBHOOK_URLS")); err != nil {
+		return errors.Wrap(err, "failed to initialize webhooks")
+	}
+	if os.Getenv("PUBSUB_MODE") == inProcess {
+		ps, err := services.InitPubSub(ctx, projectID)
+		if err != nil {
+			return err
+		}
+		dispatcher = newDispatcher(ps)
+	}
+	if topic := os.Getenv("DEAD_LETTER_TOPIC"); topic != "" {
+		ps, err := pubSub(ctx)
+		if err != nil {
+			return errors.Wrap(err, "failed to initialize dead-letter topic")
+		}
+		s.DeadLetter = services.NewDeadLetter(ps, topic)
+	}
+	if bucket := os.Getenv("APPROVAL_BUCKET"); bucket != "" {
+		if s.Approvals, err = services.InitApprovals(ctx, bucket, os.Getenv("APPROVAL_SECRET"), os.Getenv("APPROVAL_URL")); err != nil {
+			return errors.Wrap(err, "failed to initialize approvals")
+		}
+	}
+	if bucket := os.Getenv("SCHEDULE_BUCKET"); bucket != "" {
+		if s.Queue, err = services.InitQueue(ctx, bucket); err != nil {
+			return errors.Wrap(err, "failed to initialize schedule queue")
+		}
+	}
+	if collection := os.Getenv("IDEMPOTENCY_COLLECTION"); collection != "" {
+		if s.Idempotency, err = services.InitIdempotency(ctx, projectID, collection); err != nil {
+			return errors.Wrap(err, "failed to initialize idempotency store")
+		}
+	}
+	if collection := os.Getenv("LIMITS_COLLECTION"); collection != "" {
+		if s.Limiter, err = services.InitLimiter(ctx, projectID, collection); err != nil {
+			return errors.Wrap(err, "failed to initialize limit store")
+		}
+	}
+	if bucket := os.Getenv("CONFIG_BUCKET"); bucket != "" {
+		if routerConfig, err = router.InitConfigLoader(ctx, bucket, os.Getenv("CONFIG_OBJECT")); err != nil {
+			return errors.Wrap(err, "failed to initialize router configuration")
+		}
+	}
+	if location := os.Getenv("FILTER_BUNDLE"); location != "" {
+		if filterBundles, err = filter.InitBundleLoader(ctx, location, os.Getenv("FILTER_BUNDLE_TOKEN")); err != nil {
+			return errors.Wrap(err, "failed to initialize filter bundles")
+		}
+	}
+	svcs = s
+	return nil
+}
+
+// newDispatcher returns a dispatcher calling the entry point each topic triggers, as the Cloud
+// Functions are triggered in Terraform. The router is triggered by the filter's OUTPUT_TOPIC.
+//
+// Actions are looked up in the registry. Messages to actions registered without an entry point
+// here, and to the topics filters route findings to, are published with ps for the functions
+// consuming them outside of the process. Circuit breaker notices and dead letters are logged.
+func newDispatcher(ps services.PubSubClient) *services.Dispatcher {
+	entryPoints := map[string]services.Handler{
+		"gce_create_disk_snapshot":  SnapshotDisk,
+		"iam_revoke":                IAMRevoke,
+		"close_bucket":              CloseBucket,
+		"enable_bucket_only_policy": EnableBucketOnlyPolicy,
+		"close_cloud_sql":           CloseCloudSQL,
+		"cloud_sql_require_ssl":     CloudSQLRequireSSL,
+		"cloud_sql_update_password": UpdatePassword,
+		"disable_dashboard":         DisableDashboard,
+		"remove_public_ip":          RemovePublicIP,
+		"remediate_firewall":        OpenFirewall,
+		"close_public_dataset":      ClosePublicDataset,
+		"enable_audit_logs":         EnableAuditLogs,
+		"remove_non_org_members":    RemoveNonOrganizationMembers,
+		"block_domain":              BlockDomain,
+		"gce_quarantine_instance":   QuarantineInstance,
+		"notify_email":              NotifyEmail,
+		"pagerduty_incident":        PagerDutyIncident,
+		"notify_webhook":            NotifyWebhook,
+	}
+	d := services.NewDispatcher()
+	d.Fallback(ps)
+	for action, topic := range registry.Topics() {
+		h, ok := entryPoints[action]
+		if !ok {
+			log.Printf("no entry point for action %q, publishing to %q", action, topic)
+			continue
+		}
+		d.Handle(topic, h)
 	}
+	d.Handle("threat-findings", Filter)
+	d.Handle(os.Getenv("OUTPUT_TOPIC"), Router)
+	d.Handle("threat-findings-rollback", Rollback)
+	d.Handle(approval.Topic, RequestApproval)
+	d.Handle(schedule.Topic, ScheduleAutomation)
+	d.Handle(router.BreakerTopic, logMessages("circuit breaker opened"))
+	if topic := os.Getenv("DEAD_LETTER_TOPIC"); topic != "" {
+		d.Handle(topic, logMessages("dead letter"))
+	}
+	return d
+}
+
+// logMessages returns a handler logging the messages of a topic nothing consumes within the
+// process.
+func logMessages(kind string) services.Handler {
+	return func(ctx context.Context, m pubsub.Message) error {
+		log.Printf("%s: %s", kind, m.Data)
+		return nil
+	}
+}
+
+// pubSub returns the PubSub service entry points publish with, delivering messages within the
+// process if PUBSUB_MODE is in-process.
+func pubSub(ctx context.Context) (*services.PubSub, error) {
+	if dispatcher != nil {
+		return services.NewPubSub(dispatcher), nil
+	}
+	return services.InitPubSub(ctx, projectID)
+}
+
+// audit returns the audit trail attributed to the finding that triggered the message.
+func audit(m pubsub.Message) *services.Audit {
+	return svcs.Audit.WithFinding(m.Attributes[router.FindingAttribute], m.Attributes[router.RuleAttribute])
+}
+
+// idempotency returns the idempotency check keyed on the finding that triggered the message.
+func idempotency(m pubsub.Message) *services.Idempotency {
+	return svcs.Idempotency.WithFinding(m.Attributes[router.FindingAttribute], m.Attributes[router.EventTimeAttribute], m.Attributes[router.IndexAttribute])
 }
 
 // Filter is the entry point for the Filter Cloud function.
 // This function will receive all findings and filter them against
 // any user-defined Rego policies before forwarding along to the
 // Router function.
-func Filter(ctx context.Context, m pubsub.Message) error {
-	ps, err := services.InitPubSub(ctx, projectID)
+func Filter(ctx context.Context, m pubsub.Message) (err error) {
+	defer func() { err = deadLetter(ctx, "Filter", &m, err) }()
+	if err := ready(lazySecurityCommandCenter); err != nil {
+		return err
+	}
+	ps, err := pubSub(ctx)
 	if err != nil {
 		return err
 	}
@@ -71,18 +344,53 @@ func Filter(ctx context.Context, m pubsub.Message) error {
 		PubSub:                ps,
 		Logger:                svcs.Logger,
 		SecurityCommandCenter: svcs.SecurityCommandCenter,
+		Bundles:               filterBundles,
+	})
+}
+
+// SweepSuppressions reactivates findings whose suppression expired and sends them through the
+// router again.
+//
+// This Cloud Function is triggered every few minutes by Cloud Scheduler. Findings suppressed
+// until a time are marked with `sra-filter-expires`.
+//
+// Permissions required
+//	- roles/securitycenter.findingsViewer to list suppressed findings.
+//	- roles/securitycenter.findingsStateSetter to reactivate findings.
+//	- roles/securitycenter.findingSecurityMarksWriter to remove the suppression's marks.
+//	- roles/pubsub.publisher to send findings to the router.
+//
+func SweepSuppressions(ctx context.Context, m pubsub.Message) (err error) {
+	defer func() { err = deadLetter(ctx, "SweepSuppressions", &m, err) }()
+	if err := ready(lazySecurityCommandCenter); err != nil {
+		return err
+	}
+	ps, err := pubSub(ctx)
+	if err != nil {
+		return err
+	}
+	return filter.Sweep(ctx, os.Getenv("ORGANIZATION_ID"), &filter.Services{
+		PubSub:                ps,
+		Logger:                svcs.Logger,
+		SecurityCommandCenter: svcs.SecurityCommandCenter,
 	})
 }
 
 // Router is the entry point for the router Cloud Function.
 //
-// This Cloud Function will receive all findings and route them to configured automation.
-func Router(ctx context.Context, m pubsub.Message) error {
-	ps, err := services.InitPubSub(ctx, projectID)
+// This Cloud Function will receive all findings and route them to configured automation. The
+// configuration is read from the CONFIG_OBJECT object in CONFIG_BUCKET if set, falling back to
+// the configuration bundled with the function.
+func Router(ctx context.Context, m pubsub.Message) (err error) {
+	defer func() { err = deadLetter(ctx, "Router", &m, err) }()
+	if err := ready(lazyResource, lazySecurityCommandCenter); err != nil {
+		return err
+	}
+	ps, err := pubSub(ctx)
 	if err != nil {
 		return err
 	}
-	conf, err := router.Config()
+	conf, err := routerConfig.Load(ctx)
 	if err != nil {
 		return err
 	}
@@ -94,6 +402,9 @@ func Router(ctx context.Context, m pubsub.Message) error {
 		Logger:                svcs.Logger,
 		Resource:              svcs.Resource,
 		SecurityCommandCenter: svcs.SecurityCommandCenter,
+		Idempotency:           svcs.Idempotency,
+		DeadLetter:            svcs.DeadLetter,
+		Limiter:               svcs.Limiter,
 	})
 }
 
@@ -111,13 +422,19 @@ func Router(ctx context.Context, m pubsub.Message) error {
 // 	- roles/resourcemanager.folderAdmin to revoke IAM grants.
 //	- roles/viewer to verify the affected project is within the enforced folder.
 //
-func IAMRevoke(ctx context.Context, m pubsub.Message) error {
+func IAMRevoke(ctx context.Context, m pubsub.Message) (err error) {
+	defer func() { err = deadLetter(ctx, "IAMRevoke", &m, err) }()
+	if err := ready(lazyResource); err != nil {
+		return err
+	}
 	var values revoke.Values
 	switch err := json.Unmarshal(m.Data, &values); err {
 	case nil:
 		return revoke.Execute(ctx, &values, &revoke.Services{
-			Resource: svcs.Resource,
-			Logger:   svcs.Logger,
+			Resource:    svcs.Resource,
+			Logger:      svcs.Logger,
+			Audit:       audit(m),
+			Idempotency: idempotency(m),
 		})
 	default:
 		return err
@@ -134,13 +451,19 @@ func IAMRevoke(ctx context.Context, m pubsub.Message) error {
 // Permissions required
 //	- roles/compute.instanceAdmin.v1 to manage disk snapshots.
 //
-func SnapshotDisk(ctx context.Context, m pubsub.Message) error {
+func SnapshotDisk(ctx context.Context, m pubsub.Message) (err error) {
+	defer func() { err = deadLetter(ctx, "SnapshotDisk", &m, err) }()
+	if err := ready(lazyHost); err != nil {
+		return err
+	}
 	var values createsnapshot.Values
 	switch err := json.Unmarshal(m.Data, &values); err {
 	case nil:
 		output, err := createsnapshot.Execute(ctx, &values, &createsnapshot.Services{
-			Host:   svcs.Host,
-			Logger: svcs.Logger,
+			Host:        svcs.Host,
+			Logger:      svcs.Logger,
+			Audit:       audit(m),
+			Idempotency: idempotency(m),
 		})
 		if err != nil {
 			return err
@@ -171,13 +494,20 @@ func SnapshotDisk(ctx context.Context, m pubsub.Message) error {
 //	- roles/viewer to retrieve ancestry.
 //	- roles/storeage.admin to modify buckets.
 //
-func CloseBucket(ctx context.Context, m pubsub.Message) error {
+func CloseBucket(ctx context.Context, m pubsub.Message) (err error) {
+	defer func() { err = deadLetter(ctx, "CloseBucket", &m, err) }()
+	if err := ready(lazyResource); err != nil {
+		return err
+	}
 	var values closebucket.Values
 	switch err := json.Unmarshal(m.Data, &values); err {
 	case nil:
 		return closebucket.Execute(ctx, &values, &closebucket.Services{
-			Resource: svcs.Resource,
-			Logger:   svcs.Logger,
+			Resource:    svcs.Resource,
+			Logger:      svcs.Logger,
+			Audit:       audit(m),
+			Snapshots:   svcs.Snapshots,
+			Idempotency: idempotency(m),
 		})
 	default:
 		return err
@@ -190,14 +520,21 @@ func CloseBucket(ctx context.Context, m pubsub.Message) error {
 //	- roles/viewer to retrieve ancestry.
 //	- roles/compute.securityAdmin to modify firewall rules.
 //
-func OpenFirewall(ctx context.Context, m pubsub.Message) error {
+func OpenFirewall(ctx context.Context, m pubsub.Message) (err error) {
+	defer func() { err = deadLetter(ctx, "OpenFirewall", &m, err) }()
+	if err := ready(lazyResource, lazyFirewall); err != nil {
+		return err
+	}
 	var values openfirewall.Values
 	switch err := json.Unmarshal(m.Data, &values); err {
 	case nil:
 		err := openfirewall.Execute(ctx, &values, &openfirewall.Services{
-			Firewall: svcs.Firewall,
-			Resource: svcs.Resource,
-			Logger:   svcs.Logger,
+			Firewall:    svcs.Firewall,
+			Resource:    svcs.Resource,
+			Logger:      svcs.Logger,
+			Audit:       audit(m),
+			Snapshots:   svcs.Snapshots,
+			Idempotency: idempotency(m),
 		})
 		if err != nil {
 			return err
@@ -216,13 +553,52 @@ func OpenFirewall(ctx context.Context, m pubsub.Message) error {
 // Permissions required
 //	- roles/resourcemanager.organizationAdmin to get org info and policies and set policies.
 //
-func RemoveNonOrganizationMembers(ctx context.Context, m pubsub.Message) error {
+func RemoveNonOrganizationMembers(ctx context.Context, m pubsub.Message) (err error) {
+	defer func() { err = deadLetter(ctx, "RemoveNonOrganizationMembers", &m, err) }()
+	if err := ready(lazyResource); err != nil {
+		return err
+	}
 	var values removenonorgmembers.Values
 	switch err := json.Unmarshal(m.Data, &values); err {
 	case nil:
 		return removenonorgmembers.Execute(ctx, &values, &removenonorgmembers.Services{
-			Logger:   svcs.Logger,
-			Resource: svcs.Resource,
+			Logger:      svcs.Logger,
+			Resource:    svcs.Resource,
+			Audit:       audit(m),
+			Snapshots:   svcs.Snapshots,
+			Idempotency: idempotency(m),
+		})
+	default:
+		return err
+	}
+}
+
+// QuarantineInstance isolates a GCE instance from the network.
+//
+// This Cloud Function will respond to Event Threat Detection **Bad IP** and **SSH Brute Force**
+// findings. Deny-all ingress and egress firewall rules are added for a quarantine network tag,
+// the instance's tags are replaced by the quarantine tag and optionally the instance is stopped.
+// The original tags are recorded in the instance's metadata so they can be restored.
+//
+// Permissions required
+//	- roles/compute.instanceAdmin.v1 to set tags and metadata and stop the instance.
+//	- roles/compute.securityAdmin to add firewall rules.
+//
+func QuarantineInstance(ctx context.Context, m pubsub.Message) (err error) {
+	defer func() { err = deadLetter(ctx, "QuarantineInstance", &m, err) }()
+	if err := ready(lazyHost, lazyFirewall); err != nil {
+		return err
+	}
+	var values quarantine.Values
+	switch err := json.Unmarshal(m.Data, &values); err {
+	case nil:
+		return quarantine.Execute(ctx, &values, &quarantine.Services{
+			Firewall:    svcs.Firewall,
+			Host:        svcs.Host,
+			Snapshots:   svcs.Snapshots,
+			Logger:      svcs.Logger,
+			Audit:       audit(m),
+			Idempotency: idempotency(m),
 		})
 	default:
 		return err
@@ -238,20 +614,310 @@ func RemoveNonOrganizationMembers(ctx context.Context, m pubsub.Message) error {
 // Permissions required
 //	- roles/compute.instanceAdmin.v1 to get instance data and delete access config.
 //
-func RemovePublicIP(ctx context.Context, m pubsub.Message) error {
+func RemovePublicIP(ctx context.Context, m pubsub.Message) (err error) {
+	defer func() { err = deadLetter(ctx, "RemovePublicIP", &m, err) }()
+	if err := ready(lazyResource, lazyHost); err != nil {
+		return err
+	}
 	var values removepublicip.Values
 	switch err := json.Unmarshal(m.Data, &values); err {
 	case nil:
 		return removepublicip.Execute(ctx, &values, &removepublicip.Services{
-			Host:     svcs.Host,
-			Resource: svcs.Resource,
-			Logger:   svcs.Logger,
+			Host:        svcs.Host,
+			Resource:    svcs.Resource,
+			Logger:      svcs.Logger,
+			Audit:       audit(m),
+			Snapshots:   svcs.Snapshots,
+			Idempotency: idempotency(m),
+		})
+	default:
+		return err
+	}
+}
+
+// Rollback restores the state of a resource changed by a previous remediation.
+//
+// Remediations that change state which can not be derived from the finding save a snapshot of the
+// resource before acting. Publish the remediation ID recorded in the audit trail to roll it back.
+//
+// Permissions required
+//	- roles/storage.objectViewer to read snapshots.
+//	- roles/storage.admin to restore bucket policies.
+//	- roles/resourcemanager.folderAdmin to restore project policies.
+//	- roles/compute.securityAdmin to restore firewall rules.
+//	- roles/compute.instanceAdmin.v1 to restore external IP addresses.
+//
+func Rollback(ctx context.Context, m pubsub.Message) (err error) {
+	defer func() { err = deadLetter(ctx, "Rollback", &m, err) }()
+	if err := ready(lazyResource, lazyHost, lazyFirewall); err != nil {
+		return err
+	}
+	var values rollback.Values
+	switch err := json.Unmarshal(m.Data, &values); err {
+	case nil:
+		return rollback.Execute(ctx, &values, &rollback.Services{
+			Firewall:  svcs.Firewall,
+			Host:      svcs.Host,
+			Resource:  svcs.Resource,
+			Snapshots: svcs.Snapshots,
+			Logger:    svcs.Logger,
+			Audit:     audit(m),
+		})
+	default:
+		return err
+	}
+}
+
+// NotifyEmail emails a summary of a finding and the automations it was dispatched to.
+//
+// Recipients, sender and template are configured per finding with the `notify_email` action.
+// Templates are read from the templates directory.
+//
+// Permissions required
+//	- None, a SendGrid API key is read from the SENDGRID_API_KEY environment variable.
+//
+func NotifyEmail(ctx context.Context, m pubsub.Message) (err error) {
+	defer func() { err = deadLetter(ctx, "NotifyEmail", &m, err) }()
+	if err := ready(); err != nil {
+		return err
+	}
+	var values email.Values
+	switch err := json.Unmarshal(m.Data, &values); err {
+	case nil:
+		return email.Execute(ctx, &values, &email.Services{
+			Email:       svcs.Email,
+			Logger:      svcs.Logger,
+			Audit:       audit(m),
+			Idempotency: idempotency(m),
+		})
+	default:
+		return err
+	}
+}
+
+// PagerDutyIncident opens a PagerDuty incident for a finding.
+//
+// Incidents are opened on the service configured per finding with the `pagerduty_incident` action
+// and deduplicated on the finding's name, so repeated notifications of the same finding are
+// added as notes to the open incident. The finding's severity is mapped to the incident urgency.
+//
+// Permissions required
+//	- None, a PagerDuty API key is read from the PAGERDUTY_API_KEY environment variable.
+//
+func PagerDutyIncident(ctx context.Context, m pubsub.Message) (err error) {
+	defer func() { err = deadLetter(ctx, "PagerDutyIncident", &m, err) }()
+	if err := ready(); err != nil {
+		return err
+	}
+	var values pagerduty.Values
+	switch err := json.Unmarshal(m.Data, &values); err {
+	case nil:
+		return pagerduty.Execute(ctx, &values, &pagerduty.Services{
+			PagerDuty:   svcs.PagerDuty,
+			Logger:      svcs.Logger,
+			Audit:       audit(m),
+			Idempotency: idempotency(m),
+		})
+	default:
+		return err
+	}
+}
+
+// NotifyWebhook posts a templated JSON payload describing a finding to a webhook.
+//
+// The URL and payload template are configured per finding with the `notify_webhook` action.
+// Payloads are signed with the secret read from the WEBHOOK_SECRET environment variable if set.
+//
+// Permissions required
+//	- None.
+//
+func NotifyWebhook(ctx context.Context, m pubsub.Message) (err error) {
+	defer func() { err = deadLetter(ctx, "NotifyWebhook", &m, err) }()
+	if err := ready(); err != nil {
+		return err
+	}
+	var values webhook.Values
+	switch err := json.Unmarshal(m.Data, &values); err {
+	case nil:
+		return webhook.Execute(ctx, &values, &webhook.Services{
+			Webhook:     svcs.Webhook,
+			Logger:      svcs.Logger,
+			Audit:       audit(m),
+			Idempotency: idempotency(m),
+		})
+	default:
+		return err
+	}
+}
+
+// RequestApproval parks an automation until it is approved and sends approvers signed links to
+// approve or deny it.
+//
+// The router publishes automations configured with the `approval` property here instead of to
+// their own topic. Requests are stored in the APPROVAL_BUCKET bucket.
+//
+// Permissions required
+//	- roles/storage.objectAdmin to store approval requests.
+//
+func RequestApproval(ctx context.Context, m pubsub.Message) (err error) {
+	defer func() { err = deadLetter(ctx, "RequestApproval", &m, err) }()
+	if err := ready(); err != nil {
+		return err
+	}
+	var values approval.Values
+	switch err := json.Unmarshal(m.Data, &values); err {
+	case nil:
+		return approval.Request(ctx, &values, &approval.Services{
+			Approvals:   svcs.Approvals,
+			Email:       svcs.Email,
+			Webhook:     svcs.Webhook,
+			Logger:      svcs.Logger,
+			Audit:       audit(m),
+			Idempotency: idempotency(m),
+		})
+	default:
+		return err
+	}
+}
+
+// Approve is the HTTP entry point of the links sent to approvers.
+//
+// Following a link shows a confirmation form so link scanners do not make decisions. Submitting
+// the form publishes an approved automation to its topic.
+//
+// Permissions required
+//	- roles/storage.objectAdmin to update approval requests.
+//	- roles/pubsub.publisher to publish approved automations.
+//
+func Approve(w http.ResponseWriter, r *http.Request) {
+	if err := ready(); err != nil {
+		log.Printf("failed to initialize: %q", err)
+		http.Error(w, "Failed to record decision.", http.StatusInternalServerError)
+		return
+	}
+	token := r.FormValue("token")
+	decision, err := approval.ParseToken(token)
+	if err != nil {
+		http.Error(w, "Invalid link.", http.StatusForbidden)
+		return
+	}
+	if r.Method != http.MethodPost {
+		fmt.Fprintf(w, `<form method="post"><input type="hidden" name="token" value="%s"><button type="submit">Confirm %s</button></form>`, html.EscapeString(token), html.EscapeString(decision.Decision))
+		return
+	}
+	ctx := r.Context()
+	ps, err := pubSub(ctx)
+	if err != nil {
+		http.Error(w, "Failed to record decision.", http.StatusInternalServerError)
+		return
+	}
+	req, err := approval.Decide(ctx, decision, &approval.Services{
+		Approvals: svcs.Approvals,
+		PubSub:    ps,
+		Limiter:   svcs.Limiter,
+		Logger:    svcs.Logger,
+		Audit:     svcs.Audit,
+	})
+	switch err {
+	case nil:
+		fmt.Fprintf(w, "%s %s in project %s.", strings.Title(strings.ToLower(req.Status)), req.Action, req.ProjectID)
+	case approval.ErrInvalidSignature:
+		http.Error(w, "Invalid link.", http.StatusForbidden)
+	case approval.ErrDecided:
+		http.Error(w, fmt.Sprintf("Already %s.", strings.ToLower(req.Status)), http.StatusConflict)
+	default:
+		log.Printf("failed to decide on approval request: %q", err)
+		http.Error(w, "Failed to record decision.", http.StatusInternalServerError)
+	}
+}
+
+// ApprovalTimeout approves pending automations whose `auto_approve` duration has passed.
+//
+// This Cloud Function is triggered every few minutes by Cloud Scheduler.
+//
+// Permissions required
+//	- roles/storage.objectAdmin to update approval requests.
+//	- roles/pubsub.publisher to publish approved automations.
+//
+func ApprovalTimeout(ctx context.Context, m pubsub.Message) (err error) {
+	defer func() { err = deadLetter(ctx, "ApprovalTimeout", &m, err) }()
+	if err := ready(); err != nil {
+		return err
+	}
+	ps, err := pubSub(ctx)
+	if err != nil {
+		return err
+	}
+	return approval.Sweep(ctx, &approval.Services{
+		Approvals: svcs.Approvals,
+		PubSub:    ps,
+		Limiter:   svcs.Limiter,
+		Logger:    svcs.Logger,
+		Audit:     svcs.Audit,
+	})
+}
+
+// ScheduleAutomation runs an automation restricted to maintenance windows, queueing it until
+// the next window opens if none is open.
+//
+// The router publishes automations configured with the `schedule` property here instead of to
+// their own topic. Queued automations are stored in the SCHEDULE_BUCKET bucket.
+//
+// Permissions required
+//	- roles/storage.objectAdmin to queue automations.
+//	- roles/pubsub.publisher to publish automations within their window.
+//
+func ScheduleAutomation(ctx context.Context, m pubsub.Message) (err error) {
+	defer func() { err = deadLetter(ctx, "ScheduleAutomation", &m, err) }()
+	if err := ready(); err != nil {
+		return err
+	}
+	var values schedule.Values
+	switch err := json.Unmarshal(m.Data, &values); err {
+	case nil:
+		ps, err := pubSub(ctx)
+		if err != nil {
+			return err
+		}
+		return schedule.Execute(ctx, &values, &schedule.Services{
+			Queue:       svcs.Queue,
+			PubSub:      ps,
+			Limiter:     svcs.Limiter,
+			Logger:      svcs.Logger,
+			Audit:       audit(m),
+			Idempotency: idempotency(m),
 		})
 	default:
 		return err
 	}
 }
 
+// ReplayScheduled runs queued automations whose maintenance window has opened.
+//
+// This Cloud Function is triggered every few minutes by Cloud Scheduler.
+//
+// Permissions required
+//	- roles/storage.objectAdmin to remove automations from the queue.
+//	- roles/pubsub.publisher to publish queued automations.
+//
+func ReplayScheduled(ctx context.Context, m pubsub.Message) (err error) {
+	defer func() { err = deadLetter(ctx, "ReplayScheduled", &m, err) }()
+	if err := ready(); err != nil {
+		return err
+	}
+	ps, err := pubSub(ctx)
+	if err != nil {
+		return err
+	}
+	return schedule.Replay(ctx, &schedule.Services{
+		Queue:   svcs.Queue,
+		PubSub:  ps,
+		Limiter: svcs.Limiter,
+		Logger:  svcs.Logger,
+		Audit:   svcs.Audit,
+	})
+}
+
 // ClosePublicDataset removes public access of a BigQuery dataset.
 //
 // This Cloud Function will respond to Security Health Analytics **Public Dataset** findings
@@ -261,7 +927,11 @@ func RemovePublicIP(ctx context.Context, m pubsub.Message) error {
 // Permissions required
 //	- roles/bigquery.dataOwner to get and update dataset metadata.
 //
-func ClosePublicDataset(ctx context.Context, m pubsub.Message) error {
+func ClosePublicDataset(ctx context.Context, m pubsub.Message) (err error) {
+	defer func() { err = deadLetter(ctx, "ClosePublicDataset", &m, err) }()
+	if err := ready(); err != nil {
+		return err
+	}
 	var values closepublicdataset.Values
 	switch err := json.Unmarshal(m.Data, &values); err {
 	case nil:
@@ -270,8 +940,10 @@ func ClosePublicDataset(ctx context.Context, m pubsub.Message) error {
 			return err
 		}
 		return closepublicdataset.Execute(ctx, &values, &closepublicdataset.Services{
-			BigQuery: bigquery,
-			Logger:   svcs.Logger,
+			BigQuery:    bigquery,
+			Logger:      svcs.Logger,
+			Audit:       audit(m),
+			Idempotency: idempotency(m),
 		})
 	default:
 		return err
@@ -286,13 +958,19 @@ func ClosePublicDataset(ctx context.Context, m pubsub.Message) error {
 // Permissions required
 //	- roles/storage.admin to change the Bucket policy mode.
 //
-func EnableBucketOnlyPolicy(ctx context.Context, m pubsub.Message) error {
+func EnableBucketOnlyPolicy(ctx context.Context, m pubsub.Message) (err error) {
+	defer func() { err = deadLetter(ctx, "EnableBucketOnlyPolicy", &m, err) }()
+	if err := ready(lazyResource); err != nil {
+		return err
+	}
 	var values enablebucketonlypolicy.Values
 	switch err := json.Unmarshal(m.Data, &values); err {
 	case nil:
 		return enablebucketonlypolicy.Execute(ctx, &values, &enablebucketonlypolicy.Services{
-			Resource: svcs.Resource,
-			Logger:   svcs.Logger,
+			Resource:    svcs.Resource,
+			Logger:      svcs.Logger,
+			Audit:       audit(m),
+			Idempotency: idempotency(m),
 		})
 	default:
 		return err
@@ -308,14 +986,20 @@ func EnableBucketOnlyPolicy(ctx context.Context, m pubsub.Message) error {
 // Permissions required
 //	- roles/cloudsql.editor to get instance data and delete access config.
 //
-func CloseCloudSQL(ctx context.Context, m pubsub.Message) error {
+func CloseCloudSQL(ctx context.Context, m pubsub.Message) (err error) {
+	defer func() { err = deadLetter(ctx, "CloseCloudSQL", &m, err) }()
+	if err := ready(lazyResource, lazyCloudSQL); err != nil {
+		return err
+	}
 	var values removepublic.Values
 	switch err := json.Unmarshal(m.Data, &values); err {
 	case nil:
 		return removepublic.Execute(ctx, &values, &removepublic.Services{
-			CloudSQL: svcs.CloudSQL,
-			Resource: svcs.Resource,
-			Logger:   svcs.Logger,
+			CloudSQL:    svcs.CloudSQL,
+			Resource:    svcs.Resource,
+			Logger:      svcs.Logger,
+			Audit:       audit(m),
+			Idempotency: idempotency(m),
 		})
 	default:
 		return err
@@ -331,14 +1015,20 @@ func CloseCloudSQL(ctx context.Context, m pubsub.Message) error {
 // Permissions required
 //	- roles/cloudsql.editor to get instance data and delete access config.
 //
-func CloudSQLRequireSSL(ctx context.Context, m pubsub.Message) error {
+func CloudSQLRequireSSL(ctx context.Context, m pubsub.Message) (err error) {
+	defer func() { err = deadLetter(ctx, "CloudSQLRequireSSL", &m, err) }()
+	if err := ready(lazyResource, lazyCloudSQL); err != nil {
+		return err
+	}
 	var values requiressl.Values
 	switch err := json.Unmarshal(m.Data, &values); err {
 	case nil:
 		return requiressl.Execute(ctx, &values, &requiressl.Services{
-			CloudSQL: svcs.CloudSQL,
-			Resource: svcs.Resource,
-			Logger:   svcs.Logger,
+			CloudSQL:    svcs.CloudSQL,
+			Resource:    svcs.Resource,
+			Logger:      svcs.Logger,
+			Audit:       audit(m),
+			Idempotency: idempotency(m),
 		})
 	default:
 		return err
@@ -354,14 +1044,20 @@ func CloudSQLRequireSSL(ctx context.Context, m pubsub.Message) error {
 // Permissions required
 //	- roles/container.clusterAdmin update cluster addon.
 //
-func DisableDashboard(ctx context.Context, m pubsub.Message) error {
+func DisableDashboard(ctx context.Context, m pubsub.Message) (err error) {
+	defer func() { err = deadLetter(ctx, "DisableDashboard", &m, err) }()
+	if err := ready(lazyResource, lazyContainer); err != nil {
+		return err
+	}
 	var values disabledashboard.Values
 	switch err := json.Unmarshal(m.Data, &values); err {
 	case nil:
 		return disabledashboard.Execute(ctx, &values, &disabledashboard.Services{
-			Container: svcs.Container,
-			Resource:  svcs.Resource,
-			Logger:    svcs.Logger,
+			Container:   svcs.Container,
+			Resource:    svcs.Resource,
+			Logger:      svcs.Logger,
+			Audit:       audit(m),
+			Idempotency: idempotency(m),
 		})
 	default:
 		return err
@@ -377,13 +1073,19 @@ func DisableDashboard(ctx context.Context, m pubsub.Message) error {
 //	- roles/resourcemanager.folderAdmin to get/update resource policy from projects in folder.
 //	- roles/editor to get/update resource policy to specific project.
 //
-func EnableAuditLogs(ctx context.Context, m pubsub.Message) error {
+func EnableAuditLogs(ctx context.Context, m pubsub.Message) (err error) {
+	defer func() { err = deadLetter(ctx, "EnableAuditLogs", &m, err) }()
+	if err := ready(lazyResource); err != nil {
+		return err
+	}
 	var values enableauditlogs.Values
 	switch err := json.Unmarshal(m.Data, &values); err {
 	case nil:
 		return enableauditlogs.Execute(ctx, &values, &enableauditlogs.Services{
-			Resource: svcs.Resource,
-			Logger:   svcs.Logger,
+			Resource:    svcs.Resource,
+			Logger:      svcs.Logger,
+			Audit:       audit(m),
+			Idempotency: idempotency(m),
 		})
 	default:
 		return err
@@ -399,14 +1101,50 @@ func EnableAuditLogs(ctx context.Context, m pubsub.Message) error {
 // Permissions required
 //	- roles/cloudsql.admin to update a user password.
 //
-func UpdatePassword(ctx context.Context, m pubsub.Message) error {
+func UpdatePassword(ctx context.Context, m pubsub.Message) (err error) {
+	defer func() { err = deadLetter(ctx, "UpdatePassword", &m, err) }()
+	if err := ready(lazyResource, lazyCloudSQL); err != nil {
+		return err
+	}
 	var values updatepassword.Values
 	switch err := json.Unmarshal(m.Data, &values); err {
 	case nil:
 		return updatepassword.Execute(ctx, &values, &updatepassword.Services{
-			CloudSQL: svcs.CloudSQL,
-			Resource: svcs.Resource,
-			Logger:   svcs.Logger,
+			CloudSQL:    svcs.CloudSQL,
+			Resource:    svcs.Resource,
+			Logger:      svcs.Logger,
+			Audit:       audit(m),
+			Idempotency: idempotency(m),
+		})
+	default:
+		return err
+	}
+}
+
+// BlockDomain blocks resolution of malicious domains from the affected instance's networks.
+//
+// This Cloud Function will respond to Event Threat Detection **Bad Domain** findings. A private
+// Cloud DNS zone for each domain will be bound to the networks of the affected instance so
+// lookups no longer resolve.
+//
+// Permissions required
+//	- roles/dns.admin to create and update managed zones.
+//	- roles/compute.viewer to get instance data.
+//
+func BlockDomain(ctx context.Context, m pubsub.Message) (err error) {
+	defer func() { err = deadLetter(ctx, "BlockDomain", &m, err) }()
+	if err := ready(lazyHost, lazyDNS); err != nil {
+		return err
+	}
+	var values blockdomain.Values
+	switch err := json.Unmarshal(m.Data, &values); err {
+	case nil:
+		return blockdomain.Execute(ctx, &values, &blockdomain.Services{
+			DNS:         svcs.DNS,
+			Host:        svcs.Host,
+			Logger:      svcs.Logger,
+			Audit:       audit(m),
+			Idempotency: idempotency(m),
 		})
 	default:
 		return err
@@ -0,0 +1,53 @@
+package exec
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"cloud.google.com/go/pubsub"
+	"github.com/googlecloudplatform/security-response-automation/clients/stubs"
+	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/router"
+	"github.com/googlecloudplatform/security-response-automation/providers/registry"
+)
+
+func TestDispatcherEntryPoints(t *testing.T) {
+	ctx := context.Background()
+	// Entry points fail to initialize without a project rather than reaching any API.
+	defer os.Setenv("GCP_PROJECT", os.Getenv("GCP_PROJECT"))
+	os.Unsetenv("GCP_PROJECT")
+	topics := []string{router.BreakerTopic}
+	for _, topic := range registry.Topics() {
+		topics = append(topics, topic)
+	}
+	registry.RegisterAction("external_action", "threat-findings-external")
+	defer registry.UnregisterAction("external_action")
+	fallback := &stubs.PubSubStub{}
+	d := newDispatcher(fallback)
+	for _, topic := range topics {
+		d.Publish(ctx, topic, &pubsub.Message{Data: []byte("{}")})
+		if fallback.PublishedTopic != "" {
+			t.Errorf("message to %q was published to Pub/Sub, want it handled within the process", topic)
+			fallback.PublishedTopic = ""
+		}
+	}
+	for _, topic := range []string{"threat-findings-external", "threat-findings-routed"} {
+		if _, err := d.Publish(ctx, topic, &pubsub.Message{Data: []byte("{}")}); err != nil || fallback.PublishedTopic != topic {
+			t.Errorf("message to %q was not published to Pub/Sub: %v", topic, err)
+		}
+	}
+}
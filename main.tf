@@ -32,17 +32,22 @@ module "google-setup" {
   cscc-notifications-topic-prefix = local.cscc-findings-topic
   findings-topic                  = local.findings-topic
   enable-scc-notification         = var.enable-scc-notification
+  idempotency-collection          = var.idempotency-collection
+  limits-collection               = var.limits-collection
 }
 
 module "filter" {
-  source = "./cloudfunctions/filter"
-  setup  = module.google-setup
+  source              = "./cloudfunctions/filter"
+  setup               = module.google-setup
+  filter-bundle       = var.filter-bundle
+  filter-bundle-token = var.filter-bundle-token
 }
 
 module "router" {
-  source     = "./cloudfunctions/router/"
-  setup      = module.google-setup
-  folder-ids = var.folder-ids
+  source        = "./cloudfunctions/router/"
+  setup         = module.google-setup
+  folder-ids    = var.folder-ids
+  config-bucket = var.config-bucket
 }
 
 module "close_public_bucket" {
@@ -77,12 +82,62 @@ module "open_firewall" {
   folder-ids = var.folder-ids
 }
 
+module "quarantine_instance" {
+  source     = "./cloudfunctions/gce/quarantine"
+  setup      = module.google-setup
+  folder-ids = var.folder-ids
+}
+
 module "remove_public_ip" {
   source     = "./cloudfunctions/gce/removepublicip"
   setup      = module.google-setup
   folder-ids = var.folder-ids
 }
 
+module "block_domain" {
+  source     = "./cloudfunctions/dns/blockdomain"
+  setup      = module.google-setup
+  folder-ids = var.folder-ids
+}
+
+module "rollback" {
+  source     = "./cloudfunctions/rollback"
+  setup      = module.google-setup
+  folder-ids = var.folder-ids
+}
+
+module "approval" {
+  source           = "./cloudfunctions/approval"
+  setup            = module.google-setup
+  sendgrid-api-key = var.sendgrid-api-key
+  webhook-secret   = var.webhook-secret
+  webhook-urls     = var.webhook-urls
+}
+
+module "schedule" {
+  source = "./cloudfunctions/schedule"
+  setup  = module.google-setup
+}
+
+module "notify_email" {
+  source           = "./cloudfunctions/notify/email"
+  setup            = module.google-setup
+  sendgrid-api-key = var.sendgrid-api-key
+}
+
+module "pagerduty_incident" {
+  source            = "./cloudfunctions/notify/pagerduty"
+  setup             = module.google-setup
+  pagerduty-api-key = var.pagerduty-api-key
+}
+
+module "notify_webhook" {
+  source         = "./cloudfunctions/notify/webhook"
+  setup          = module.google-setup
+  webhook-secret = var.webhook-secret
+  webhook-urls   = var.webhook-urls
+}
+
 module "close_public_dataset" {
   source     = "./cloudfunctions/bigquery/closepublicdataset"
   setup      = module.google-setup
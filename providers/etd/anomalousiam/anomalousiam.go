@@ -3,11 +3,24 @@ package anomalousiam
 
 import (
 	"encoding/json"
+	"fmt"
 
 	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/iam/revoke"
 	pb "github.com/googlecloudplatform/security-response-automation/compiled/etd/protos"
+	"github.com/googlecloudplatform/security-response-automation/providers/registry"
 )
 
+func init() {
+	registry.Register(&registry.Rule{
+		Provider: "etd",
+		Name:     "iam_anomalous_grant",
+		Key:      "anomalous_iam",
+		Finding:  &Finding{},
+		New:      func(b []byte) (registry.Finding, error) { return New(b) },
+		Actions:  []string{"iam_revoke"},
+	})
+}
+
 // Name verifies and returns the rule name of the finding.
 func (f *Finding) Name(b []byte) string {
 	ff, err := New(b)
@@ -62,3 +75,16 @@ func (f *Finding) IAMRevoke() *revoke.Values {
 		ExternalMembers: f.anomalousIAM.GetJsonPayload().GetProperties().GetSensitiveRoleGrant().GetMembers(),
 	}
 }
+
+// Values returns values for the given automation.
+func (f *Finding) Values(automation *registry.Automation) (string, interface{}, error) {
+	switch automation.Action {
+	case "iam_revoke":
+		values := f.IAMRevoke()
+		values.DryRun = automation.Properties.DryRun
+		values.AllowDomains = automation.Properties.RevokeIAM.AllowDomains
+		return values.ProjectID, values, nil
+	default:
+		return "", nil, fmt.Errorf("action %q not found", automation.Action)
+	}
+}
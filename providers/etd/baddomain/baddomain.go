@@ -0,0 +1,148 @@
+// Package baddomain represents the bad domain finding.
+package baddomain
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/dns/blockdomain"
+	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/gce/createsnapshot"
+	pb "github.com/googlecloudplatform/security-response-automation/compiled/etd/protos"
+	"github.com/googlecloudplatform/security-response-automation/providers/etd"
+	"github.com/googlecloudplatform/security-response-automation/providers/registry"
+)
+
+func init() {
+	registry.Register(&registry.Rule{
+		Provider: "etd",
+		Name:     "bad_domain",
+		Finding:  &Finding{},
+		New:      func(b []byte) (registry.Finding, error) { return New(b) },
+		Actions:  []string{"gce_create_disk_snapshot", "block_domain"},
+	})
+}
+
+// Name returns the rule name of the finding.
+func (f *Finding) Name(b []byte) string {
+	ff, err := New(b)
+	if err != nil {
+		return ""
+	}
+	name := ""
+	if ff.UseCSCC {
+		name = ff.BadDomainSCC.GetFinding().GetSourceProperties().GetDetectionCategory().GetRuleName()
+	} else {
+		name = ff.badDomain.GetJsonPayload().GetDetectionCategory().GetRuleName()
+	}
+	if name != "bad_domain" {
+		return ""
+	}
+	return name
+}
+
+// Finding represents a bad domain finding.
+type Finding struct {
+	UseCSCC      bool
+	badDomain    *pb.BadDomain
+	BadDomainSCC *pb.BadDomainSCC
+}
+
+// New returns a new bad domain finding.
+func New(b []byte) (*Finding, error) {
+	var f Finding
+	if err := json.Unmarshal(b, &f.badDomain); err != nil {
+		return nil, err
+	}
+	if f.badDomain.GetJsonPayload().GetDetectionCategory().GetRuleName() != "" {
+		return &f, nil
+	}
+	if err := json.Unmarshal(b, &f.BadDomainSCC); err != nil {
+		return nil, err
+	}
+	f.UseCSCC = true
+	return &f, nil
+}
+
+// CreateSnapshot returns values for the create snapshot automation.
+func (f *Finding) CreateSnapshot() *createsnapshot.Values {
+	if f.UseCSCC {
+		return &createsnapshot.Values{
+			ProjectID: f.BadDomainSCC.GetFinding().GetSourceProperties().GetProperties().GetNetwork().GetProject(),
+			RuleName:  f.BadDomainSCC.GetFinding().GetSourceProperties().GetDetectionCategory().GetRuleName(),
+			Instance:  etd.Instance(f.BadDomainSCC.GetFinding().GetSourceProperties().GetProperties().GetInstanceDetails()),
+			Zone:      etd.Zone(f.BadDomainSCC.GetFinding().GetSourceProperties().GetProperties().GetInstanceDetails()),
+		}
+	}
+	return &createsnapshot.Values{
+		ProjectID: f.badDomain.GetJsonPayload().GetProperties().GetNetwork().GetProject(),
+		RuleName:  f.badDomain.GetJsonPayload().GetDetectionCategory().GetRuleName(),
+		Instance:  etd.Instance(f.badDomain.GetJsonPayload().GetProperties().GetInstanceDetails()),
+		Zone:      etd.Zone(f.badDomain.GetJsonPayload().GetProperties().GetInstanceDetails()),
+	}
+}
+
+// BlockDomain returns values for the block domain automation.
+func (f *Finding) BlockDomain() *blockdomain.Values {
+	if f.UseCSCC {
+		return &blockdomain.Values{
+			ProjectID: f.BadDomainSCC.GetFinding().GetSourceProperties().GetProperties().GetNetwork().GetProject(),
+			Instance:  etd.Instance(f.BadDomainSCC.GetFinding().GetSourceProperties().GetProperties().GetInstanceDetails()),
+			Zone:      etd.Zone(f.BadDomainSCC.GetFinding().GetSourceProperties().GetProperties().GetInstanceDetails()),
+			Domains:   f.BadDomainSCC.GetFinding().GetSourceProperties().GetProperties().GetDomain(),
+		}
+	}
+	return &blockdomain.Values{
+		ProjectID: f.badDomain.GetJsonPayload().GetProperties().GetNetwork().GetProject(),
+		Instance:  etd.Instance(f.badDomain.GetJsonPayload().GetProperties().GetInstanceDetails()),
+		Zone:      etd.Zone(f.badDomain.GetJsonPayload().GetProperties().GetInstanceDetails()),
+		Domains:   f.badDomain.GetJsonPayload().GetProperties().GetDomain(),
+	}
+}
+
+// Values returns values for the given automation.
+func (f *Finding) Values(automation *registry.Automation) (string, interface{}, error) {
+	switch automation.Action {
+	case "gce_create_disk_snapshot":
+		values := f.CreateSnapshot()
+		values.DryRun = automation.Properties.DryRun
+		values.Output = automation.Properties.CreateSnapshot.Output
+		values.DestProjectID = automation.Properties.CreateSnapshot.TargetSnapshotProjectID
+		values.DestZone = automation.Properties.CreateSnapshot.TargetSnapshotZone
+		values.Turbinia.ProjectID = automation.Properties.CreateSnapshot.Turbinia.ProjectID
+		values.Turbinia.Topic = automation.Properties.CreateSnapshot.Turbinia.Topic
+		values.Turbinia.Zone = automation.Properties.CreateSnapshot.Turbinia.Zone
+		return values.ProjectID, values, nil
+	case "block_domain":
+		values := f.BlockDomain()
+		values.DryRun = automation.Properties.DryRun
+		return values.ProjectID, values, nil
+	default:
+		return "", nil, fmt.Errorf("action %q not found", automation.Action)
+	}
+}
+
+// SCC returns the Security Command Center attributes of the finding.
+func (f *Finding) SCC() *registry.SCC {
+	if !f.UseCSCC {
+		return nil
+	}
+	return &registry.SCC{
+		Name:          f.BadDomainSCC.GetFinding().GetName(),
+		EventTime:     f.BadDomainSCC.GetFinding().GetEventTime(),
+		SecurityMarks: f.BadDomainSCC.GetFinding().GetSecurityMarks().GetMarks(),
+	}
+}
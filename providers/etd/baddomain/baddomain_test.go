@@ -0,0 +1,106 @@
+package baddomain
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestBadDomain(t *testing.T) {
+	const (
+		badDomainSCC = `{
+			"finding": {
+				"name": "organizations/0000000000000/sources/0000000000000000000/findings/5b1fa260753f3b56a30ce604c1141799",
+				"parent": "organizations/0000000000000/sources/0000000000000000000",
+				"resourceName": "//cloudresourcemanager.googleapis.com/projects/000000000000",
+				"state": "ACTIVE",
+				"category": "Malware: Bad Domain",
+				"externalUri": "https://console.cloud.google.com/home?project=test-project-15511551515",
+				"sourceProperties": {
+					"detectionCategory": {
+						"ruleName": "bad_domain"
+					},
+					"properties": {
+						"domain": ["3322.org"],
+						"instanceDetails": "/projects/test-project-15511551515/zones/us-central1-a/instances/bad-domain-caller",
+						"network": {
+							"project": "test-project-15511551515"
+						}
+					}
+				},
+				"securityMarks": {},
+				"eventTime": "2019-11-22T18:34:36.153Z",
+				"createTime": "2019-11-22T18:34:36.688Z"
+			}
+		}`
+		badDomainStackdriver = `{
+			"jsonPayload": {
+				"properties": {
+					"domain": ["3322.org"],
+					"instanceDetails": "/projects/test-project-15511551515/zones/us-central1-a/instances/bad-domain-caller",
+					"network": {
+						"project": "test-project-15511551515"
+					}
+				},
+				"detectionCategory": {
+					"ruleName": "bad_domain"
+				}
+			},
+			"logName": "projects/test-project/logs/threatdetection.googleapis.com` + "%%2F" + `detection"
+		}`
+	)
+
+	for _, tt := range []struct {
+		name      string
+		ruleName  string
+		finding   []byte
+		projectID string
+		instance  string
+		zone      string
+		domains   []string
+	}{
+		{name: "bad_domain SD", finding: []byte(badDomainStackdriver), ruleName: "bad_domain", projectID: "test-project-15511551515", instance: "bad-domain-caller", zone: "us-central1-a", domains: []string{"3322.org"}},
+		{name: "bad_domain CSCC", finding: []byte(badDomainSCC), ruleName: "bad_domain", projectID: "test-project-15511551515", instance: "bad-domain-caller", zone: "us-central1-a", domains: []string{"3322.org"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := New(tt.finding)
+			if err != nil {
+				t.Fatalf("%q failed: %q", tt.name, err)
+			}
+			if name := f.Name(tt.finding); name != tt.ruleName {
+				t.Errorf("%q got:%q want:%q", tt.name, name, tt.ruleName)
+			}
+			snapshot := f.CreateSnapshot()
+			if snapshot.ProjectID != tt.projectID {
+				t.Errorf("%s failed: got:%q want:%q", tt.name, snapshot.ProjectID, tt.projectID)
+			}
+			if snapshot.Instance != tt.instance {
+				t.Errorf("%s failed: got:%q want:%q", tt.name, snapshot.Instance, tt.instance)
+			}
+			if snapshot.Zone != tt.zone {
+				t.Errorf("%s failed: got:%q want:%q", tt.name, snapshot.Zone, tt.zone)
+			}
+			block := f.BlockDomain()
+			if block.ProjectID != tt.projectID {
+				t.Errorf("%s failed: got:%q want:%q", tt.name, block.ProjectID, tt.projectID)
+			}
+			if diff := cmp.Diff(tt.domains, block.Domains); diff != "" {
+				t.Errorf("%s failed, domains difference: %+v", tt.name, diff)
+			}
+		})
+	}
+}
@@ -17,12 +17,25 @@ package badip
 
 import (
 	"encoding/json"
+	"fmt"
 
 	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/gce/createsnapshot"
+	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/gce/quarantine"
 	pb "github.com/googlecloudplatform/security-response-automation/compiled/etd/protos"
 	"github.com/googlecloudplatform/security-response-automation/providers/etd"
+	"github.com/googlecloudplatform/security-response-automation/providers/registry"
 )
 
+func init() {
+	registry.Register(&registry.Rule{
+		Provider: "etd",
+		Name:     "bad_ip",
+		Finding:  &Finding{},
+		New:      func(b []byte) (registry.Finding, error) { return New(b) },
+		Actions:  []string{"gce_create_disk_snapshot", "gce_quarantine_instance"},
+	})
+}
+
 // Name returns the rule name of the finding.
 func (f *Finding) Name(b []byte) string {
 	ff, err := New(b)
@@ -81,3 +94,55 @@ func (f *Finding) CreateSnapshot() *createsnapshot.Values {
 		Zone:      etd.Zone(f.badIP.GetJsonPayload().GetProperties().GetInstanceDetails()),
 	}
 }
+
+// Quarantine returns values for the quarantine instance automation.
+func (f *Finding) Quarantine() *quarantine.Values {
+	if f.UseCSCC {
+		return &quarantine.Values{
+			ProjectID: f.BadIPCSCC.GetFinding().GetSourceProperties().GetProperties().GetNetwork().GetProject(),
+			Instance:  etd.Instance(f.BadIPCSCC.GetFinding().GetSourceProperties().GetProperties().GetInstanceDetails()),
+			Zone:      etd.Zone(f.BadIPCSCC.GetFinding().GetSourceProperties().GetProperties().GetInstanceDetails()),
+		}
+	}
+	return &quarantine.Values{
+		ProjectID: f.badIP.GetJsonPayload().GetProperties().GetNetwork().GetProject(),
+		Instance:  etd.Instance(f.badIP.GetJsonPayload().GetProperties().GetInstanceDetails()),
+		Zone:      etd.Zone(f.badIP.GetJsonPayload().GetProperties().GetInstanceDetails()),
+	}
+}
+
+// Values returns values for the given automation.
+func (f *Finding) Values(automation *registry.Automation) (string, interface{}, error) {
+	switch automation.Action {
+	case "gce_create_disk_snapshot":
+		values := f.CreateSnapshot()
+		values.DryRun = automation.Properties.DryRun
+		values.Output = automation.Properties.CreateSnapshot.Output
+		values.DestProjectID = automation.Properties.CreateSnapshot.TargetSnapshotProjectID
+		values.DestZone = automation.Properties.CreateSnapshot.TargetSnapshotZone
+		values.Turbinia.ProjectID = automation.Properties.CreateSnapshot.Turbinia.ProjectID
+		values.Turbinia.Topic = automation.Properties.CreateSnapshot.Turbinia.Topic
+		values.Turbinia.Zone = automation.Properties.CreateSnapshot.Turbinia.Zone
+		return values.ProjectID, values, nil
+	case "gce_quarantine_instance":
+		values := f.Quarantine()
+		values.DryRun = automation.Properties.DryRun
+		values.Tag = automation.Properties.QuarantineInstance.Tag
+		values.StopInstance = automation.Properties.QuarantineInstance.StopInstance
+		return values.ProjectID, values, nil
+	default:
+		return "", nil, fmt.Errorf("action %q not found", automation.Action)
+	}
+}
+
+// SCC returns the Security Command Center attributes of the finding.
+func (f *Finding) SCC() *registry.SCC {
+	if !f.UseCSCC {
+		return nil
+	}
+	return &registry.SCC{
+		Name:          f.BadIPCSCC.GetFinding().GetName(),
+		EventTime:     f.BadIPCSCC.GetFinding().GetEventTime(),
+		SecurityMarks: f.BadIPCSCC.GetFinding().GetSecurityMarks().GetMarks(),
+	}
+}
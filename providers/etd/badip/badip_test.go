@@ -90,6 +90,13 @@ func TestBadIP(t *testing.T) {
 				if values.Zone != tt.zone {
 					t.Errorf("%s failed: got:%q want:%q", tt.name, values.Zone, tt.zone)
 				}
+				quarantine := f.Quarantine()
+				if quarantine.Instance != tt.instance {
+					t.Errorf("%s failed: got:%q want:%q", tt.name, quarantine.Instance, tt.instance)
+				}
+				if quarantine.Zone != tt.zone {
+					t.Errorf("%s failed: got:%q want:%q", tt.name, quarantine.Zone, tt.zone)
+				}
 
 			}
 		})
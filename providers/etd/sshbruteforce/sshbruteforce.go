@@ -2,11 +2,24 @@ package sshbruteforce
 
 import (
 	"encoding/json"
+	"fmt"
 
 	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/gce/openfirewall"
+	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/gce/quarantine"
 	pb "github.com/googlecloudplatform/security-response-automation/compiled/etd/protos"
+	"github.com/googlecloudplatform/security-response-automation/providers/registry"
 )
 
+func init() {
+	registry.Register(&registry.Rule{
+		Provider: "etd",
+		Name:     "ssh_brute_force",
+		Finding:  &Finding{},
+		New:      func(b []byte) (registry.Finding, error) { return New(b) },
+		Actions:  []string{"remediate_firewall", "gce_quarantine_instance"},
+	})
+}
+
 // Finding represents this finding.
 type Finding struct {
 	UseCSCC          bool
@@ -80,3 +93,48 @@ func (f *Finding) OpenFirewall() *openfirewall.Values {
 		SourceRanges: sourceIPRanges(f.sshBruteForce),
 	}
 }
+
+// Quarantine returns values for the quarantine instance automation.
+func (f *Finding) Quarantine() *quarantine.Values {
+	if f.UseCSCC {
+		properties := f.sshBruteForceSCC.GetFinding().GetSourceProperties().GetProperties()
+		instance := properties.GetInstanceId()
+		if attempts := properties.GetLoginAttempts(); len(attempts) > 0 && attempts[0].GetVmName() != "" {
+			instance = attempts[0].GetVmName()
+		}
+		return &quarantine.Values{
+			ProjectID: properties.GetProjectId(),
+			Instance:  instance,
+			Zone:      properties.GetZone(),
+		}
+	}
+	properties := f.sshBruteForce.GetJsonPayload().GetProperties()
+	instance := properties.GetInstanceId()
+	if attempts := properties.GetLoginAttempts(); len(attempts) > 0 && attempts[0].GetVmName() != "" {
+		instance = attempts[0].GetVmName()
+	}
+	return &quarantine.Values{
+		ProjectID: properties.GetProjectId(),
+		Instance:  instance,
+		Zone:      properties.GetZone(),
+	}
+}
+
+// Values returns values for the given automation.
+func (f *Finding) Values(automation *registry.Automation) (string, interface{}, error) {
+	switch automation.Action {
+	case "remediate_firewall":
+		values := f.OpenFirewall()
+		values.DryRun = automation.Properties.DryRun
+		values.Action = "block_ssh"
+		return values.ProjectID, values, nil
+	case "gce_quarantine_instance":
+		values := f.Quarantine()
+		values.DryRun = automation.Properties.DryRun
+		values.Tag = automation.Properties.QuarantineInstance.Tag
+		values.StopInstance = automation.Properties.QuarantineInstance.StopInstance
+		return values.ProjectID, values, nil
+	default:
+		return "", nil, fmt.Errorf("action %q not found", automation.Action)
+	}
+}
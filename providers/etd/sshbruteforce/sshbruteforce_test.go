@@ -24,6 +24,8 @@ func TestReadFinding(t *testing.T) {
 					},
 					"properties": {
 						"project_id": "onboarding-project",
+				"zone": "us-west1-a",
+						"zone": "us-west1-a",
 						"loginAttempts": [{
 							"authResult": "FAIL",
 							"sourceIp": "10.200.0.2",
@@ -46,6 +48,7 @@ func TestReadFinding(t *testing.T) {
 		"jsonPayload": {
 			"properties": {
 				"project_id": "onboarding-project",
+				"zone": "us-west1-a",
 				"loginAttempts": [{
 					"authResult": "FAIL",
 					"sourceIp": "10.200.0.2",
@@ -67,13 +70,14 @@ func TestReadFinding(t *testing.T) {
 	)
 	for _, tt := range []struct {
 		name, firewallID, projectID string
+		instance, zone              string
 		ranges                      []string
 		bytes                       []byte
 		expectedError               error
 		ruleName                    string
 	}{
-		{name: "read etd", ranges: []string{"10.200.0.2/32", "10.200.0.3/32"}, projectID: "onboarding-project", firewallID: "", bytes: []byte(etdSSHBruteForceFinding), expectedError: nil, ruleName: "ssh_brute_force"},
-		{name: "read SCC", ranges: []string{"10.200.0.2/32", "10.200.0.3/32"}, projectID: "onboarding-project", firewallID: "", bytes: []byte(sccSSHBruteForceFinding), expectedError: nil, ruleName: "ssh_brute_force"},
+		{name: "read etd", ranges: []string{"10.200.0.2/32", "10.200.0.3/32"}, projectID: "onboarding-project", instance: "ssh-password-auth-debian-9", zone: "us-west1-a", firewallID: "", bytes: []byte(etdSSHBruteForceFinding), expectedError: nil, ruleName: "ssh_brute_force"},
+		{name: "read SCC", ranges: []string{"10.200.0.2/32", "10.200.0.3/32"}, projectID: "onboarding-project", instance: "ssh-password-auth-debian-9", zone: "us-west1-a", firewallID: "", bytes: []byte(sccSSHBruteForceFinding), expectedError: nil, ruleName: "ssh_brute_force"},
 	} {
 		t.Run(tt.name, func(t *testing.T) {
 			r, err := New(tt.bytes)
@@ -97,6 +101,13 @@ func TestReadFinding(t *testing.T) {
 				if values.ProjectID != tt.projectID {
 					t.Errorf("%s failed: got:%q want:%q", tt.name, values.ProjectID, tt.projectID)
 				}
+				quarantine := r.Quarantine()
+				if quarantine.Instance != tt.instance {
+					t.Errorf("%s failed: got:%q want:%q", tt.name, quarantine.Instance, tt.instance)
+				}
+				if quarantine.Zone != tt.zone {
+					t.Errorf("%s failed: got:%q want:%q", tt.name, quarantine.Zone, tt.zone)
+				}
 			}
 		})
 	}
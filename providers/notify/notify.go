@@ -0,0 +1,99 @@
+// Package notify registers the notification actions available to every rule.
+package notify
+
+import (
+	"strings"
+
+	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/notify/email"
+	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/notify/pagerduty"
+	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/notify/webhook"
+	"github.com/googlecloudplatform/security-response-automation/providers/registry"
+)
+
+// defaultUrgencies maps finding severities to PagerDuty urgencies when none are configured.
+var defaultUrgencies = map[string]string{
+	"CRITICAL": "high",
+	"HIGH":     "high",
+	"MEDIUM":   "low",
+	"LOW":      "low",
+}
+
+func init() {
+	registry.RegisterNotifier("notify_email", "threat-findings-notify-email", Email)
+	registry.RegisterNotifier("pagerduty_incident", "threat-findings-pagerduty-incident", PagerDuty)
+	registry.RegisterNotifier("notify_webhook", "threat-findings-notify-webhook", Webhook)
+}
+
+// Email returns values for the email notification automation.
+func Email(n *registry.Notification, automation *registry.Automation) (interface{}, error) {
+	properties := automation.Properties.NotifyEmail
+	actions := make([]email.Action, 0, len(n.Actions))
+	for _, a := range n.Actions {
+		actions = append(actions, email.Action{Action: a.Action, DryRun: a.DryRun, State: a.State})
+	}
+	return &email.Values{
+		ProjectID: n.ProjectID,
+		Rule:      n.Rule,
+		Finding:   n.Finding,
+		Actions:   actions,
+		Data:      n.Data,
+		To:        properties.To,
+		From:      properties.From,
+		Subject:   properties.Subject,
+		Template:  properties.Template,
+		DryRun:    automation.Properties.DryRun,
+	}, nil
+}
+
+// PagerDuty returns values for the PagerDuty incident automation.
+//
+// Incidents are deduplicated on the Security Command Center finding name. Findings without one
+// are deduplicated on their rule and project.
+func PagerDuty(n *registry.Notification, automation *registry.Automation) (interface{}, error) {
+	properties := automation.Properties.PagerDutyIncident
+	urgencies := properties.Urgency
+	if len(urgencies) == 0 {
+		urgencies = defaultUrgencies
+	}
+	key := n.Finding
+	if key == "" {
+		key = n.Rule + "/" + n.ProjectID
+	}
+	actions := make([]pagerduty.Action, 0, len(n.Actions))
+	for _, a := range n.Actions {
+		actions = append(actions, pagerduty.Action{Action: a.Action, DryRun: a.DryRun, State: a.State})
+	}
+	return &pagerduty.Values{
+		ProjectID: n.ProjectID,
+		Rule:      n.Rule,
+		Finding:   n.Finding,
+		Severity:  n.Severity,
+		Actions:   actions,
+		ServiceID: properties.ServiceID,
+		From:      properties.From,
+		Urgency:   urgencies[strings.ToUpper(n.Severity)],
+		DedupKey:  key,
+		DryRun:    automation.Properties.DryRun,
+	}, nil
+}
+
+// Webhook returns values for the webhook notification automation.
+func Webhook(n *registry.Notification, automation *registry.Automation) (interface{}, error) {
+	properties := automation.Properties.NotifyWebhook
+	actions := make([]webhook.Action, 0, len(n.Actions))
+	for _, a := range n.Actions {
+		actions = append(actions, webhook.Action{Action: a.Action, DryRun: a.DryRun, State: a.State})
+	}
+	return &webhook.Values{
+		ProjectID: n.ProjectID,
+		Rule:      n.Rule,
+		Finding:   n.Finding,
+		Severity:  n.Severity,
+		Actions:   actions,
+		Data:      n.Data,
+		Webhook:   properties.Webhook,
+		Body:      properties.Body,
+		Template:  properties.Template,
+		DryRun:    automation.Properties.DryRun,
+	}, nil
+}
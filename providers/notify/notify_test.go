@@ -0,0 +1,103 @@
+package notify
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/notify/pagerduty"
+	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/notify/webhook"
+	"github.com/googlecloudplatform/security-response-automation/providers/registry"
+)
+
+func TestPagerDuty(t *testing.T) {
+	configured := &registry.Automation{Action: "pagerduty_incident"}
+	configured.Properties.PagerDutyIncident.ServiceID = "PSERVICE"
+	configured.Properties.PagerDutyIncident.Urgency = map[string]string{"MEDIUM": "high"}
+	defaults := &registry.Automation{Action: "pagerduty_incident"}
+	defaults.Properties.PagerDutyIncident.ServiceID = "PSERVICE"
+
+	for _, tt := range []struct {
+		name         string
+		notification *registry.Notification
+		automation   *registry.Automation
+		want         *pagerduty.Values
+	}{
+		{
+			name:         "scc finding",
+			notification: &registry.Notification{Rule: "bad_ip", Finding: "organizations/1/sources/2/findings/3", ProjectID: "p", Severity: "HIGH"},
+			automation:   defaults,
+			want: &pagerduty.Values{
+				ProjectID: "p",
+				Rule:      "bad_ip",
+				Finding:   "organizations/1/sources/2/findings/3",
+				Severity:  "HIGH",
+				Actions:   []pagerduty.Action{},
+				ServiceID: "PSERVICE",
+				Urgency:   "high",
+				DedupKey:  "organizations/1/sources/2/findings/3",
+			},
+		},
+		{
+			name:         "log finding with configured urgency",
+			notification: &registry.Notification{Rule: "bad_ip", ProjectID: "p", Severity: "medium", Actions: []registry.ActionTaken{{Action: "gce_create_disk_snapshot", State: registry.Scheduled}}},
+			automation:   configured,
+			want: &pagerduty.Values{
+				ProjectID: "p",
+				Rule:      "bad_ip",
+				Severity:  "medium",
+				Actions:   []pagerduty.Action{{Action: "gce_create_disk_snapshot", State: registry.Scheduled}},
+				ServiceID: "PSERVICE",
+				Urgency:   "high",
+				DedupKey:  "bad_ip/p",
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := PagerDuty(tt.notification, tt.automation)
+			if err != nil {
+				t.Fatalf("PagerDuty() failed: %q", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("PagerDuty() unexpected values (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	automation := &registry.Automation{Action: "notify_webhook"}
+	automation.Properties.DryRun = true
+	automation.Properties.NotifyWebhook.Webhook = "secops"
+	automation.Properties.NotifyWebhook.Body = `{"text": {{json .Summary}}}`
+	n := &registry.Notification{Rule: "bad_ip", ProjectID: "p", Data: []byte(`{"finding": {}}`)}
+	want := &webhook.Values{
+		ProjectID: "p",
+		Rule:      "bad_ip",
+		Actions:   []webhook.Action{},
+		Data:      []byte(`{"finding": {}}`),
+		Webhook:   "secops",
+		Body:      `{"text": {{json .Summary}}}`,
+		DryRun:    true,
+	}
+	got, err := Webhook(n, automation)
+	if err != nil {
+		t.Fatalf("Webhook() failed: %q", err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Webhook() unexpected values (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,89 @@
+package registry
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Automation represents configuration for an automation.
+type Automation struct {
+	Action     string
+	Target     []string
+	Exclude    []string
+	Properties Properties
+}
+
+// Properties holds the settings of an automation.
+type Properties struct {
+	DryRun    bool `yaml:"dry_run"`
+	RevokeIAM struct {
+		AllowDomains []string `yaml:"allow_domains"`
+	} `yaml:"revoke_iam"`
+	CreateSnapshot struct {
+		TargetSnapshotProjectID string `yaml:"target_snapshot_project_id"`
+		TargetSnapshotZone      string `yaml:"target_snapshot_zone"`
+		Output                  []string
+		Turbinia                struct {
+			ProjectID string
+			Topic     string
+			Zone      string
+		}
+	} `yaml:"gce_create_snapshot"`
+	QuarantineInstance struct {
+		Tag          string
+		StopInstance bool `yaml:"stop_instance"`
+	} `yaml:"gce_quarantine_instance"`
+	OpenFirewall struct {
+		SourceRanges      []string `yaml:"source_ranges"`
+		RemediationAction string   `yaml:"remediation_action"`
+	} `yaml:"open_firewall"`
+	NonOrgMembers struct {
+		AllowDomains []string `yaml:"allow_domains"`
+	} `yaml:"non_org_members"`
+	NotifyEmail struct {
+		To       []string
+		From     string
+		Subject  string
+		Template string
+	} `yaml:"notify_email"`
+	PagerDutyIncident struct {
+		ServiceID string `yaml:"service_id"`
+		From      string
+		Urgency   map[string]string
+	} `yaml:"pagerduty_incident"`
+	NotifyWebhook struct {
+		// Webhook names the URL to post to, configured outside of the automation as URLs hold
+		// credentials.
+		Webhook  string
+		Body     string
+		Template string
+	} `yaml:"notify_webhook"`
+	Approval Approval
+	Schedule Schedule
+}
+
+// Approval holds the approvers of an automation that must be approved before it runs.
+type Approval struct {
+	// Email and Webhook are sent links to approve or deny the automation. Webhook is the name of
+	// a configured webhook.
+	Email   []string
+	From    string
+	Webhook string
+	// AutoApprove is how long to wait for a decision before the automation is approved, such
+	// as "4h". The automation waits for a decision indefinitely if empty.
+	AutoApprove string `yaml:"auto_approve"`
+}
+
+// Required returns if the automation must be approved before it runs.
+func (a *Approval) Required() bool {
+	return len(a.Email) > 0 || a.Webhook != ""
+}
@@ -0,0 +1,80 @@
+package registry
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Notification summarizes a routed finding and the automations it was dispatched to.
+type Notification struct {
+	// Provider is the configuration section holding the finding's rule, such as "etd" or "sha".
+	Provider string
+	// Rule is the rule name of the finding.
+	Rule string
+	// Finding is the Security Command Center name of the finding, if it has one.
+	Finding string
+	// ProjectID is the ID of the project affected by the finding.
+	ProjectID string
+	// Severity is the severity of the finding, such as "HIGH", if it reports one.
+	Severity string
+	// Actions holds the automations the finding was dispatched to.
+	Actions []ActionTaken
+	// Data is the finding as received by the router.
+	Data json.RawMessage
+}
+
+// States of an automation a finding was dispatched to, as known to the router. The automation
+// itself may still fail once run.
+const (
+	// Dispatched automations were published to their topic to run.
+	Dispatched = "dispatched"
+	// PendingApproval automations run once an approver approves them.
+	PendingApproval = "pending approval"
+	// Scheduled automations run once one of their maintenance windows opens.
+	Scheduled = "scheduled"
+)
+
+// ActionTaken is an automation a finding was dispatched to.
+type ActionTaken struct {
+	Action string
+	DryRun bool
+	// State is Dispatched, PendingApproval or Scheduled.
+	State string
+}
+
+// Notifier builds the values published to a notification action.
+type Notifier func(n *Notification, automation *Automation) (interface{}, error)
+
+// RegisterNotifier makes a notification action available to every rule. Notification actions
+// are dispatched after the finding's other automations so they can report on them.
+func RegisterNotifier(action, topic string, n Notifier) {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := notifiers[action]; ok {
+		panic(fmt.Sprintf("registry: notifier %q registered twice", action))
+	}
+	topics[action] = topic
+	notifiers[action] = n
+}
+
+// LookupNotifier returns the notifier registered for the given action.
+func LookupNotifier(action string) (Notifier, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	n, ok := notifiers[action]
+	return n, ok
+}
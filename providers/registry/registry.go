@@ -0,0 +1,187 @@
+// Package registry holds the findings and automations known to the router.
+//
+// Each provider package registers the rules it understands from its init function. The router
+// then dispatches findings to automations without needing to know about individual findings.
+// To add a new finding, create a package that calls Register and import it for its side effects:
+//
+//	import _ "example.com/detections/mydetection"
+package registry
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"fmt"
+	"sync"
+)
+
+var (
+	mu sync.RWMutex
+	// rules holds registered rules in the order they were registered.
+	rules []*Rule
+	// byName maps rule names to registered rules.
+	byName = map[string]*Rule{}
+	// topics maps automation actions to PubSub topics.
+	topics = map[string]string{
+		"gce_create_disk_snapshot":  "threat-findings-create-disk-snapshot",
+		"iam_revoke":                "threat-findings-iam-revoke",
+		"close_bucket":              "threat-findings-close-bucket",
+		"enable_bucket_only_policy": "threat-findings-enable-bucket-only-policy",
+		"close_cloud_sql":           "threat-findings-remove-public-sql",
+		"cloud_sql_require_ssl":     "threat-findings-require-ssl",
+		"cloud_sql_update_password": "threat-findings-update-password",
+		"disable_dashboard":         "threat-findings-disable-dashboard",
+		"remove_public_ip":          "threat-findings-remove-public-ip",
+		"remediate_firewall":        "threat-findings-open-firewall",
+		"close_public_dataset":      "threat-findings-close-public-dataset",
+		"enable_audit_logs":         "threat-findings-enable-audit-logs",
+		"remove_non_org_members":    "threat-findings-remove-non-org-members",
+		"block_domain":              "threat-findings-block-domain",
+		"gce_quarantine_instance":   "threat-findings-quarantine-instance",
+	}
+	// notifiers maps notification actions to the functions building their values.
+	notifiers = map[string]Notifier{}
+)
+
+// Namer represents findings that export their name.
+type Namer interface {
+	Name([]byte) string
+}
+
+// Finding is a parsed finding able to build values for the automations it supports.
+type Finding interface {
+	// Values returns the ID of the affected project along with the values to publish
+	// for the given automation.
+	Values(automation *Automation) (string, interface{}, error)
+}
+
+// SCC holds the Security Command Center attributes used to track remediation.
+type SCC struct {
+	Name          string
+	EventTime     string
+	SecurityMarks map[string]string
+}
+
+// SCCFinding is implemented by findings that are marked as remediated once routed.
+type SCCFinding interface {
+	// SCC returns the Security Command Center attributes of the finding or nil if the
+	// finding was not delivered as a Security Command Center notification.
+	SCC() *SCC
+}
+
+// Rule describes a finding rule and the automations it supports.
+type Rule struct {
+	// Provider is the configuration section holding this rule, such as "etd" or "sha".
+	Provider string
+	// Name is the rule name returned by the finding's Namer.
+	Name string
+	// Key is the configuration key holding the rule's automations. Defaults to Name.
+	Key string
+	// Finding extracts the rule name from a raw finding.
+	Finding Namer
+	// New parses a raw finding.
+	New func([]byte) (Finding, error)
+	// Actions contains the names of the automations this rule supports.
+	Actions []string
+}
+
+// Supports returns if the rule can be routed to the given action.
+func (r *Rule) Supports(action string) bool {
+	for _, a := range r.Actions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
+// Register makes a rule available to the router. Register panics if a rule with the
+// same name is registered twice or if the rule is missing required fields.
+func Register(r *Rule) {
+	mu.Lock()
+	defer mu.Unlock()
+	if r.Provider == "" || r.Name == "" || r.Finding == nil || r.New == nil {
+		panic(fmt.Sprintf("registry: incomplete rule %q", r.Name))
+	}
+	if _, ok := byName[r.Name]; ok {
+		panic(fmt.Sprintf("registry: rule %q registered twice", r.Name))
+	}
+	if r.Key == "" {
+		r.Key = r.Name
+	}
+	rules = append(rules, r)
+	byName[r.Name] = r
+}
+
+// RegisterAction maps an automation action to the PubSub topic its Cloud Function listens on.
+func RegisterAction(action, topic string) {
+	mu.Lock()
+	defer mu.Unlock()
+	topics[action] = topic
+}
+
+// UnregisterAction removes the action registered with RegisterAction, so tests registering
+// actions leave the registry as they found it.
+func UnregisterAction(action string) {
+	mu.Lock()
+	defer mu.Unlock()
+	delete(topics, action)
+}
+
+// Topic returns the PubSub topic for the given action.
+func Topic(action string) (string, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	t, ok := topics[action]
+	return t, ok
+}
+
+// Topics returns the PubSub topic of every registered action, keyed by action.
+func Topics() map[string]string {
+	mu.RLock()
+	defer mu.RUnlock()
+	t := make(map[string]string, len(topics))
+	for action, topic := range topics {
+		t[action] = topic
+	}
+	return t
+}
+
+// Lookup returns the rule matching the raw finding along with the extracted rule name.
+// If a name was extracted but no rule is registered under it, the returned rule is nil.
+func Lookup(b []byte) (*Rule, string) {
+	mu.RLock()
+	defer mu.RUnlock()
+	name := ""
+	for _, r := range rules {
+		n := r.Finding.Name(b)
+		if n == "" {
+			continue
+		}
+		if rr, ok := byName[n]; ok {
+			return rr, n
+		}
+		if name == "" {
+			name = n
+		}
+	}
+	return nil, name
+}
+
+// Rules returns all registered rules in registration order.
+func Rules() []*Rule {
+	mu.RLock()
+	defer mu.RUnlock()
+	return append([]*Rule(nil), rules...)
+}
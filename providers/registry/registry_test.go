@@ -0,0 +1,84 @@
+package registry
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"strings"
+	"testing"
+)
+
+// customFinding is a finding named after the prefix of its raw bytes.
+type customFinding struct{}
+
+func (f *customFinding) Name(b []byte) string {
+	if !strings.HasPrefix(string(b), "custom_") {
+		return ""
+	}
+	return string(b)
+}
+
+func (f *customFinding) Values(automation *Automation) (string, interface{}, error) {
+	return "project", nil, nil
+}
+
+func TestRegistry(t *testing.T) {
+	Register(&Rule{
+		Provider: "custom",
+		Name:     "custom_rule",
+		Finding:  &customFinding{},
+		New:      func(b []byte) (Finding, error) { return &customFinding{}, nil },
+		Actions:  []string{"custom_action"},
+	})
+	RegisterAction("custom_action", "custom-topic")
+	defer UnregisterAction("custom_action")
+
+	for _, tt := range []struct {
+		name, finding, wantName string
+		wantRule                bool
+	}{
+		{name: "registered", finding: "custom_rule", wantName: "custom_rule", wantRule: true},
+		{name: "unregistered", finding: "custom_other", wantName: "custom_other", wantRule: false},
+		{name: "unknown", finding: "other", wantName: "", wantRule: false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, name := Lookup([]byte(tt.finding))
+			if name != tt.wantName {
+				t.Errorf("Lookup(%q) name = %q, want %q", tt.finding, name, tt.wantName)
+			}
+			if got := rule != nil; got != tt.wantRule {
+				t.Fatalf("Lookup(%q) found rule = %t, want %t", tt.finding, got, tt.wantRule)
+			}
+			if rule == nil {
+				return
+			}
+			if rule.Key != "custom_rule" {
+				t.Errorf("rule.Key = %q, want %q", rule.Key, "custom_rule")
+			}
+			if !rule.Supports("custom_action") || rule.Supports("close_bucket") {
+				t.Errorf("rule.Supports returned unexpected results for %q", rule.Actions)
+			}
+		})
+	}
+	if topic, ok := Topic("custom_action"); !ok || topic != "custom-topic" {
+		t.Errorf("Topic(%q) = %q, %t, want %q, true", "custom_action", topic, ok, "custom-topic")
+	}
+	if topics := Topics(); topics["custom_action"] != "custom-topic" || topics["close_bucket"] != "threat-findings-close-bucket" {
+		t.Errorf("Topics() = %v, want the built-in and registered actions", topics)
+	}
+	UnregisterAction("custom_action")
+	if topic, ok := Topic("custom_action"); ok {
+		t.Errorf("Topic(%q) = %q after UnregisterAction, want none", "custom_action", topic)
+	}
+}
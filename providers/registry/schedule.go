@@ -0,0 +1,137 @@
+package registry
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// days maps the day names accepted by windows to their weekday.
+var days = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// Schedule restricts an automation to maintenance windows. Findings arriving outside the
+// windows are queued and the automation runs once the next window opens.
+type Schedule struct {
+	// Timezone is the IANA time zone of the windows, such as "Europe/Paris". UTC if empty.
+	Timezone string
+	Windows  []Window
+}
+
+// Window is a maintenance window recurring on the given days.
+type Window struct {
+	// Days are the days the window opens on, such as "sat". Every day if empty.
+	Days []string
+	// Start and End are times of day such as "22:00". A window ending before it starts ends on
+	// the following day.
+	Start string
+	End   string
+}
+
+// Configured returns if the automation is restricted to maintenance windows.
+func (s *Schedule) Configured() bool {
+	return len(s.Windows) > 0
+}
+
+// Validate returns an error if the timezone, a day or a time of day is invalid.
+func (s *Schedule) Validate() error {
+	if _, err := time.LoadLocation(s.Timezone); err != nil {
+		return fmt.Errorf("unknown timezone %q", s.Timezone)
+	}
+	for i, w := range s.Windows {
+		for _, d := range w.Days {
+			if _, ok := days[strings.ToLower(d)]; !ok {
+				return fmt.Errorf("windows[%d]: unknown day %q, expected one of sun, mon, tue, wed, thu, fri or sat", i, d)
+			}
+		}
+		start, err := time.Parse("15:04", w.Start)
+		if err != nil {
+			return fmt.Errorf("windows[%d]: start must be a time of day such as 22:00, got %q", i, w.Start)
+		}
+		end, err := time.Parse("15:04", w.End)
+		if err != nil {
+			return fmt.Errorf("windows[%d]: end must be a time of day such as 06:00, got %q", i, w.End)
+		}
+		if start.Equal(end) {
+			return fmt.Errorf("windows[%d]: start and end are equal", i)
+		}
+	}
+	return nil
+}
+
+// Next returns when the automation may run if it was due at t. That is t itself if a window is
+// open at t, or when the next window opens.
+func (s *Schedule) Next(t time.Time) (time.Time, error) {
+	if !s.Configured() {
+		return t, nil
+	}
+	if err := s.Validate(); err != nil {
+		return time.Time{}, err
+	}
+	loc, _ := time.LoadLocation(s.Timezone)
+	local := t.In(loc)
+	var next time.Time
+	// Start a day early for windows opened the previous day and ending after midnight.
+	for d := -1; d <= 7; d++ {
+		day := time.Date(local.Year(), local.Month(), local.Day()+d, 0, 0, 0, 0, loc)
+		for _, w := range s.Windows {
+			if !w.opensOn(day.Weekday()) {
+				continue
+			}
+			start, end := w.on(day)
+			if !local.Before(start) && local.Before(end) {
+				return t, nil
+			}
+			if start.After(local) && (next.IsZero() || start.Before(next)) {
+				next = start
+			}
+		}
+	}
+	return next, nil
+}
+
+// opensOn returns if the window opens on the weekday.
+func (w *Window) opensOn(weekday time.Weekday) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, d := range w.Days {
+		if days[strings.ToLower(d)] == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// on returns when the window opening on the given day starts and ends.
+func (w *Window) on(day time.Time) (time.Time, time.Time) {
+	s, _ := time.Parse("15:04", w.Start)
+	e, _ := time.Parse("15:04", w.End)
+	start := time.Date(day.Year(), day.Month(), day.Day(), s.Hour(), s.Minute(), 0, 0, day.Location())
+	end := time.Date(day.Year(), day.Month(), day.Day(), e.Hour(), e.Minute(), 0, 0, day.Location())
+	if !end.After(start) {
+		end = end.AddDate(0, 0, 1)
+	}
+	return start, end
+}
@@ -0,0 +1,88 @@
+package registry
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduleNext(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Skipf("time zone database unavailable: %q", err)
+	}
+	s := &Schedule{
+		Timezone: "Europe/Paris",
+		Windows: []Window{
+			{Days: []string{"sat", "sun"}, Start: "08:00", End: "18:00"},
+			{Days: []string{"tue"}, Start: "22:00", End: "02:00"},
+		},
+	}
+	for _, tt := range []struct {
+		name string
+		at   time.Time
+		want time.Time
+	}{
+		{
+			name: "in window",
+			at:   time.Date(2020, 1, 4, 10, 0, 0, 0, paris),
+			want: time.Date(2020, 1, 4, 10, 0, 0, 0, paris),
+		},
+		{
+			name: "before window",
+			at:   time.Date(2020, 1, 4, 7, 59, 0, 0, paris),
+			want: time.Date(2020, 1, 4, 8, 0, 0, 0, paris),
+		},
+		{
+			name: "after window closes",
+			at:   time.Date(2020, 1, 5, 18, 0, 0, 0, paris),
+			want: time.Date(2020, 1, 7, 22, 0, 0, 0, paris),
+		},
+		{
+			name: "window open past midnight",
+			at:   time.Date(2020, 1, 8, 1, 30, 0, 0, paris),
+			want: time.Date(2020, 1, 8, 1, 30, 0, 0, paris),
+		},
+		{
+			name: "other time zone",
+			at:   time.Date(2020, 1, 4, 6, 30, 0, 0, time.UTC),
+			want: time.Date(2020, 1, 4, 8, 0, 0, 0, paris),
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.Next(tt.at)
+			if err != nil {
+				t.Fatalf("Next() failed: %q", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("Next(%s) = %s, want %s", tt.at, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScheduleValidate(t *testing.T) {
+	for _, s := range []Schedule{
+		{Timezone: "Mars/Olympus", Windows: []Window{{Start: "22:00", End: "06:00"}}},
+		{Windows: []Window{{Days: []string{"saturday"}, Start: "22:00", End: "06:00"}}},
+		{Windows: []Window{{Start: "10pm", End: "06:00"}}},
+		{Windows: []Window{{Start: "06:00", End: "06:00"}}},
+	} {
+		if err := s.Validate(); err == nil {
+			t.Errorf("Validate(%+v) = nil, want error", s)
+		}
+	}
+}
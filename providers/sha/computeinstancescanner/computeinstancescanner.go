@@ -2,13 +2,25 @@ package computeinstancescanner
 
 import (
 	"encoding/json"
+	"fmt"
 	"strings"
 
 	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/gce/removepublicip"
 	pb "github.com/googlecloudplatform/security-response-automation/compiled/sha/protos"
+	"github.com/googlecloudplatform/security-response-automation/providers/registry"
 	"github.com/googlecloudplatform/security-response-automation/providers/sha"
 )
 
+func init() {
+	registry.Register(&registry.Rule{
+		Provider: "sha",
+		Name:     "public_ip_address",
+		Finding:  &Finding{},
+		New:      func(b []byte) (registry.Finding, error) { return New(b) },
+		Actions:  []string{"remove_public_ip"},
+	})
+}
+
 // Finding represents this finding.
 type Finding struct {
 	ComputeInstanceScanner *pb.ComputeInstanceScanner
@@ -43,3 +55,24 @@ func (f *Finding) RemovePublicIP() *removepublicip.Values {
 		InstanceID:   sha.Instance(f.ComputeInstanceScanner.GetFinding().GetResourceName()),
 	}
 }
+
+// Values returns values for the given automation.
+func (f *Finding) Values(automation *registry.Automation) (string, interface{}, error) {
+	switch automation.Action {
+	case "remove_public_ip":
+		values := f.RemovePublicIP()
+		values.DryRun = automation.Properties.DryRun
+		return values.ProjectID, values, nil
+	default:
+		return "", nil, fmt.Errorf("action %q not found", automation.Action)
+	}
+}
+
+// SCC returns the Security Command Center attributes of the finding.
+func (f *Finding) SCC() *registry.SCC {
+	return &registry.SCC{
+		Name:          f.ComputeInstanceScanner.GetFinding().GetName(),
+		EventTime:     f.ComputeInstanceScanner.GetFinding().GetEventTime(),
+		SecurityMarks: f.ComputeInstanceScanner.GetFinding().GetSecurityMarks().GetMarks(),
+	}
+}
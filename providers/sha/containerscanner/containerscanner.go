@@ -2,13 +2,25 @@ package containerscanner
 
 import (
 	"encoding/json"
+	"fmt"
 	"strings"
 
 	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/gke/disabledashboard"
 	pb "github.com/googlecloudplatform/security-response-automation/compiled/sha/protos"
+	"github.com/googlecloudplatform/security-response-automation/providers/registry"
 	"github.com/googlecloudplatform/security-response-automation/providers/sha"
 )
 
+func init() {
+	registry.Register(&registry.Rule{
+		Provider: "sha",
+		Name:     "web_ui_enabled",
+		Finding:  &Finding{},
+		New:      func(b []byte) (registry.Finding, error) { return New(b) },
+		Actions:  []string{"disable_dashboard"},
+	})
+}
+
 // Finding represents this finding.
 type Finding struct {
 	Containerscanner *pb.ContainerScanner
@@ -43,3 +55,24 @@ func (f *Finding) DisableDashboard() *disabledashboard.Values {
 		ClusterID: sha.ClusterID(f.Containerscanner.GetFinding().GetResourceName()),
 	}
 }
+
+// Values returns values for the given automation.
+func (f *Finding) Values(automation *registry.Automation) (string, interface{}, error) {
+	switch automation.Action {
+	case "disable_dashboard":
+		values := f.DisableDashboard()
+		values.DryRun = automation.Properties.DryRun
+		return values.ProjectID, values, nil
+	default:
+		return "", nil, fmt.Errorf("action %q not found", automation.Action)
+	}
+}
+
+// SCC returns the Security Command Center attributes of the finding.
+func (f *Finding) SCC() *registry.SCC {
+	return &registry.SCC{
+		Name:          f.Containerscanner.GetFinding().GetName(),
+		EventTime:     f.Containerscanner.GetFinding().GetEventTime(),
+		SecurityMarks: f.Containerscanner.GetFinding().GetSecurityMarks().GetMarks(),
+	}
+}
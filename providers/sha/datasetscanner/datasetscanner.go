@@ -2,13 +2,26 @@ package datasetscanner
 
 import (
 	"encoding/json"
+	"fmt"
 	"strings"
 
 	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/bigquery/closepublicdataset"
 	pb "github.com/googlecloudplatform/security-response-automation/compiled/sha/protos"
+	"github.com/googlecloudplatform/security-response-automation/providers/registry"
 	"github.com/googlecloudplatform/security-response-automation/providers/sha"
 )
 
+func init() {
+	registry.Register(&registry.Rule{
+		Provider: "sha",
+		Name:     "public_dataset",
+		Key:      "bigquery_public_dataset",
+		Finding:  &Finding{},
+		New:      func(b []byte) (registry.Finding, error) { return New(b) },
+		Actions:  []string{"close_public_dataset"},
+	})
+}
+
 // Finding represents this finding structure by SHA scanner.
 type Finding struct {
 	DatasetScanner *pb.DatasetScanner
@@ -42,3 +55,24 @@ func (f *Finding) ClosePublicDataset() *closepublicdataset.Values {
 		DatasetID: sha.Dataset(f.DatasetScanner.GetFinding().GetResourceName()),
 	}
 }
+
+// Values returns values for the given automation.
+func (f *Finding) Values(automation *registry.Automation) (string, interface{}, error) {
+	switch automation.Action {
+	case "close_public_dataset":
+		values := f.ClosePublicDataset()
+		values.DryRun = automation.Properties.DryRun
+		return values.ProjectID, values, nil
+	default:
+		return "", nil, fmt.Errorf("action %q not found", automation.Action)
+	}
+}
+
+// SCC returns the Security Command Center attributes of the finding.
+func (f *Finding) SCC() *registry.SCC {
+	return &registry.SCC{
+		Name:          f.DatasetScanner.GetFinding().GetName(),
+		EventTime:     f.DatasetScanner.GetFinding().GetEventTime(),
+		SecurityMarks: f.DatasetScanner.GetFinding().GetSecurityMarks().GetMarks(),
+	}
+}
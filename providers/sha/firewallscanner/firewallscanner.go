@@ -2,13 +2,41 @@ package firewallscanner
 
 import (
 	"encoding/json"
+	"fmt"
 	"strings"
 
 	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/gce/openfirewall"
 	pb "github.com/googlecloudplatform/security-response-automation/compiled/sha/protos"
+	"github.com/googlecloudplatform/security-response-automation/providers/registry"
 	"github.com/googlecloudplatform/security-response-automation/providers/sha"
 )
 
+func init() {
+	registry.Register(&registry.Rule{
+		Provider: "sha",
+		Name:     "open_firewall",
+		Finding:  &Finding{},
+		New:      func(b []byte) (registry.Finding, error) { return New(b) },
+		Actions:  []string{"remediate_firewall"},
+	})
+	registry.Register(&registry.Rule{
+		Provider: "sha",
+		Name:     "open_ssh_port",
+		Key:      "open_firewall",
+		Finding:  &Finding{},
+		New:      func(b []byte) (registry.Finding, error) { return New(b) },
+		Actions:  []string{"remediate_firewall"},
+	})
+	registry.Register(&registry.Rule{
+		Provider: "sha",
+		Name:     "open_rdp_port",
+		Key:      "open_firewall",
+		Finding:  &Finding{},
+		New:      func(b []byte) (registry.Finding, error) { return New(b) },
+		Actions:  []string{"remediate_firewall"},
+	})
+}
+
 // Finding represents this finding.
 type Finding struct {
 	FirewallScanner *pb.FirewallScanner
@@ -42,3 +70,26 @@ func (f *Finding) OpenFirewall() *openfirewall.Values {
 		FirewallID: sha.FirewallID(f.FirewallScanner.GetFinding().GetResourceName()),
 	}
 }
+
+// Values returns values for the given automation.
+func (f *Finding) Values(automation *registry.Automation) (string, interface{}, error) {
+	switch automation.Action {
+	case "remediate_firewall":
+		values := f.OpenFirewall()
+		values.DryRun = automation.Properties.DryRun
+		values.SourceRanges = automation.Properties.OpenFirewall.SourceRanges
+		values.Action = automation.Properties.OpenFirewall.RemediationAction
+		return values.ProjectID, values, nil
+	default:
+		return "", nil, fmt.Errorf("action %q not found", automation.Action)
+	}
+}
+
+// SCC returns the Security Command Center attributes of the finding.
+func (f *Finding) SCC() *registry.SCC {
+	return &registry.SCC{
+		Name:          f.FirewallScanner.GetFinding().GetName(),
+		EventTime:     f.FirewallScanner.GetFinding().GetEventTime(),
+		SecurityMarks: f.FirewallScanner.GetFinding().GetSecurityMarks().GetMarks(),
+	}
+}
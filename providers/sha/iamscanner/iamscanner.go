@@ -2,12 +2,25 @@ package iamscanner
 
 import (
 	"encoding/json"
+	"fmt"
 	"strings"
 
 	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/iam/removenonorgmembers"
 	pb "github.com/googlecloudplatform/security-response-automation/compiled/sha/protos"
+	"github.com/googlecloudplatform/security-response-automation/providers/registry"
 )
 
+func init() {
+	registry.Register(&registry.Rule{
+		Provider: "sha",
+		Name:     "non_org_iam_member",
+		Key:      "non_org_members",
+		Finding:  &Finding{},
+		New:      func(b []byte) (registry.Finding, error) { return New(b) },
+		Actions:  []string{"remove_non_org_members"},
+	})
+}
+
 // Finding represents this finding structure by SHA scanner.
 type Finding struct {
 	IAMScanner *pb.IamScanner
@@ -40,3 +53,25 @@ func (f *Finding) RemoveNonOrgMembers() *removenonorgmembers.Values {
 		ProjectID: f.IAMScanner.GetFinding().GetSourceProperties().GetProjectID(),
 	}
 }
+
+// Values returns values for the given automation.
+func (f *Finding) Values(automation *registry.Automation) (string, interface{}, error) {
+	switch automation.Action {
+	case "remove_non_org_members":
+		values := f.RemoveNonOrgMembers()
+		values.DryRun = automation.Properties.DryRun
+		values.AllowDomains = automation.Properties.NonOrgMembers.AllowDomains
+		return values.ProjectID, values, nil
+	default:
+		return "", nil, fmt.Errorf("action %q not found", automation.Action)
+	}
+}
+
+// SCC returns the Security Command Center attributes of the finding.
+func (f *Finding) SCC() *registry.SCC {
+	return &registry.SCC{
+		Name:          f.IAMScanner.GetFinding().GetName(),
+		EventTime:     f.IAMScanner.GetFinding().GetEventTime(),
+		SecurityMarks: f.IAMScanner.GetFinding().GetSecurityMarks().GetMarks(),
+	}
+}
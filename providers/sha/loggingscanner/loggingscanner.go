@@ -2,12 +2,24 @@ package loggingscanner
 
 import (
 	"encoding/json"
+	"fmt"
 	"strings"
 
 	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/iam/enableauditlogs"
 	pb "github.com/googlecloudplatform/security-response-automation/compiled/sha/protos"
+	"github.com/googlecloudplatform/security-response-automation/providers/registry"
 )
 
+func init() {
+	registry.Register(&registry.Rule{
+		Provider: "sha",
+		Name:     "audit_logging_disabled",
+		Finding:  &Finding{},
+		New:      func(b []byte) (registry.Finding, error) { return New(b) },
+		Actions:  []string{"enable_audit_logs"},
+	})
+}
+
 // Finding represents this finding.
 type Finding struct {
 	Loggingscanner *pb.LoggingScanner
@@ -40,3 +52,24 @@ func (f *Finding) EnableAuditLogs() *enableauditlogs.Values {
 		ProjectID: f.Loggingscanner.GetFinding().GetSourceProperties().GetProjectID(),
 	}
 }
+
+// Values returns values for the given automation.
+func (f *Finding) Values(automation *registry.Automation) (string, interface{}, error) {
+	switch automation.Action {
+	case "enable_audit_logs":
+		values := f.EnableAuditLogs()
+		values.DryRun = automation.Properties.DryRun
+		return values.ProjectID, values, nil
+	default:
+		return "", nil, fmt.Errorf("action %q not found", automation.Action)
+	}
+}
+
+// SCC returns the Security Command Center attributes of the finding.
+func (f *Finding) SCC() *registry.SCC {
+	return &registry.SCC{
+		Name:          f.Loggingscanner.GetFinding().GetName(),
+		EventTime:     f.Loggingscanner.GetFinding().GetEventTime(),
+		SecurityMarks: f.Loggingscanner.GetFinding().GetSecurityMarks().GetMarks(),
+	}
+}
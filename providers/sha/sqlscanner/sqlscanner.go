@@ -2,16 +2,42 @@ package sqlscanner
 
 import (
 	"encoding/json"
+	"fmt"
 	"strings"
 
 	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/cloud-sql/removepublic"
 	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/cloud-sql/requiressl"
 	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/cloud-sql/updatepassword"
 	pb "github.com/googlecloudplatform/security-response-automation/compiled/sha/protos"
+	"github.com/googlecloudplatform/security-response-automation/providers/registry"
 	"github.com/googlecloudplatform/security-response-automation/providers/sha"
 	"github.com/googlecloudplatform/security-response-automation/services"
 )
 
+func init() {
+	registry.Register(&registry.Rule{
+		Provider: "sha",
+		Name:     "public_sql_instance",
+		Finding:  &Finding{},
+		New:      func(b []byte) (registry.Finding, error) { return New(b) },
+		Actions:  []string{"close_cloud_sql"},
+	})
+	registry.Register(&registry.Rule{
+		Provider: "sha",
+		Name:     "ssl_not_enforced",
+		Finding:  &Finding{},
+		New:      func(b []byte) (registry.Finding, error) { return New(b) },
+		Actions:  []string{"cloud_sql_require_ssl"},
+	})
+	registry.Register(&registry.Rule{
+		Provider: "sha",
+		Name:     "sql_no_root_password",
+		Finding:  &Finding{},
+		New:      func(b []byte) (registry.Finding, error) { return New(b) },
+		Actions:  []string{"cloud_sql_update_password"},
+	})
+}
+
 const (
 	// hostWildcard matches any MySQL host. Reference: https://cloud.google.com/sql/docs/mysql/users.
 	hostWildcard = "%"
@@ -75,3 +101,35 @@ func (f *Finding) RequireSSL() *requiressl.Values {
 		InstanceName: sha.Instance(f.SQLScanner.GetFinding().GetResourceName()),
 	}
 }
+
+// Values returns values for the given automation.
+func (f *Finding) Values(automation *registry.Automation) (string, interface{}, error) {
+	switch automation.Action {
+	case "close_cloud_sql":
+		values := f.RemovePublic()
+		values.DryRun = automation.Properties.DryRun
+		return values.ProjectID, values, nil
+	case "cloud_sql_require_ssl":
+		values := f.RequireSSL()
+		values.DryRun = automation.Properties.DryRun
+		return values.ProjectID, values, nil
+	case "cloud_sql_update_password":
+		values, err := f.UpdatePassword()
+		if err != nil {
+			return "", nil, err
+		}
+		values.DryRun = automation.Properties.DryRun
+		return values.ProjectID, values, nil
+	default:
+		return "", nil, fmt.Errorf("action %q not found", automation.Action)
+	}
+}
+
+// SCC returns the Security Command Center attributes of the finding.
+func (f *Finding) SCC() *registry.SCC {
+	return &registry.SCC{
+		Name:          f.SQLScanner.GetFinding().GetName(),
+		EventTime:     f.SQLScanner.GetFinding().GetEventTime(),
+		SecurityMarks: f.SQLScanner.GetFinding().GetSecurityMarks().GetMarks(),
+	}
+}
@@ -2,14 +2,33 @@ package storagescanner
 
 import (
 	"encoding/json"
+	"fmt"
 	"strings"
 
 	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/gcs/closebucket"
 	"github.com/googlecloudplatform/security-response-automation/cloudfunctions/gcs/enablebucketonlypolicy"
 	pb "github.com/googlecloudplatform/security-response-automation/compiled/sha/protos"
+	"github.com/googlecloudplatform/security-response-automation/providers/registry"
 	"github.com/googlecloudplatform/security-response-automation/providers/sha"
 )
 
+func init() {
+	registry.Register(&registry.Rule{
+		Provider: "sha",
+		Name:     "public_bucket_acl",
+		Finding:  &Finding{},
+		New:      func(b []byte) (registry.Finding, error) { return New(b) },
+		Actions:  []string{"close_bucket"},
+	})
+	registry.Register(&registry.Rule{
+		Provider: "sha",
+		Name:     "bucket_policy_only_disabled",
+		Finding:  &Finding{},
+		New:      func(b []byte) (registry.Finding, error) { return New(b) },
+		Actions:  []string{"enable_bucket_only_policy"},
+	})
+}
+
 // Finding represents this finding.
 type Finding struct {
 	StorageScanner *pb.StorageScanner
@@ -51,3 +70,28 @@ func (f *Finding) CloseBucket() *closebucket.Values {
 		BucketName: sha.BucketName(f.StorageScanner.GetFinding().GetResourceName()),
 	}
 }
+
+// Values returns values for the given automation.
+func (f *Finding) Values(automation *registry.Automation) (string, interface{}, error) {
+	switch automation.Action {
+	case "close_bucket":
+		values := f.CloseBucket()
+		values.DryRun = automation.Properties.DryRun
+		return values.ProjectID, values, nil
+	case "enable_bucket_only_policy":
+		values := f.EnableBucketOnlyPolicy()
+		values.DryRun = automation.Properties.DryRun
+		return values.ProjectID, values, nil
+	default:
+		return "", nil, fmt.Errorf("action %q not found", automation.Action)
+	}
+}
+
+// SCC returns the Security Command Center attributes of the finding.
+func (f *Finding) SCC() *registry.SCC {
+	return &registry.SCC{
+		Name:          f.StorageScanner.GetFinding().GetName(),
+		EventTime:     f.StorageScanner.GetFinding().GetEventTime(),
+		SecurityMarks: f.StorageScanner.GetFinding().GetSecurityMarks().GetMarks(),
+	}
+}
@@ -0,0 +1,265 @@
+package services
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"crypto/hmac"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+const (
+	// approvalPrefix is the object name prefix approval requests are stored under.
+	approvalPrefix = "approvals/"
+	// pendingPrefix is the object name prefix of the empty objects indexing pending requests, so
+	// sweeps do not load every request ever decided.
+	pendingPrefix = "pending-approvals/"
+)
+
+const (
+	// ApprovalPending is the status of a request waiting for a decision.
+	ApprovalPending = "PENDING"
+	// ApprovalApproved is the status of a request approved by an approver or by timeout.
+	ApprovalApproved = "APPROVED"
+	// ApprovalDenied is the status of a request denied by an approver.
+	ApprovalDenied = "DENIED"
+)
+
+// ApprovalClient contains minimum interface required by the approval service.
+type ApprovalClient interface {
+	WriteObject(ctx context.Context, bucketName, name string, b []byte) error
+	ReadObject(ctx context.Context, bucketName, name string) ([]byte, error)
+	ListObjects(ctx context.Context, bucketName, prefix string) ([]string, error)
+	ObjectGeneration(ctx context.Context, bucketName, name string) (int64, error)
+	ReadObjectGeneration(ctx context.Context, bucketName, name string, generation int64) ([]byte, error)
+	WriteObjectGeneration(ctx context.Context, bucketName, name string, b []byte, generation int64) (int64, error)
+	DeleteObject(ctx context.Context, bucketName, name string) error
+}
+
+var (
+	// ErrApprovalChanged is returned by Update when the request was changed since it was loaded.
+	ErrApprovalChanged = errors.New("approval request changed since it was loaded")
+	// ErrApprovalNotFound is returned by Load when no request has the given ID.
+	ErrApprovalNotFound = errors.New("approval request not found")
+)
+
+// ApprovalRequest holds an automation waiting for approval along with the message to publish
+// once approved.
+type ApprovalRequest struct {
+	ID        string
+	Action    string
+	ProjectID string
+	Rule      string
+	Finding   string
+	// Topic, Values and Attributes are the message published to run the automation.
+	Topic      string
+	Values     json.RawMessage
+	Attributes map[string]string
+	// Limits are counted when the automation is approved, DryRunValues are published instead of
+	// Values if one is exceeded.
+	Limits       []Limit
+	DryRunValues json.RawMessage
+	Status       string
+	Created      time.Time
+	// AutoApprove is when the request is approved if no decision was made. The request waits
+	// for a decision indefinitely if zero.
+	AutoApprove time.Time
+	Decided     time.Time
+	// DecidedBy is "approver" or "timeout".
+	DecidedBy string
+	// generation is the generation of the object the request was loaded from.
+	generation int64
+}
+
+// IsPending returns if the request is waiting for a decision.
+func (r *ApprovalRequest) IsPending() bool {
+	return r.Status == ApprovalPending
+}
+
+// Decide records the decision on the request. By is "approver" or "timeout".
+func (r *ApprovalRequest) Decide(approved bool, by string) {
+	r.Status = ApprovalDenied
+	if approved {
+		r.Status = ApprovalApproved
+	}
+	r.DecidedBy = by
+	r.Decided = time.Now().UTC()
+}
+
+// Reopen returns a decided request to pending.
+func (r *ApprovalRequest) Reopen() {
+	r.Status = ApprovalPending
+	r.DecidedBy = ""
+	r.Decided = time.Time{}
+}
+
+// Approvals stores automations waiting for approval in a GCS bucket and signs the links
+// approvers use to decide on them.
+type Approvals struct {
+	client ApprovalClient
+	bucket string
+	secret []byte
+	url    string
+}
+
+// NewApprovals returns an approval store writing to the given bucket. Links point to url and are
+// signed with secret.
+func NewApprovals(client ApprovalClient, bucket string, secret []byte, url string) *Approvals {
+	return &Approvals{client: client, bucket: bucket, secret: secret, url: url}
+}
+
+// NewApprovalRequest returns a pending request with a new ID.
+func NewApprovalRequest() *ApprovalRequest {
+	return &ApprovalRequest{ID: uuid.New().String(), Status: ApprovalPending, Created: time.Now().UTC()}
+}
+
+// Save stores the request, replacing any previous version.
+func (a *Approvals) Save(ctx context.Context, r *ApprovalRequest) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal approval request")
+	}
+	if err := a.client.WriteObject(ctx, a.bucket, approvalPrefix+r.ID, b); err != nil {
+		return errors.Wrapf(err, "failed to write approval request %q", r.ID)
+	}
+	return a.index(ctx, r)
+}
+
+// index adds the request to the pending index if it is pending and removes it otherwise.
+func (a *Approvals) index(ctx context.Context, r *ApprovalRequest) error {
+	if r.IsPending() {
+		if err := a.client.WriteObject(ctx, a.bucket, pendingPrefix+r.ID, nil); err != nil {
+			return errors.Wrapf(err, "failed to index approval request %q", r.ID)
+		}
+		return nil
+	}
+	if err := a.client.DeleteObject(ctx, a.bucket, pendingPrefix+r.ID); err != nil && errors.Cause(err) != storage.ErrObjectNotExist {
+		return errors.Wrapf(err, "failed to unindex approval request %q", r.ID)
+	}
+	return nil
+}
+
+// Create stores the request unless one with the same ID was already stored, and returns the
+// stored request.
+func (a *Approvals) Create(ctx context.Context, r *ApprovalRequest) (*ApprovalRequest, error) {
+	stored, err := a.Load(ctx, r.ID)
+	if err == nil && stored.IsPending() {
+		// The previous attempt may have failed to index it.
+		return stored, a.index(ctx, stored)
+	}
+	if err != ErrApprovalNotFound {
+		return stored, err
+	}
+	if err := a.Save(ctx, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Update stores the request only if it was not changed since it was loaded, returning
+// ErrApprovalChanged otherwise. Failing to update the pending index is only logged as the
+// request itself was stored, Pending skips decided requests left in the index.
+func (a *Approvals) Update(ctx context.Context, r *ApprovalRequest) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal approval request")
+	}
+	generation, err := a.client.WriteObjectGeneration(ctx, a.bucket, approvalPrefix+r.ID, b, r.generation)
+	if isStatus(err, http.StatusPreconditionFailed) {
+		return ErrApprovalChanged
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to write approval request %q", r.ID)
+	}
+	r.generation = generation
+	if err := a.index(ctx, r); err != nil {
+		log.Printf("%v", err)
+	}
+	return nil
+}
+
+// Load returns the request with the given ID, or ErrApprovalNotFound if there is none.
+func (a *Approvals) Load(ctx context.Context, id string) (*ApprovalRequest, error) {
+	generation, err := a.client.ObjectGeneration(ctx, a.bucket, approvalPrefix+id)
+	if errors.Cause(err) == storage.ErrObjectNotExist {
+		return nil, ErrApprovalNotFound
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read approval request %q", id)
+	}
+	b, err := a.client.ReadObjectGeneration(ctx, a.bucket, approvalPrefix+id, generation)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read approval request %q", id)
+	}
+	r := ApprovalRequest{generation: generation}
+	if err := json.Unmarshal(b, &r); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal approval request %q", id)
+	}
+	return &r, nil
+}
+
+// Pending returns the requests waiting for a decision, as listed by the pending index. Requests
+// decided since they were indexed are removed from the index.
+func (a *Approvals) Pending(ctx context.Context) ([]*ApprovalRequest, error) {
+	names, err := a.client.ListObjects(ctx, a.bucket, pendingPrefix)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list approval requests")
+	}
+	var pending []*ApprovalRequest
+	for _, name := range names {
+		id := name[len(pendingPrefix):]
+		r, err := a.Load(ctx, id)
+		if err == ErrApprovalNotFound {
+			r = &ApprovalRequest{ID: id}
+		} else if err != nil {
+			return nil, err
+		}
+		if r.IsPending() {
+			pending = append(pending, r)
+		} else if err := a.index(ctx, r); err != nil {
+			log.Printf("%v", err)
+		}
+	}
+	return pending, nil
+}
+
+// Link returns the signed link an approver follows to make the decision on the request. The
+// link carries a single token parameter of the form "<id>.<decision>.<signature>" so it is not
+// mangled when rendered by HTML templates.
+func (a *Approvals) Link(id, decision string) string {
+	return a.url + "?token=" + url.QueryEscape(strings.Join([]string{id, decision, a.sign(id, decision)}, "."))
+}
+
+// Verify returns if the signature was issued by Link for the request and decision.
+func (a *Approvals) Verify(id, decision, signature string) bool {
+	if len(a.secret) == 0 {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(a.sign(id, decision)))
+}
+
+// sign returns the hex HMAC-SHA256 signature of the decision on the request.
+func (a *Approvals) sign(id, decision string) string {
+	return strings.TrimPrefix(Sign(a.secret, []byte(id+":"+decision)), "sha256=")
+}
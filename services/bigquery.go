@@ -16,15 +16,29 @@ package services
 
 import (
 	"context"
+	"encoding/json"
+	"log"
+	"time"
 
 	"cloud.google.com/go/bigquery"
+	"github.com/google/uuid"
 	"github.com/pkg/errors"
 )
 
+const (
+	// OutcomeSuccess is the outcome of a remediation that completed.
+	OutcomeSuccess = "success"
+	// OutcomeFailure is the outcome of a remediation that returned an error.
+	OutcomeFailure = "failure"
+	// OutcomeSkipped is the outcome of a remediation not taken, such as on an exempt resource.
+	OutcomeSkipped = "skipped"
+)
+
 // BigQueryClient contains minimum interface required by the service.
 type BigQueryClient interface {
 	DatasetMetadata(ctx context.Context, projectID, datasetID string) (*bigquery.DatasetMetadata, error)
 	OverwriteDatasetMetadata(ctx context.Context, projectID, datasetID string, dm bigquery.DatasetMetadataToUpdate) (*bigquery.DatasetMetadata, error)
+	Insert(ctx context.Context, projectID, datasetID, tableID string, rows interface{}) error
 }
 
 // BigQuery service.
@@ -54,6 +68,28 @@ func (bq *BigQuery) RemoveDatasetPublicAccess(ctx context.Context, projectID, da
 	return nil
 }
 
+// DatasetAccess returns the dataset's access entries as roles mapped to their entities.
+func (bq *BigQuery) DatasetAccess(ctx context.Context, projectID, datasetID string) (Bindings, error) {
+	md, err := bq.client.DatasetMetadata(ctx, projectID, datasetID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get metadata for bigquery dataset %q in project %q", datasetID, projectID)
+	}
+	b := Bindings{}
+	for _, a := range md.Access {
+		b[string(a.Role)] = append(b[string(a.Role)], a.Entity)
+	}
+	return b, nil
+}
+
+// DatasetLabels returns the labels of the dataset.
+func (bq *BigQuery) DatasetLabels(ctx context.Context, projectID, datasetID string) (Labels, error) {
+	md, err := bq.client.DatasetMetadata(ctx, projectID, datasetID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get metadata for bigquery dataset %q in project %q", datasetID, projectID)
+	}
+	return md.Labels, nil
+}
+
 func removePublicUsers(metadata *bigquery.DatasetMetadata) []*bigquery.AccessEntry {
 	newAccesses := []*bigquery.AccessEntry{}
 	for _, a := range metadata.Access {
@@ -63,3 +99,143 @@ func removePublicUsers(metadata *bigquery.DatasetMetadata) []*bigquery.AccessEnt
 	}
 	return newAccesses
 }
+
+// Remediation is a record of an action taken on a resource by an automation.
+type Remediation struct {
+	// ID uniquely identifies the remediation, such as when rolling it back.
+	ID          string
+	FindingName string
+	Rule        string
+	Action      string
+	Resource    string
+	// Before and After hold the state of the resource before and after the action, when known.
+	Before interface{}
+	After  interface{}
+	DryRun bool
+	// Skipped holds why the action was not taken, if it was skipped.
+	Skipped string
+}
+
+// Exempt returns whether the labels exempt the resource from the remediation's action. If so
+// the remediation is recorded as skipped.
+func (r *Remediation) Exempt(labels Labels) bool {
+	reason, ok := labels.Exempt(r.Action)
+	if ok {
+		r.Skipped = "exempt by label " + reason
+	}
+	return ok
+}
+
+// RemediationRow is a remediation record as stored in BigQuery.
+type RemediationRow struct {
+	Time          time.Time `bigquery:"time"`
+	RemediationID string    `bigquery:"remediation_id"`
+	FindingName   string    `bigquery:"finding_name"`
+	Rule          string    `bigquery:"rule"`
+	Action        string    `bigquery:"action"`
+	Resource      string    `bigquery:"resource"`
+	Before        string    `bigquery:"before"`
+	After         string    `bigquery:"after"`
+	DryRun        bool      `bigquery:"dry_run"`
+	Outcome       string    `bigquery:"outcome"`
+	Error         string    `bigquery:"error"`
+	Reason        string    `bigquery:"reason"`
+}
+
+// Audit writes remediation records to a BigQuery table.
+//
+// A nil *Audit is valid and discards all records, so automations run unchanged when no audit
+// table is configured.
+type Audit struct {
+	client      BigQueryClient
+	projectID   string
+	datasetID   string
+	tableID     string
+	findingName string
+	rule        string
+}
+
+// NewAudit returns an audit trail writing to the given table.
+func NewAudit(client BigQueryClient, projectID, datasetID, tableID string) *Audit {
+	return &Audit{client: client, projectID: projectID, datasetID: datasetID, tableID: tableID}
+}
+
+// WithFinding returns a copy of the audit trail attributing its records to the given finding.
+func (a *Audit) WithFinding(name, rule string) *Audit {
+	if a == nil {
+		return nil
+	}
+	aa := *a
+	aa.findingName = name
+	aa.rule = rule
+	return &aa
+}
+
+// Remediation starts a record of the action on the resource.
+func (a *Audit) Remediation(action, resource string, dryRun bool) *Remediation {
+	r := &Remediation{ID: uuid.New().String(), Action: action, Resource: resource, DryRun: dryRun}
+	if a != nil {
+		r.FindingName = a.findingName
+		r.Rule = a.rule
+	}
+	return r
+}
+
+// Record writes the remediation along with its outcome. Failing to write the record is logged
+// rather than returned so the audit trail never blocks a remediation.
+func (a *Audit) Record(ctx context.Context, r *Remediation, err error) {
+	if a == nil {
+		return
+	}
+	row, rerr := remediationRow(r, err)
+	if rerr != nil {
+		log.Printf("failed to build remediation record for %q on %q: %q", r.Action, r.Resource, rerr)
+		return
+	}
+	if err := a.client.Insert(ctx, a.projectID, a.datasetID, a.tableID, row); err != nil {
+		log.Printf("failed to write remediation record for %q on %q: %q", r.Action, r.Resource, err)
+	}
+}
+
+func remediationRow(r *Remediation, err error) (*RemediationRow, error) {
+	before, berr := stateJSON(r.Before)
+	if berr != nil {
+		return nil, berr
+	}
+	after, aerr := stateJSON(r.After)
+	if aerr != nil {
+		return nil, aerr
+	}
+	row := &RemediationRow{
+		Time:          time.Now().UTC(),
+		RemediationID: r.ID,
+		FindingName:   r.FindingName,
+		Rule:          r.Rule,
+		Action:        r.Action,
+		Resource:      r.Resource,
+		Before:        before,
+		After:         after,
+		DryRun:        r.DryRun,
+		Outcome:       OutcomeSuccess,
+	}
+	switch {
+	case err != nil:
+		row.Outcome = OutcomeFailure
+		row.Error = err.Error()
+	case r.Skipped != "":
+		row.Outcome = OutcomeSkipped
+		row.Reason = r.Skipped
+	}
+	return row, nil
+}
+
+func stateJSON(state interface{}) (string, error) {
+	if state == nil {
+		return "", nil
+	}
+	b, err := json.Marshal(state)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to marshal state")
+	}
+	return string(b), nil
+}
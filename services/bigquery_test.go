@@ -16,10 +16,12 @@ package services
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"cloud.google.com/go/bigquery"
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/googlecloudplatform/security-response-automation/clients/stubs"
 )
 
@@ -84,3 +86,73 @@ func TestRemoveDatasetPublicAccess(t *testing.T) {
 		})
 	}
 }
+
+func TestAuditRecord(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name     string
+		before   interface{}
+		after    interface{}
+		dryRun   bool
+		err      error
+		expected *RemediationRow
+	}{
+		{
+			name:   "successful remediation",
+			before: map[string]bool{"allUsers": true},
+			after:  map[string]bool{},
+			expected: &RemediationRow{
+				FindingName: "organizations/1/sources/2/findings/3",
+				Rule:        "public_bucket_acl",
+				Action:      "close_bucket",
+				Resource:    "//storage.googleapis.com/bucket",
+				Before:      `{"allUsers":true}`,
+				After:       `{}`,
+				Outcome:     OutcomeSuccess,
+			},
+		},
+		{
+			name:   "failed dry run",
+			dryRun: true,
+			err:    errors.New("permission denied"),
+			expected: &RemediationRow{
+				FindingName: "organizations/1/sources/2/findings/3",
+				Rule:        "public_bucket_acl",
+				Action:      "close_bucket",
+				Resource:    "//storage.googleapis.com/bucket",
+				DryRun:      true,
+				Outcome:     OutcomeFailure,
+				Error:       "permission denied",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bqStub := &stubs.BigQueryStub{}
+			audit := NewAudit(bqStub, "audit-project", "sra_audit", "remediations").WithFinding("organizations/1/sources/2/findings/3", "public_bucket_acl")
+			r := audit.Remediation("close_bucket", "//storage.googleapis.com/bucket", tt.dryRun)
+			r.Before = tt.before
+			r.After = tt.after
+			audit.Record(ctx, r, tt.err)
+			if len(bqStub.InsertedRows) != 1 {
+				t.Fatalf("%v failed, got %d inserts want 1", tt.name, len(bqStub.InsertedRows))
+			}
+			row := bqStub.InsertedRows[0].(*RemediationRow)
+			if diff := cmp.Diff(tt.expected, row, cmpopts.IgnoreFields(RemediationRow{}, "Time", "RemediationID")); diff != "" {
+				t.Errorf("%v failed:%+v", tt.name, diff)
+			}
+			if row.RemediationID == "" || row.RemediationID != r.ID {
+				t.Errorf("%v failed, remediation ID got:%q want:%q", tt.name, row.RemediationID, r.ID)
+			}
+		})
+	}
+}
+
+func TestNilAudit(t *testing.T) {
+	var audit *Audit
+	r := audit.WithFinding("finding", "rule").Remediation("close_bucket", "bucket", false)
+	audit.Record(context.Background(), r, nil)
+	if r.Action != "close_bucket" || r.Resource != "bucket" {
+		t.Errorf("Remediation() = %+v, want action and resource set", r)
+	}
+}
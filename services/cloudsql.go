@@ -78,6 +78,18 @@ func (s *CloudSQL) InstanceDetails(ctx context.Context, projectID string, instan
 	return s.client.InstanceDetails(ctx, projectID, instance)
 }
 
+// InstanceLabels returns the user labels of the instance.
+func (s *CloudSQL) InstanceLabels(ctx context.Context, projectID, instance string) (Labels, error) {
+	i, err := s.client.InstanceDetails(ctx, projectID, instance)
+	if err != nil {
+		return nil, err
+	}
+	if i.Settings == nil {
+		return nil, nil
+	}
+	return i.Settings.UserLabels, nil
+}
+
 // ClosePublicAccess removes all valid IPs the from the authorized networks for an instance.
 func (s *CloudSQL) ClosePublicAccess(ctx context.Context, projectID, instance string, acls []*sqladmin.AclEntry) error {
 	var authorizedNetworks []*sqladmin.AclEntry
@@ -26,6 +26,8 @@ import (
 type CommandCenterClient interface {
 	AddSecurityMarks(context.Context, *crm.UpdateSecurityMarksRequest) (*crm.SecurityMarks, error)
 	SetFindingState(ctx context.Context, request *crm.SetFindingStateRequest) (*crm.Finding, error)
+	GetFinding(ctx context.Context, name string) (*crm.Finding, error)
+	ListFindings(ctx context.Context, parent, filter string) ([]*crm.Finding, error)
 }
 
 // CommandCenter service.
@@ -56,6 +58,23 @@ func (r *CommandCenter) AddSecurityMarks(ctx context.Context, serviceID string,
 	})
 }
 
+// RemoveSecurityMarks removes security marks from a finding or asset.
+func (r *CommandCenter) RemoveSecurityMarks(ctx context.Context, serviceID string, keys []string) (*crm.SecurityMarks, error) {
+	var paths []string
+	for _, k := range keys {
+		paths = append(paths, "marks."+k)
+	}
+	// Keys in the mask but not in the marks are removed.
+	return r.client.AddSecurityMarks(ctx, &crm.UpdateSecurityMarksRequest{
+		UpdateMask: &field_mask.FieldMask{
+			Paths: paths,
+		},
+		SecurityMarks: &crm.SecurityMarks{
+			Name: serviceID + "/securityMarks",
+		},
+	})
+}
+
 // SetInactive sets a finding as inactive
 func (r *CommandCenter) SetInactive(ctx context.Context, name string) (*crm.Finding, error) {
 	return r.client.SetFindingState(ctx, &crm.SetFindingStateRequest{
@@ -64,3 +83,23 @@ func (r *CommandCenter) SetInactive(ctx context.Context, name string) (*crm.Find
 		StartTime: timestamppb.Now(),
 	})
 }
+
+// SetActive sets a finding as active
+func (r *CommandCenter) SetActive(ctx context.Context, name string) (*crm.Finding, error) {
+	return r.client.SetFindingState(ctx, &crm.SetFindingStateRequest{
+		Name:      name,
+		State:     crm.Finding_ACTIVE,
+		StartTime: timestamppb.Now(),
+	})
+}
+
+// Findings returns the findings under the parent, such as organizations/123/sources/-, matching
+// the filter.
+func (r *CommandCenter) Findings(ctx context.Context, parent, filter string) ([]*crm.Finding, error) {
+	return r.client.ListFindings(ctx, parent, filter)
+}
+
+// Finding returns the finding with the given name.
+func (r *CommandCenter) Finding(ctx context.Context, name string) (*crm.Finding, error) {
+	return r.client.GetFinding(ctx, name)
+}
@@ -22,6 +22,7 @@ import (
 
 // ContainerClient holds the minimum interface required by the Container service.
 type ContainerClient interface {
+	GetCluster(context.Context, string, string, string) (*container.Cluster, error)
 	UpdateAddonsConfig(context.Context, string, string, string, *container.SetAddonsConfigRequest) (*container.Operation, error)
 }
 
@@ -46,3 +47,15 @@ func (c *Container) DisableDashboard(ctx context.Context, projectID, zone, clust
 	}
 	return c.client.UpdateAddonsConfig(ctx, projectID, zone, clusterID, req)
 }
+
+// DashboardDisabled returns whether the Kubernetes dashboard add-on is disabled on the cluster.
+func (c *Container) DashboardDisabled(ctx context.Context, projectID, zone, clusterID string) (bool, error) {
+	cl, err := c.client.GetCluster(ctx, projectID, zone, clusterID)
+	if err != nil {
+		return false, err
+	}
+	if cl.AddonsConfig == nil || cl.AddonsConfig.KubernetesDashboard == nil {
+		return false, nil
+	}
+	return cl.AddonsConfig.KubernetesDashboard.Disabled, nil
+}
@@ -0,0 +1,115 @@
+package services
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"cloud.google.com/go/functions/metadata"
+	"cloud.google.com/go/pubsub"
+	"github.com/pkg/errors"
+)
+
+// retryWindow is how long a message failing with a transient error is retried before it is
+// sent to the dead-letter topic.
+const retryWindow = time.Hour
+
+// DeadLetterMessage holds a message that could not be handled along with the reason.
+type DeadLetterMessage struct {
+	// Function is the Cloud Function that failed to handle the message.
+	Function string
+	// Topic is the topic the message was being published to, if publishing it failed.
+	Topic string `json:",omitempty"`
+	// Reason is the error the message failed with.
+	Reason string
+	// Transient is true if the message was retried until the retry window ran out.
+	Transient bool
+	Time      time.Time
+	// Data and Attributes hold the original message.
+	Data       []byte
+	Attributes map[string]string
+}
+
+// DeadLetter publishes messages that could not be handled to a dead-letter topic so they are
+// not lost and can be inspected or replayed.
+//
+// A nil *DeadLetter is valid and returns errors unchanged, so functions behave as before when no
+// dead-letter topic is configured.
+type DeadLetter struct {
+	pubsub *PubSub
+	topic  string
+	window time.Duration
+}
+
+// NewDeadLetter returns a dead letter service publishing to the given topic.
+func NewDeadLetter(ps *PubSub, topic string) *DeadLetter {
+	return &DeadLetter{pubsub: ps, topic: topic, window: retryWindow}
+}
+
+// Handle decides what happens to a message the function failed with. Transient errors are
+// returned so the message is retried while it is within the retry window. Permanent errors and
+// messages out of retries are published to the dead-letter topic and nil is returned so the
+// message is acknowledged. If the dead-letter topic cannot be reached the error is returned.
+func (d *DeadLetter) Handle(ctx context.Context, function string, m *pubsub.Message, err error) error {
+	if d == nil || err == nil {
+		return err
+	}
+	transient := Transient(err)
+	if transient && d.withinWindow(ctx) {
+		log.Printf("%s failed with transient error, retrying: %q", function, err)
+		return err
+	}
+	if perr := d.Publish(ctx, function, "", m, err); perr != nil {
+		log.Printf("failed to send message to dead-letter topic %q: %q", d.topic, perr)
+		return err
+	}
+	log.Printf("%s failed, sent message to dead-letter topic %q: %q", function, d.topic, err)
+	return nil
+}
+
+// Publish sends the message to the dead-letter topic. Topic is the topic the message was being
+// published to, if any.
+func (d *DeadLetter) Publish(ctx context.Context, function, topic string, m *pubsub.Message, reason error) error {
+	if d == nil {
+		return nil
+	}
+	b, err := json.Marshal(&DeadLetterMessage{
+		Function:   function,
+		Topic:      topic,
+		Reason:     reason.Error(),
+		Transient:  Transient(reason),
+		Time:       time.Now().UTC(),
+		Data:       m.Data,
+		Attributes: m.Attributes,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal dead letter")
+	}
+	_, err = d.pubsub.Publish(ctx, d.topic, &pubsub.Message{Data: b, Attributes: m.Attributes})
+	return err
+}
+
+// withinWindow returns if the event being handled is recent enough to be retried. Events without
+// a timestamp are always retried.
+func (d *DeadLetter) withinWindow(ctx context.Context) bool {
+	meta, err := metadata.FromContext(ctx)
+	if err != nil || meta.Timestamp.IsZero() {
+		return true
+	}
+	return time.Since(meta.Timestamp) < d.window
+}
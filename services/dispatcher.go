@@ -0,0 +1,92 @@
+package services
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+	"github.com/pkg/errors"
+)
+
+// Handler handles the messages published to a topic, as the Cloud Function triggered by the
+// topic would.
+type Handler func(context.Context, pubsub.Message) error
+
+// Dispatcher is a PubSubClient delivering messages to handlers within the process instead of
+// through Pub/Sub, so the filter, router and automations can run without any Pub/Sub topics.
+//
+// Messages are delivered once, before Publish returns. A handler failing fails Publish with the
+// handler's error, so transient errors reach the entry point that received the message from
+// Pub/Sub and it is redelivered. Messages to topics without a handler are published with the
+// fallback client if one is set.
+type Dispatcher struct {
+	mu       sync.RWMutex
+	handlers map[string]Handler
+	fallback PubSubClient
+	ids      int64
+}
+
+// NewDispatcher returns a dispatcher without handlers.
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{handlers: make(map[string]Handler)}
+}
+
+// Handle registers the handler of the messages published to the topic, replacing any
+// previously registered handler.
+func (d *Dispatcher) Handle(topicID string, h Handler) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.handlers[topicID] = h
+}
+
+// Fallback sets the client publishing messages to topics without a handler, such as topics
+// consumed outside of the process.
+func (d *Dispatcher) Fallback(client PubSubClient) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.fallback = client
+}
+
+// Publish calls the topic's handler with the message. An error is returned if the handler
+// fails, or if no handler is registered for the topic and there is no fallback client.
+func (d *Dispatcher) Publish(ctx context.Context, topicID string, message *pubsub.Message) (string, error) {
+	d.mu.RLock()
+	h, ok := d.handlers[topicID]
+	fallback := d.fallback
+	d.mu.RUnlock()
+	if !ok && fallback != nil {
+		return fallback.Publish(ctx, topicID, message)
+	}
+	if !ok {
+		return "", errors.Errorf("no handler for topic %q", topicID)
+	}
+	id := strconv.FormatInt(atomic.AddInt64(&d.ids, 1), 10)
+	attributes := make(map[string]string, len(message.Attributes))
+	for k, v := range message.Attributes {
+		attributes[k] = v
+	}
+	m := pubsub.Message{ID: id, Data: message.Data, Attributes: attributes, PublishTime: time.Now()}
+	if err := h(ctx, m); err != nil {
+		log.Printf("handler of topic %q failed on message %q: %q", topicID, id, err)
+		return id, errors.Wrapf(err, "handler of topic %q failed", topicID)
+	}
+	return id, nil
+}
@@ -0,0 +1,67 @@
+package services
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"cloud.google.com/go/pubsub"
+	"github.com/google/go-cmp/cmp"
+	"github.com/googlecloudplatform/security-response-automation/clients/stubs"
+	perrors "github.com/pkg/errors"
+)
+
+func TestDispatcher(t *testing.T) {
+	ctx := context.Background()
+	d := NewDispatcher()
+	ps := NewPubSub(d)
+	var got []string
+	failed := errors.New("automation failed")
+	// The first topic publishes to the second, as the router publishes to automations.
+	d.Handle("first", func(ctx context.Context, m pubsub.Message) error {
+		got = append(got, "first:"+string(m.Data)+":"+m.Attributes["finding"])
+		_, err := ps.Publish(ctx, "second", &pubsub.Message{Data: m.Data, Attributes: m.Attributes})
+		return err
+	})
+	d.Handle("second", func(ctx context.Context, m pubsub.Message) error {
+		got = append(got, "second:"+string(m.Data)+":"+m.Attributes["finding"])
+		return failed
+	})
+
+	id, err := ps.Publish(ctx, "first", &pubsub.Message{Data: []byte("data"), Attributes: map[string]string{"finding": "f"}})
+	if perrors.Cause(err) != failed {
+		t.Fatalf("Publish() = %v, want the handler's error %v", err, failed)
+	}
+	if id != "1" {
+		t.Errorf("Publish() = %q, want message ID %q", id, "1")
+	}
+	if diff := cmp.Diff([]string{"first:data:f", "second:data:f"}, got); diff != "" {
+		t.Errorf("dispatched messages mismatch (-want +got):\n%s", diff)
+	}
+	if _, err := ps.Publish(ctx, "unknown", &pubsub.Message{}); err == nil {
+		t.Errorf("Publish() to a topic without handler succeeded, want error")
+	}
+
+	fallback := &stubs.PubSubStub{}
+	d.Fallback(fallback)
+	if _, err := ps.Publish(ctx, "unknown", &pubsub.Message{Data: []byte("data")}); err != nil {
+		t.Fatalf("Publish() to a topic without handler failed: %q", err)
+	}
+	if fallback.PublishedTopic != "unknown" {
+		t.Errorf("message to a topic without handler was published to %q, want the fallback client", fallback.PublishedTopic)
+	}
+}
@@ -0,0 +1,157 @@
+package services
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	dns "google.golang.org/api/dns/v1"
+	"google.golang.org/api/googleapi"
+)
+
+// blockZonePrefix is prepended to the names of managed zones created to block domains.
+const blockZonePrefix = "sra-block-"
+
+// maxZoneNameLength is the longest name a managed zone may have.
+const maxZoneNameLength = 63
+
+// invalidZoneChars matches characters not allowed in a managed zone name.
+var invalidZoneChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// DNSClient contains minimum interface required by the DNS service.
+type DNSClient interface {
+	ManagedZone(context.Context, string, string) (*dns.ManagedZone, error)
+	CreateManagedZone(context.Context, string, *dns.ManagedZone) (*dns.ManagedZone, error)
+	PatchManagedZone(context.Context, string, string, *dns.ManagedZone) (*dns.Operation, error)
+}
+
+// DNS service.
+type DNS struct {
+	client DNSClient
+}
+
+// NewDNS returns a DNS service.
+func NewDNS(client DNSClient) *DNS {
+	return &DNS{client: client}
+}
+
+// BlockDomain prevents the given networks from resolving a domain.
+//
+// An empty private managed zone for the domain is bound to the networks. Lookups of the domain or
+// any of its subdomains from within these networks are answered by the zone, which has no records,
+// rather than by public DNS. If the zone already exists any missing networks are added to it.
+func (d *DNS) BlockDomain(ctx context.Context, projectID, domain string, networks []string) error {
+	name := BlockZoneName(domain)
+	zone, err := d.client.ManagedZone(ctx, projectID, name)
+	if err != nil {
+		if e, ok := err.(*googleapi.Error); !ok || e.Code != 404 {
+			return errors.Wrapf(err, "failed getting managed zone: %q", name)
+		}
+		log.Printf("adding a new managed zone %q to block %q", name, domain)
+		_, err := d.client.CreateManagedZone(ctx, projectID, &dns.ManagedZone{
+			Name:        name,
+			DnsName:     dnsName(domain),
+			Description: "Block " + domain + " by Security Response Automation",
+			Visibility:  "private",
+			PrivateVisibilityConfig: &dns.ManagedZonePrivateVisibilityConfig{
+				Networks: zoneNetworks(nil, networks),
+			},
+		})
+		return err
+	}
+	if zone.DnsName != dnsName(domain) {
+		return fmt.Errorf("managed zone %q is for %q, not %q", name, zone.DnsName, dnsName(domain))
+	}
+	var bound []*dns.ManagedZonePrivateVisibilityConfigNetwork
+	if zone.PrivateVisibilityConfig != nil {
+		bound = zone.PrivateVisibilityConfig.Networks
+	}
+	merged := zoneNetworks(bound, networks)
+	if len(merged) == len(bound) {
+		log.Printf("managed zone %q already blocks %q for all networks", name, domain)
+		return nil
+	}
+	log.Printf("existing managed zone %q found, adding networks %q", name, networks)
+	if _, err := d.client.PatchManagedZone(ctx, projectID, name, &dns.ManagedZone{
+		PrivateVisibilityConfig: &dns.ManagedZonePrivateVisibilityConfig{Networks: merged},
+	}); err != nil {
+		return errors.Wrapf(err, "failed updating managed zone: %q", name)
+	}
+	return nil
+}
+
+// BlockedNetworks returns the networks the domain is blocked for, none if it has no block zone.
+func (d *DNS) BlockedNetworks(ctx context.Context, projectID, domain string) ([]string, error) {
+	name := BlockZoneName(domain)
+	zone, err := d.client.ManagedZone(ctx, projectID, name)
+	if err != nil {
+		if e, ok := err.(*googleapi.Error); ok && e.Code == 404 {
+			return []string{}, nil
+		}
+		return nil, errors.Wrapf(err, "failed getting managed zone: %q", name)
+	}
+	networks := []string{}
+	if zone.DnsName != dnsName(domain) || zone.PrivateVisibilityConfig == nil {
+		return networks, nil
+	}
+	for _, n := range zone.PrivateVisibilityConfig.Networks {
+		networks = append(networks, n.NetworkUrl)
+	}
+	return networks, nil
+}
+
+// BlockZoneName returns the name of the managed zone used to block the given domain.
+//
+// Zone names only allow lowercase letters, digits and dashes, so the readable part of the name
+// is followed by a hash of the domain to keep domains such as evil.example.com and
+// evil-example.com, or long domains sharing a prefix, from mapping to the same zone.
+func BlockZoneName(domain string) string {
+	sum := sha256.Sum256([]byte(dnsName(domain)))
+	suffix := "-" + hex.EncodeToString(sum[:])[:8]
+	label := invalidZoneChars.ReplaceAllString(strings.TrimSuffix(dnsName(domain), "."), "-")
+	if max := maxZoneNameLength - len(blockZonePrefix) - len(suffix); len(label) > max {
+		label = label[:max]
+	}
+	return blockZonePrefix + strings.Trim(label, "-") + suffix
+}
+
+// dnsName returns the fully qualified, lowercase DNS name of the domain.
+func dnsName(domain string) string {
+	return strings.ToLower(strings.TrimSuffix(domain, ".")) + "."
+}
+
+// zoneNetworks appends the network URLs to the existing networks, skipping any already present.
+func zoneNetworks(existing []*dns.ManagedZonePrivateVisibilityConfigNetwork, networks []string) []*dns.ManagedZonePrivateVisibilityConfigNetwork {
+	out := append([]*dns.ManagedZonePrivateVisibilityConfigNetwork{}, existing...)
+	seen := map[string]bool{}
+	for _, n := range existing {
+		seen[n.NetworkUrl] = true
+	}
+	for _, n := range networks {
+		if seen[n] {
+			continue
+		}
+		seen[n] = true
+		out = append(out, &dns.ManagedZonePrivateVisibilityConfigNetwork{NetworkUrl: n})
+	}
+	return out
+}
@@ -0,0 +1,38 @@
+package services
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBlockZoneName(t *testing.T) {
+	long := strings.Repeat("a", 70) + ".com"
+	names := map[string]string{}
+	for _, domain := range []string{"evil.example.com", "evil-example.com", "Evil.Example.com.", long, long[1:]} {
+		name := BlockZoneName(domain)
+		if len(name) > maxZoneNameLength || !strings.HasPrefix(name, blockZonePrefix) || invalidZoneChars.MatchString(name) {
+			t.Errorf("BlockZoneName(%q) = %q, not a valid zone name", domain, name)
+		}
+		if other, ok := names[name]; ok && dnsName(other) != dnsName(domain) {
+			t.Errorf("BlockZoneName(%q) = BlockZoneName(%q) = %q", domain, other, name)
+		}
+		names[name] = domain
+	}
+	if len(names) != 4 {
+		t.Errorf("got %d zone names for 4 domains: %v", len(names), names)
+	}
+}
@@ -46,12 +46,18 @@ type EmailResponse struct {
 
 // Email is the service used to send emails.
 type Email struct {
-	service EmailClient
+	service   EmailClient
+	templates string
 }
 
 // NewEmail creates a new email service.
 func NewEmail(service EmailClient) *Email {
-	return &Email{service: service}
+	return &Email{service: service, templates: templatesPath}
+}
+
+// NewEmailWithTemplates creates a new email service rendering templates from the given directory.
+func NewEmailWithTemplates(service EmailClient, templates string) *Email {
+	return &Email{service: service, templates: templates}
 }
 
 // Send will send an email.
@@ -61,7 +67,7 @@ func (m *Email) Send(subject, from, body string, to []string) (*rest.Response, e
 
 // RenderTemplate parses the content based on template.
 func (m *Email) RenderTemplate(templateName string, templateContent interface{}) (string, error) {
-	fileName := filepath.Join(templatesPath, templateName)
+	fileName := filepath.Join(m.templates, templateName)
 	file, err := template.ParseGlob(fileName)
 
 	if err != nil {
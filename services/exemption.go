@@ -0,0 +1,63 @@
+package services
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"regexp"
+	"strings"
+)
+
+const (
+	// ExemptLabel exempts a resource from every automation when set to "true".
+	ExemptLabel = "sra-exempt"
+	// ExemptActionsLabel exempts a resource from the listed automations. Label values can not
+	// hold commas so actions may be separated by any character not used in action names, such
+	// as "close_bucket-enable_bucket_only_policy".
+	ExemptActionsLabel = "sra-exempt-actions"
+)
+
+// exemptTerm matches exemption labels written as key=value pairs in the description of
+// resources that do not support labels, such as firewall rules.
+var exemptTerm = regexp.MustCompile(`(?:^|\s)(sra-exempt(?:-actions)?)=(\S+)`)
+
+// Labels holds the labels of a resource.
+type Labels map[string]string
+
+// Exempt returns the label exempting the resource from the action, if any.
+func (l Labels) Exempt(action string) (string, bool) {
+	if v, ok := l[ExemptLabel]; ok && strings.EqualFold(v, "true") {
+		return ExemptLabel + "=" + v, true
+	}
+	v := l[ExemptActionsLabel]
+	for _, a := range strings.FieldsFunc(v, isNotActionRune) {
+		if a == action {
+			return ExemptActionsLabel + "=" + v, true
+		}
+	}
+	return "", false
+}
+
+func isNotActionRune(r rune) bool {
+	return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_')
+}
+
+// descriptionLabels returns the exemption labels written in a resource's description.
+func descriptionLabels(description string) Labels {
+	l := Labels{}
+	for _, m := range exemptTerm.FindAllStringSubmatch(description, -1) {
+		l[m[1]] = m[2]
+	}
+	return l
+}
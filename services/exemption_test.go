@@ -0,0 +1,50 @@
+package services
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import "testing"
+
+func TestExempt(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		labels Labels
+		action string
+		exempt bool
+	}{
+		{name: "no labels", action: "close_bucket"},
+		{name: "exempt", labels: Labels{"sra-exempt": "true"}, action: "close_bucket", exempt: true},
+		{name: "exempt false", labels: Labels{"sra-exempt": "false"}, action: "close_bucket"},
+		{name: "listed action", labels: Labels{"sra-exempt-actions": "close_bucket"}, action: "close_bucket", exempt: true},
+		{name: "one of actions", labels: Labels{"sra-exempt-actions": "remove_public_ip-gce_quarantine_instance"}, action: "gce_quarantine_instance", exempt: true},
+		{name: "other action", labels: Labels{"sra-exempt-actions": "close_bucket"}, action: "enable_bucket_only_policy"},
+		{name: "prefix of action", labels: Labels{"sra-exempt-actions": "close"}, action: "close_bucket"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, got := tt.labels.Exempt(tt.action); got != tt.exempt {
+				t.Errorf("Exempt(%q) = %t, want %t", tt.action, got, tt.exempt)
+			}
+		})
+	}
+}
+
+func TestDescriptionLabels(t *testing.T) {
+	l := descriptionLabels("Public web servers. sra-exempt-actions=remediate_firewall,close_bucket")
+	if _, ok := l.Exempt("remediate_firewall"); !ok {
+		t.Errorf("descriptionLabels() = %q, want remediate_firewall exempt", l)
+	}
+	if l := descriptionLabels("not-sra-exempt=true"); len(l) != 0 {
+		t.Errorf("descriptionLabels() = %q, want no labels", l)
+	}
+}
@@ -16,8 +16,12 @@ package services
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"log"
+	"regexp"
+	"strings"
 
 	"github.com/pkg/errors"
 	compute "google.golang.org/api/compute/v1"
@@ -27,6 +31,12 @@ import (
 // sshBlockName is the firewall rule name created when blocking SSH.
 const sshBlockName = "automatic-ssh-block"
 
+// maxRuleNameLength is the longest name a firewall rule may have.
+const maxRuleNameLength = 63
+
+// extractNetworkProject is used to extract the project ID from a network URL.
+var extractNetworkProject = regexp.MustCompile(`/projects/([^/]+)/global/networks/`)
+
 // FirewallClient holds the minimum interface required by the firewall service.
 type FirewallClient interface {
 	InsertFirewallRule(context.Context, string, *compute.Firewall) (*compute.Operation, error)
@@ -81,6 +91,71 @@ func (f *Firewall) BlockSSH(ctx context.Context, projectID string, sourceRanges
 	return nil
 }
 
+// DenyAllForTag will add firewall rules denying all ingress and egress traffic on the network
+// for instances with the given tag. Existing rules are left untouched.
+//
+// Firewall rules belong to the network's project, which is the Shared VPC host project rather
+// than the instance's project for shared networks, so the rules are added to the project in the
+// network's URL. The given project is only used if the URL has none.
+func (f *Firewall) DenyAllForTag(ctx context.Context, projectID, network, tag string) error {
+	projectID = networkProject(network, projectID)
+	for _, direction := range []string{"INGRESS", "EGRESS"} {
+		name := DenyAllRuleName(tag, network, direction)
+		_, err := f.FirewallRule(ctx, projectID, name)
+		if err == nil {
+			log.Printf("firewall rule %q already exists in %q", name, projectID)
+			continue
+		}
+		if e, ok := errors.Cause(err).(*googleapi.Error); !ok || e.Code != 404 {
+			return errors.Wrapf(err, "failed getting firewall rule: %q", name)
+		}
+		fw := &compute.Firewall{
+			Name:            name,
+			Description:     fmt.Sprintf("Deny all %s traffic for instances tagged %q by Security Response Automation", strings.ToLower(direction), tag),
+			Network:         network,
+			Direction:       direction,
+			Priority:        0,
+			Denied:          []*compute.FirewallDenied{{IPProtocol: "all"}},
+			TargetTags:      []string{tag},
+			ForceSendFields: []string{"Priority"},
+		}
+		if direction == "INGRESS" {
+			fw.SourceRanges = []string{"0.0.0.0/0"}
+		} else {
+			fw.DestinationRanges = []string{"0.0.0.0/0"}
+		}
+		if err := f.addFirewallRule(ctx, projectID, fw); err != nil {
+			return errors.Wrapf(err, "failed to add firewall rule: %q", name)
+		}
+		log.Printf("added firewall rule %q in %q", name, projectID)
+	}
+	return nil
+}
+
+// DenyAllRuleName returns the name of the firewall rule denying traffic in the given direction
+// for the tag on the network.
+//
+// Rule names are limited to 63 characters, so the readable part of the name is followed by a
+// hash of the tag, network and direction to keep long tags or networks sharing a prefix from
+// mapping to the same rule.
+func DenyAllRuleName(tag, network, direction string) string {
+	sum := sha256.Sum256([]byte(tag + "\n" + network + "\n" + direction))
+	suffix := "-" + hex.EncodeToString(sum[:])[:8]
+	name := fmt.Sprintf("%s-deny-%s-%s", tag, strings.ToLower(direction), network[strings.LastIndex(network, "/")+1:])
+	if max := maxRuleNameLength - len(suffix); len(name) > max {
+		name = name[:max]
+	}
+	return strings.TrimRight(name, "-") + suffix
+}
+
+// networkProject returns the project of the network URL, or the given project if it has none.
+func networkProject(network, projectID string) string {
+	if m := extractNetworkProject.FindStringSubmatch(network); m != nil {
+		return m[1]
+	}
+	return projectID
+}
+
 // addFirewallRule will add a firewall rule.
 func (f *Firewall) addFirewallRule(ctx context.Context, projectID string, fw *compute.Firewall) error {
 	op, err := f.client.InsertFirewallRule(ctx, projectID, fw)
@@ -115,6 +190,25 @@ func (f *Firewall) UpdateFirewallRuleSourceRange(ctx context.Context, projectID
 	return nil
 }
 
+// RestoreFirewallRule restores the enabled state, source ranges and source tags of the firewall
+// rule. Empty fields are sent too, so ranges added to a rule matching only tags are removed.
+func (f *Firewall) RestoreFirewallRule(ctx context.Context, projectID string, rule *compute.Firewall) error {
+	op, err := f.client.PatchFirewallRule(ctx, projectID, rule.Name, &compute.Firewall{
+		Name:            rule.Name,
+		Disabled:        rule.Disabled,
+		SourceRanges:    rule.SourceRanges,
+		SourceTags:      rule.SourceTags,
+		ForceSendFields: []string{"Disabled", "SourceRanges", "SourceTags"},
+	})
+	if err != nil {
+		return err
+	}
+	if errs := f.WaitGlobal(projectID, op); len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
 // DeleteFirewallRule delete the firewall rule.
 func (f *Firewall) DeleteFirewallRule(ctx context.Context, projectID string, ruleID string) (*compute.Operation, error) {
 	return f.client.DeleteFirewallRule(ctx, projectID, ruleID)
@@ -125,6 +219,16 @@ func (f *Firewall) FirewallRule(ctx context.Context, projectID string, ruleID st
 	return f.client.FirewallRule(ctx, projectID, ruleID)
 }
 
+// RuleLabels returns the exemption labels of the firewall rule. Firewall rules do not support
+// labels so they are read from key=value pairs in the rule's description.
+func (f *Firewall) RuleLabels(ctx context.Context, projectID string, ruleID string) (Labels, error) {
+	rule, err := f.client.FirewallRule(ctx, projectID, ruleID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get firewall rule %q", ruleID)
+	}
+	return descriptionLabels(rule.Description), nil
+}
+
 // WaitGlobal will wait for the global operation to complete.
 func (f *Firewall) WaitGlobal(project string, op *compute.Operation) []error {
 	return f.client.WaitGlobal(project, op)
@@ -30,6 +30,7 @@ import (
 type ComputeClient interface {
 	DiskInsert(context.Context, string, string, *compute.Disk) (*compute.Operation, error)
 	CreateSnapshot(context.Context, string, string, string, *compute.Snapshot) (*compute.Operation, error)
+	AddAccessConfig(ctx context.Context, project, zone, instance, networkInterface string, accessConfig *compute.AccessConfig) (*compute.Operation, error)
 	DeleteAccessConfig(ctx context.Context, project, zone, instance, accessConfig, networkInterface string) (*compute.Operation, error)
 	DeleteDiskSnapshot(context.Context, string, string) (*compute.Operation, error)
 	DeleteInstance(context.Context, string, string, string) (*compute.Operation, error)
@@ -37,12 +38,20 @@ type ComputeClient interface {
 	ListDisks(context.Context, string, string) (*compute.DiskList, error)
 	ListProjectSnapshots(context.Context, string) (*compute.SnapshotList, error)
 	SetLabels(context.Context, string, string, *compute.GlobalSetLabelsRequest) (*compute.Operation, error)
+	SetMetadata(ctx context.Context, project, zone, instance string, metadata *compute.Metadata) (*compute.Operation, error)
+	SetTags(ctx context.Context, project, zone, instance string, tags *compute.Tags) (*compute.Operation, error)
 	StartInstance(context.Context, string, string, string) (*compute.Operation, error)
 	StopInstance(context.Context, string, string, string) (*compute.Operation, error)
 	WaitGlobal(string, *compute.Operation) []error
 	WaitZone(string, string, *compute.Operation) []error
 }
 
+// AccessConfig is an access config attached to a network interface of an instance.
+type AccessConfig struct {
+	NetworkInterface string
+	Config           *compute.AccessConfig
+}
+
 // Host service.
 type Host struct {
 	client ComputeClient
@@ -69,7 +78,7 @@ func (h *Host) DeleteDiskSnapshot(ctx context.Context, projectID, snapshot strin
 func (h *Host) RemoveExternalIPs(ctx context.Context, project, zone, instance string) error {
 	i, err := h.client.GetInstance(ctx, project, zone, instance)
 	if err != nil {
-		return fmt.Errorf("failed to get instance: %q", err)
+		return errors.Wrap(err, "failed to get instance")
 	}
 
 	for _, ni := range i.NetworkInterfaces {
@@ -80,10 +89,10 @@ func (h *Host) RemoveExternalIPs(ctx context.Context, project, zone, instance st
 
 			op, err := h.client.DeleteAccessConfig(ctx, project, zone, instance, ac.Name, ni.Name)
 			if err != nil {
-				return fmt.Errorf("failed to remove external ip: %q", err)
+				return errors.Wrap(err, "failed to remove external ip")
 			}
 			if errs := h.WaitZone(project, zone, op); len(errs) > 0 {
-				return fmt.Errorf("failed to waiting instance. Errors[0]: %s", errs[0])
+				return errors.Wrap(errs[0], "failed to waiting instance")
 			}
 		}
 	}
@@ -91,6 +100,163 @@ func (h *Host) RemoveExternalIPs(ctx context.Context, project, zone, instance st
 	return nil
 }
 
+// ExternalAccessConfigs returns the access configs giving the instance external IP addresses.
+func (h *Host) ExternalAccessConfigs(ctx context.Context, project, zone, instance string) ([]AccessConfig, error) {
+	i, err := h.client.GetInstance(ctx, project, zone, instance)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get instance")
+	}
+	configs := []AccessConfig{}
+	for _, ni := range i.NetworkInterfaces {
+		for _, ac := range ni.AccessConfigs {
+			if ac.Type != "ONE_TO_ONE_NAT" {
+				continue
+			}
+			configs = append(configs, AccessConfig{NetworkInterface: ni.Name, Config: ac})
+		}
+	}
+	return configs, nil
+}
+
+// RestoreAccessConfigs adds the access configs back to the instance. Network interfaces that
+// already have an access config are left untouched.
+func (h *Host) RestoreAccessConfigs(ctx context.Context, project, zone, instance string, configs []AccessConfig) error {
+	i, err := h.client.GetInstance(ctx, project, zone, instance)
+	if err != nil {
+		return errors.Wrap(err, "failed to get instance")
+	}
+	existing := map[string]bool{}
+	for _, ni := range i.NetworkInterfaces {
+		existing[ni.Name] = len(ni.AccessConfigs) > 0
+	}
+	for _, c := range configs {
+		if existing[c.NetworkInterface] {
+			continue
+		}
+		ac := &compute.AccessConfig{
+			Name:        c.Config.Name,
+			Type:        c.Config.Type,
+			NatIP:       c.Config.NatIP,
+			NetworkTier: c.Config.NetworkTier,
+		}
+		op, err := h.client.AddAccessConfig(ctx, project, zone, instance, c.NetworkInterface, ac)
+		if err != nil {
+			return errors.Wrap(err, "failed to add access config")
+		}
+		if errs := h.WaitZone(project, zone, op); len(errs) > 0 {
+			return errors.Wrap(errs[0], "failed to waiting instance")
+		}
+		existing[c.NetworkInterface] = true
+	}
+	return nil
+}
+
+// InstanceNetworks returns the URLs of the networks the instance is attached to.
+func (h *Host) InstanceNetworks(ctx context.Context, project, zone, instance string) ([]string, error) {
+	i, err := h.client.GetInstance(ctx, project, zone, instance)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get instance")
+	}
+	networks := []string{}
+	for _, ni := range i.NetworkInterfaces {
+		networks = append(networks, ni.Network)
+	}
+	return networks, nil
+}
+
+// Instance returns the given instance.
+func (h *Host) Instance(ctx context.Context, project, zone, instance string) (*compute.Instance, error) {
+	i, err := h.client.GetInstance(ctx, project, zone, instance)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get instance")
+	}
+	return i, nil
+}
+
+// InstanceLabels returns the labels of the instance. Network tags can not hold values so a
+// network tag named after ExemptLabel is returned as that label set to "true".
+func (h *Host) InstanceLabels(ctx context.Context, project, zone, instance string) (Labels, error) {
+	i, err := h.Instance(ctx, project, zone, instance)
+	if err != nil {
+		return nil, err
+	}
+	l := Labels{}
+	for k, v := range i.Labels {
+		l[k] = v
+	}
+	if i.Tags == nil {
+		return l, nil
+	}
+	for _, t := range i.Tags.Items {
+		if t == ExemptLabel {
+			l[ExemptLabel] = "true"
+		}
+	}
+	return l, nil
+}
+
+// SetInstanceTags replaces the network tags of the instance.
+func (h *Host) SetInstanceTags(ctx context.Context, project, zone string, instance *compute.Instance, tags []string) error {
+	fingerprint := ""
+	if instance.Tags != nil {
+		fingerprint = instance.Tags.Fingerprint
+	}
+	op, err := h.client.SetTags(ctx, project, zone, instance.Name, &compute.Tags{Items: tags, Fingerprint: fingerprint})
+	if err != nil {
+		return errors.Wrap(err, "failed to set tags")
+	}
+	if errs := h.WaitZone(project, zone, op); len(errs) > 0 {
+		return errors.Wrap(errs[0], "failed to waiting instance")
+	}
+	return nil
+}
+
+// SetInstanceMetadata sets a metadata item on the instance, keeping any other items.
+func (h *Host) SetInstanceMetadata(ctx context.Context, project, zone string, instance *compute.Instance, key, value string) error {
+	md := &compute.Metadata{}
+	if instance.Metadata != nil {
+		md.Fingerprint = instance.Metadata.Fingerprint
+		for _, item := range instance.Metadata.Items {
+			if item.Key != key {
+				md.Items = append(md.Items, item)
+			}
+		}
+	}
+	md.Items = append(md.Items, &compute.MetadataItems{Key: key, Value: &value})
+	op, err := h.client.SetMetadata(ctx, project, zone, instance.Name, md)
+	if err != nil {
+		return errors.Wrap(err, "failed to set metadata")
+	}
+	if errs := h.WaitZone(project, zone, op); len(errs) > 0 {
+		return errors.Wrap(errs[0], "failed to waiting instance")
+	}
+	return nil
+}
+
+// RemoveInstanceMetadata removes a metadata key of the instance, keeping all others.
+func (h *Host) RemoveInstanceMetadata(ctx context.Context, project, zone string, instance *compute.Instance, key string) error {
+	if instance.Metadata == nil {
+		return nil
+	}
+	md := &compute.Metadata{Fingerprint: instance.Metadata.Fingerprint}
+	for _, item := range instance.Metadata.Items {
+		if item.Key != key {
+			md.Items = append(md.Items, item)
+		}
+	}
+	if len(md.Items) == len(instance.Metadata.Items) {
+		return nil
+	}
+	op, err := h.client.SetMetadata(ctx, project, zone, instance.Name, md)
+	if err != nil {
+		return errors.Wrap(err, "failed to set metadata")
+	}
+	if errs := h.WaitZone(project, zone, op); len(errs) > 0 {
+		return errors.Wrap(errs[0], "failed to waiting instance")
+	}
+	return nil
+}
+
 // DiskSnapshot gets a snapshot by name associated with a given disk.
 func (h *Host) DiskSnapshot(ctx context.Context, snapshotName, projectID string, disk *compute.Disk) (*compute.Snapshot, error) {
 	snapshots, err := h.ListProjectSnapshots(ctx, projectID)
@@ -113,7 +279,7 @@ func (h *Host) CreateDiskSnapshot(ctx context.Context, projectID, zone, disk, na
 		CreationTimestamp: time.Now().Format(time.RFC3339),
 	})
 	if err != nil {
-		return fmt.Errorf("failed to create snapshot: %q", err)
+		return errors.Wrap(err, "failed to create snapshot")
 	}
 	if errs := h.WaitZone(projectID, zone, op); len(errs) > 0 {
 		return errors.Wrap(errs[0], "failed waiting: first error")
@@ -128,7 +294,7 @@ func (h *Host) CopyDiskSnapshot(ctx context.Context, srcProjectID, dstProjectID,
 		SourceSnapshot: fmt.Sprintf("projects/%s/global/snapshots/%s", srcProjectID, name),
 	})
 	if err != nil {
-		return fmt.Errorf("failed to copy snapshot: %q", err)
+		return errors.Wrap(err, "failed to copy snapshot")
 	}
 	if errs := h.WaitZone(dstProjectID, zone, op); len(errs) > 0 {
 		return errors.Wrap(errs[0], "failed waiting: first error")
@@ -145,7 +311,7 @@ func (h *Host) ListProjectSnapshots(ctx context.Context, projectID string) (*com
 func (h *Host) ListInstanceDisks(ctx context.Context, projectID, zone, instance string) ([]*compute.Disk, error) {
 	ds, err := h.client.ListDisks(ctx, projectID, zone)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list disks: %q", err)
+		return nil, errors.Wrap(err, "failed to list disks")
 	}
 	dl := []*compute.Disk{}
 	for _, d := range ds.Items {
@@ -203,10 +369,10 @@ func (h *Host) diskBelongsToInstance(disks *compute.Disk, instance string) bool
 func (h *Host) StopInstance(ctx context.Context, projectID, zone, instance string) error {
 	op, err := h.client.StopInstance(ctx, projectID, zone, instance)
 	if err != nil {
-		return fmt.Errorf("failed to stop instance: %q", err)
+		return errors.Wrap(err, "failed to stop instance")
 	}
 	if errs := h.WaitZone(projectID, zone, op); len(errs) > 0 {
-		return fmt.Errorf("failed to waiting instance. Errors[0]: %s", errs[0])
+		return errors.Wrap(errs[0], "failed to waiting instance")
 	}
 	return nil
 }
@@ -215,10 +381,10 @@ func (h *Host) StopInstance(ctx context.Context, projectID, zone, instance strin
 func (h *Host) StartInstance(ctx context.Context, projectID, zone, instance string) error {
 	op, err := h.client.StartInstance(ctx, projectID, zone, instance)
 	if err != nil {
-		return fmt.Errorf("failed to start instance: %q", err)
+		return errors.Wrap(err, "failed to start instance")
 	}
 	if errs := h.WaitZone(projectID, zone, op); len(errs) > 0 {
-		return fmt.Errorf("failed to waiting instance. Errors[0]: %s", errs[0])
+		return errors.Wrap(errs[0], "failed to waiting instance")
 	}
 	return nil
 }
@@ -0,0 +1,264 @@
+package services
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/api/googleapi"
+)
+
+// ClaimLease is how long a claim holds before another delivery may take it over, in case the
+// function holding it crashed before releasing it. It is longer than the longest Cloud Function
+// timeout so a running action is never taken over.
+const ClaimLease = 10 * time.Minute
+
+// IdempotencyStore records claimed keys so an action is taken once per finding.
+type IdempotencyStore interface {
+	// Claim records the key until expires and returns false if it was already claimed. A claim
+	// that was not completed and expired before now is taken over.
+	Claim(ctx context.Context, key string, now, expires time.Time) (bool, error)
+	// Complete marks the key's claim as done so it never expires.
+	Complete(ctx context.Context, key string) error
+	// Release removes the key so it can be claimed again.
+	Release(ctx context.Context, key string) error
+}
+
+// FirestoreClient contains minimum interface required by the Firestore idempotency store.
+type FirestoreClient interface {
+	CreateDocument(ctx context.Context, collection, id string, fields map[string]string) error
+	GetDocumentVersion(ctx context.Context, collection, id string) (map[string]string, string, error)
+	SetDocument(ctx context.Context, collection, id string, fields map[string]string) error
+	DeleteDocument(ctx context.Context, collection, id string) error
+	DeleteDocumentVersion(ctx context.Context, collection, id, version string) error
+}
+
+// FirestoreStore is an idempotency store keeping one document per key in a Firestore
+// collection. Documents are created only if absent, and expired claims are deleted only if
+// unchanged since read, so concurrent claims are safe.
+type FirestoreStore struct {
+	client     FirestoreClient
+	collection string
+}
+
+// NewFirestoreStore returns an idempotency store writing to the given collection.
+func NewFirestoreStore(client FirestoreClient, collection string) *FirestoreStore {
+	return &FirestoreStore{client: client, collection: collection}
+}
+
+// Claim creates the key's document and returns false if it already exists, unless the existing
+// claim expired without being completed. The expired document is then replaced.
+func (f *FirestoreStore) Claim(ctx context.Context, key string, now, expires time.Time) (bool, error) {
+	fields := map[string]string{
+		"claimed": now.UTC().Format(time.RFC3339),
+		"expires": expires.UTC().Format(time.RFC3339),
+	}
+	err := f.client.CreateDocument(ctx, f.collection, key, fields)
+	if !isStatus(err, http.StatusConflict) {
+		return err == nil, err
+	}
+	existing, version, err := f.client.GetDocumentVersion(ctx, f.collection, key)
+	if isStatus(err, http.StatusNotFound) {
+		// Released by a failed delivery, which will be redelivered.
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if !expired(existing, now) {
+		return false, nil
+	}
+	log.Printf("taking over claim %q which expired at %s", key, existing["expires"])
+	err = f.client.DeleteDocumentVersion(ctx, f.collection, key, version)
+	if isStatus(err, http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusPreconditionFailed) {
+		// Another delivery took the claim over first.
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	err = f.client.CreateDocument(ctx, f.collection, key, fields)
+	if isStatus(err, http.StatusConflict) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Complete replaces the key's document with one marked done, without an expiry.
+func (f *FirestoreStore) Complete(ctx context.Context, key string) error {
+	return f.client.SetDocument(ctx, f.collection, key, map[string]string{
+		"done": time.Now().UTC().Format(time.RFC3339),
+	})
+}
+
+// expired returns whether the claim's fields hold an expiry before now and it was not completed.
+// Claims without an expiry never expire.
+func expired(fields map[string]string, now time.Time) bool {
+	if fields["done"] != "" {
+		return false
+	}
+	expires, err := time.Parse(time.RFC3339, fields["expires"])
+	return err == nil && now.After(expires)
+}
+
+// isStatus returns whether err is a Google API error with one of the given HTTP status codes.
+func isStatus(err error, codes ...int) bool {
+	e, ok := err.(*googleapi.Error)
+	if !ok {
+		return false
+	}
+	for _, code := range codes {
+		if e.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// Release deletes the key's document.
+func (f *FirestoreStore) Release(ctx context.Context, key string) error {
+	if err := f.client.DeleteDocument(ctx, f.collection, key); !isStatus(err, http.StatusNotFound) {
+		return err
+	}
+	return nil
+}
+
+// MemoryStore is an idempotency store holding keys in memory. Keys are only shared within a
+// single process so it is meant for tests and local runs.
+type MemoryStore struct {
+	mu   sync.Mutex
+	keys map[string]memoryClaim
+}
+
+type memoryClaim struct {
+	expires time.Time
+	done    bool
+}
+
+// NewMemoryStore returns an empty in-memory idempotency store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{keys: map[string]memoryClaim{}}
+}
+
+// Claim records the key and returns false if it was already claimed and has not expired.
+func (m *MemoryStore) Claim(ctx context.Context, key string, now, expires time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if c, ok := m.keys[key]; ok && (c.done || !now.After(c.expires)) {
+		return false, nil
+	}
+	m.keys[key] = memoryClaim{expires: expires}
+	return true, nil
+}
+
+// Complete marks the key's claim as done.
+func (m *MemoryStore) Complete(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.keys[key] = memoryClaim{done: true}
+	return nil
+}
+
+// Release removes the key.
+func (m *MemoryStore) Release(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.keys, key)
+	return nil
+}
+
+// Idempotency prevents an action from being taken more than once for the same finding when a
+// finding or PubSub message is delivered more than once.
+//
+// Actions are keyed on the finding's name and event time so a finding that fires again later
+// is remediated again. A nil *Idempotency, or one without a finding, allows every action so
+// automations run unchanged when no store is configured.
+type Idempotency struct {
+	store     IdempotencyStore
+	lease     time.Duration
+	now       func() time.Time
+	finding   string
+	eventTime string
+	index     string
+}
+
+// NewIdempotency returns an idempotency check backed by the given store. Claims are held for
+// ClaimLease unless the action completes or fails first.
+func NewIdempotency(store IdempotencyStore) *Idempotency {
+	return &Idempotency{store: store, lease: ClaimLease, now: time.Now}
+}
+
+// WithFinding returns a copy of the idempotency check keyed on the given finding. Index
+// distinguishes an action configured more than once for the same finding.
+func (i *Idempotency) WithFinding(name, eventTime, index string) *Idempotency {
+	if i == nil {
+		return nil
+	}
+	ii := *i
+	ii.finding = name
+	ii.eventTime = eventTime
+	ii.index = index
+	return &ii
+}
+
+// Begin claims the action for the finding and returns false if it was already claimed by a
+// previous delivery, unless that claim's lease expired without the action ending. Failing to
+// reach the store is logged and the action is allowed, so the store never blocks a remediation.
+func (i *Idempotency) Begin(ctx context.Context, action string) bool {
+	if i == nil || i.finding == "" {
+		return true
+	}
+	now := i.now()
+	ok, err := i.store.Claim(ctx, i.key(action), now, now.Add(i.lease))
+	if err != nil {
+		log.Printf("failed to claim %q for finding %q: %q", action, i.finding, err)
+		return true
+	}
+	if !ok {
+		log.Printf("skipping %q, already taken for finding %q at %q", action, i.finding, i.eventTime)
+	}
+	return ok
+}
+
+// End marks the action's claim as done if it succeeded, or releases it if it failed so a
+// redelivery can retry it.
+func (i *Idempotency) End(ctx context.Context, action string, err error) {
+	if i == nil || i.finding == "" {
+		return
+	}
+	if err == nil {
+		if err := i.store.Complete(ctx, i.key(action)); err != nil {
+			log.Printf("failed to complete %q for finding %q: %q", action, i.finding, err)
+		}
+		return
+	}
+	if err := i.store.Release(ctx, i.key(action)); err != nil {
+		log.Printf("failed to release %q for finding %q: %q", action, i.finding, err)
+	}
+}
+
+// key returns the store key for the action. Finding names contain slashes so the key is hashed
+// to be usable as a document ID.
+func (i *Idempotency) key(action string) string {
+	h := sha256.Sum256([]byte(strings.Join([]string{i.finding, i.eventTime, action, i.index}, "\x00")))
+	return hex.EncodeToString(h[:])
+}
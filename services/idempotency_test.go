@@ -0,0 +1,139 @@
+package services
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/googlecloudplatform/security-response-automation/clients/stubs"
+)
+
+func TestIdempotency(t *testing.T) {
+	ctx := context.Background()
+	for _, tt := range []struct {
+		name  string
+		store IdempotencyStore
+	}{
+		{name: "memory", store: NewMemoryStore()},
+		{name: "firestore", store: NewFirestoreStore(&stubs.FirestoreStub{}, "sra-idempotency")},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			i := NewIdempotency(tt.store)
+			first := i.WithFinding("organizations/1/sources/2/findings/3", "2019-11-22T18:34:36.153Z", "0")
+			if !first.Begin(ctx, "close_bucket") {
+				t.Fatalf("first delivery was not allowed")
+			}
+			first.End(ctx, "close_bucket", nil)
+			if first.Begin(ctx, "close_bucket") {
+				t.Errorf("redelivery was allowed")
+			}
+			if !first.Begin(ctx, "enable_bucket_only_policy") {
+				t.Errorf("other action was not allowed")
+			}
+			if !first.WithFinding("organizations/1/sources/2/findings/3", "2019-11-22T18:34:36.153Z", "1").Begin(ctx, "close_bucket") {
+				t.Errorf("action configured twice was not allowed")
+			}
+			later := i.WithFinding("organizations/1/sources/2/findings/3", "2019-11-23T10:00:00.000Z", "0")
+			if !later.Begin(ctx, "close_bucket") {
+				t.Errorf("later event was not allowed")
+			}
+			later.End(ctx, "close_bucket", errors.New("failed"))
+			if !later.Begin(ctx, "close_bucket") {
+				t.Errorf("retry after failure was not allowed")
+			}
+		})
+	}
+}
+
+func TestIdempotencyLease(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2019, 11, 22, 18, 34, 36, 0, time.UTC)
+	for _, tt := range []struct {
+		name  string
+		store IdempotencyStore
+	}{
+		{name: "memory", store: NewMemoryStore()},
+		{name: "firestore", store: NewFirestoreStore(&stubs.FirestoreStub{}, "sra-idempotency")},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			now := start
+			i := NewIdempotency(tt.store)
+			i.now = func() time.Time { return now }
+			crashed := i.WithFinding("organizations/1/sources/2/findings/3", "2019-11-22T18:34:36.153Z", "0")
+			if !crashed.Begin(ctx, "close_bucket") {
+				t.Fatalf("first delivery was not allowed")
+			}
+			now = start.Add(ClaimLease - time.Second)
+			if crashed.Begin(ctx, "close_bucket") {
+				t.Errorf("redelivery within the lease was allowed")
+			}
+			now = start.Add(ClaimLease + time.Second)
+			if !crashed.Begin(ctx, "close_bucket") {
+				t.Errorf("redelivery after the lease expired was not allowed")
+			}
+			if crashed.Begin(ctx, "close_bucket") {
+				t.Errorf("expired claim was taken over twice")
+			}
+
+			now = start
+			done := i.WithFinding("organizations/1/sources/2/findings/4", "2019-11-22T18:34:36.153Z", "0")
+			if !done.Begin(ctx, "close_bucket") {
+				t.Fatalf("first delivery was not allowed")
+			}
+			done.End(ctx, "close_bucket", nil)
+			now = start.Add(24 * time.Hour)
+			if done.Begin(ctx, "close_bucket") {
+				t.Errorf("redelivery of a completed action was allowed")
+			}
+		})
+	}
+}
+
+func TestFirestoreStoreTakeOver(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2019, 11, 22, 18, 34, 36, 0, time.UTC)
+	stub := &stubs.FirestoreStub{}
+	store := NewFirestoreStore(stub, "sra-idempotency")
+	if ok, err := store.Claim(ctx, "key", start, start.Add(ClaimLease)); !ok || err != nil {
+		t.Fatalf("Claim() = %t, %v, want true", ok, err)
+	}
+	// A delivery that read the expired claim before it was taken over can not delete it.
+	_, version, err := stub.GetDocumentVersion(ctx, "sra-idempotency", "key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	later := start.Add(2 * ClaimLease)
+	if ok, err := store.Claim(ctx, "key", later, later.Add(ClaimLease)); !ok || err != nil {
+		t.Fatalf("Claim() = %t, %v, want the expired claim taken over", ok, err)
+	}
+	if err := stub.DeleteDocumentVersion(ctx, "sra-idempotency", "key", version); err == nil {
+		t.Errorf("DeleteDocumentVersion() of a stale version succeeded")
+	}
+}
+
+func TestNilIdempotency(t *testing.T) {
+	ctx := context.Background()
+	var i *Idempotency
+	if !i.WithFinding("finding", "time", "0").Begin(ctx, "close_bucket") {
+		t.Errorf("nil idempotency did not allow action")
+	}
+	unscoped := NewIdempotency(NewMemoryStore())
+	if !unscoped.Begin(ctx, "close_bucket") || !unscoped.Begin(ctx, "close_bucket") {
+		t.Errorf("idempotency without finding did not allow action")
+	}
+}
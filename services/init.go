@@ -2,9 +2,11 @@ package services
 
 import (
 	"context"
-	"fmt"
+	"encoding/json"
+	"net/http"
 
 	"github.com/googlecloudplatform/security-response-automation/clients"
+	"github.com/pkg/errors"
 )
 
 // Global holds all initialized services.
@@ -16,41 +18,68 @@ type Global struct {
 	Container             *Container
 	CloudSQL              *CloudSQL
 	SecurityCommandCenter *CommandCenter
+	DNS                   *DNS
+	// Audit is nil unless an audit table is configured with InitAudit.
+	Audit *Audit
+	// Snapshots is nil unless a snapshot bucket is configured with InitSnapshots.
+	Snapshots *Snapshots
+	// Email is nil unless a SendGrid API key is configured with InitEmail.
+	Email *Email
+	// PagerDuty is nil unless a PagerDuty API key is configured with InitPagerDuty.
+	PagerDuty *PagerDuty
+	// Webhook is nil unless initialized with InitWebhook.
+	Webhook *Webhook
+	// Idempotency is nil unless a Firestore collection is configured with InitIdempotency.
+	Idempotency *Idempotency
+	// DeadLetter is nil unless a dead-letter topic is configured with InitDeadLetter.
+	DeadLetter *DeadLetter
+	// Approvals is nil unless an approval bucket is configured with InitApprovals.
+	Approvals *Approvals
+	// Queue is nil unless a schedule bucket is configured with InitQueue.
+	Queue *Queue
+	// Limiter is nil, leaving limits unenforced, unless a Firestore collection is configured
+	// with InitLimiter.
+	Limiter *Limiter
 }
 
 // New returns an initialized Global struct.
 func New(ctx context.Context) (*Global, error) {
-	host, err := initHost(ctx)
+	host, err := InitHost(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	log, err := initLog(ctx)
+	log, err := InitLogger(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	res, err := initResource(ctx)
+	res, err := InitResource(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	fw, err := initFirewall(ctx)
+	fw, err := InitFirewall(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	cont, err := initContainer(ctx)
+	cont, err := InitContainer(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	sql, err := initCloudSQL(ctx)
+	sql, err := InitCloudSQL(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	scc, err := initSecurityCommandCenter(ctx)
+	scc, err := InitSecurityCommandCenter(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dns, err := InitDNS(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -63,6 +92,7 @@ func New(ctx context.Context) (*Global, error) {
 		Container:             cont,
 		CloudSQL:              sql,
 		SecurityCommandCenter: scc,
+		DNS:                   dns,
 	}, nil
 }
 
@@ -72,80 +102,180 @@ func InitPagerDuty(apiKey string) *PagerDuty {
 	return NewPagerDuty(pd)
 }
 
+// InitEmail creates and initializes a new instance of Email rendering templates from the given directory.
+func InitEmail(apiKey, templates string) *Email {
+	sg := clients.NewSendGridClient(apiKey)
+	return NewEmailWithTemplates(sg, templates)
+}
+
+// InitWebhook creates and initializes a new instance of Webhook rendering templates from the given
+// directory. The URLs are a JSON object mapping webhook names to their URLs. Requests are signed
+// if secret is set.
+func InitWebhook(templates, secret, urls string) (*Webhook, error) {
+	u := map[string]string{}
+	if urls != "" {
+		if err := json.Unmarshal([]byte(urls), &u); err != nil {
+			return nil, errors.Wrap(err, "failed to parse webhook URLs")
+		}
+	}
+	return NewWebhook(&http.Client{Timeout: webhookTimeout}, templates, []byte(secret), u), nil
+}
+
 // InitBigQuery creates and initializes a new instance of BigQuery.
 func InitBigQuery(ctx context.Context, projectID string) (*BigQuery, error) {
 	bq, err := clients.NewBigQuery(ctx, projectID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to initialize bigquery client: %q", err)
+		return nil, errors.Wrap(err, "failed to initialize bigquery client")
 	}
 	return NewBigQuery(bq), nil
 }
 
+// InitAudit creates and initializes a new audit trail writing to the given BigQuery table.
+func InitAudit(ctx context.Context, projectID, datasetID, tableID string) (*Audit, error) {
+	bq, err := clients.NewBigQuery(ctx, projectID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to initialize bigquery client")
+	}
+	return NewAudit(bq, projectID, datasetID, tableID), nil
+}
+
+// InitSnapshots creates and initializes a new snapshot store writing to the given bucket.
+func InitSnapshots(ctx context.Context, bucket string) (*Snapshots, error) {
+	stg, err := clients.NewStorage(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to initialize storage client")
+	}
+	return NewSnapshots(stg, bucket), nil
+}
+
+// InitIdempotency creates and initializes a new idempotency check storing claims in the given
+// Firestore collection.
+func InitIdempotency(ctx context.Context, projectID, collection string) (*Idempotency, error) {
+	fs, err := clients.NewFirestore(ctx, projectID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to initialize firestore client")
+	}
+	return NewIdempotency(NewFirestoreStore(fs, collection)), nil
+}
+
+// InitLimiter creates and initializes a new limiter storing counters in the given Firestore
+// collection.
+func InitLimiter(ctx context.Context, projectID, collection string) (*Limiter, error) {
+	fs, err := clients.NewFirestore(ctx, projectID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to initialize firestore client")
+	}
+	return NewLimiter(NewFirestoreLimitStore(fs, collection)), nil
+}
+
+// InitDeadLetter creates and initializes a new dead letter service publishing to the given topic.
+func InitDeadLetter(ctx context.Context, projectID, topic string) (*DeadLetter, error) {
+	ps, err := InitPubSub(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	return NewDeadLetter(ps, topic), nil
+}
+
+// InitApprovals creates and initializes a new approval store writing to the given bucket.
+func InitApprovals(ctx context.Context, bucket, secret, url string) (*Approvals, error) {
+	stg, err := clients.NewStorage(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to initialize storage client")
+	}
+	return NewApprovals(stg, bucket, []byte(secret), url), nil
+}
+
+// InitQueue creates and initializes a new queue of scheduled automations writing to the given bucket.
+func InitQueue(ctx context.Context, bucket string) (*Queue, error) {
+	stg, err := clients.NewStorage(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to initialize storage client")
+	}
+	return NewQueue(stg, bucket), nil
+}
+
 // InitPubSub creates and initializes a new instance of PubSub.
 func InitPubSub(ctx context.Context, projectID string) (*PubSub, error) {
 	pubsub, err := clients.NewPubSub(ctx, projectID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to initialize pubsub client: %q", err)
+		return nil, errors.Wrap(err, "failed to initialize pubsub client")
 	}
 	return NewPubSub(pubsub), nil
 }
 
-func initHost(ctx context.Context) (*Host, error) {
+// InitHost creates and initializes a new instance of Host.
+func InitHost(ctx context.Context) (*Host, error) {
 	cs, err := clients.NewCompute(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to initialize compute client: %q", err)
+		return nil, errors.Wrap(err, "failed to initialize compute client")
 	}
 	return NewHost(cs), nil
 }
 
-func initLog(ctx context.Context) (*Logger, error) {
+// InitLogger creates and initializes a new instance of Logger.
+func InitLogger(ctx context.Context) (*Logger, error) {
 	logClient, err := clients.NewLogger(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to initialize logger client: %q", err)
+		return nil, errors.Wrap(err, "failed to initialize logger client")
 	}
 	return NewLogger(logClient), nil
 }
 
-func initResource(ctx context.Context) (*Resource, error) {
+// InitResource creates and initializes a new instance of Resource.
+func InitResource(ctx context.Context) (*Resource, error) {
 	crm, err := clients.NewCloudResourceManager(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to initialize cloud resource manager client: %q", err)
+		return nil, errors.Wrap(err, "failed to initialize cloud resource manager client")
 	}
 	stg, err := clients.NewStorage(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to initialize storage client: %q", err)
+		return nil, errors.Wrap(err, "failed to initialize storage client")
 	}
 	return NewResource(crm, stg), nil
 }
 
-func initFirewall(ctx context.Context) (*Firewall, error) {
+// InitFirewall creates and initializes a new instance of Firewall.
+func InitFirewall(ctx context.Context) (*Firewall, error) {
 	cs, err := clients.NewCompute(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to initialize compute client: %q", err)
+		return nil, errors.Wrap(err, "failed to initialize compute client")
 	}
 	return NewFirewall(cs), nil
 }
 
-func initContainer(ctx context.Context) (*Container, error) {
+// InitContainer creates and initializes a new instance of Container.
+func InitContainer(ctx context.Context) (*Container, error) {
 	cc, err := clients.NewContainer(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("Failed to initialize container client: %q", err)
+		return nil, errors.Wrap(err, "Failed to initialize container client")
 	}
 	return NewContainer(cc), nil
 }
 
-func initCloudSQL(ctx context.Context) (*CloudSQL, error) {
+// InitCloudSQL creates and initializes a new instance of CloudSQL.
+func InitCloudSQL(ctx context.Context) (*CloudSQL, error) {
 	cs, err := clients.NewCloudSQL(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to initialize sql client: %q", err)
+		return nil, errors.Wrap(err, "failed to initialize sql client")
 	}
 	return NewCloudSQL(cs), nil
 }
 
-func initSecurityCommandCenter(ctx context.Context) (*CommandCenter, error) {
+// InitSecurityCommandCenter creates and initializes a new instance of CommandCenter.
+func InitSecurityCommandCenter(ctx context.Context) (*CommandCenter, error) {
 	scc, err := clients.NewSecurityCommandCenter(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to initialize scc client: %q", err)
+		return nil, errors.Wrap(err, "failed to initialize scc client")
 	}
 	return NewCommandCenter(scc), nil
 }
+
+// InitDNS creates and initializes a new instance of DNS.
+func InitDNS(ctx context.Context) (*DNS, error) {
+	d, err := clients.NewDNS(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to initialize dns client")
+	}
+	return NewDNS(d), nil
+}
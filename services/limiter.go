@@ -0,0 +1,218 @@
+package services
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/api/googleapi"
+)
+
+// LimitStore counts events and holds open circuit breakers.
+type LimitStore interface {
+	// Increment adds one to the key's counter and returns its new value. The counter is no
+	// longer needed after expires.
+	Increment(ctx context.Context, key string, expires time.Time) (int64, error)
+	// Open records the key's breaker as open until the given time.
+	Open(ctx context.Context, key string, until time.Time) error
+	// OpenUntil returns when the key's breaker closes, or the zero time if it was never opened.
+	OpenUntil(ctx context.Context, key string) (time.Time, error)
+}
+
+// CounterClient contains minimum interface required by the Firestore limit store.
+type CounterClient interface {
+	Increment(ctx context.Context, collection, id, field string, fields map[string]string) (int64, error)
+	GetDocument(ctx context.Context, collection, id string) (map[string]string, error)
+	SetDocument(ctx context.Context, collection, id string, fields map[string]string) error
+}
+
+// FirestoreLimitStore is a limit store keeping counters and breakers as documents in a Firestore
+// collection so they are shared by every function instance. Counters are incremented atomically.
+type FirestoreLimitStore struct {
+	client     CounterClient
+	collection string
+}
+
+// NewFirestoreLimitStore returns a limit store writing to the given collection.
+func NewFirestoreLimitStore(client CounterClient, collection string) *FirestoreLimitStore {
+	return &FirestoreLimitStore{client: client, collection: collection}
+}
+
+// Increment adds one to the key's counter document. The expires field can be used as the
+// collection's time to live policy.
+func (f *FirestoreLimitStore) Increment(ctx context.Context, key string, expires time.Time) (int64, error) {
+	return f.client.Increment(ctx, f.collection, key, "count", map[string]string{
+		"expires": expires.UTC().Format(time.RFC3339),
+	})
+}
+
+// Open writes the key's breaker document.
+func (f *FirestoreLimitStore) Open(ctx context.Context, key string, until time.Time) error {
+	return f.client.SetDocument(ctx, f.collection, key, map[string]string{
+		"until": until.UTC().Format(time.RFC3339),
+	})
+}
+
+// OpenUntil reads the key's breaker document.
+func (f *FirestoreLimitStore) OpenUntil(ctx context.Context, key string) (time.Time, error) {
+	fields, err := f.client.GetDocument(ctx, f.collection, key)
+	if e, ok := err.(*googleapi.Error); ok && e.Code == http.StatusNotFound {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Parse(time.RFC3339, fields["until"])
+}
+
+// MemoryLimitStore is a limit store holding counters and breakers in memory. They are only
+// shared within a single process, so limits are enforced per function instance.
+type MemoryLimitStore struct {
+	mu       sync.Mutex
+	counts   map[string]int64
+	breakers map[string]time.Time
+}
+
+// NewMemoryLimitStore returns an empty in-memory limit store.
+func NewMemoryLimitStore() *MemoryLimitStore {
+	return &MemoryLimitStore{counts: map[string]int64{}, breakers: map[string]time.Time{}}
+}
+
+// Increment adds one to the key's counter.
+func (m *MemoryLimitStore) Increment(ctx context.Context, key string, expires time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counts[key]++
+	return m.counts[key], nil
+}
+
+// Open records the key's breaker as open.
+func (m *MemoryLimitStore) Open(ctx context.Context, key string, until time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.breakers[key] = until
+	return nil
+}
+
+// OpenUntil returns when the key's breaker closes.
+func (m *MemoryLimitStore) OpenUntil(ctx context.Context, key string) (time.Time, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.breakers[key], nil
+}
+
+// Limit caps how many times an event may happen within a window.
+type Limit struct {
+	// Key identifies what is counted, such as an action within a project.
+	Key    string
+	Max    int64
+	Window time.Duration
+	// Cooldown is how long the limit's circuit breaker stays open once the limit is exceeded.
+	// It defaults to Window.
+	Cooldown time.Duration
+}
+
+// Limiter enforces limits on events using a store shared by all function instances.
+//
+// Events are counted within fixed windows. The event exceeding a limit opens the limit's circuit
+// breaker and every event checked against the limit is denied until its cooldown has passed.
+// A nil *Limiter allows every event.
+type Limiter struct {
+	store LimitStore
+	now   func() time.Time
+}
+
+// NewLimiter returns a limiter backed by the given store.
+func NewLimiter(store LimitStore) *Limiter {
+	return &Limiter{store: store, now: time.Now}
+}
+
+// Allow counts an event against the limits and returns false if a limit was exceeded or its
+// breaker is open. The limits whose breaker was opened by this event are returned as well so a
+// single notification is raised for each. Failing to reach the store is logged and the event
+// allowed, so the store never blocks a remediation.
+func (l *Limiter) Allow(ctx context.Context, limits []Limit) (bool, []Limit) {
+	if l == nil || len(limits) == 0 {
+		return true, nil
+	}
+	now := l.now()
+	for _, limit := range limits {
+		until, err := l.store.OpenUntil(ctx, limitKey("breaker", limit.Key))
+		if err != nil {
+			log.Printf("failed to check circuit breaker of %q: %q", limit.Key, err)
+			continue
+		}
+		if now.Before(until) {
+			log.Printf("circuit breaker of %q is open until %s", limit.Key, until.Format(time.RFC3339))
+			return false, nil
+		}
+	}
+	allowed := true
+	var opened []Limit
+	for _, limit := range limits {
+		start := now.Truncate(limit.Window)
+		n, err := l.store.Increment(ctx, limitKey("count", limit.Key, start.Format(time.RFC3339)), start.Add(limit.Window))
+		if err != nil {
+			log.Printf("failed to count %q: %q", limit.Key, err)
+			continue
+		}
+		if n <= limit.Max {
+			continue
+		}
+		allowed = false
+		// Counters are incremented atomically so only one event sees the limit first exceeded.
+		if n != limit.Max+1 {
+			continue
+		}
+		cooldown := limit.Cooldown
+		if cooldown == 0 {
+			cooldown = limit.Window
+		}
+		if err := l.store.Open(ctx, limitKey("breaker", limit.Key), now.Add(cooldown)); err != nil {
+			log.Printf("failed to open circuit breaker of %q: %q", limit.Key, err)
+		}
+		opened = append(opened, limit)
+	}
+	return allowed, opened
+}
+
+// Release counts an automation released from approval or from the schedule queue against the
+// limits it was parked with, and returns the values to run it with. These are its dry run values
+// if a limit was exceeded, along with false.
+func (l *Limiter) Release(ctx context.Context, limits []Limit, values, dryRun json.RawMessage) (json.RawMessage, bool) {
+	ok, opened := l.Allow(ctx, limits)
+	for _, o := range opened {
+		log.Printf("circuit breaker opened, more than %d automations matching %q within %s", o.Max, o.Key, o.Window)
+	}
+	if ok || dryRun == nil {
+		return values, true
+	}
+	return dryRun, false
+}
+
+// limitKey returns the store key for the parts. Keys hold slashes so they are hashed to be
+// usable as document IDs.
+func limitKey(parts ...string) string {
+	h := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(h[:])
+}
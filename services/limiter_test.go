@@ -0,0 +1,66 @@
+package services
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/googlecloudplatform/security-response-automation/clients/stubs"
+)
+
+func TestLimiter(t *testing.T) {
+	ctx := context.Background()
+	for _, tt := range []struct {
+		name  string
+		store LimitStore
+	}{
+		{name: "memory", store: NewMemoryLimitStore()},
+		{name: "firestore", store: NewFirestoreLimitStore(&stubs.FirestoreStub{}, "sra-limits")},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+			l := NewLimiter(tt.store)
+			l.now = func() time.Time { return now }
+			limits := []Limit{{Key: "close_bucket/organization/1h", Max: 2, Window: time.Hour, Cooldown: 3 * time.Hour}}
+			for i, want := range []bool{true, true, false, false} {
+				ok, opened := l.Allow(ctx, limits)
+				if ok != want {
+					t.Errorf("event %d: Allow() = %t, want %t", i, ok, want)
+				}
+				if wantOpened := i == 2; (len(opened) == 1) != wantOpened {
+					t.Errorf("event %d: opened %v, want opened %t", i, opened, wantOpened)
+				}
+			}
+			// The window has passed but the breaker is still open.
+			now = now.Add(2 * time.Hour)
+			if ok, _ := l.Allow(ctx, limits); ok {
+				t.Errorf("Allow() = true during cooldown, want false")
+			}
+			now = now.Add(2 * time.Hour)
+			if ok, _ := l.Allow(ctx, limits); !ok {
+				t.Errorf("Allow() = false after cooldown, want true")
+			}
+		})
+	}
+}
+
+func TestNilLimiter(t *testing.T) {
+	var l *Limiter
+	if ok, _ := l.Allow(context.Background(), []Limit{{Key: "k", Max: 0, Window: time.Hour}}); !ok {
+		t.Errorf("Allow() = false, want nil limiter to allow everything")
+	}
+}
@@ -18,6 +18,7 @@ import (
 	"context"
 
 	"github.com/PagerDuty/go-pagerduty"
+	"github.com/pkg/errors"
 )
 
 // PagerDuty service.
@@ -28,6 +29,9 @@ type PagerDuty struct {
 // PagerDutyClient contains methods used by the PagerDuty service.
 type PagerDutyClient interface {
 	CreateIncident(from, serviceID, title, body string) (*pagerduty.Incident, error)
+	CreateIncidentWithKey(from, serviceID, title, body, key, urgency string) (*pagerduty.Incident, error)
+	OpenIncident(serviceID, key string) (*pagerduty.Incident, error)
+	AddNote(from, incidentID, content string) error
 }
 
 // NewPagerDuty returns a PagerDuty service.
@@ -42,3 +46,24 @@ func (p *PagerDuty) CreateIncident(ctx context.Context, from, serviceID, title,
 	}
 	return nil
 }
+
+// TriggerIncident opens an incident deduplicated on key and returns its ID. If an incident with
+// the same key is already open the body is added to it as a note instead, so repeated
+// notifications of the same finding update one incident rather than opening many.
+func (p *PagerDuty) TriggerIncident(ctx context.Context, from, serviceID, title, body, key, urgency string) (string, bool, error) {
+	open, err := p.client.OpenIncident(serviceID, key)
+	if err != nil {
+		return "", false, errors.Wrapf(err, "failed to find open incident for %q", key)
+	}
+	if open != nil {
+		if err := p.client.AddNote(from, open.ID, body); err != nil {
+			return "", false, errors.Wrapf(err, "failed to add note to incident %q", open.ID)
+		}
+		return open.ID, false, nil
+	}
+	incident, err := p.client.CreateIncidentWithKey(from, serviceID, title, body, key, urgency)
+	if err != nil {
+		return "", false, errors.Wrapf(err, "failed to create incident for %q", key)
+	}
+	return incident.ID, true, nil
+}
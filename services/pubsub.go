@@ -22,8 +22,7 @@ import (
 
 // PubSubClient contains minimum interface required by the service.
 type PubSubClient interface {
-	Topic(string) *pubsub.Topic
-	Publish(context.Context, *pubsub.Topic, *pubsub.Message) (string, error)
+	Publish(context.Context, string, *pubsub.Message) (string, error)
 }
 
 // PubSub service.
@@ -38,6 +37,5 @@ func NewPubSub(client PubSubClient) *PubSub {
 
 // Publish will publish a message to a PubSub topic.
 func (e *PubSub) Publish(ctx context.Context, topicID string, message *pubsub.Message) (string, error) {
-	topic := e.client.Topic(topicID)
-	return e.client.Publish(ctx, topic, message)
+	return e.client.Publish(ctx, topicID, message)
 }
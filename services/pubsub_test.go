@@ -36,13 +36,15 @@ func TestPubSub(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			stub := &stubs.PubSubStub{}
-			stub.StubbedTopic = &pubsub.Topic{}
 			ctx := context.Background()
 
 			e := NewPubSub(stub)
 			if _, err := e.Publish(ctx, "topic-id", tt.message); err != nil {
 				t.Errorf("%s failed: %q", tt.name, err)
 			}
+			if stub.PublishedTopic != "topic-id" {
+				t.Errorf("%s published to %q, want %q", tt.name, stub.PublishedTopic, "topic-id")
+			}
 			if diff := cmp.Diff(stub.PublishedMessage.Data, tt.message.Data); diff != "" {
 				t.Errorf("%s failed diff:%q", tt.name, diff)
 			}
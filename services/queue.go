@@ -0,0 +1,118 @@
+package services
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// queuePrefix is the object name prefix queued automations are stored under.
+const queuePrefix = "scheduled/"
+
+// QueueClient contains minimum interface required by the queue service.
+type QueueClient interface {
+	WriteObject(ctx context.Context, bucketName, name string, b []byte) error
+	ReadObject(ctx context.Context, bucketName, name string) ([]byte, error)
+	ListObjects(ctx context.Context, bucketName, prefix string) ([]string, error)
+	DeleteObject(ctx context.Context, bucketName, name string) error
+}
+
+// QueuedAutomation holds an automation waiting for its maintenance window along with the
+// message to publish once the window opens.
+type QueuedAutomation struct {
+	ID        string
+	Action    string
+	ProjectID string
+	Rule      string
+	Finding   string
+	// Topic, Values and Attributes are the message published to run the automation.
+	Topic      string
+	Values     json.RawMessage
+	Attributes map[string]string
+	Queued     time.Time
+	// Due is when the automation's next maintenance window opens.
+	Due time.Time
+	// Schedule is the automation's schedule, checked again before the automation is run in case
+	// the window closed since it was due.
+	Schedule json.RawMessage
+	// Limits are counted when the automation is replayed, DryRunValues are published instead of
+	// Values if one is exceeded.
+	Limits       []Limit
+	DryRunValues json.RawMessage
+}
+
+// Queue stores automations waiting for their maintenance window in a GCS bucket.
+type Queue struct {
+	client QueueClient
+	bucket string
+}
+
+// NewQueue returns a queue writing to the given bucket.
+func NewQueue(client QueueClient, bucket string) *Queue {
+	return &Queue{client: client, bucket: bucket}
+}
+
+// NewQueuedAutomation returns a queued automation with a new ID.
+func NewQueuedAutomation() *QueuedAutomation {
+	return &QueuedAutomation{ID: uuid.New().String(), Queued: time.Now().UTC()}
+}
+
+// Push stores the automation until it is removed.
+func (q *Queue) Push(ctx context.Context, a *QueuedAutomation) error {
+	b, err := json.Marshal(a)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal queued automation")
+	}
+	if err := q.client.WriteObject(ctx, q.bucket, queuePrefix+a.ID, b); err != nil {
+		return errors.Wrapf(err, "failed to write queued automation %q", a.ID)
+	}
+	return nil
+}
+
+// Due returns the queued automations due at or before t.
+func (q *Queue) Due(ctx context.Context, t time.Time) ([]*QueuedAutomation, error) {
+	names, err := q.client.ListObjects(ctx, q.bucket, queuePrefix)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list queued automations")
+	}
+	var due []*QueuedAutomation
+	for _, name := range names {
+		b, err := q.client.ReadObject(ctx, q.bucket, name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read queued automation %q", name)
+		}
+		var a QueuedAutomation
+		if err := json.Unmarshal(b, &a); err != nil {
+			return nil, errors.Wrapf(err, "failed to unmarshal queued automation %q", name)
+		}
+		if !a.Due.After(t) {
+			due = append(due, &a)
+		}
+	}
+	return due, nil
+}
+
+// Remove deletes the automation from the queue.
+func (q *Queue) Remove(ctx context.Context, id string) error {
+	if err := q.client.DeleteObject(ctx, q.bucket, queuePrefix+id); err != nil {
+		return errors.Wrapf(err, "failed to delete queued automation %q", id)
+	}
+	return nil
+}
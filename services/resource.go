@@ -16,12 +16,12 @@ package services
 
 import (
 	"context"
-	"fmt"
 	"log"
 	"regexp"
 	"strings"
 
 	"cloud.google.com/go/iam"
+	"cloud.google.com/go/storage"
 	"github.com/pkg/errors"
 	crm "google.golang.org/api/cloudresourcemanager/v1"
 )
@@ -40,8 +40,12 @@ type storageClient interface {
 	SetBucketPolicy(context.Context, string, *iam.Policy) error
 	BucketPolicy(context.Context, string) (*iam.Policy, error)
 	EnableBucketOnlyPolicy(context.Context, string) error
+	BucketAttrs(context.Context, string) (*storage.BucketAttrs, error)
 }
 
+// Bindings maps IAM roles to their members.
+type Bindings map[string][]string
+
 // Resource service.
 type Resource struct {
 	crm     crmClient
@@ -60,14 +64,14 @@ func NewResource(crm crmClient, s storageClient) *Resource {
 func (r *Resource) ProjectOnlyKeepUsersFromDomains(ctx context.Context, projectID string, allowDomains []string) ([]string, error) {
 	existingPolicy, err := r.crm.GetPolicyProject(ctx, projectID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get project policy: %q", err)
+		return nil, errors.Wrap(err, "failed to get project policy")
 	}
 	removed, policy, err := r.keepUsersFromPolicy(existingPolicy, allowDomains)
 	if err != nil {
 		return nil, err
 	}
 	if _, err := r.crm.SetPolicyProject(ctx, projectID, policy); err != nil {
-		return nil, fmt.Errorf("failed to set project policy: %q", err)
+		return nil, errors.Wrap(err, "failed to set project policy")
 	}
 	return removed, nil
 }
@@ -76,14 +80,14 @@ func (r *Resource) ProjectOnlyKeepUsersFromDomains(ctx context.Context, projectI
 func (r *Resource) OrganizationOnlyKeepUsersFromDomains(ctx context.Context, orgID string, allowDomains []string) ([]string, error) {
 	existingPolicy, err := r.crm.GetPolicyOrganization(ctx, orgID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get project policy: %q", err)
+		return nil, errors.Wrap(err, "failed to get project policy")
 	}
 	removed, policy, err := r.keepUsersFromPolicy(existingPolicy, allowDomains)
 	if err != nil {
 		return nil, err
 	}
 	if _, err := r.crm.SetPolicyOrganization(ctx, orgID, policy); err != nil {
-		return nil, fmt.Errorf("failed to set project policy: %q", err)
+		return nil, errors.Wrap(err, "failed to set project policy")
 	}
 	return removed, nil
 }
@@ -92,11 +96,11 @@ func (r *Resource) OrganizationOnlyKeepUsersFromDomains(ctx context.Context, org
 func (r *Resource) RemoveUsersProject(ctx context.Context, projectID string, remove []string) error {
 	existingPolicy, err := r.crm.GetPolicyProject(ctx, projectID)
 	if err != nil {
-		return fmt.Errorf("failed to get project policy: %q", err)
+		return errors.Wrap(err, "failed to get project policy")
 	}
 	policy := r.removeUsersFromPolicy(existingPolicy, remove)
 	if _, err := r.crm.SetPolicyProject(ctx, projectID, policy); err != nil {
-		return fmt.Errorf("failed to set project policy: %q", err)
+		return errors.Wrap(err, "failed to set project policy")
 	}
 	return nil
 }
@@ -132,6 +136,101 @@ func (r *Resource) RemoveMembersFromBucket(ctx context.Context, bucketName strin
 	return r.storage.SetBucketPolicy(ctx, bucketName, p)
 }
 
+// BucketBindings returns the role bindings of the bucket's IAM policy.
+func (r *Resource) BucketBindings(ctx context.Context, bucketName string) (Bindings, error) {
+	p, err := r.storage.BucketPolicy(ctx, bucketName)
+	if err != nil {
+		return nil, err
+	}
+	b := Bindings{}
+	for _, role := range p.Roles() {
+		b[string(role)] = append([]string{}, p.Members(role)...)
+	}
+	return b, nil
+}
+
+// RestoreBucketBindings adds back any members of the bindings missing from the bucket's IAM policy.
+// Members added since the bindings were taken are kept.
+func (r *Resource) RestoreBucketBindings(ctx context.Context, bucketName string, bindings Bindings) error {
+	p, err := r.storage.BucketPolicy(ctx, bucketName)
+	if err != nil {
+		return err
+	}
+	for role, members := range bindings {
+		for _, m := range members {
+			if !p.HasRole(m, iam.RoleName(role)) {
+				p.Add(m, iam.RoleName(role))
+			}
+		}
+	}
+	return r.storage.SetBucketPolicy(ctx, bucketName, p)
+}
+
+// ProjectBindings returns the unconditional role bindings of the project's IAM policy.
+func (r *Resource) ProjectBindings(ctx context.Context, projectID string) (Bindings, error) {
+	p, err := r.crm.GetPolicyProject(ctx, projectID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get project policy")
+	}
+	b := Bindings{}
+	for _, binding := range p.Bindings {
+		if binding.Condition != nil {
+			continue
+		}
+		b[binding.Role] = append(b[binding.Role], binding.Members...)
+	}
+	return b, nil
+}
+
+// RestoreProjectBindings adds back any members of the bindings missing from the project's IAM
+// policy. Members added since the bindings were taken are kept.
+func (r *Resource) RestoreProjectBindings(ctx context.Context, projectID string, bindings Bindings) error {
+	policy, err := r.crm.GetPolicyProject(ctx, projectID)
+	if err != nil {
+		return errors.Wrap(err, "failed to get project policy")
+	}
+	for role, members := range bindings {
+		var binding *crm.Binding
+		for _, b := range policy.Bindings {
+			if b.Role == role && b.Condition == nil {
+				binding = b
+				break
+			}
+		}
+		if binding == nil {
+			binding = &crm.Binding{Role: role}
+			policy.Bindings = append(policy.Bindings, binding)
+		}
+		for _, m := range members {
+			if !contains(binding.Members, m) {
+				binding.Members = append(binding.Members, m)
+			}
+		}
+	}
+	if _, err := r.crm.SetPolicyProject(ctx, projectID, policy); err != nil {
+		return errors.Wrap(err, "failed to set project policy")
+	}
+	return nil
+}
+
+func contains(s []string, v string) bool {
+	for _, vv := range s {
+		if vv == v {
+			return true
+		}
+	}
+	return false
+}
+
+// AuditConfigs returns the audit log configuration of the project.
+func (r *Resource) AuditConfigs(ctx context.Context, projectID string) ([]*crm.AuditConfig, error) {
+	res, err := r.crm.GetPolicyProject(ctx, projectID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get project policy")
+	}
+	return res.AuditConfigs, nil
+}
+
 // EnableAuditLogs enable audit logs to all services and LogTypes.
 func (r *Resource) EnableAuditLogs(ctx context.Context, projectID string) (*crm.Policy, error) {
 	res, err := r.crm.GetPolicyProject(ctx, projectID)
@@ -173,7 +272,7 @@ func (r *Resource) keepUsersFromPolicy(policy *crm.Policy, allowedDomains []stri
 	allowed := strings.Replace(strings.Join(allowedDomains, "|"), ".", `\.`, -1)
 	allowedRegExp, err := regexp.Compile("^.+@(?:" + allowed + ")$")
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to compile regex: %q", err)
+		return nil, nil, errors.Wrap(err, "failed to compile regex")
 	}
 	removed := []string{}
 	for _, b := range policy.Bindings {
@@ -230,6 +329,24 @@ func (r *Resource) Organization(ctx context.Context, orgID string) (*crm.Organiz
 	return r.crm.GetOrganization(ctx, "organizations/"+orgID)
 }
 
+// BucketLabels returns the labels of the bucket.
+func (r *Resource) BucketLabels(ctx context.Context, bucketName string) (Labels, error) {
+	attrs, err := r.storage.BucketAttrs(ctx, bucketName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get attributes of bucket %q", bucketName)
+	}
+	return attrs.Labels, nil
+}
+
+// BucketPolicyOnly returns whether bucket only policy is enabled on the bucket.
+func (r *Resource) BucketPolicyOnly(ctx context.Context, bucketName string) (bool, error) {
+	attrs, err := r.storage.BucketAttrs(ctx, bucketName)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to get attributes of bucket %q", bucketName)
+	}
+	return attrs.BucketPolicyOnly.Enabled, nil
+}
+
 // EnableBucketOnlyPolicy enable bucket only policy for the given bucket
 func (r *Resource) EnableBucketOnlyPolicy(ctx context.Context, bucketName string) error {
 	return r.storage.EnableBucketOnlyPolicy(ctx, bucketName)
@@ -247,6 +364,22 @@ func (r *Resource) getProjectAncestryPath(ctx context.Context, projectID string)
 	return strings.Join(s, "/"), nil
 }
 
+// ancestryPattern matches the patterns accepted by CheckMatches. IDs may be replaced by "*" and
+// "/*" matches any number of folders or, at the end, all descendants.
+var ancestryPattern = regexp.MustCompile(`^organizations/(\d+|\*)(/folders/(\d+|\*)|/\*)*(/projects/([a-z][-a-z0-9]*|\*))?$`)
+
+// ValidateAncestryPattern returns an error if the pattern can not be used as a target or exclude
+// pattern by CheckMatches.
+func ValidateAncestryPattern(pattern string) error {
+	if _, err := regexp.Compile("^" + strings.Replace(pattern, "*", ".*", -1)); err != nil {
+		return errors.Wrapf(err, "failed to parse: %s", pattern)
+	}
+	if !ancestryPattern.MatchString(pattern) {
+		return errors.Errorf("%q is not an ancestry pattern such as organizations/123/folders/456/*", pattern)
+	}
+	return nil
+}
+
 func (r *Resource) ancestryMatches(patterns []string, ancestorPath string) (bool, error) {
 	for _, pattern := range patterns {
 		match, err := regexp.MatchString("^"+strings.Replace(pattern, "*", ".*", -1), ancestorPath)
@@ -297,3 +297,27 @@ func TestCheckMatches(t *testing.T) {
 	}
 
 }
+
+func TestValidateAncestryPattern(t *testing.T) {
+	for _, tt := range []struct {
+		pattern string
+		valid   bool
+	}{
+		{pattern: "organizations/456/*", valid: true},
+		{pattern: "organizations/456/folders/123/*", valid: true},
+		{pattern: "organizations/456/folders/123/projects/test-project", valid: true},
+		{pattern: "organizations/456/*/projects/test-project", valid: true},
+		{pattern: "organizations/456/folders/123/folders/789/*", valid: true},
+		{pattern: "organization/456/*", valid: false},
+		{pattern: "folders/123/*", valid: false},
+		{pattern: "organizations/456/folders/abc", valid: false},
+		{pattern: "organizations/456/projects/Test_Project", valid: false},
+		{pattern: "", valid: false},
+	} {
+		t.Run(tt.pattern, func(t *testing.T) {
+			if err := ValidateAncestryPattern(tt.pattern); (err == nil) != tt.valid {
+				t.Errorf("ValidateAncestryPattern(%q) = %v, want valid %t", tt.pattern, err, tt.valid)
+			}
+		})
+	}
+}
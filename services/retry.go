@@ -0,0 +1,89 @@
+package services
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// transientCodes are the HTTP status codes of requests that may succeed if retried.
+var transientCodes = map[int]bool{
+	http.StatusRequestTimeout:      true,
+	http.StatusTooManyRequests:     true,
+	http.StatusInternalServerError: true,
+	http.StatusBadGateway:          true,
+	http.StatusServiceUnavailable:  true,
+	http.StatusGatewayTimeout:      true,
+}
+
+// transientStatus are the gRPC status codes of calls that may succeed if retried.
+var transientStatus = map[codes.Code]bool{
+	codes.Aborted:           true,
+	codes.DeadlineExceeded:  true,
+	codes.Internal:          true,
+	codes.ResourceExhausted: true,
+	codes.Unavailable:       true,
+}
+
+// Transient returns if the error is temporary and the failed call may succeed if retried. Errors
+// such as a 403 or 404 from a Google API are permanent, as are errors that cannot be classified.
+func Transient(err error) bool {
+	if err == nil {
+		return false
+	}
+	cause := errors.Cause(err)
+	if cause == context.DeadlineExceeded {
+		return true
+	}
+	if e, ok := cause.(*googleapi.Error); ok {
+		return transientCodes[e.Code]
+	}
+	if e, ok := cause.(net.Error); ok && e.Timeout() {
+		return true
+	}
+	if s, ok := status.FromError(cause); ok && s.Code() != codes.OK && s.Code() != codes.Unknown {
+		return transientStatus[s.Code()]
+	}
+	return false
+}
+
+// Retry calls fn until it succeeds or returns a permanent error, up to the given number of
+// attempts. The wait between attempts starts at backoff and doubles on each retry.
+func Retry(ctx context.Context, attempts int, backoff time.Duration, fn func() error) error {
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err = fn(); err == nil || !Transient(err) {
+			return err
+		}
+		if attempt == attempts {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+	return errors.Wrapf(err, "failed after %d attempts", attempts)
+}
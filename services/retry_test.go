@@ -0,0 +1,134 @@
+package services
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/functions/metadata"
+	"cloud.google.com/go/pubsub"
+	"github.com/googlecloudplatform/security-response-automation/clients/stubs"
+	perrors "github.com/pkg/errors"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestTransient(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "forbidden", err: &googleapi.Error{Code: http.StatusForbidden}, want: false},
+		{name: "not found", err: &googleapi.Error{Code: http.StatusNotFound}, want: false},
+		{name: "rate limited", err: &googleapi.Error{Code: http.StatusTooManyRequests}, want: true},
+		{name: "unavailable", err: &googleapi.Error{Code: http.StatusServiceUnavailable}, want: true},
+		{name: "wrapped", err: perrors.Wrap(&googleapi.Error{Code: http.StatusBadGateway}, "failed"), want: true},
+		{name: "grpc unavailable", err: status.Error(codes.Unavailable, "unavailable"), want: true},
+		{name: "grpc permission denied", err: status.Error(codes.PermissionDenied, "denied"), want: false},
+		{name: "deadline", err: context.DeadlineExceeded, want: true},
+		{name: "unclassified", err: errors.New("rule not found"), want: false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Transient(tt.err); got != tt.want {
+				t.Errorf("Transient(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetry(t *testing.T) {
+	ctx := context.Background()
+	for _, tt := range []struct {
+		name     string
+		errs     []error
+		wantErr  bool
+		wantRuns int
+	}{
+		{name: "success", errs: []error{nil}, wantRuns: 1},
+		{name: "transient then success", errs: []error{&googleapi.Error{Code: 503}, nil}, wantRuns: 2},
+		{name: "permanent", errs: []error{&googleapi.Error{Code: 403}}, wantErr: true, wantRuns: 1},
+		{name: "out of attempts", errs: []error{&googleapi.Error{Code: 503}, &googleapi.Error{Code: 503}, &googleapi.Error{Code: 503}}, wantErr: true, wantRuns: 3},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			runs := 0
+			err := Retry(ctx, 3, time.Millisecond, func() error {
+				runs++
+				return tt.errs[runs-1]
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Retry() = %v, want error %v", err, tt.wantErr)
+			}
+			if runs != tt.wantRuns {
+				t.Errorf("Retry() ran %d times, want %d", runs, tt.wantRuns)
+			}
+		})
+	}
+}
+
+func TestDeadLetter(t *testing.T) {
+	m := &pubsub.Message{Data: []byte(`{"BucketName": "b"}`), Attributes: map[string]string{"rule": "public_bucket_acl"}}
+	for _, tt := range []struct {
+		name       string
+		err        error
+		age        time.Duration
+		wantErr    bool
+		wantLetter bool
+	}{
+		{name: "success", err: nil},
+		{name: "transient within window", err: &googleapi.Error{Code: 503}, age: time.Minute, wantErr: true},
+		{name: "transient out of window", err: &googleapi.Error{Code: 503}, age: 2 * time.Hour, wantLetter: true},
+		{name: "permanent", err: &googleapi.Error{Code: 403}, age: time.Minute, wantLetter: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := metadata.NewContext(context.Background(), &metadata.Metadata{Timestamp: time.Now().Add(-tt.age)})
+			psStub := &stubs.PubSubStub{}
+			d := NewDeadLetter(NewPubSub(psStub), "threat-findings-dead-letter")
+			err := d.Handle(ctx, "CloseBucket", m, tt.err)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Handle() = %v, want error %v", err, tt.wantErr)
+			}
+			if got := psStub.PublishedMessage != nil; got != tt.wantLetter {
+				t.Fatalf("Handle() published dead letter %v, want %v", got, tt.wantLetter)
+			}
+			if !tt.wantLetter {
+				return
+			}
+			var got DeadLetterMessage
+			if err := json.Unmarshal(psStub.PublishedMessage.Data, &got); err != nil {
+				t.Fatalf("failed to unmarshal dead letter: %q", err)
+			}
+			if got.Function != "CloseBucket" || got.Reason != tt.err.Error() || string(got.Data) != string(m.Data) || got.Attributes["rule"] != "public_bucket_acl" {
+				t.Errorf("unexpected dead letter: %+v", got)
+			}
+		})
+	}
+}
+
+func TestNilDeadLetter(t *testing.T) {
+	var d *DeadLetter
+	err := fmt.Errorf("failed")
+	if got := d.Handle(context.Background(), "CloseBucket", &pubsub.Message{}, err); got != err {
+		t.Errorf("Handle() = %v, want %v", got, err)
+	}
+}
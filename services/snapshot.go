@@ -0,0 +1,109 @@
+package services
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// snapshotPrefix is the object name prefix snapshots are stored under.
+const snapshotPrefix = "snapshots/"
+
+// SnapshotClient contains minimum interface required by the snapshot service.
+type SnapshotClient interface {
+	WriteObjectMetadata(ctx context.Context, bucketName, name string, b []byte, metadata map[string]string) error
+	ReadObject(ctx context.Context, bucketName, name string) ([]byte, error)
+}
+
+// Snapshot holds the state of a resource before a remediation changed it.
+type Snapshot struct {
+	RemediationID string
+	Action        string
+	Resource      string
+	Time          time.Time
+	State         json.RawMessage
+}
+
+// Decode unmarshals the snapshot's state into v.
+func (s *Snapshot) Decode(v interface{}) error {
+	return json.Unmarshal(s.State, v)
+}
+
+// Snapshots stores resource state snapshots in a GCS bucket so remediations can be rolled back.
+//
+// A nil *Snapshots is valid and discards all snapshots, so automations run unchanged when no
+// snapshot bucket is configured.
+type Snapshots struct {
+	client SnapshotClient
+	bucket string
+}
+
+// NewSnapshots returns a snapshot store writing to the given bucket.
+func NewSnapshots(client SnapshotClient, bucket string) *Snapshots {
+	return &Snapshots{client: client, bucket: bucket}
+}
+
+// Save stores the state of the resource changed by the remediation under the remediation's ID.
+// The action, resource and finding are set as object metadata so snapshots can be found without
+// the audit table.
+func (s *Snapshots) Save(ctx context.Context, r *Remediation, state interface{}) error {
+	if s == nil {
+		return nil
+	}
+	st, err := json.Marshal(state)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal state")
+	}
+	b, err := json.Marshal(&Snapshot{
+		RemediationID: r.ID,
+		Action:        r.Action,
+		Resource:      r.Resource,
+		Time:          time.Now().UTC(),
+		State:         st,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal snapshot")
+	}
+	metadata := map[string]string{"action": r.Action, "resource": r.Resource}
+	if r.FindingName != "" {
+		metadata["finding"] = r.FindingName
+	}
+	if err := s.client.WriteObjectMetadata(ctx, s.bucket, snapshotPrefix+r.ID, b, metadata); err != nil {
+		return errors.Wrapf(err, "failed to write snapshot for remediation %q", r.ID)
+	}
+	log.Printf("saved snapshot of %q before %q as remediation %q", r.Resource, r.Action, r.ID)
+	return nil
+}
+
+// Load returns the snapshot taken by the given remediation.
+func (s *Snapshots) Load(ctx context.Context, remediationID string) (*Snapshot, error) {
+	if s == nil {
+		return nil, errors.New("no snapshot bucket configured")
+	}
+	b, err := s.client.ReadObject(ctx, s.bucket, snapshotPrefix+remediationID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read snapshot for remediation %q", remediationID)
+	}
+	var snapshot Snapshot
+	if err := json.Unmarshal(b, &snapshot); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal snapshot for remediation %q", remediationID)
+	}
+	return &snapshot, nil
+}
@@ -0,0 +1,178 @@
+package services
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// SignatureHeader is the HTTP header holding the HMAC-SHA256 signature of a webhook's body.
+	SignatureHeader = "X-SRA-Signature"
+	// webhookAttempts is the number of times a webhook is sent before giving up.
+	webhookAttempts = 3
+	// webhookBackoff is the time waited before the first retry. It doubles on each retry.
+	webhookBackoff = time.Second
+	// webhookTimeout is the time allowed for a single request.
+	webhookTimeout = 10 * time.Second
+)
+
+// WebhookClient is the interface used for sending webhooks.
+type WebhookClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Webhook is the service used to send templated JSON payloads to HTTP endpoints such as Slack or
+// Microsoft Teams incoming webhooks.
+//
+// Incoming webhook URLs embed their credentials, so automations refer to webhooks by name and
+// the URLs are only known to this service.
+type Webhook struct {
+	client    WebhookClient
+	templates string
+	secret    []byte
+	urls      map[string]string
+	attempts  int
+	backoff   time.Duration
+}
+
+// NewWebhook creates a new webhook service rendering templates from the given directory and
+// posting to the URLs keyed by webhook name. If secret is set every request is signed with it.
+func NewWebhook(client WebhookClient, templates string, secret []byte, urls map[string]string) *Webhook {
+	return &Webhook{
+		client:    client,
+		templates: templates,
+		secret:    secret,
+		urls:      urls,
+		attempts:  webhookAttempts,
+		backoff:   webhookBackoff,
+	}
+}
+
+// templateFuncs are the functions available to webhook templates.
+var templateFuncs = template.FuncMap{
+	// json returns v encoded as JSON so values can be safely embedded in a JSON payload.
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// Render executes the template text with the given data and returns the resulting JSON body.
+func (w *Webhook) Render(text string, data interface{}) ([]byte, error) {
+	t, err := template.New("webhook").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, errors.Wrap(errLoadTemplate, err.Error())
+	}
+	return renderJSON(t, data)
+}
+
+// RenderTemplate executes the named template from the templates directory with the given data and
+// returns the resulting JSON body.
+func (w *Webhook) RenderTemplate(templateName string, data interface{}) ([]byte, error) {
+	t, err := template.New(filepath.Base(templateName)).Funcs(templateFuncs).ParseFiles(filepath.Join(w.templates, templateName))
+	if err != nil {
+		return nil, errors.Wrap(errLoadTemplate, err.Error())
+	}
+	return renderJSON(t, data)
+}
+
+// renderJSON executes the template and checks the result is valid JSON.
+func renderJSON(t *template.Template, data interface{}) ([]byte, error) {
+	out := &bytes.Buffer{}
+	if err := t.Execute(out, data); err != nil {
+		return nil, errors.Wrap(errParseTemplate, err.Error())
+	}
+	if !json.Valid(out.Bytes()) {
+		return nil, errors.Errorf("template rendered invalid JSON: %s", out.String())
+	}
+	return out.Bytes(), nil
+}
+
+// Sign returns the HMAC-SHA256 signature of body as sent in the SignatureHeader, a hex digest
+// prefixed with "sha256=".
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Post sends the JSON body to the named webhook. Requests failing with a network error, a 429 or
+// a 5xx status are retried with exponential backoff.
+func (w *Webhook) Post(ctx context.Context, name string, body []byte) error {
+	url, ok := w.urls[name]
+	if !ok {
+		return errors.Errorf("webhook %q is not configured", name)
+	}
+	backoff := w.backoff
+	var err error
+	for attempt := 1; attempt <= w.attempts; attempt++ {
+		var retry bool
+		if retry, err = w.post(ctx, url, body); err == nil || !retry {
+			return err
+		}
+		if attempt == w.attempts {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+	return errors.Wrapf(err, "failed after %d attempts", w.attempts)
+}
+
+// post sends a single request and returns if it should be retried on failure.
+func (w *Webhook) post(ctx context.Context, url string, body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	if len(w.secret) > 0 {
+		req.Header.Set(SignatureHeader, Sign(w.secret, body))
+	}
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	// Drain the body so the connection can be reused.
+	if _, err := io.Copy(ioutil.Discard, resp.Body); err != nil {
+		return true, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	err = fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500, err
+}
@@ -0,0 +1,103 @@
+package services
+
+// Copyright 2019 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestWebhookRender(t *testing.T) {
+	w := NewWebhook(http.DefaultClient, "../templates/", nil, nil)
+	for _, tt := range []struct {
+		name    string
+		text    string
+		want    string
+		wantErr error
+	}{
+		{name: "escaped", text: `{"text": {{json .Text}}}`, want: `{"text": "say \"hi\""}`},
+		{name: "invalid json", text: `{"text": {{.Text}}}`, wantErr: nil},
+		{name: "parse error", text: `{{.Text`, wantErr: errLoadTemplate},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := w.Render(tt.text, struct{ Text string }{Text: `say "hi"`})
+			if tt.want == "" {
+				if err == nil {
+					t.Fatalf("Render() = %s, want error", b)
+				}
+				if tt.wantErr != nil && errors.Cause(err) != tt.wantErr {
+					t.Errorf("Render() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Render() failed: %q", err)
+			}
+			if string(b) != tt.want {
+				t.Errorf("Render() = %s, want %s", b, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebhookPost(t *testing.T) {
+	ctx := context.Background()
+	secret := []byte("secret")
+	body := []byte(`{"text": "hello"}`)
+	for _, tt := range []struct {
+		name         string
+		webhook      string
+		statuses     []int
+		wantAttempts int
+		wantErr      bool
+	}{
+		{name: "success", statuses: []int{200}, wantAttempts: 1},
+		{name: "unknown webhook", webhook: "other", wantAttempts: 0, wantErr: true},
+		{name: "retry server error", statuses: []int{500, 429, 200}, wantAttempts: 3},
+		{name: "give up", statuses: []int{503, 503, 503}, wantAttempts: 3, wantErr: true},
+		{name: "permanent failure", statuses: []int{404}, wantAttempts: 1, wantErr: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+				b, _ := ioutil.ReadAll(r.Body)
+				if got, want := r.Header.Get(SignatureHeader), Sign(secret, b); got != want {
+					t.Errorf("got signature %q want %q", got, want)
+				}
+				rw.WriteHeader(tt.statuses[attempts])
+				attempts++
+			}))
+			defer srv.Close()
+			w := NewWebhook(srv.Client(), "", secret, map[string]string{"secops": srv.URL})
+			w.backoff = 0
+			name := tt.webhook
+			if name == "" {
+				name = "secops"
+			}
+			err := w.Post(ctx, name, body)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Post() error = %v, want error %t", err, tt.wantErr)
+			}
+			if attempts != tt.wantAttempts {
+				t.Errorf("got %d attempts want %d", attempts, tt.wantAttempts)
+			}
+		})
+	}
+}
@@ -78,6 +78,33 @@ resource "google_pubsub_topic" "router-topic" {
   project = var.automation-project
   name    = "threat-findings-router"
 }
+
+// PubSub topic receiving findings and automation messages that failed permanently or ran out
+// of retries. The subscription keeps them for a week so they can be inspected or replayed.
+resource "google_pubsub_topic" "dead-letter-topic" {
+  project = var.automation-project
+  name    = "threat-findings-dead-letter"
+}
+
+resource "google_pubsub_subscription" "dead-letter-subscription" {
+  project                    = var.automation-project
+  name                       = "threat-findings-dead-letter"
+  topic                      = google_pubsub_topic.dead-letter-topic.name
+  message_retention_duration = "604800s"
+}
+
+// PubSub topic notified when the router's circuit breaker opens because a limit was exceeded.
+resource "google_pubsub_topic" "circuit-breaker-topic" {
+  project = var.automation-project
+  name    = "threat-findings-circuit-breaker"
+}
+
+resource "google_pubsub_subscription" "circuit-breaker-subscription" {
+  project                    = var.automation-project
+  name                       = "threat-findings-circuit-breaker"
+  topic                      = google_pubsub_topic.circuit-breaker-topic.name
+  message_retention_duration = "604800s"
+}
 // NOTE: Since SCC Notification Config is not yet supported
 // as a terraform resource, we create it here instead via a
 // null_resource
@@ -111,6 +138,86 @@ resource "null_resource" "notification" {
   depends_on = [google_project_service.securitycenter_api]
 }
 
+// BigQuery table holding the audit trail of every remediation.
+resource "google_bigquery_dataset" "audit" {
+  project    = var.automation-project
+  dataset_id = "sra_audit"
+  location   = "US"
+  depends_on = [google_project_service.bigquery_api]
+}
+
+resource "google_bigquery_table" "remediations" {
+  project    = var.automation-project
+  dataset_id = google_bigquery_dataset.audit.dataset_id
+  table_id   = "remediations"
+
+  time_partitioning {
+    type  = "DAY"
+    field = "time"
+  }
+
+  schema = <<EOF
+[
+  {"name": "time", "type": "TIMESTAMP", "mode": "REQUIRED"},
+  {"name": "remediation_id", "type": "STRING"},
+  {"name": "finding_name", "type": "STRING"},
+  {"name": "rule", "type": "STRING"},
+  {"name": "action", "type": "STRING"},
+  {"name": "resource", "type": "STRING"},
+  {"name": "before", "type": "STRING"},
+  {"name": "after", "type": "STRING"},
+  {"name": "dry_run", "type": "BOOLEAN"},
+  {"name": "outcome", "type": "STRING"},
+  {"name": "error", "type": "STRING"},
+  {"name": "reason", "type": "STRING"}
+]
+EOF
+}
+
+resource "google_bigquery_dataset_iam_member" "audit-writer" {
+  project    = var.automation-project
+  dataset_id = google_bigquery_dataset.audit.dataset_id
+  role       = "roles/bigquery.dataEditor"
+  member     = "serviceAccount:${google_service_account.automation-service-account.email}"
+}
+
+// GCS bucket holding snapshots of resources taken before reversible remediations.
+resource "google_storage_bucket" "snapshots" {
+  name    = "${var.automation-project}-sra-snapshots"
+  project = var.automation-project
+}
+
+resource "google_storage_bucket_iam_member" "snapshot-writer" {
+  bucket = google_storage_bucket.snapshots.name
+  role   = "roles/storage.objectAdmin"
+  member = "serviceAccount:${google_service_account.automation-service-account.email}"
+}
+
+// Firestore collections holding idempotency claims and limit counters, used when
+// idempotency-collection or limits-collection is set. The project must already have a Firestore
+// database in Native mode.
+resource "google_project_service" "firestore_api" {
+  count                      = var.idempotency-collection == "" && var.limits-collection == "" ? 0 : 1
+  project                    = var.automation-project
+  service                    = "firestore.googleapis.com"
+  disable_dependent_services = false
+  disable_on_destroy         = false
+}
+
+resource "google_project_iam_member" "idempotency-writer" {
+  count   = var.idempotency-collection == "" && var.limits-collection == "" ? 0 : 1
+  project = var.automation-project
+  role    = "roles/datastore.user"
+  member  = "serviceAccount:${google_service_account.automation-service-account.email}"
+}
+
+resource "google_project_service" "bigquery_api" {
+  project                    = var.automation-project
+  service                    = "bigquery.googleapis.com"
+  disable_dependent_services = false
+  disable_on_destroy         = false
+}
+
 resource "google_project_iam_member" "stackdriver-writer" {
   project = var.automation-project
   role    = "roles/logging.logWriter"
@@ -37,3 +37,27 @@ output "router-topic-id" {
 output "organization-id" {
   value = var.organization-id
 }
+
+output "audit-dataset" {
+  value = google_bigquery_dataset.audit.dataset_id
+}
+
+output "audit-table" {
+  value = google_bigquery_table.remediations.table_id
+}
+
+output "snapshot-bucket" {
+  value = google_storage_bucket.snapshots.name
+}
+
+output "idempotency-collection" {
+  value = var.idempotency-collection
+}
+
+output "limits-collection" {
+  value = var.limits-collection
+}
+
+output "dead-letter-topic" {
+  value = google_pubsub_topic.dead-letter-topic.name
+}
@@ -36,3 +36,15 @@ variable "notification-name" {
   type    = string
   default = "sra-notifications"
 }
+
+variable "idempotency-collection" {
+  type        = string
+  default     = ""
+  description = "Firestore collection used to deduplicate finding deliveries. Deduplication is disabled if empty."
+}
+
+variable "limits-collection" {
+  type        = string
+  default     = ""
+  description = "Firestore collection holding limit counters and circuit breakers. Limits are counted in memory if empty."
+}
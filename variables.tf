@@ -24,3 +24,57 @@ variable "enable-scc-notification" {
   default     = true
   description = "If true, create the notification config from SCC instead of Cloud Logging"
 }
+
+variable "sendgrid-api-key" {
+  type        = string
+  default     = ""
+  description = "SendGrid API key used by the notify_email automation. Emails are not sent if empty."
+}
+
+variable "pagerduty-api-key" {
+  type        = string
+  default     = ""
+  description = "PagerDuty REST API key used by the pagerduty_incident automation."
+}
+
+variable "webhook-secret" {
+  type        = string
+  default     = ""
+  description = "Secret used by the notify_webhook automation to sign payloads. Payloads are not signed if empty."
+}
+
+variable "webhook-urls" {
+  type        = map(string)
+  default     = {}
+  description = "Webhook URLs keyed by the names used in `notify_webhook.webhook` and `approval.webhook`."
+}
+
+variable "config-bucket" {
+  type        = string
+  default     = ""
+  description = "Bucket holding the router configuration as `sra.yaml`. Changes to the object are picked up without redeploying. The configuration bundled with the function is used if empty."
+}
+
+variable "filter-bundle" {
+  type        = string
+  default     = ""
+  description = "Cloud Storage prefix such as gs://bucket/filters/ or OPA bundle URL to load the Rego filters from. Changes are picked up without redeploying. The filters in `config/filters` are used if empty."
+}
+
+variable "filter-bundle-token" {
+  type        = string
+  default     = ""
+  description = "Bearer token sent to the OPA bundle server, if any."
+}
+
+variable "idempotency-collection" {
+  type        = string
+  default     = ""
+  description = "Firestore collection used to skip duplicate deliveries of a finding. Requires a Firestore database in Native mode in the automation project. Deduplication is disabled if empty."
+}
+
+variable "limits-collection" {
+  type        = string
+  default     = ""
+  description = "Firestore collection holding the router's limit counters and circuit breakers. Requires a Firestore database in Native mode in the automation project. Limits are not enforced if empty."
+}